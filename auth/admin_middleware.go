@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/observability"
+)
+
+// adminUserTypes 允许访问管理员接口的 QiniuStub ut 位
+const adminUserTypes = interfaces.UserTypeAdmin | interfaces.UserTypeSudoers
+
+// RequireAdmin 管理员权限检查，必须位于 QstubAuthMiddleware 之后
+// 只接受 QiniuStub 认证且 ut 包含 UserTypeAdmin 或 UserTypeSudoers 位的主账户（IAM 子账户无权访问）
+func RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		qstubUser, ok := r.Context().Value("qstub_user").(*QstubUserInfo)
+		if !ok || qstubUser == nil || qstubUser.Utype&adminUserTypes == 0 ||
+			qstubUser.IamUid != "" || qstubUser.IamAlias != "" {
+			observability.AuthFailuresTotal.WithLabelValues("qstub", "not_admin").Inc()
+			respondAuthError(w, http.StatusForbidden, interfaces.ErrCodePermissionDenied, "admin privileges required")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+)
+
+// ========================================
+// TestRequireAdmin
+// ========================================
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       *QstubUserInfo
+		wantStatus int
+	}{
+		{"admin", &QstubUserInfo{UID: "1", Utype: interfaces.UserTypeAdmin}, http.StatusOK},
+		{"sudoers", &QstubUserInfo{UID: "1", Utype: interfaces.UserTypeSudoers}, http.StatusOK},
+		{"normal user", &QstubUserInfo{UID: "1", Utype: interfaces.UserTypeStdUser}, http.StatusForbidden},
+		{"iam sub-account of admin", &QstubUserInfo{UID: "1", Utype: interfaces.UserTypeAdmin, IamUid: "8901234"}, http.StatusForbidden},
+		{"no qstub user", nil, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RequireAdmin(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v2/admin/accounts", nil)
+			if tt.user != nil {
+				req = req.WithContext(context.WithValue(req.Context(), "qstub_user", tt.user))
+			}
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+)
+
+// ========================================
+// HMAC AK/SK 签名认证
+// ========================================
+
+// HMACAuthScheme HMAC 认证的 Authorization 头前缀
+// 格式: Authorization: QINIU {AccessKey}:{Signature}
+const HMACAuthScheme = "QINIU "
+
+// DefaultHMACTimestampTolerance 默认时间戳容忍度（请求时间与服务器时间的最大偏差）
+const DefaultHMACTimestampTolerance = 15 * time.Minute
+
+// HMAC 认证错误（中间件据此返回对应的业务错误码）
+var (
+	ErrInvalidAuthHeader = errors.New("invalid authorization header, expected 'QINIU {AccessKey}:{Signature}'")
+	ErrInvalidTimestamp  = errors.New("invalid timestamp, expected RFC3339 format")
+	ErrTimestampExpired  = errors.New("request timestamp expired")
+	ErrAccessKeyNotFound = errors.New("access key not found")
+	ErrInvalidSignature  = errors.New("invalid signature")
+)
+
+// HMACAuthenticatorImpl HMAC 签名认证实现（同时实现 interfaces.HMACAuthenticator 和 interfaces.SignatureBuilder）
+//
+// 签名算法：
+//
+//	StringToSign = Method + "\n" + URI + "\n" + Timestamp + "\n" + Body
+//	Signature    = Base64(HMAC-SHA256(SecretKey, StringToSign))
+//
+// URI 为请求路径加查询参数（如 /api/v2/tokens?limit=10），Timestamp 为 X-Qiniu-Date 头（RFC3339）
+// 请求携带 X-Qiniu-Nonce 时，Timestamp 行为 "Timestamp Nonce"（空格分隔），nonce 随签名一起受保护
+type HMACAuthenticatorImpl struct {
+	accountRepo interfaces.AccountRepository
+	tolerance   time.Duration
+}
+
+// NewHMACAuthenticator 创建 HMAC 签名认证实例
+func NewHMACAuthenticator(accountRepo interfaces.AccountRepository, tolerance time.Duration) *HMACAuthenticatorImpl {
+	if tolerance <= 0 {
+		tolerance = DefaultHMACTimestampTolerance
+	}
+	return &HMACAuthenticatorImpl{
+		accountRepo: accountRepo,
+		tolerance:   tolerance,
+	}
+}
+
+// BuildStringToSign 构建待签名字符串
+func (a *HMACAuthenticatorImpl) BuildStringToSign(method string, uri string, timestamp string, body string) string {
+	return fmt.Sprintf("%s\n%s\n%s\n%s", method, uri, timestamp, body)
+}
+
+// ParseAuthHeader 解析 Authorization 头，返回 AccessKey 和签名
+func (a *HMACAuthenticatorImpl) ParseAuthHeader(authHeader string) (string, string, error) {
+	if !strings.HasPrefix(authHeader, HMACAuthScheme) {
+		return "", "", ErrInvalidAuthHeader
+	}
+
+	credential := strings.TrimSpace(strings.TrimPrefix(authHeader, HMACAuthScheme))
+	accessKey, signature, ok := strings.Cut(credential, ":")
+	if !ok || accessKey == "" || signature == "" {
+		return "", "", ErrInvalidAuthHeader
+	}
+	return accessKey, signature, nil
+}
+
+// GenerateSignature 生成 HMAC-SHA256 签名（Base64 编码）
+func (a *HMACAuthenticatorImpl) GenerateSignature(secretKey string, stringToSign string) (string, error) {
+	if secretKey == "" {
+		return "", errors.New("secret key is empty")
+	}
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// VerifySignature 根据 AccessKey 查询账户并验证签名
+func (a *HMACAuthenticatorImpl) VerifySignature(accessKey string, signature string, stringToSign string) (bool, error) {
+	account, err := a.getAccount(context.Background(), accessKey)
+	if err != nil {
+		return false, err
+	}
+	return a.verify(account.SecretKey, signature, stringToSign), nil
+}
+
+// ValidateTimestamp 验证时间戳是否在容忍范围内（防重放攻击）
+func (a *HMACAuthenticatorImpl) ValidateTimestamp(timestamp string, tolerance time.Duration) error {
+	ts, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+
+	skew := time.Since(ts)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > tolerance {
+		return ErrTimestampExpired
+	}
+	return nil
+}
+
+// ExtractAccountFromRequest 验证请求签名并返回对应账户
+// 依次校验 Authorization 头格式、时间戳、AccessKey 和签名；不检查账户状态
+func (a *HMACAuthenticatorImpl) ExtractAccountFromRequest(ctx context.Context, authHeader string, timestamp string, method string, uri string, body string) (*interfaces.Account, error) {
+	return a.authenticate(ctx, authHeader, timestamp, "", method, uri, body)
+}
+
+// authenticate 验证请求签名（nonce 为空表示请求未携带 nonce）
+func (a *HMACAuthenticatorImpl) authenticate(ctx context.Context, authHeader, timestamp, nonce, method, uri, body string) (*interfaces.Account, error) {
+	accessKey, signature, err := a.ParseAuthHeader(authHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.ValidateTimestamp(timestamp, a.tolerance); err != nil {
+		return nil, err
+	}
+
+	account, err := a.getAccount(ctx, accessKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if !a.verify(account.SecretKey, signature, a.BuildStringToSign(method, uri, signedTimestamp(timestamp, nonce), body)) {
+		return nil, ErrInvalidSignature
+	}
+
+	return account, nil
+}
+
+// Tolerance 返回时间戳容忍度
+func (a *HMACAuthenticatorImpl) Tolerance() time.Duration {
+	return a.tolerance
+}
+
+// getAccount 根据 AccessKey 查询账户
+func (a *HMACAuthenticatorImpl) getAccount(ctx context.Context, accessKey string) (*interfaces.Account, error) {
+	account, err := a.accountRepo.GetByAccessKey(ctx, accessKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	if account == nil {
+		return nil, ErrAccessKeyNotFound
+	}
+	return account, nil
+}
+
+// verify 常量时间比较签名
+func (a *HMACAuthenticatorImpl) verify(secretKey, signature, stringToSign string) bool {
+	expected, err := a.GenerateSignature(secretKey, stringToSign)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// signedTimestamp 待签名字符串中的时间戳行
+// RFC3339 时间戳不含空格，因此带 nonce 与不带 nonce 的待签名字符串不会混淆
+func signedTimestamp(timestamp, nonce string) string {
+	if nonce == "" {
+		return timestamp
+	}
+	return timestamp + " " + nonce
+}
@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/observability"
+)
+
+// ========================================
+// HMAC AK/SK 认证中间件
+// ========================================
+
+const (
+	// HMACDateHeader 请求时间戳头（RFC3339，如 2025-01-01T00:00:00Z）
+	HMACDateHeader = "X-Qiniu-Date"
+
+	// HMACNonceHeader 请求唯一标识头（可选，携带时参与签名并做防重放检查）
+	HMACNonceHeader = "X-Qiniu-Nonce"
+
+	// maxNonceLength nonce 最大长度
+	maxNonceLength = 64
+
+	// maxSignedBodySize 参与签名的请求体最大长度
+	maxSignedBodySize = 1 << 20
+)
+
+// HMACAuthMiddleware HMAC AK/SK 认证中间件
+// 验证签名和时间戳，nonce 在容忍窗口内只能使用一次；拒绝已停用账户
+type HMACAuthMiddleware struct {
+	authenticator *HMACAuthenticatorImpl
+	nonces        NonceStore
+	requireNonce  bool
+}
+
+// NewHMACAuthMiddleware 创建 HMAC 认证中间件
+// requireNonce 为 true 时请求必须携带 X-Qiniu-Nonce（否则同一签名在时间戳容忍窗口内可被重放）
+func NewHMACAuthMiddleware(authenticator *HMACAuthenticatorImpl, nonces NonceStore, requireNonce bool) *HMACAuthMiddleware {
+	return &HMACAuthMiddleware{
+		authenticator: authenticator,
+		nonces:        nonces,
+		requireNonce:  requireNonce,
+	}
+}
+
+// Authenticate HMAC 认证处理器
+//
+// 请求头：
+//
+//	Authorization: QINIU {AccessKey}:{Signature}
+//	X-Qiniu-Date:  2025-01-01T00:00:00Z
+//	X-Qiniu-Nonce: 9f86d081884c7d65（可选）
+func (m *HMACAuthMiddleware) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		timestamp := r.Header.Get(HMACDateHeader)
+		nonce := r.Header.Get(HMACNonceHeader)
+
+		if !strings.HasPrefix(authHeader, HMACAuthScheme) {
+			m.fail(w, http.StatusUnauthorized, interfaces.ErrCodeInvalidAuthHeader, "invalid_header", ErrInvalidAuthHeader.Error())
+			return
+		}
+		if nonce == "" && m.requireNonce {
+			m.fail(w, http.StatusUnauthorized, interfaces.ErrCodeInvalidAuthHeader, "nonce", HMACNonceHeader+" header is required")
+			return
+		}
+		if len(nonce) > maxNonceLength || strings.ContainsAny(nonce, " \t\r\n") {
+			m.fail(w, http.StatusUnauthorized, interfaces.ErrCodeInvalidAuthHeader, "nonce", "invalid "+HMACNonceHeader+" header")
+			return
+		}
+
+		// 1. 读取请求体（参与签名），并还原供后续 handler 读取
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodySize+1))
+		if err != nil {
+			m.fail(w, http.StatusBadRequest, interfaces.ErrCodeBadRequest, "error", "failed to read request body")
+			return
+		}
+		if len(body) > maxSignedBodySize {
+			m.fail(w, http.StatusRequestEntityTooLarge, interfaces.ErrCodeBadRequest, "error", "request body too large")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// 2. 验证签名
+		account, err := m.authenticator.authenticate(r.Context(), authHeader, timestamp, nonce, r.Method, r.URL.RequestURI(), string(body))
+		if err != nil {
+			m.failWithError(w, r, err)
+			return
+		}
+
+		// 3. 检查账户状态
+		if account.Status == interfaces.AccountStatusSuspended {
+			m.fail(w, http.StatusForbidden, interfaces.ErrCodeAccountSuspended, "suspended", "account is suspended")
+			return
+		}
+
+		// 4. 防重放：签名通过后再登记 nonce（避免未认证请求占用 nonce）
+		if nonce != "" {
+			ok, err := m.nonces.Use(r.Context(), account.AccessKey+":"+nonce, 2*m.authenticator.Tolerance())
+			if err != nil {
+				observability.LogWarn(r.Context(), "Failed to check HMAC nonce",
+					slog.String("access_key", account.AccessKey),
+					slog.String("error", err.Error()))
+				m.fail(w, http.StatusServiceUnavailable, interfaces.ErrCodeServiceUnavailable, "error", "failed to check nonce")
+				return
+			}
+			if !ok {
+				m.fail(w, http.StatusUnauthorized, interfaces.ErrCodeNonceReused, "nonce", "nonce has already been used")
+				return
+			}
+		}
+
+		// 5. 注入账户信息
+		ctx := context.WithValue(r.Context(), "account", &AccountInfo{
+			ID:    account.ID,
+			Email: account.Email,
+		})
+		ctx = context.WithValue(ctx, "account_id", account.ID)
+		ctx = context.WithValue(ctx, "auth_method", "hmac") // 标记认证方式
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
+// failWithError 将签名验证错误映射为响应
+func (m *HMACAuthMiddleware) failWithError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidAuthHeader):
+		m.fail(w, http.StatusUnauthorized, interfaces.ErrCodeInvalidAuthHeader, "invalid_header", err.Error())
+	case errors.Is(err, ErrInvalidTimestamp), errors.Is(err, ErrTimestampExpired):
+		m.fail(w, http.StatusUnauthorized, interfaces.ErrCodeTimestampExpired, "timestamp", err.Error())
+	case errors.Is(err, ErrAccessKeyNotFound):
+		m.fail(w, http.StatusUnauthorized, interfaces.ErrCodeAccessKeyNotFound, "access_key", err.Error())
+	case errors.Is(err, ErrInvalidSignature):
+		m.fail(w, http.StatusUnauthorized, interfaces.ErrCodeInvalidSignature, "signature", err.Error())
+	default:
+		observability.LogError(r.Context(), "HMAC authentication failed", err)
+		m.fail(w, http.StatusInternalServerError, interfaces.ErrCodeInternalServerError, "error", "authentication failed")
+	}
+}
+
+// fail 记录失败指标并返回错误响应
+func (m *HMACAuthMiddleware) fail(w http.ResponseWriter, statusCode int, code int, reason string, message string) {
+	observability.AuthFailuresTotal.WithLabelValues("hmac", reason).Inc()
+	respondAuthError(w, statusCode, code, message)
+}
+
+// respondAuthError 返回带业务错误码的认证错误响应
+func respondAuthError(w http.ResponseWriter, statusCode int, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": message,
+		"code":  code,
+	})
+}
@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ========================================
+// Mock AccountRepository
+// ========================================
+
+type MockAccountRepository struct {
+	mock.Mock
+}
+
+func (m *MockAccountRepository) Create(ctx context.Context, account *interfaces.Account) error {
+	args := m.Called(ctx, account)
+	return args.Error(0)
+}
+
+func (m *MockAccountRepository) GetByAccessKey(ctx context.Context, accessKey string) (*interfaces.Account, error) {
+	args := m.Called(ctx, accessKey)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.Account), args.Error(1)
+}
+
+func (m *MockAccountRepository) GetByEmail(ctx context.Context, email string) (*interfaces.Account, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.Account), args.Error(1)
+}
+
+func (m *MockAccountRepository) GetByID(ctx context.Context, id string) (*interfaces.Account, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.Account), args.Error(1)
+}
+
+func (m *MockAccountRepository) UpdateSecretKey(ctx context.Context, accountID string, newSecretKey string) error {
+	args := m.Called(ctx, accountID, newSecretKey)
+	return args.Error(0)
+}
+
+func (m *MockAccountRepository) UpdateStatus(ctx context.Context, accountID string, status string) error {
+	args := m.Called(ctx, accountID, status)
+	return args.Error(0)
+}
+
+func (m *MockAccountRepository) List(ctx context.Context, query *interfaces.AccountListQuery) ([]interfaces.Account, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]interfaces.Account), args.Error(1)
+}
+
+func (m *MockAccountRepository) Count(ctx context.Context, query *interfaces.AccountListQuery) (int64, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccountRepository) ListSuspendedIDs(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// ========================================
+// 辅助函数
+// ========================================
+
+const (
+	testAccessKey = "AK_test"
+	testSecretKey = "SK_test"
+)
+
+func newTestHMACMiddleware(status string, requireNonce bool) (*HMACAuthMiddleware, *HMACAuthenticatorImpl) {
+	repo := new(MockAccountRepository)
+	repo.On("GetByAccessKey", mock.Anything, testAccessKey).Return(&interfaces.Account{
+		ID:        "acc_1",
+		Email:     "user@example.com",
+		AccessKey: testAccessKey,
+		SecretKey: testSecretKey,
+		Status:    status,
+	}, nil)
+	repo.On("GetByAccessKey", mock.Anything, mock.Anything).Return(nil, nil)
+
+	authenticator := NewHMACAuthenticator(repo, 15*time.Minute)
+	return NewHMACAuthMiddleware(authenticator, NewMemoryNonceStore(), requireNonce), authenticator
+}
+
+// newSignedRequest 按客户端流程构造签名请求
+func newSignedRequest(a *HMACAuthenticatorImpl, method, uri, body, accessKey string, at time.Time, nonce string) *http.Request {
+	timestamp := at.UTC().Format(time.RFC3339)
+	signature, _ := a.GenerateSignature(testSecretKey, a.BuildStringToSign(method, uri, signedTimestamp(timestamp, nonce), body))
+
+	req := httptest.NewRequest(method, uri, strings.NewReader(body))
+	req.Header.Set("Authorization", HMACAuthScheme+accessKey+":"+signature)
+	req.Header.Set(HMACDateHeader, timestamp)
+	if nonce != "" {
+		req.Header.Set(HMACNonceHeader, nonce)
+	}
+	return req
+}
+
+// ========================================
+// TestHMACAuthMiddleware
+// ========================================
+
+func TestHMACAuthMiddleware_Success(t *testing.T) {
+	middleware, authenticator := newTestHMACMiddleware(interfaces.AccountStatusActive, true)
+
+	body := `{"description":"test"}`
+	req := newSignedRequest(authenticator, http.MethodPost, "/api/v2/tokens?limit=10", body, testAccessKey, time.Now(), "n-1")
+
+	var gotBody, gotAccountID, gotMethod string
+	handler := middleware.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		gotBody = string(data)
+		gotAccountID, _ = ExtractAccountIDFromContext(r.Context())
+		gotMethod = ExtractAuthMethod(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, body, gotBody)
+	assert.Equal(t, "acc_1", gotAccountID)
+	assert.Equal(t, "hmac", gotMethod)
+}
+
+func TestHMACAuthMiddleware_Rejected(t *testing.T) {
+	middleware, authenticator := newTestHMACMiddleware(interfaces.AccountStatusActive, true)
+
+	tamperedBody := newSignedRequest(authenticator, http.MethodPost, "/api/v2/tokens", `{"a":1}`, testAccessKey, time.Now(), "n-1")
+	tamperedBody.Body = io.NopCloser(strings.NewReader(`{"a":2}`))
+
+	tests := []struct {
+		name       string
+		req        *http.Request
+		wantStatus int
+		wantCode   string
+	}{
+		{"tampered body", tamperedBody, http.StatusUnauthorized, `"code":4001`},
+		{"expired timestamp", newSignedRequest(authenticator, http.MethodGet, "/api/v2/tokens", "", testAccessKey, time.Now().Add(-time.Hour), "n-2"), http.StatusUnauthorized, `"code":4002`},
+		{"unknown access key", newSignedRequest(authenticator, http.MethodGet, "/api/v2/tokens", "", "AK_unknown", time.Now(), "n-3"), http.StatusUnauthorized, `"code":4003`},
+		{"missing nonce", newSignedRequest(authenticator, http.MethodGet, "/api/v2/tokens", "", testAccessKey, time.Now(), ""), http.StatusUnauthorized, `"code":4005`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+				t.Fatal("next handler should not be called")
+			})
+
+			w := httptest.NewRecorder()
+			handler(w, tt.req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantCode)
+		})
+	}
+}
+
+func TestHMACAuthMiddleware_SuspendedAccount(t *testing.T) {
+	middleware, authenticator := newTestHMACMiddleware(interfaces.AccountStatusSuspended, true)
+
+	w := httptest.NewRecorder()
+	middleware.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next handler should not be called")
+	})(w, newSignedRequest(authenticator, http.MethodGet, "/api/v2/tokens", "", testAccessKey, time.Now(), "n-1"))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":4004`)
+}
+
+func TestHMACAuthMiddleware_NonceReplay(t *testing.T) {
+	middleware, authenticator := newTestHMACMiddleware(interfaces.AccountStatusActive, true)
+	handler := middleware.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	now := time.Now()
+
+	// 必须携带 nonce
+	w := httptest.NewRecorder()
+	handler(w, newSignedRequest(authenticator, http.MethodGet, "/api/v2/tokens", "", testAccessKey, now, ""))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// 首次使用通过
+	w = httptest.NewRecorder()
+	handler(w, newSignedRequest(authenticator, http.MethodGet, "/api/v2/tokens", "", testAccessKey, now, "n-1"))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 原样重放被拒绝
+	w = httptest.NewRecorder()
+	handler(w, newSignedRequest(authenticator, http.MethodGet, "/api/v2/tokens", "", testAccessKey, now, "n-1"))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":4006`)
+
+	// 替换 nonce 但沿用原签名，签名校验失败
+	req := newSignedRequest(authenticator, http.MethodGet, "/api/v2/tokens", "", testAccessKey, now, "n-1")
+	req.Header.Set(HMACNonceHeader, "n-2")
+	w = httptest.NewRecorder()
+	handler(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":4001`)
+}
+
+func TestHMACAuthMiddleware_NonceOptional(t *testing.T) {
+	// HMAC_NONCE_REQUIRED=false：兼容不带 nonce 的旧客户端，带 nonce 时仍然防重放
+	middleware, authenticator := newTestHMACMiddleware(interfaces.AccountStatusActive, false)
+	handler := middleware.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	now := time.Now()
+
+	w := httptest.NewRecorder()
+	handler(w, newSignedRequest(authenticator, http.MethodGet, "/api/v2/tokens", "", testAccessKey, now, ""))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	handler(w, newSignedRequest(authenticator, http.MethodGet, "/api/v2/tokens", "", testAccessKey, now, "n-1"))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	handler(w, newSignedRequest(authenticator, http.MethodGet, "/api/v2/tokens", "", testAccessKey, now, "n-1"))
+	assert.Contains(t, w.Body.String(), `"code":4006`)
+}
+
+// ========================================
+// TestMultiAuthMiddleware
+// ========================================
+
+func TestMultiAuthMiddleware(t *testing.T) {
+	hmacMiddleware, authenticator := newTestHMACMiddleware(interfaces.AccountStatusActive, true)
+	qstubMiddleware := NewQstubAuthMiddleware(NewSimpleQiniuUIDMapper())
+	qstubMiddleware.SetTrustPolicy(newOpenQstubTrustPolicy(t))
+	multi := NewMultiAuthMiddleware().
+		Register("QiniuStub ", qstubMiddleware).
+		Register(HMACAuthScheme, hmacMiddleware).
+		Register(QiniuMACAuthScheme, NewQiniuMACAuthMiddleware(fakeAccessInfoGetter{}, NewSimpleQiniuUIDMapper(), 0))
+
+	handler := multi.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(ExtractAuthMethod(r.Context())))
+	})
+
+	// QiniuStub
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/tokens", nil)
+	req.Header.Set("Authorization", "QiniuStub uid=12345&ut=1")
+	w := httptest.NewRecorder()
+	handler(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "qstub", w.Body.String())
+
+	// HMAC
+	w = httptest.NewRecorder()
+	handler(w, newSignedRequest(authenticator, http.MethodGet, "/api/v2/tokens", "", testAccessKey, time.Now(), "n-1"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hmac", w.Body.String())
+
+	// 七牛请求签名（"Qiniu " 与 HMAC 的 "QINIU " 区分大小写）
+	w = httptest.NewRecorder()
+	handler(w, newQiniuSignedRequest(http.MethodGet, "/api/v2/tokens", "", "", testQiniuAccessKey, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "qiniu_mac", w.Body.String())
+
+	// 不支持的认证方式
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/tokens", nil)
+	req.Header.Set("Authorization", "Bearer sk-xxx")
+	w = httptest.NewRecorder()
+	handler(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":4005`)
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/observability"
+)
+
+// ========================================
+// 多认证方式中间件
+// ========================================
+
+// Authenticator 认证中间件通用接口（QstubAuthMiddleware、HMACAuthMiddleware、QiniuMACAuthMiddleware 均满足）
+type Authenticator interface {
+	Authenticate(next http.HandlerFunc) http.HandlerFunc
+}
+
+// authScheme Authorization 头前缀与对应的认证中间件
+type authScheme struct {
+	prefix        string
+	authenticator Authenticator
+}
+
+// MultiAuthMiddleware 按 Authorization 头前缀分派到对应的认证中间件
+// 例如同一路由同时接受 "QiniuStub uid=..." 和 "QINIU {AK}:{Signature}"
+type MultiAuthMiddleware struct {
+	schemes []authScheme
+}
+
+// NewMultiAuthMiddleware 创建多认证方式中间件
+func NewMultiAuthMiddleware() *MultiAuthMiddleware {
+	return &MultiAuthMiddleware{}
+}
+
+// Register 注册认证方式（前缀区分大小写，按注册顺序匹配）
+func (m *MultiAuthMiddleware) Register(prefix string, authenticator Authenticator) *MultiAuthMiddleware {
+	m.schemes = append(m.schemes, authScheme{prefix: prefix, authenticator: authenticator})
+	return m
+}
+
+// Authenticate 按 Authorization 头前缀选择认证方式
+func (m *MultiAuthMiddleware) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	// 在包装时预先构建各认证方式的 handler，避免每个请求重复包装
+	handlers := make([]http.HandlerFunc, len(m.schemes))
+	for i, scheme := range m.schemes {
+		handlers[i] = scheme.authenticator.Authenticate(next)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		for i, scheme := range m.schemes {
+			if strings.HasPrefix(authHeader, scheme.prefix) {
+				handlers[i](w, r)
+				return
+			}
+		}
+
+		observability.AuthFailuresTotal.WithLabelValues("none", "invalid_header").Inc()
+		respondAuthError(w, http.StatusUnauthorized, interfaces.ErrCodeInvalidAuthHeader,
+			"missing or unsupported Authorization header, expected one of: "+m.supportedSchemes())
+	}
+}
+
+// supportedSchemes 已注册的认证方式前缀列表
+func (m *MultiAuthMiddleware) supportedSchemes() string {
+	prefixes := make([]string, len(m.schemes))
+	for i, scheme := range m.schemes {
+		prefixes[i] = "'" + strings.TrimSpace(scheme.prefix) + "'"
+	}
+	return strings.Join(prefixes, ", ")
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ========================================
+// Nonce 存储（HMAC 请求防重放）
+// ========================================
+
+// NonceStore 记录已使用的 nonce
+type NonceStore interface {
+	// Use 标记 nonce 已使用，ttl 内再次使用同一 nonce 返回 false
+	Use(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// nonceSweepInterval 内存 nonce 存储清理过期条目的最小间隔
+const nonceSweepInterval = time.Minute
+
+// MemoryNonceStore 进程内 nonce 存储（多副本时每个副本独立记录，仅适用于单实例部署）
+type MemoryNonceStore struct {
+	mu        sync.Mutex
+	entries   map[string]time.Time // key -> 过期时间
+	lastSweep time.Time
+}
+
+// NewMemoryNonceStore 创建进程内 nonce 存储
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{
+		entries:   make(map[string]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+// Use 标记 nonce 已使用
+func (s *MemoryNonceStore) Use(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= nonceSweepInterval {
+		for k, expireAt := range s.entries {
+			if now.After(expireAt) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if expireAt, ok := s.entries[key]; ok && now.Before(expireAt) {
+		return false, nil
+	}
+	s.entries[key] = now.Add(ttl)
+	return true, nil
+}
+
+// NonceRedis Redis 脚本执行接口（cache.RedisClient 满足该接口，避免 auth 依赖 cache 包）
+type NonceRedis interface {
+	Eval(ctx context.Context, script string, keys []string, args ...any) (any, error)
+}
+
+// useNonceScript SET NX PX，成功返回 1，key 已存在返回 0
+const useNonceScript = `
+if redis.call('SET', KEYS[1], '1', 'PX', ARGV[1], 'NX') then
+	return 1
+end
+return 0
+`
+
+// RedisNonceStore Redis nonce 存储（多副本共享）
+type RedisNonceStore struct {
+	redis NonceRedis
+}
+
+// NewRedisNonceStore 创建 Redis nonce 存储
+func NewRedisNonceStore(redis NonceRedis) *RedisNonceStore {
+	return &RedisNonceStore{redis: redis}
+}
+
+// Use 标记 nonce 已使用
+func (s *RedisNonceStore) Use(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	result, err := s.redis.Eval(ctx, useNonceScript, []string{"hmac:nonce:" + key}, ttl.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+
+	n, ok := result.(int64)
+	if !ok {
+		return false, fmt.Errorf("unexpected nonce script result: %v", result)
+	}
+	return n == 1, nil
+}
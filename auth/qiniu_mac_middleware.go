@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/observability"
+	"github.com/qiniu/bearer-token-service/v2/pkg/qconfapi"
+	"github.com/qiniu/xlog.v1"
+	qiniumac "qiniu.com/auth/qiniumac.v1"
+)
+
+// ========================================
+// 七牛 AK/SK 请求签名认证中间件（Qiniu MAC）
+// ========================================
+
+// QiniuMACAuthScheme 七牛请求签名的 Authorization 头前缀
+// 格式: Authorization: Qiniu {AccessKey}:{EncodedSign}（与 HMAC 的 "QINIU " 前缀区分大小写）
+const QiniuMACAuthScheme = "Qiniu "
+
+// QiniuMACDateHeader 请求时间头（X-Qiniu-* 头参与签名），格式为 20060102T150405Z（UTC）
+const QiniuMACDateHeader = "X-Qiniu-Date"
+
+// qiniuMACDateLayout X-Qiniu-Date 的时间格式（与七牛 SDK 一致）
+const qiniuMACDateLayout = "20060102T150405Z"
+
+// DefaultQiniuMACDateTolerance 默认请求时间容忍度（X-Qiniu-Date 与服务器时间的最大偏差）
+const DefaultQiniuMACDateTolerance = 15 * time.Minute
+
+// QiniuAccessInfoGetter 按 AccessKey 查询七牛密钥信息（qconfapi.Client 满足该接口）
+type QiniuAccessInfoGetter interface {
+	GetAccessInfo(l *xlog.Logger, accessKey string) (qconfapi.AccountAccessInfo, error)
+}
+
+// QiniuMACAuthMiddleware 七牛 AK/SK 请求签名认证中间件
+// 使用七牛 SDK 相同的签名算法（pkg/auth/qiniumac.v1）验证请求，通过 qconfapi 查询 SK 和 UID，
+// 再经 QiniuUIDMapper 映射为 account_id，注入与 QstubAuthMiddleware 相同的 Context 值；
+// 请求必须携带参与签名的 X-Qiniu-Date 头，且与服务器时间的偏差不超过 dateTolerance（限制签名的重放窗口）
+type QiniuMACAuthMiddleware struct {
+	accessInfo     QiniuAccessInfoGetter
+	qiniuUIDMapper QiniuUIDMapper
+	dateTolerance  time.Duration
+}
+
+// NewQiniuMACAuthMiddleware 创建七牛请求签名认证中间件
+// dateTolerance 为 X-Qiniu-Date 与服务器时间的最大偏差，<= 0 时使用 DefaultQiniuMACDateTolerance
+func NewQiniuMACAuthMiddleware(accessInfo QiniuAccessInfoGetter, qiniuUIDMapper QiniuUIDMapper, dateTolerance time.Duration) *QiniuMACAuthMiddleware {
+	if dateTolerance <= 0 {
+		dateTolerance = DefaultQiniuMACDateTolerance
+	}
+
+	return &QiniuMACAuthMiddleware{
+		accessInfo:     accessInfo,
+		qiniuUIDMapper: qiniuUIDMapper,
+		dateTolerance:  dateTolerance,
+	}
+}
+
+// Authenticate 七牛请求签名认证处理器
+//
+// 待签名数据（与 qiniumac.SignRequest 一致）：
+//
+//	Method + " " + Path[?RawQuery] + "\nHost: " + Host
+//	[+ "\nContent-Type: " + Content-Type]
+//	[+ "\n" + 按名称排序的 X-Qiniu-* 头（"Key: Value"，必须包含 X-Qiniu-Date）]
+//	+ "\n\n" [+ Body（Content-Type 非空且不是 application/octet-stream 时）]
+//
+// EncodedSign = URLSafeBase64(HMAC-SHA1(SecretKey, 待签名数据))
+func (m *QiniuMACAuthMiddleware) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// 1. 解析 Authorization 头
+		accessKey, signature, err := parseQiniuMACAuthHeader(r.Header.Get("Authorization"))
+		if err != nil {
+			m.fail(w, http.StatusUnauthorized, interfaces.ErrCodeInvalidAuthHeader, "invalid_header", err.Error())
+			return
+		}
+
+		// 2. 检查请求时间（X-Qiniu-Date 参与签名，篡改会导致签名不匹配）
+		if err := m.checkDate(r.Header.Get(QiniuMACDateHeader)); err != nil {
+			m.fail(w, http.StatusUnauthorized, interfaces.ErrCodeTimestampExpired, "timestamp", err.Error())
+			return
+		}
+
+		// 3. 读取请求体（可能参与签名），并还原供后续 handler 读取
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodySize+1))
+		if err != nil {
+			m.fail(w, http.StatusBadRequest, interfaces.ErrCodeBadRequest, "error", "failed to read request body")
+			return
+		}
+		if len(body) > maxSignedBodySize {
+			m.fail(w, http.StatusRequestEntityTooLarge, interfaces.ErrCodeBadRequest, "error", "request body too large")
+			return
+		}
+
+		// 4. 通过 qconfapi 查询 SK 和 UID
+		info, err := m.accessInfo.GetAccessInfo(xlog.NewWith("ak="+accessKey), accessKey)
+		if err != nil {
+			if isQconfNotFound(err) {
+				m.fail(w, http.StatusUnauthorized, interfaces.ErrCodeAccessKeyNotFound, "access_key", ErrAccessKeyNotFound.Error())
+				return
+			}
+			observability.LogError(r.Context(), "Failed to get qiniu access info", err)
+			m.fail(w, http.StatusServiceUnavailable, interfaces.ErrCodeServiceUnavailable, "error", "failed to get access key info")
+			return
+		}
+
+		// 5. 验证签名
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		expected, err := qiniumac.SignRequest(info.Secret, r)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			observability.LogError(r.Context(), "Failed to sign qiniu request", err)
+			m.fail(w, http.StatusInternalServerError, interfaces.ErrCodeInternalServerError, "error", "authentication failed")
+			return
+		}
+		if !hmac.Equal([]byte(base64.URLEncoding.EncodeToString(expected)), []byte(signature)) {
+			m.fail(w, http.StatusUnauthorized, interfaces.ErrCodeInvalidSignature, "signature", ErrInvalidSignature.Error())
+			return
+		}
+
+		// 6. 映射七牛 UID 到 account_id（错误详情只记录日志）
+		accountID, err := m.qiniuUIDMapper.GetAccountIDByQiniuUID(r.Context(), info.Uid)
+		if err != nil {
+			observability.LogWarn(r.Context(), "Failed to map qiniu uid to account",
+				slog.Uint64("uid", uint64(info.Uid)),
+				slog.String("error", err.Error()))
+			m.fail(w, http.StatusUnauthorized, interfaces.ErrCodeUnauthorized, "access_key", "authentication failed")
+			return
+		}
+
+		// 7. 注入账户信息（与 QiniuStub 认证相同的 Context 值，服务层据此记录 UID）
+		qstubUser := &QstubUserInfo{
+			UID:    strconv.FormatUint(uint64(info.Uid), 10),
+			Appid:  info.Appid,
+			Access: accessKey,
+		}
+		ctx := context.WithValue(r.Context(), "account", &AccountInfo{ID: accountID})
+		ctx = context.WithValue(ctx, "account_id", accountID)
+		ctx = context.WithValue(ctx, "auth_method", "qiniu_mac") // 标记认证方式
+		ctx = context.WithValue(ctx, "qstub_user", qstubUser)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
+// checkDate 检查 X-Qiniu-Date 是否存在且在容忍范围内
+func (m *QiniuMACAuthMiddleware) checkDate(value string) error {
+	if value == "" {
+		return errors.New(QiniuMACDateHeader + " header is required")
+	}
+
+	date, err := time.Parse(qiniuMACDateLayout, value)
+	if err != nil {
+		return errors.New("invalid " + QiniuMACDateHeader + " header, expected format 20060102T150405Z")
+	}
+
+	skew := time.Since(date)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > m.dateTolerance {
+		return errors.New("request date expired")
+	}
+	return nil
+}
+
+// fail 记录失败指标并返回错误响应
+func (m *QiniuMACAuthMiddleware) fail(w http.ResponseWriter, statusCode int, code int, reason string, message string) {
+	observability.AuthFailuresTotal.WithLabelValues("qiniu_mac", reason).Inc()
+	respondAuthError(w, statusCode, code, message)
+}
+
+// parseQiniuMACAuthHeader 解析 "Qiniu {AccessKey}:{EncodedSign}"
+func parseQiniuMACAuthHeader(authHeader string) (string, string, error) {
+	if !strings.HasPrefix(authHeader, QiniuMACAuthScheme) {
+		return "", "", errors.New("invalid authorization header, expected 'Qiniu {AccessKey}:{EncodedSign}'")
+	}
+
+	credential := strings.TrimSpace(strings.TrimPrefix(authHeader, QiniuMACAuthScheme))
+	accessKey, signature, ok := strings.Cut(credential, ":")
+	if !ok || accessKey == "" || signature == "" {
+		return "", "", errors.New("invalid authorization header, expected 'Qiniu {AccessKey}:{EncodedSign}'")
+	}
+	return accessKey, signature, nil
+}
+
+// isQconfNotFound qconfapi 对不存在的条目返回 612 / 404
+func isQconfNotFound(err error) bool {
+	var httpErr interface{ HTTPCode() int }
+	return errors.As(err, &httpErr) && (httpErr.HTTPCode() == 612 || httpErr.HTTPCode() == http.StatusNotFound)
+}
@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/pkg/qconfapi"
+	"github.com/qiniu/xlog.v1"
+	"github.com/stretchr/testify/assert"
+	qiniumac "qiniu.com/auth/qiniumac.v1"
+)
+
+// ========================================
+// 辅助函数
+// ========================================
+
+const (
+	testQiniuAccessKey = "qiniu_ak"
+	testQiniuSecretKey = "qiniu_sk"
+	testQiniuUID       = 12345
+)
+
+// fakeAccessInfoGetter 只认识 testQiniuAccessKey 的 qconfapi 替身
+type fakeAccessInfoGetter struct{}
+
+func (fakeAccessInfoGetter) GetAccessInfo(l *xlog.Logger, accessKey string) (qconfapi.AccountAccessInfo, error) {
+	if accessKey != testQiniuAccessKey {
+		return qconfapi.AccountAccessInfo{}, qconfNotFoundError{}
+	}
+	return qconfapi.AccountAccessInfo{Secret: []byte(testQiniuSecretKey), Uid: testQiniuUID}, nil
+}
+
+type qconfNotFoundError struct{}
+
+func (qconfNotFoundError) Error() string { return "no such entry" }
+func (qconfNotFoundError) HTTPCode() int { return 612 }
+
+// newQiniuSignedRequest 按七牛 SDK 的方式签名请求（未指定 X-Qiniu-Date 时使用当前时间）
+func newQiniuSignedRequest(method, uri, contentType, body, accessKey string, qiniuHeaders map[string]string) *http.Request {
+	req := httptest.NewRequest(method, uri, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set(QiniuMACDateHeader, qiniuMACDate(time.Now()))
+	for k, v := range qiniuHeaders {
+		req.Header.Set(k, v)
+	}
+	sign, _ := qiniumac.SignRequest([]byte(testQiniuSecretKey), req)
+	req.Header.Set("Authorization", QiniuMACAuthScheme+accessKey+":"+base64.URLEncoding.EncodeToString(sign))
+	return req
+}
+
+func qiniuMACDate(t time.Time) string {
+	return t.UTC().Format(qiniuMACDateLayout)
+}
+
+// failingQiniuUIDMapper 映射总是失败的 QiniuUIDMapper
+type failingQiniuUIDMapper struct{}
+
+func (failingQiniuUIDMapper) GetAccountIDByQiniuUID(ctx context.Context, uid uint32) (string, error) {
+	return "", errors.New("mongo: connection refused at 10.0.0.5:27017")
+}
+
+// ========================================
+// TestQiniuMACAuthMiddleware
+// ========================================
+
+func TestQiniuMACAuthMiddleware_Success(t *testing.T) {
+	middleware := NewQiniuMACAuthMiddleware(fakeAccessInfoGetter{}, NewSimpleQiniuUIDMapper(), 0)
+
+	body := `{"description":"test"}`
+	req := newQiniuSignedRequest(http.MethodPost, "/api/v2/tokens?limit=10", "application/json", body, testQiniuAccessKey,
+		map[string]string{"X-Qiniu-Meta": "test"})
+
+	var gotBody, gotAccountID, gotMethod string
+	var gotUser *QstubUserInfo
+	handler := middleware.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		gotBody = string(data)
+		gotAccountID, _ = ExtractAccountIDFromContext(r.Context())
+		gotMethod = ExtractAuthMethod(r.Context())
+		gotUser = ExtractQstubUser(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, body, gotBody)
+	assert.Equal(t, "qiniu_12345", gotAccountID)
+	assert.Equal(t, "qiniu_mac", gotMethod)
+	if assert.NotNil(t, gotUser) {
+		assert.Equal(t, "12345", gotUser.UID)
+		assert.Equal(t, testQiniuAccessKey, gotUser.Access)
+	}
+}
+
+func TestQiniuMACAuthMiddleware_Rejected(t *testing.T) {
+	middleware := NewQiniuMACAuthMiddleware(fakeAccessInfoGetter{}, NewSimpleQiniuUIDMapper(), 0)
+
+	tamperedBody := newQiniuSignedRequest(http.MethodPost, "/api/v2/tokens", "application/json", `{"a":1}`, testQiniuAccessKey, nil)
+	tamperedBody.Body = io.NopCloser(strings.NewReader(`{"a":2}`))
+
+	now := time.Now()
+	tamperedHeader := newQiniuSignedRequest(http.MethodGet, "/api/v2/tokens", "", "", testQiniuAccessKey, nil)
+	tamperedHeader.Header.Set(QiniuMACDateHeader, qiniuMACDate(now.Add(time.Minute)))
+
+	missingDate := newQiniuSignedRequest(http.MethodGet, "/api/v2/tokens", "", "", testQiniuAccessKey, nil)
+	missingDate.Header.Del(QiniuMACDateHeader)
+
+	expiredDate := newQiniuSignedRequest(http.MethodGet, "/api/v2/tokens", "", "", testQiniuAccessKey,
+		map[string]string{QiniuMACDateHeader: qiniuMACDate(now.Add(-20 * time.Minute))})
+
+	futureDate := newQiniuSignedRequest(http.MethodGet, "/api/v2/tokens", "", "", testQiniuAccessKey,
+		map[string]string{QiniuMACDateHeader: qiniuMACDate(now.Add(20 * time.Minute))})
+
+	malformedDate := newQiniuSignedRequest(http.MethodGet, "/api/v2/tokens", "", "", testQiniuAccessKey,
+		map[string]string{QiniuMACDateHeader: now.UTC().Format(time.RFC3339)})
+
+	tamperedQuery := newQiniuSignedRequest(http.MethodGet, "/api/v2/tokens?limit=10", "", "", testQiniuAccessKey, nil)
+	tamperedQuery.URL.RawQuery = "limit=100"
+
+	malformed := httptest.NewRequest(http.MethodGet, "/api/v2/tokens", nil)
+	malformed.Header.Set("Authorization", QiniuMACAuthScheme+testQiniuAccessKey)
+
+	tests := []struct {
+		name       string
+		req        *http.Request
+		wantStatus int
+		wantCode   string
+	}{
+		{"tampered body", tamperedBody, http.StatusUnauthorized, `"code":4001`},
+		{"tampered X-Qiniu header", tamperedHeader, http.StatusUnauthorized, `"code":4001`},
+		{"missing date", missingDate, http.StatusUnauthorized, `"code":4002`},
+		{"expired date", expiredDate, http.StatusUnauthorized, `"code":4002`},
+		{"future date", futureDate, http.StatusUnauthorized, `"code":4002`},
+		{"malformed date", malformedDate, http.StatusUnauthorized, `"code":4002`},
+		{"tampered query", tamperedQuery, http.StatusUnauthorized, `"code":4001`},
+		{"unknown access key", newQiniuSignedRequest(http.MethodGet, "/api/v2/tokens", "", "", "unknown_ak", nil), http.StatusUnauthorized, `"code":4003`},
+		{"malformed header", malformed, http.StatusUnauthorized, `"code":4005`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+				t.Fatal("next handler should not be called")
+			})
+
+			w := httptest.NewRecorder()
+			handler(w, tt.req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantCode)
+		})
+	}
+}
+
+func TestQiniuMACAuthMiddleware_OctetStreamBodyNotSigned(t *testing.T) {
+	middleware := NewQiniuMACAuthMiddleware(fakeAccessInfoGetter{}, NewSimpleQiniuUIDMapper(), 0)
+
+	// application/octet-stream 的请求体不参与签名（incBody 规则）
+	req := newQiniuSignedRequest(http.MethodPost, "/api/v2/tokens", "application/octet-stream", "abc", testQiniuAccessKey, nil)
+	req.Body = io.NopCloser(strings.NewReader("xyz"))
+
+	w := httptest.NewRecorder()
+	middleware.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestQiniuMACAuthMiddleware_DateTolerance(t *testing.T) {
+	middleware := NewQiniuMACAuthMiddleware(fakeAccessInfoGetter{}, NewSimpleQiniuUIDMapper(), time.Hour)
+
+	req := newQiniuSignedRequest(http.MethodGet, "/api/v2/tokens", "", "", testQiniuAccessKey,
+		map[string]string{QiniuMACDateHeader: qiniuMACDate(time.Now().Add(-30 * time.Minute))})
+
+	w := httptest.NewRecorder()
+	middleware.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestQiniuMACAuthMiddleware_MappingErrorNotExposed(t *testing.T) {
+	middleware := NewQiniuMACAuthMiddleware(fakeAccessInfoGetter{}, failingQiniuUIDMapper{}, 0)
+
+	req := newQiniuSignedRequest(http.MethodGet, "/api/v2/tokens", "", "", testQiniuAccessKey, nil)
+
+	w := httptest.NewRecorder()
+	middleware.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next handler should not be called")
+	})(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "authentication failed")
+	assert.NotContains(t, w.Body.String(), "mongo")
+	assert.NotContains(t, w.Body.String(), "10.0.0.5")
+}
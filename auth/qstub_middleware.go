@@ -2,10 +2,15 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/observability"
 )
 
 // ========================================
@@ -15,7 +20,8 @@ import (
 // QstubAuthMiddleware QiniuStub 认证中间件
 // 只支持 QiniuStub 认证（七牛内部用户系统）
 type QstubAuthMiddleware struct {
-	qiniuUIDMapper QiniuUIDMapper // 将七牛 UID 映射到 account_id
+	qiniuUIDMapper QiniuUIDMapper    // 将七牛 UID 映射到 account_id
+	trust          *QstubTrustPolicy // 可信来源策略（nil 时拒绝所有 QiniuStub 请求）
 }
 
 // QiniuUIDMapper 七牛 UID 映射接口
@@ -51,6 +57,18 @@ func NewQstubAuthMiddleware(qiniuUIDMapper QiniuUIDMapper) *QstubAuthMiddleware
 	}
 }
 
+// SetTrustPolicy 设置可信来源策略
+func (m *QstubAuthMiddleware) SetTrustPolicy(policy *QstubTrustPolicy) {
+	m.trust = policy
+}
+
+// WithTrustPolicy 返回使用另一可信来源策略的副本（UID 映射器共享），用于按路由设置不同的策略
+func (m *QstubAuthMiddleware) WithTrustPolicy(policy *QstubTrustPolicy) *QstubAuthMiddleware {
+	clone := *m
+	clone.trust = policy
+	return &clone
+}
+
 // Authenticate QiniuStub 认证处理器
 //
 // 认证方式：
@@ -70,6 +88,12 @@ func (m *QstubAuthMiddleware) Authenticate(next http.HandlerFunc) http.HandlerFu
 			return
 		}
 
+		// 校验请求来源（QiniuStub 头不含凭证，只接受可信来源设置的头）
+		if err := m.trust.Verify(r); err != nil {
+			m.rejectUntrusted(w, r, err)
+			return
+		}
+
 		// 处理 QiniuStub 认证
 		m.authenticateQstub(w, r, next)
 	}
@@ -178,6 +202,37 @@ func (m *QstubAuthMiddleware) parseQstubURLParams(authHeader string) (*QstubUser
 	return userInfo, nil
 }
 
+// rejectUntrusted 记录并拒绝来自不可信来源的 QiniuStub 请求
+func (m *QstubAuthMiddleware) rejectUntrusted(w http.ResponseWriter, r *http.Request, err error) {
+	statusCode, code, reason, message := http.StatusUnauthorized, interfaces.ErrCodeInvalidSignature, "stub_signature", err.Error()
+	switch {
+	case errors.Is(err, ErrQstubUntrustedSource):
+		statusCode, code, reason = http.StatusForbidden, interfaces.ErrCodePermissionDenied, "untrusted_source"
+	case errors.Is(err, ErrQstubClientCertRequired):
+		code, reason = interfaces.ErrCodeUnauthorized, "client_cert"
+	case errors.Is(err, ErrQstubMissingSignature):
+		code = interfaces.ErrCodeInvalidAuthHeader
+	case errors.Is(err, ErrQstubSignatureExpired):
+		code = interfaces.ErrCodeTimestampExpired
+	case errors.Is(err, ErrQstubNonceReused):
+		code, reason = interfaces.ErrCodeNonceReused, "stub_nonce"
+	case errors.Is(err, ErrQstubNonceCheckFailed):
+		// 不向调用方暴露 nonce 存储的错误详情
+		statusCode, code, reason, message = http.StatusServiceUnavailable, interfaces.ErrCodeServiceUnavailable, "error", ErrQstubNonceCheckFailed.Error()
+	case errors.Is(err, ErrQstubBodyTooLarge):
+		statusCode, code, reason = http.StatusRequestEntityTooLarge, interfaces.ErrCodeBadRequest, "error"
+	}
+
+	observability.AuthFailuresTotal.WithLabelValues("qstub", reason).Inc()
+	observability.LogWarn(r.Context(), "Rejected untrusted QiniuStub request",
+		slog.String("reason", reason),
+		slog.String("remote_addr", r.RemoteAddr),
+		slog.String("x_forwarded_for", r.Header.Get("X-Forwarded-For")),
+		slog.String("path", r.URL.Path),
+		slog.String("error", err.Error()))
+	respondAuthError(w, statusCode, code, message)
+}
+
 // respondError 返回错误响应
 func (m *QstubAuthMiddleware) respondError(w http.ResponseWriter, statusCode int, message string) {
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,275 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/pkg/clientip"
+)
+
+// ========================================
+// QiniuStub 可信来源校验
+// ========================================
+
+const (
+	// QstubTimestampHeader 签名 QiniuStub 头的时间戳（Unix 秒）
+	QstubTimestampHeader = "X-Qiniu-Stub-Timestamp"
+
+	// QstubSignatureHeader 签名 QiniuStub 头的签名
+	QstubSignatureHeader = "X-Qiniu-Stub-Signature"
+
+	// QstubNonceHeader 签名 QiniuStub 头的一次性随机数（容忍窗口内不可重复使用）
+	QstubNonceHeader = "X-Qiniu-Stub-Nonce"
+
+	// DefaultQstubSignatureTolerance 默认签名时间戳容忍度
+	DefaultQstubSignatureTolerance = 5 * time.Minute
+)
+
+// QiniuStub 可信来源校验错误（中间件据此返回对应的业务错误码和指标 reason）
+var (
+	ErrQstubUntrustedSource    = errors.New("QiniuStub authentication is not accepted from this source")
+	ErrQstubClientCertRequired = errors.New("QiniuStub authentication requires a trusted client certificate")
+	ErrQstubMissingSignature   = errors.New("missing " + QstubSignatureHeader + ", " + QstubTimestampHeader + " or " + QstubNonceHeader + " header")
+	ErrQstubSignatureExpired   = errors.New("QiniuStub signature timestamp expired")
+	ErrQstubInvalidSignature   = errors.New("invalid QiniuStub signature")
+	ErrQstubNonceReused        = errors.New("QiniuStub signature nonce has already been used")
+	ErrQstubNonceCheckFailed   = errors.New("failed to check QiniuStub signature nonce")
+	ErrQstubBodyTooLarge       = errors.New("request body too large")
+)
+
+// QstubTrustOptions QiniuStub 可信来源配置，已配置的检查必须全部通过
+type QstubTrustOptions struct {
+	// 允许的来源地址（CIDR 或 IP），与请求经过的可验证地址链（直连地址 + 可信代理转发的 X-Forwarded-For）逐跳匹配
+	TrustedSources []string
+
+	// 要求 mTLS 客户端证书（由 HTTP 服务器按 TLS_CLIENT_CA_FILE 验证）
+	RequireClientCert bool
+
+	// 允许的客户端证书主体（CN 或 DNS SAN），为空时接受任何通过验证的证书
+	ClientCertSubjects []string
+
+	// 共享签名密钥，配置多个时任意一个验证通过即可（用于轮换）
+	SigningSecrets []string
+
+	// 签名时间戳容忍度
+	SignatureTolerance time.Duration
+
+	// 未配置任何校验时信任所有来源（显式关闭校验，仅用于开发 / 测试）；为 false 时未配置校验的策略拒绝所有请求
+	AllowAnySource bool
+}
+
+// QstubTrustPolicy QiniuStub 头的可信来源策略
+//
+// QiniuStub 头本身不含凭证，只应由内部网关设置，策略支持三种互相叠加的校验：
+//  1. 来源地址白名单：请求须直连自或经可信代理转发自白名单地址
+//  2. mTLS 客户端证书：请求须携带通过 CA 验证的证书，可限定证书主体
+//  3. 签名头：网关用共享密钥签名 QiniuStub 头、请求体摘要和一次性 nonce
+//
+// StringToSign = Authorization + "\n" + Method + " " + RequestURI + "\n" + Timestamp + "\n" + Nonce + "\n" + Hex(SHA256(Body))
+// Signature    = URLSafeBase64(HMAC-SHA256(Secret, StringToSign))
+//
+// 未配置任何校验的策略拒绝所有请求，除非显式设置 AllowAnySource；nil 策略同样拒绝所有请求
+type QstubTrustPolicy struct {
+	sources      []netip.Prefix
+	hops         *clientip.Resolver
+	requireCert  bool
+	certSubjects map[string]bool
+	secrets      []string
+	tolerance    time.Duration
+	allowAny     bool
+	nonces       NonceStore
+}
+
+// NewQstubTrustPolicy 创建可信来源策略
+// hops 用于沿可信代理还原转发链（可为 nil，只检查直连地址）
+func NewQstubTrustPolicy(opts QstubTrustOptions, hops *clientip.Resolver) (*QstubTrustPolicy, error) {
+	p := &QstubTrustPolicy{
+		hops:        hops,
+		requireCert: opts.RequireClientCert,
+		tolerance:   opts.SignatureTolerance,
+		allowAny:    opts.AllowAnySource,
+		nonces:      NewMemoryNonceStore(),
+	}
+	if p.tolerance <= 0 {
+		p.tolerance = DefaultQstubSignatureTolerance
+	}
+
+	for _, s := range opts.TrustedSources {
+		prefix, err := clientip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid QiniuStub trusted source %q: %w", s, err)
+		}
+		p.sources = append(p.sources, prefix)
+	}
+	if len(opts.ClientCertSubjects) > 0 {
+		p.certSubjects = make(map[string]bool, len(opts.ClientCertSubjects))
+		for _, subject := range opts.ClientCertSubjects {
+			p.certSubjects[subject] = true
+		}
+	}
+	for _, secret := range opts.SigningSecrets {
+		if secret != "" {
+			p.secrets = append(p.secrets, secret)
+		}
+	}
+	return p, nil
+}
+
+// SetNonceStore 设置签名 nonce 存储（默认进程内存储；多副本部署时应使用共享存储）
+func (p *QstubTrustPolicy) SetNonceStore(store NonceStore) {
+	p.nonces = store
+}
+
+// IsOpen 策略显式信任所有来源（未配置任何校验且设置了 AllowAnySource）
+func (p *QstubTrustPolicy) IsOpen() bool {
+	return p != nil && p.allowAny && p.isUnconfigured()
+}
+
+// IsClosed 策略拒绝所有请求（nil，或未配置任何校验且未设置 AllowAnySource）
+func (p *QstubTrustPolicy) IsClosed() bool {
+	return p == nil || (!p.allowAny && p.isUnconfigured())
+}
+
+// isUnconfigured 未配置任何校验
+func (p *QstubTrustPolicy) isUnconfigured() bool {
+	return len(p.sources) == 0 && !p.requireCert && len(p.secrets) == 0
+}
+
+// Verify 校验请求是否来自可信来源，nil 策略拒绝所有请求
+// 签名校验需要读取请求体，读取后还原供后续 handler 使用
+func (p *QstubTrustPolicy) Verify(r *http.Request) error {
+	if p.IsClosed() {
+		return ErrQstubUntrustedSource
+	}
+	if len(p.sources) > 0 && !p.fromTrustedSource(r) {
+		return ErrQstubUntrustedSource
+	}
+	if p.requireCert && !p.hasTrustedClientCert(r) {
+		return ErrQstubClientCertRequired
+	}
+	if len(p.secrets) > 0 {
+		if err := p.verifySignature(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SignQstubRequest 生成 QiniuStub 签名头的值（供网关及测试使用），body 为完整请求体（无请求体时为 nil）
+func SignQstubRequest(secret string, authHeader, method, requestURI, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(authHeader + "\n" + method + " " + requestURI + "\n" + timestamp + "\n" + nonce + "\n" + hex.EncodeToString(bodyHash[:])))
+	return base64.URLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// fromTrustedSource 请求经过的可验证地址链中是否有白名单地址
+func (p *QstubTrustPolicy) fromTrustedSource(r *http.Request) bool {
+	for _, hop := range p.hops.Hops(r) {
+		for _, prefix := range p.sources {
+			if prefix.Contains(hop) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasTrustedClientCert 请求是否携带通过验证且主体符合要求的客户端证书
+func (p *QstubTrustPolicy) hasTrustedClientCert(r *http.Request) bool {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return false
+	}
+	if p.certSubjects == nil {
+		return true
+	}
+	return p.matchesSubject(r.TLS.VerifiedChains[0][0])
+}
+
+// matchesSubject 证书 CN 或任一 DNS SAN 在允许列表中
+func (p *QstubTrustPolicy) matchesSubject(cert *x509.Certificate) bool {
+	if p.certSubjects[cert.Subject.CommonName] {
+		return true
+	}
+	for _, name := range cert.DNSNames {
+		if p.certSubjects[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// verifySignature 校验 QiniuStub 签名头，签名通过后登记 nonce（容忍窗口内重复使用时拒绝）
+func (p *QstubTrustPolicy) verifySignature(r *http.Request) error {
+	timestamp := r.Header.Get(QstubTimestampHeader)
+	signature := r.Header.Get(QstubSignatureHeader)
+	nonce := r.Header.Get(QstubNonceHeader)
+	if timestamp == "" || signature == "" || nonce == "" || len(nonce) > maxNonceLength {
+		return ErrQstubMissingSignature
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrQstubSignatureExpired
+	}
+	skew := time.Since(time.Unix(ts, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > p.tolerance {
+		return ErrQstubSignatureExpired
+	}
+
+	body, err := readSignedBody(r)
+	if err != nil {
+		return err
+	}
+
+	authHeader := r.Header.Get("Authorization")
+	for _, secret := range p.secrets {
+		expected := SignQstubRequest(secret, authHeader, r.Method, r.URL.RequestURI(), timestamp, nonce, body)
+		if hmac.Equal([]byte(expected), []byte(signature)) {
+			return p.useNonce(r, nonce)
+		}
+	}
+	return ErrQstubInvalidSignature
+}
+
+// useNonce 登记 nonce，保留时长覆盖时间戳前后两个容忍窗口
+func (p *QstubTrustPolicy) useNonce(r *http.Request, nonce string) error {
+	ok, err := p.nonces.Use(r.Context(), "qstub:"+nonce, 2*p.tolerance)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrQstubNonceCheckFailed, err)
+	}
+	if !ok {
+		return ErrQstubNonceReused
+	}
+	return nil
+}
+
+// readSignedBody 读取请求体（不超过 maxSignedBodySize）并还原
+func readSignedBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodySize+1))
+	if err != nil {
+		return nil, ErrQstubInvalidSignature
+	}
+	if len(body) > maxSignedBodySize {
+		return nil, ErrQstubBodyTooLarge
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
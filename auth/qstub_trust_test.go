@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/pkg/clientip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testStubSecret = "stub-secret"
+
+// newQstubRequest 构造来自指定地址的 QiniuStub 请求
+func newQstubRequest(remoteAddr string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/tokens?limit=10", nil)
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("Authorization", "QiniuStub uid=12345&ut=1")
+	return req
+}
+
+// newQstubBodyRequest 构造带请求体的 QiniuStub 请求
+func newQstubBodyRequest(remoteAddr, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/tokens", strings.NewReader(body))
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("Authorization", "QiniuStub uid=12345&ut=1")
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+// testQstubNonce 每次调用返回不同的 nonce
+var testQstubNonce int
+
+// signQstub 按网关流程为请求添加签名头（使用新的 nonce）
+func signQstub(req *http.Request, secret string, at time.Time) *http.Request {
+	testQstubNonce++
+	return signQstubWithNonce(req, secret, at, "nonce-"+strconv.Itoa(testQstubNonce))
+}
+
+// signQstubWithNonce 使用指定 nonce 签名（读取并还原请求体）
+func signQstubWithNonce(req *http.Request, secret string, at time.Time, nonce string) *http.Request {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		req.Body = io.NopCloser(strings.NewReader(string(body)))
+	}
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	req.Header.Set(QstubTimestampHeader, timestamp)
+	req.Header.Set(QstubNonceHeader, nonce)
+	req.Header.Set(QstubSignatureHeader, SignQstubRequest(secret, req.Header.Get("Authorization"), req.Method, req.URL.RequestURI(), timestamp, nonce, body))
+	return req
+}
+
+// failingNonceStore 总是出错的 nonce 存储
+type failingNonceStore struct{}
+
+func (failingNonceStore) Use(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return false, errors.New("redis: connection refused")
+}
+
+// newOpenQstubTrustPolicy 显式信任所有来源的策略
+func newOpenQstubTrustPolicy(t *testing.T) *QstubTrustPolicy {
+	policy, err := NewQstubTrustPolicy(QstubTrustOptions{AllowAnySource: true}, nil)
+	require.NoError(t, err)
+	return policy
+}
+
+// withClientCert 模拟已通过 CA 验证的 mTLS 客户端证书
+func withClientCert(req *http.Request, commonName string) *http.Request {
+	req.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: commonName}}}},
+	}
+	return req
+}
+
+func TestQstubTrustPolicy_Verify(t *testing.T) {
+	resolver, err := clientip.New([]string{"10.0.0.0/8"}, clientip.DefaultHeader, nil)
+	require.NoError(t, err)
+
+	sourcePolicy, err := NewQstubTrustPolicy(QstubTrustOptions{TrustedSources: []string{"172.16.0.0/12"}}, resolver)
+	require.NoError(t, err)
+	certPolicy, err := NewQstubTrustPolicy(QstubTrustOptions{RequireClientCert: true, ClientCertSubjects: []string{"gateway.internal"}}, nil)
+	require.NoError(t, err)
+	signedPolicy, err := NewQstubTrustPolicy(QstubTrustOptions{SigningSecrets: []string{"old-secret", testStubSecret}}, nil)
+	require.NoError(t, err)
+
+	viaProxy := newQstubRequest("10.0.0.2:5000")
+	viaProxy.Header.Set("X-Forwarded-For", "203.0.113.7, 172.16.0.5")
+	spoofed := newQstubRequest("203.0.113.7:5000")
+	spoofed.Header.Set("X-Forwarded-For", "172.16.0.5")
+	tamperedPath := signQstub(newQstubRequest("203.0.113.7:5000"), testStubSecret, time.Now())
+	tamperedPath.URL.Path = "/api/v2/admin/accounts"
+	tamperedBody := signQstub(newQstubBodyRequest("203.0.113.7:5000", `{"description":"a"}`), testStubSecret, time.Now())
+	tamperedBody.Body = io.NopCloser(strings.NewReader(`{"description":"b"}`))
+	missingNonce := signQstub(newQstubRequest("203.0.113.7:5000"), testStubSecret, time.Now())
+	missingNonce.Header.Del(QstubNonceHeader)
+
+	unconfigured, err := NewQstubTrustPolicy(QstubTrustOptions{}, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		policy  *QstubTrustPolicy
+		req     *http.Request
+		wantErr error
+	}{
+		{"direct from trusted source", sourcePolicy, newQstubRequest("172.16.0.5:5000"), nil},
+		{"forwarded by trusted proxy", sourcePolicy, viaProxy, nil},
+		{"untrusted peer cannot spoof", sourcePolicy, spoofed, ErrQstubUntrustedSource},
+		{"trusted client cert", certPolicy, withClientCert(newQstubRequest("203.0.113.7:5000"), "gateway.internal"), nil},
+		{"client cert subject not allowed", certPolicy, withClientCert(newQstubRequest("203.0.113.7:5000"), "other"), ErrQstubClientCertRequired},
+		{"no client cert", certPolicy, newQstubRequest("203.0.113.7:5000"), ErrQstubClientCertRequired},
+		{"valid signature", signedPolicy, signQstub(newQstubRequest("203.0.113.7:5000"), testStubSecret, time.Now()), nil},
+		{"missing signature", signedPolicy, newQstubRequest("203.0.113.7:5000"), ErrQstubMissingSignature},
+		{"wrong secret", signedPolicy, signQstub(newQstubRequest("203.0.113.7:5000"), "wrong", time.Now()), ErrQstubInvalidSignature},
+		{"signature replayed on another path", signedPolicy, tamperedPath, ErrQstubInvalidSignature},
+		{"signed body", signedPolicy, signQstub(newQstubBodyRequest("203.0.113.7:5000", `{"description":"a"}`), testStubSecret, time.Now()), nil},
+		{"tampered body", signedPolicy, tamperedBody, ErrQstubInvalidSignature},
+		{"missing nonce", signedPolicy, missingNonce, ErrQstubMissingSignature},
+		{"expired signature", signedPolicy, signQstub(newQstubRequest("203.0.113.7:5000"), testStubSecret, time.Now().Add(-time.Hour)), ErrQstubSignatureExpired},
+		{"unconfigured policy denies all", unconfigured, newQstubRequest("172.16.0.5:5000"), ErrQstubUntrustedSource},
+		{"explicit allow any source", newOpenQstubTrustPolicy(t), newQstubRequest("203.0.113.7:5000"), nil},
+		{"nil policy denies all", nil, newQstubRequest("203.0.113.7:5000"), ErrQstubUntrustedSource},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.policy.Verify(tt.req), tt.wantErr)
+		})
+	}
+}
+
+func TestQstubTrustPolicy_RejectsReusedNonce(t *testing.T) {
+	policy, err := NewQstubTrustPolicy(QstubTrustOptions{SigningSecrets: []string{testStubSecret}}, nil)
+	require.NoError(t, err)
+
+	now := time.Now()
+	assert.NoError(t, policy.Verify(signQstubWithNonce(newQstubRequest("203.0.113.7:5000"), testStubSecret, now, "n-1")))
+	assert.ErrorIs(t, policy.Verify(signQstubWithNonce(newQstubRequest("203.0.113.7:5000"), testStubSecret, now, "n-1")), ErrQstubNonceReused)
+
+	// 签名不匹配的请求不占用 nonce
+	assert.ErrorIs(t, policy.Verify(signQstubWithNonce(newQstubRequest("203.0.113.7:5000"), "wrong", now, "n-2")), ErrQstubInvalidSignature)
+	assert.NoError(t, policy.Verify(signQstubWithNonce(newQstubRequest("203.0.113.7:5000"), testStubSecret, now, "n-2")))
+}
+
+func TestQstubAuthMiddleware_SignedRequest(t *testing.T) {
+	policy, err := NewQstubTrustPolicy(QstubTrustOptions{SigningSecrets: []string{testStubSecret}}, nil)
+	require.NoError(t, err)
+	middleware := NewQstubAuthMiddleware(NewSimpleQiniuUIDMapper())
+	middleware.SetTrustPolicy(policy)
+
+	// 签名校验读取请求体后还原，后续 handler 可以读取
+	var gotBody string
+	handler := middleware.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		gotBody = string(data)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := signQstubWithNonce(newQstubBodyRequest("203.0.113.7:5000", `{"description":"a"}`), testStubSecret, time.Now(), "n-1")
+	w := httptest.NewRecorder()
+	handler(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"description":"a"}`, gotBody)
+
+	// 重放
+	req = signQstubWithNonce(newQstubBodyRequest("203.0.113.7:5000", `{"description":"a"}`), testStubSecret, time.Now(), "n-1")
+	w = httptest.NewRecorder()
+	handler(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":4006`)
+
+	// nonce 存储出错：503，不暴露错误详情
+	policy.SetNonceStore(failingNonceStore{})
+	req = signQstubWithNonce(newQstubRequest("203.0.113.7:5000"), testStubSecret, time.Now(), "n-2")
+	w = httptest.NewRecorder()
+	handler(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.NotContains(t, w.Body.String(), "redis")
+}
+
+func TestQstubAuthMiddleware_TrustPolicyPerRoute(t *testing.T) {
+	adminPolicy, err := NewQstubTrustPolicy(QstubTrustOptions{TrustedSources: []string{"172.16.0.1"}}, nil)
+	require.NoError(t, err)
+
+	middleware := NewQstubAuthMiddleware(NewSimpleQiniuUIDMapper())
+	middleware.SetTrustPolicy(newOpenQstubTrustPolicy(t))
+	adminMiddleware := middleware.WithTrustPolicy(adminPolicy)
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	// 普通路由显式信任所有来源
+	w := httptest.NewRecorder()
+	middleware.Authenticate(ok)(w, newQstubRequest("203.0.113.7:5000"))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 未设置策略的中间件拒绝所有 QiniuStub 请求
+	w = httptest.NewRecorder()
+	NewQstubAuthMiddleware(NewSimpleQiniuUIDMapper()).Authenticate(ok)(w, newQstubRequest("172.16.0.1:5000"))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 管理员路由只接受白名单来源
+	w = httptest.NewRecorder()
+	adminMiddleware.Authenticate(ok)(w, newQstubRequest("203.0.113.7:5000"))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":4031`)
+
+	w = httptest.NewRecorder()
+	adminMiddleware.Authenticate(ok)(w, newQstubRequest("172.16.0.1:5000"))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
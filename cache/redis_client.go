@@ -11,8 +11,10 @@ import (
 // RedisClient Redis 客户端接口
 type RedisClient interface {
 	Get(ctx context.Context, key string) (string, error)
+	MGet(ctx context.Context, keys ...string) ([]any, error) // 不存在的 key 对应 nil
 	Set(ctx context.Context, key string, value any, ttl time.Duration) error
 	Del(ctx context.Context, keys ...string) error
+	Eval(ctx context.Context, script string, keys []string, args ...any) (any, error)
 	Ping(ctx context.Context) error
 	Close() error
 }
@@ -72,6 +74,10 @@ func (c *singleClient) Get(ctx context.Context, key string) (string, error) {
 	return c.client.Get(ctx, key).Result()
 }
 
+func (c *singleClient) MGet(ctx context.Context, keys ...string) ([]any, error) {
+	return c.client.MGet(ctx, keys...).Result()
+}
+
 func (c *singleClient) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
 	return c.client.Set(ctx, key, value, ttl).Err()
 }
@@ -80,6 +86,11 @@ func (c *singleClient) Del(ctx context.Context, keys ...string) error {
 	return c.client.Del(ctx, keys...).Err()
 }
 
+// Eval 执行 Lua 脚本（优先 EVALSHA，脚本未加载时自动回退 EVAL）
+func (c *singleClient) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+	return redis.NewScript(script).Run(ctx, c.client, keys, args...).Result()
+}
+
 func (c *singleClient) Ping(ctx context.Context) error {
 	return c.client.Ping(ctx).Err()
 }
@@ -118,6 +129,36 @@ func (c *clusterClient) Get(ctx context.Context, key string) (string, error) {
 	return c.client.Get(ctx, key).Result()
 }
 
+// MGet 批量读取（key 可能分布在不同 slot，使用 pipeline 按节点分发 GET，而非 MGET）
+// 部分节点出错时对应 key 视为不存在；全部出错时返回错误
+func (c *clusterClient) MGet(ctx context.Context, keys ...string) ([]any, error) {
+	cmds := make([]*redis.StringCmd, len(keys))
+	c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Get(ctx, key)
+		}
+		return nil
+	})
+
+	values := make([]any, len(keys))
+	var lastErr error
+	failed := 0
+	for i, cmd := range cmds {
+		v, err := cmd.Result()
+		switch {
+		case err == nil:
+			values[i] = v
+		case err != redis.Nil:
+			lastErr = err
+			failed++
+		}
+	}
+	if len(keys) > 0 && failed == len(keys) {
+		return nil, lastErr
+	}
+	return values, nil
+}
+
 func (c *clusterClient) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
 	return c.client.Set(ctx, key, value, ttl).Err()
 }
@@ -126,6 +167,11 @@ func (c *clusterClient) Del(ctx context.Context, keys ...string) error {
 	return c.client.Del(ctx, keys...).Err()
 }
 
+// Eval 执行 Lua 脚本（集群模式下 keys 必须位于同一 slot）
+func (c *clusterClient) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+	return redis.NewScript(script).Run(ctx, c.client, keys, args...).Result()
+}
+
 func (c *clusterClient) Ping(ctx context.Context) error {
 	return c.client.Ping(ctx).Err()
 }
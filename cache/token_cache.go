@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/qiniu/bearer-token-service/v2/interfaces"
@@ -13,15 +14,20 @@ import (
 
 // TokenCache Token 缓存接口
 type TokenCache interface {
-	GetByTokenValue(ctx context.Context, tokenValue string) (*interfaces.Token, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*interfaces.Token, error)
+	GetByTokenHashes(ctx context.Context, tokenHashes []string) (map[string]*interfaces.Token, error)
 	GetByID(ctx context.Context, tokenID string) (*interfaces.Token, error)
-	InvalidateByTokenValue(ctx context.Context, tokenValue string) error
+	InvalidateByTokenHash(ctx context.Context, tokenHash string) error
 	InvalidateByID(ctx context.Context, tokenID string) error
+
+	// Wait 等待异步缓存写入完成（服务关闭、Redis 客户端关闭前调用）
+	Wait(ctx context.Context) error
 }
 
 // DirectTokenFetcher 直接数据库查询接口（绕过缓存，避免循环调用）
 type DirectTokenFetcher interface {
-	GetByTokenValueDirect(ctx context.Context, tokenValue string) (*interfaces.Token, error)
+	GetByTokenHashDirect(ctx context.Context, tokenHash string) (*interfaces.Token, error)
+	GetByTokenHashesDirect(ctx context.Context, tokenHashes []string) (map[string]*interfaces.Token, error)
 	GetByIDDirect(ctx context.Context, tokenID string) (*interfaces.Token, error)
 }
 
@@ -30,6 +36,9 @@ type TokenCacheImpl struct {
 	redis   RedisClient
 	fetcher DirectTokenFetcher
 	baseTTL time.Duration
+
+	// 在途的异步缓存写入
+	writers sync.WaitGroup
 }
 
 // NewTokenCache 创建 Token 缓存
@@ -41,9 +50,10 @@ func NewTokenCache(redis RedisClient, fetcher DirectTokenFetcher, baseTTL time.D
 	}
 }
 
-// GetByTokenValue 通过 TokenValue 获取 Token（含缓存）
-func (c *TokenCacheImpl) GetByTokenValue(ctx context.Context, tokenValue string) (*interfaces.Token, error) {
-	cacheKey := fmt.Sprintf("token:val:%s", tokenValue)
+// GetByTokenHash 通过 token 值的哈希获取 Token（含缓存）
+// 缓存键只包含哈希，Redis 中不出现明文 token
+func (c *TokenCacheImpl) GetByTokenHash(ctx context.Context, tokenHash string) (*interfaces.Token, error) {
+	cacheKey := fmt.Sprintf("token:hash:%s", tokenHash)
 	start := time.Now()
 
 	// 1. 尝试从 Redis 读取
@@ -68,18 +78,88 @@ func (c *TokenCacheImpl) GetByTokenValue(ctx context.Context, tokenValue string)
 	}
 
 	// 2. Redis 未命中或出错，降级到 MongoDB（使用 Direct 方法避免循环调用）
-	token, err := c.fetcher.GetByTokenValueDirect(ctx, tokenValue)
+	token, err := c.fetcher.GetByTokenHashDirect(ctx, tokenHash)
 	if err != nil {
 		observability.CacheOperationsTotal.WithLabelValues("get", "error").Inc()
 		return nil, err
 	}
 
 	// 3. 异步写入缓存（包括空对象）
-	go c.cacheToken(context.Background(), cacheKey, token)
+	c.cacheTokenByHashAsync(tokenHash, token)
 
 	return token, nil
 }
 
+// GetByTokenHashes 批量通过 token 值的哈希获取 Token（含缓存）
+// 先用一次 MGET 读取 Redis，未命中的哈希再批量查询 MongoDB；返回以哈希为键的结果（不存在的哈希不在结果中）
+func (c *TokenCacheImpl) GetByTokenHashes(ctx context.Context, tokenHashes []string) (map[string]*interfaces.Token, error) {
+	result := make(map[string]*interfaces.Token, len(tokenHashes))
+	if len(tokenHashes) == 0 {
+		return result, nil
+	}
+
+	keys := make([]string, len(tokenHashes))
+	for i, tokenHash := range tokenHashes {
+		keys[i] = fmt.Sprintf("token:hash:%s", tokenHash)
+	}
+	start := time.Now()
+
+	// 1. 尝试从 Redis 批量读取（出错时全部视为未命中）
+	values, err := c.redis.MGet(ctx, keys...)
+	if err != nil {
+		values = make([]any, len(keys))
+	} else {
+		observability.CacheOperationDuration.WithLabelValues("mget").Observe(time.Since(start).Seconds())
+	}
+
+	var misses []string
+	for i, tokenHash := range tokenHashes {
+		cached, ok := values[i].(string)
+		if !ok {
+			observability.CacheOperationsTotal.WithLabelValues("get", "miss").Inc()
+			misses = append(misses, tokenHash)
+			continue
+		}
+
+		if cached == "null" {
+			// 空对象缓存（防穿透）
+			observability.CacheOperationsTotal.WithLabelValues("get", "hit").Inc()
+			continue
+		}
+
+		var token interfaces.Token
+		if err := json.Unmarshal([]byte(cached), &token); err != nil {
+			observability.CacheOperationsTotal.WithLabelValues("get", "miss").Inc()
+			misses = append(misses, tokenHash)
+			continue
+		}
+		observability.CacheOperationsTotal.WithLabelValues("get", "hit").Inc()
+		result[tokenHash] = &token
+	}
+
+	if len(misses) == 0 {
+		return result, nil
+	}
+
+	// 2. 未命中的哈希降级到 MongoDB 批量查询
+	fetched, err := c.fetcher.GetByTokenHashesDirect(ctx, misses)
+	if err != nil {
+		observability.CacheOperationsTotal.WithLabelValues("get", "error").Inc()
+		return nil, err
+	}
+
+	// 3. 异步写入缓存（包括空对象）
+	for _, tokenHash := range misses {
+		token := fetched[tokenHash]
+		if token != nil {
+			result[tokenHash] = token
+		}
+		c.cacheTokenByHashAsync(tokenHash, token)
+	}
+
+	return result, nil
+}
+
 // GetByID 通过 ID 获取 Token（含缓存）
 func (c *TokenCacheImpl) GetByID(ctx context.Context, tokenID string) (*interfaces.Token, error) {
 	cacheKey := fmt.Sprintf("token:id:%s", tokenID)
@@ -113,14 +193,14 @@ func (c *TokenCacheImpl) GetByID(ctx context.Context, tokenID string) (*interfac
 	}
 
 	// 3. 异步写入缓存
-	go c.cacheToken(context.Background(), cacheKey, token)
+	c.cacheTokenAsync(cacheKey, token, 0)
 
 	return token, nil
 }
 
-// InvalidateByTokenValue 失效缓存（通过 TokenValue）
-func (c *TokenCacheImpl) InvalidateByTokenValue(ctx context.Context, tokenValue string) error {
-	return c.redis.Del(ctx, fmt.Sprintf("token:val:%s", tokenValue))
+// InvalidateByTokenHash 失效缓存（通过 token 值的哈希）
+func (c *TokenCacheImpl) InvalidateByTokenHash(ctx context.Context, tokenHash string) error {
+	return c.redis.Del(ctx, fmt.Sprintf("token:hash:%s", tokenHash))
 }
 
 // InvalidateByID 失效缓存（通过 ID）
@@ -128,8 +208,47 @@ func (c *TokenCacheImpl) InvalidateByID(ctx context.Context, tokenID string) err
 	return c.redis.Del(ctx, fmt.Sprintf("token:id:%s", tokenID))
 }
 
+// Wait 等待异步缓存写入完成
+func (c *TokenCacheImpl) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		c.writers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// cacheTokenByHashAsync 异步写入哈希查询的结果
+// 通过轮换前的旧值命中时，缓存时长不超过宽限期，保证宽限期结束后旧值缓存随之失效
+func (c *TokenCacheImpl) cacheTokenByHashAsync(tokenHash string, token *interfaces.Token) {
+	var maxTTL time.Duration
+	if token != nil && token.TokenHash != tokenHash && token.PreviousTokenExpiresAt != nil {
+		maxTTL = time.Until(*token.PreviousTokenExpiresAt)
+		if maxTTL <= 0 {
+			return
+		}
+	}
+	c.cacheTokenAsync(fmt.Sprintf("token:hash:%s", tokenHash), token, maxTTL)
+}
+
+// cacheTokenAsync 异步写入缓存（不阻塞查询，关闭时通过 Wait 等待完成）
+func (c *TokenCacheImpl) cacheTokenAsync(cacheKey string, token *interfaces.Token, maxTTL time.Duration) {
+	c.writers.Add(1)
+	go func() {
+		defer c.writers.Done()
+		c.cacheToken(context.Background(), cacheKey, token, maxTTL)
+	}()
+}
+
 // cacheToken 写入缓存（带 TTL 抖动 + 空对象缓存）
-func (c *TokenCacheImpl) cacheToken(ctx context.Context, cacheKey string, token *interfaces.Token) {
+// maxTTL > 0 时 TTL 不超过该值
+func (c *TokenCacheImpl) cacheToken(ctx context.Context, cacheKey string, token *interfaces.Token, maxTTL time.Duration) {
 	start := time.Now()
 
 	if token == nil {
@@ -153,6 +272,9 @@ func (c *TokenCacheImpl) cacheToken(ctx context.Context, cacheKey string, token
 	// TTL 加随机抖动（± 10%），防缓存雪崩
 	jitter := time.Duration(rand.Intn(int(c.baseTTL.Seconds() / 10)))
 	ttl := c.baseTTL + jitter*time.Second
+	if maxTTL > 0 && ttl > maxTTL {
+		ttl = maxTTL
+	}
 
 	err = c.redis.Set(ctx, cacheKey, data, ttl)
 	if err != nil {
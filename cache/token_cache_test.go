@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTokenFetcher 内存版 DirectTokenFetcher，记录批量查询的哈希
+type fakeTokenFetcher struct {
+	mu      sync.Mutex
+	tokens  map[string]*interfaces.Token // 以哈希为键
+	batches [][]string
+}
+
+func (f *fakeTokenFetcher) GetByTokenHashDirect(ctx context.Context, tokenHash string) (*interfaces.Token, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.tokens[tokenHash], nil
+}
+
+func (f *fakeTokenFetcher) GetByTokenHashesDirect(ctx context.Context, tokenHashes []string) (map[string]*interfaces.Token, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, tokenHashes)
+	result := make(map[string]*interfaces.Token)
+	for _, h := range tokenHashes {
+		if token, ok := f.tokens[h]; ok {
+			cp := *token
+			result[h] = &cp
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeTokenFetcher) GetByIDDirect(ctx context.Context, tokenID string) (*interfaces.Token, error) {
+	return nil, nil
+}
+
+// ========================================
+// TestTokenCache
+// ========================================
+
+func TestTokenCache_GetByTokenHashes(t *testing.T) {
+	redis := newFakeRedis()
+	fetcher := &fakeTokenFetcher{tokens: map[string]*interfaces.Token{
+		"h1": {ID: "tk_1", TokenHash: "h1", IsActive: true},
+		"h2": {ID: "tk_2", TokenHash: "h2", IsActive: true},
+	}}
+	c := NewTokenCache(redis, fetcher, time.Hour)
+	ctx := context.Background()
+
+	// 首次查询全部未命中，一次批量回源，结果（包括空对象）写入 Redis
+	tokens, err := c.GetByTokenHashes(ctx, []string{"h1", "h2", "h3"})
+	require.NoError(t, err)
+	assert.Len(t, tokens, 2)
+	assert.Equal(t, "tk_1", tokens["h1"].ID)
+	require.NoError(t, c.Wait(ctx))
+	assert.Equal(t, [][]string{{"h1", "h2", "h3"}}, fetcher.batches)
+
+	// 再次查询全部从 Redis 命中（h3 为空对象缓存），只有新哈希回源
+	tokens, err = c.GetByTokenHashes(ctx, []string{"h1", "h3", "h4"})
+	require.NoError(t, err)
+	assert.Len(t, tokens, 1)
+	assert.Equal(t, "tk_1", tokens["h1"].ID)
+	assert.Equal(t, [][]string{{"h1", "h2", "h3"}, {"h4"}}, fetcher.batches)
+}
@@ -0,0 +1,320 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/observability"
+	"golang.org/x/sync/singleflight"
+)
+
+// userInfoRefreshTimeout 后台刷新单次查询超时
+const userInfoRefreshTimeout = 5 * time.Second
+
+// UserInfoCacheConfig 用户信息缓存配置
+type UserInfoCacheConfig struct {
+	Size        int           // 进程内 LRU 容量（条目数）
+	TTL         time.Duration // 条目新鲜时长
+	StaleTTL    time.Duration // 过期后仍可返回旧值的时长（期间后台刷新，后端出错时继续返回旧值）
+	NegativeTTL time.Duration // 用户不存在结果的缓存时长
+}
+
+// userInfoEntry 进程内缓存条目
+type userInfoEntry struct {
+	uid        uint32
+	info       *interfaces.UserInfo // nil 表示用户不存在（负缓存）
+	freshUntil time.Time
+	staleUntil time.Time
+}
+
+// UserInfoCache 用户信息缓存（装饰 UserInfoRepository）
+// 两级缓存：进程内 LRU + 可选 Redis；用户不存在的结果做短时负缓存；
+// 条目过期后在 StaleTTL 内直接返回旧值并在后台刷新（stale-while-revalidate），后端出错时保留旧值
+type UserInfoCache struct {
+	backend interfaces.UserInfoRepository
+	redis   RedisClient // 可选，nil 时只使用进程内缓存
+	config  UserInfoCacheConfig
+
+	mu         sync.Mutex
+	lru        *list.List // 队首为最近使用
+	items      map[uint32]*list.Element
+	refreshing map[uint32]struct{}
+
+	group   singleflight.Group
+	writers sync.WaitGroup // 在途的后台刷新与 Redis 写入
+}
+
+// NewUserInfoCache 创建用户信息缓存
+func NewUserInfoCache(backend interfaces.UserInfoRepository, redis RedisClient, config UserInfoCacheConfig) *UserInfoCache {
+	if config.Size <= 0 {
+		config.Size = 10000
+	}
+	if config.TTL <= 0 {
+		config.TTL = 5 * time.Minute
+	}
+	if config.NegativeTTL <= 0 {
+		config.NegativeTTL = time.Minute
+	}
+
+	return &UserInfoCache{
+		backend:    backend,
+		redis:      redis,
+		config:     config,
+		lru:        list.New(),
+		items:      make(map[uint32]*list.Element),
+		refreshing: make(map[uint32]struct{}),
+	}
+}
+
+// GetUserInfoByUID 根据 UID 查询用户信息（含缓存）
+func (c *UserInfoCache) GetUserInfoByUID(ctx context.Context, uid uint32) (*interfaces.UserInfo, error) {
+	return c.get(ctx, uid, true)
+}
+
+// GetFreshUserInfoByUID 根据 UID 查询用户信息，不返回已过期的旧值
+// 供用户状态检查使用：冻结 / 解冻的生效延迟不超过 TTL，不受 StaleTTL 影响；后端出错时直接返回错误
+func (c *UserInfoCache) GetFreshUserInfoByUID(ctx context.Context, uid uint32) (*interfaces.UserInfo, error) {
+	return c.get(ctx, uid, false)
+}
+
+// get 查询用户信息，allowStale=false 时过期条目按未命中处理
+func (c *UserInfoCache) get(ctx context.Context, uid uint32, allowStale bool) (*interfaces.UserInfo, error) {
+	now := time.Now()
+
+	// 1. 进程内缓存
+	if entry, ok := c.getLocal(uid); ok {
+		switch {
+		case now.Before(entry.freshUntil):
+			if entry.info == nil {
+				observability.UserInfoCacheLookupsTotal.WithLabelValues("memory", "negative_hit").Inc()
+				return nil, userNotFound(uid)
+			}
+			observability.UserInfoCacheLookupsTotal.WithLabelValues("memory", "hit").Inc()
+			return copyUserInfo(entry.info), nil
+
+		case allowStale && entry.info != nil && now.Before(entry.staleUntil):
+			// 已过期但仍在容忍期内：返回旧值，后台刷新
+			observability.UserInfoCacheLookupsTotal.WithLabelValues("memory", "stale").Inc()
+			c.refreshAsync(uid)
+			return copyUserInfo(entry.info), nil
+		}
+	}
+	observability.UserInfoCacheLookupsTotal.WithLabelValues("memory", "miss").Inc()
+
+	// 2. Redis 缓存
+	if c.redis != nil {
+		if info, found, ok := c.getRedis(ctx, uid); ok {
+			if !found {
+				c.storeLocal(uid, nil, c.config.NegativeTTL, 0)
+				return nil, userNotFound(uid)
+			}
+			c.storeLocal(uid, info, c.config.TTL, c.config.StaleTTL)
+			return copyUserInfo(info), nil
+		}
+	}
+
+	// 3. 查询后端（同一 UID 的并发查询合并为一次）
+	v, err, _ := c.group.Do(strconv.FormatUint(uint64(uid), 10), func() (interface{}, error) {
+		return c.fetch(ctx, uid)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyUserInfo(v.(*interfaces.UserInfo)), nil
+}
+
+// Wait 等待后台刷新与 Redis 写入完成（服务关闭、Redis 客户端关闭前调用）
+func (c *UserInfoCache) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		c.writers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fetch 查询后端并写入缓存
+// 用户不存在时写入负缓存；其他错误不写缓存
+func (c *UserInfoCache) fetch(ctx context.Context, uid uint32) (*interfaces.UserInfo, error) {
+	info, err := c.backend.GetUserInfoByUID(ctx, uid)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrUserNotFound) {
+			c.storeLocal(uid, nil, c.config.NegativeTTL, 0)
+			c.setRedisAsync(uid, nil, c.config.NegativeTTL)
+		}
+		return nil, err
+	}
+
+	c.storeLocal(uid, info, c.config.TTL, c.config.StaleTTL)
+	c.setRedisAsync(uid, info, c.config.TTL)
+	return info, nil
+}
+
+// refreshAsync 后台刷新过期条目（同一 UID 同时只有一个刷新）
+func (c *UserInfoCache) refreshAsync(uid uint32) {
+	c.mu.Lock()
+	if _, ok := c.refreshing[uid]; ok {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing[uid] = struct{}{}
+	c.mu.Unlock()
+
+	c.writers.Add(1)
+	go func() {
+		defer c.writers.Done()
+		defer func() {
+			c.mu.Lock()
+			delete(c.refreshing, uid)
+			c.mu.Unlock()
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), userInfoRefreshTimeout)
+		defer cancel()
+
+		_, err, _ := c.group.Do(strconv.FormatUint(uint64(uid), 10), func() (interface{}, error) {
+			return c.fetch(ctx, uid)
+		})
+		if err != nil && !errors.Is(err, interfaces.ErrUserNotFound) {
+			// 后端出错：保留旧值，直到容忍期结束
+			observability.LogWarn(ctx, "Failed to refresh user info, serving stale entry",
+				slog.Uint64("uid", uint64(uid)),
+				slog.String("error", err.Error()))
+		}
+	}()
+}
+
+// ========================================
+// 进程内 LRU
+// ========================================
+
+func (c *UserInfoCache) getLocal(uid uint32) (userInfoEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[uid]
+	if !ok {
+		return userInfoEntry{}, false
+	}
+	c.lru.MoveToFront(elem)
+	return *elem.Value.(*userInfoEntry), true
+}
+
+func (c *UserInfoCache) storeLocal(uid uint32, info *interfaces.UserInfo, ttl, staleTTL time.Duration) {
+	now := time.Now()
+	entry := &userInfoEntry{
+		uid:        uid,
+		info:       info,
+		freshUntil: now.Add(ttl),
+		staleUntil: now.Add(ttl + staleTTL),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[uid]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.items[uid] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.config.Size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.items, oldest.Value.(*userInfoEntry).uid)
+	}
+	observability.UserInfoCacheEntries.Set(float64(c.lru.Len()))
+}
+
+// ========================================
+// Redis
+// ========================================
+
+func userInfoCacheKey(uid uint32) string {
+	return fmt.Sprintf("userinfo:uid:%d", uid)
+}
+
+// getRedis 从 Redis 读取，ok=false 表示未命中或出错
+func (c *UserInfoCache) getRedis(ctx context.Context, uid uint32) (info *interfaces.UserInfo, found bool, ok bool) {
+	start := time.Now()
+
+	cached, err := c.redis.Get(ctx, userInfoCacheKey(uid))
+	if err != nil {
+		observability.UserInfoCacheLookupsTotal.WithLabelValues("redis", "miss").Inc()
+		observability.CacheOperationsTotal.WithLabelValues("get", "miss").Inc()
+		return nil, false, false
+	}
+	observability.CacheOperationsTotal.WithLabelValues("get", "hit").Inc()
+	observability.CacheOperationDuration.WithLabelValues("get").Observe(time.Since(start).Seconds())
+
+	if cached == "null" {
+		observability.UserInfoCacheLookupsTotal.WithLabelValues("redis", "negative_hit").Inc()
+		return nil, false, true
+	}
+
+	var userInfo interfaces.UserInfo
+	if err := json.Unmarshal([]byte(cached), &userInfo); err != nil {
+		observability.UserInfoCacheLookupsTotal.WithLabelValues("redis", "error").Inc()
+		return nil, false, false
+	}
+	observability.UserInfoCacheLookupsTotal.WithLabelValues("redis", "hit").Inc()
+	return &userInfo, true, true
+}
+
+// setRedisAsync 异步写入 Redis（info 为 nil 时写入空对象）
+func (c *UserInfoCache) setRedisAsync(uid uint32, info *interfaces.UserInfo, ttl time.Duration) {
+	if c.redis == nil {
+		return
+	}
+
+	value := "null"
+	if info != nil {
+		data, err := json.Marshal(info)
+		if err != nil {
+			return
+		}
+		value = string(data)
+	}
+
+	c.writers.Add(1)
+	go func() {
+		defer c.writers.Done()
+
+		start := time.Now()
+		if err := c.redis.Set(context.Background(), userInfoCacheKey(uid), value, ttl); err != nil {
+			observability.CacheOperationsTotal.WithLabelValues("set", "error").Inc()
+			return
+		}
+		observability.CacheOperationsTotal.WithLabelValues("set", "success").Inc()
+		observability.CacheOperationDuration.WithLabelValues("set").Observe(time.Since(start).Seconds())
+	}()
+}
+
+// ========================================
+// 辅助函数
+// ========================================
+
+// copyUserInfo 返回副本（调用方可能修改返回值，例如写入 IUID）
+func copyUserInfo(info *interfaces.UserInfo) *interfaces.UserInfo {
+	cp := *info
+	return &cp
+}
+
+func userNotFound(uid uint32) error {
+	return fmt.Errorf("%w: uid=%d", interfaces.ErrUserNotFound, uid)
+}
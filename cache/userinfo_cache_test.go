@@ -0,0 +1,239 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ========================================
+// 测试替身
+// ========================================
+
+// fakeUserInfoRepo 可控的用户信息后端
+type fakeUserInfoRepo struct {
+	mu    sync.Mutex
+	users map[uint32]*interfaces.UserInfo
+	err   error
+	calls int
+}
+
+func (f *fakeUserInfoRepo) GetUserInfoByUID(ctx context.Context, uid uint32) (*interfaces.UserInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	info, ok := f.users[uid]
+	if !ok {
+		return nil, fmt.Errorf("%w: uid=%d", interfaces.ErrUserNotFound, uid)
+	}
+	cp := *info
+	return &cp, nil
+}
+
+func (f *fakeUserInfoRepo) setErr(err error) {
+	f.mu.Lock()
+	f.err = err
+	f.mu.Unlock()
+}
+
+func (f *fakeUserInfoRepo) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+// fakeRedis 内存版 RedisClient（忽略 TTL）
+type fakeRedis struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{data: make(map[string]string)}
+}
+
+func (r *fakeRedis) Get(ctx context.Context, key string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.data[key]
+	if !ok {
+		return "", errors.New("redis: nil")
+	}
+	return v, nil
+}
+
+func (r *fakeRedis) MGet(ctx context.Context, keys ...string) ([]any, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		if v, ok := r.data[key]; ok {
+			values[i] = v
+		}
+	}
+	return values, nil
+}
+
+func (r *fakeRedis) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if b, ok := value.([]byte); ok {
+		r.data[key] = string(b)
+	} else {
+		r.data[key] = fmt.Sprint(value)
+	}
+	return nil
+}
+
+func (r *fakeRedis) Del(ctx context.Context, keys ...string) error { return nil }
+func (r *fakeRedis) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+	return nil, nil
+}
+func (r *fakeRedis) Ping(ctx context.Context) error { return nil }
+func (r *fakeRedis) Close() error                   { return nil }
+
+// ========================================
+// TestUserInfoCache
+// ========================================
+
+func TestUserInfoCache_HitAndNegative(t *testing.T) {
+	backend := &fakeUserInfoRepo{users: map[uint32]*interfaces.UserInfo{1: {UID: 1, Email: "a@example.com"}}}
+	c := NewUserInfoCache(backend, nil, UserInfoCacheConfig{TTL: time.Minute})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		info, err := c.GetUserInfoByUID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "a@example.com", info.Email)
+
+		// 调用方修改返回值不影响缓存
+		info.IUID = 42
+	}
+	info, _ := c.GetUserInfoByUID(ctx, 1)
+	assert.Zero(t, info.IUID)
+
+	for i := 0; i < 3; i++ {
+		_, err := c.GetUserInfoByUID(ctx, 2)
+		assert.ErrorIs(t, err, interfaces.ErrUserNotFound)
+	}
+
+	assert.Equal(t, 2, backend.callCount())
+}
+
+func TestUserInfoCache_StaleOnBackendError(t *testing.T) {
+	backend := &fakeUserInfoRepo{users: map[uint32]*interfaces.UserInfo{1: {UID: 1, Email: "a@example.com"}}}
+	c := NewUserInfoCache(backend, nil, UserInfoCacheConfig{TTL: 10 * time.Millisecond, StaleTTL: time.Minute})
+	ctx := context.Background()
+
+	_, err := c.GetUserInfoByUID(ctx, 1)
+	require.NoError(t, err)
+
+	backend.setErr(errors.New("qconfapi unavailable"))
+	time.Sleep(20 * time.Millisecond)
+
+	// 过期后返回旧值，后台刷新失败不影响结果
+	for i := 0; i < 3; i++ {
+		info, err := c.GetUserInfoByUID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "a@example.com", info.Email)
+	}
+	require.NoError(t, c.Wait(ctx))
+
+	// 未缓存的 UID 直接返回后端错误
+	_, err = c.GetUserInfoByUID(ctx, 3)
+	assert.ErrorContains(t, err, "qconfapi unavailable")
+}
+
+func TestUserInfoCache_FreshLookupSkipsStale(t *testing.T) {
+	backend := &fakeUserInfoRepo{users: map[uint32]*interfaces.UserInfo{1: {UID: 1, Activated: true}}}
+	c := NewUserInfoCache(backend, nil, UserInfoCacheConfig{TTL: 10 * time.Millisecond, StaleTTL: time.Minute})
+	ctx := context.Background()
+
+	info, err := c.GetFreshUserInfoByUID(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, info.IsDisabled())
+
+	// 条目过期后用户被冻结
+	backend.mu.Lock()
+	backend.users[1] = &interfaces.UserInfo{UID: 1, Activated: true, Utype: interfaces.UserTypeDisabled}
+	backend.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+
+	// 新鲜查询同步回源，立即看到冻结状态
+	info, err = c.GetFreshUserInfoByUID(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, info.IsDisabled())
+	assert.Equal(t, 2, backend.callCount())
+
+	// 新鲜期内的条目直接返回
+	_, err = c.GetFreshUserInfoByUID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, backend.callCount())
+
+	// 过期且后端出错时不返回旧值
+	time.Sleep(20 * time.Millisecond)
+	backend.setErr(errors.New("qconfapi unavailable"))
+	_, err = c.GetFreshUserInfoByUID(ctx, 1)
+	assert.ErrorContains(t, err, "qconfapi unavailable")
+
+	// 普通查询仍返回旧值
+	info, err = c.GetUserInfoByUID(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, info.IsDisabled())
+	require.NoError(t, c.Wait(ctx))
+}
+
+func TestUserInfoCache_LRUEviction(t *testing.T) {
+	backend := &fakeUserInfoRepo{users: map[uint32]*interfaces.UserInfo{
+		1: {UID: 1}, 2: {UID: 2}, 3: {UID: 3},
+	}}
+	c := NewUserInfoCache(backend, nil, UserInfoCacheConfig{Size: 2, TTL: time.Minute})
+	ctx := context.Background()
+
+	c.GetUserInfoByUID(ctx, 1)
+	c.GetUserInfoByUID(ctx, 2)
+	c.GetUserInfoByUID(ctx, 1) // 1 变为最近使用
+	c.GetUserInfoByUID(ctx, 3) // 淘汰 2
+	assert.Equal(t, 3, backend.callCount())
+
+	c.GetUserInfoByUID(ctx, 1)
+	assert.Equal(t, 3, backend.callCount())
+
+	c.GetUserInfoByUID(ctx, 2)
+	assert.Equal(t, 4, backend.callCount())
+}
+
+func TestUserInfoCache_RedisTier(t *testing.T) {
+	backend := &fakeUserInfoRepo{users: map[uint32]*interfaces.UserInfo{1: {UID: 1, Email: "a@example.com"}}}
+	redis := newFakeRedis()
+	ctx := context.Background()
+
+	// 实例 A 查询后端并写入 Redis
+	a := NewUserInfoCache(backend, redis, UserInfoCacheConfig{TTL: time.Minute})
+	_, err := a.GetUserInfoByUID(ctx, 1)
+	require.NoError(t, err)
+	_, err = a.GetUserInfoByUID(ctx, 2)
+	assert.ErrorIs(t, err, interfaces.ErrUserNotFound)
+	require.NoError(t, a.Wait(ctx))
+
+	// 实例 B 从 Redis 命中（包括负缓存），不访问后端
+	b := NewUserInfoCache(backend, redis, UserInfoCacheConfig{TTL: time.Minute})
+	info, err := b.GetUserInfoByUID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "a@example.com", info.Email)
+	_, err = b.GetUserInfoByUID(ctx, 2)
+	assert.ErrorIs(t, err, interfaces.ErrUserNotFound)
+
+	assert.Equal(t, 2, backend.callCount())
+}
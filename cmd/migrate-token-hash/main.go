@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/config"
+	"github.com/qiniu/bearer-token-service/v2/repository"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
+)
+
+// 一次性迁移：将 tokens 集合中的明文 token / previous_token 替换为 HMAC-SHA256 哈希
+//
+// 使用方式（与服务使用相同的配置文件 / 环境变量，TOKEN_HASH_PEPPER 必须一致）：
+//
+//	CONFIG_FILE=/app/config.yml go run ./cmd/migrate-token-hash
+//
+// 可重复执行：已迁移的文档会被跳过
+func main() {
+	config.LoadFromYAML(os.Getenv("CONFIG_FILE"))
+
+	tokenConfig := config.LoadTokenConfig()
+	if tokenConfig.HashPepper == "" {
+		fmt.Fprintln(os.Stderr, "❌ TOKEN_HASH_PEPPER 未设置（必须与服务配置一致）")
+		os.Exit(1)
+	}
+
+	mongoURI := os.Getenv("MONGO_URI")
+	if mongoURI == "" {
+		mongoURI = "mongodb://localhost:27017"
+	}
+
+	// 数据库名称（优先级：环境变量 > URI 中的数据库名 > 默认值，与服务保持一致）
+	dbName := os.Getenv("MONGO_DATABASE")
+	if dbName == "" {
+		if cs, err := connstring.Parse(mongoURI); err == nil {
+			dbName = cs.Database
+		}
+	}
+	if dbName == "" {
+		dbName = "token_service_v2"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 连接 MongoDB 失败: %v\n", err)
+		os.Exit(1)
+	}
+	defer client.Disconnect(context.Background())
+
+	if err := client.Ping(ctx, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ MongoDB ping 失败: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("📌 数据库: %s\n", dbName)
+
+	tokenRepo := repository.NewMongoTokenRepository(client.Database(dbName), []byte(tokenConfig.HashPepper))
+
+	// 迁移本身不设超时，文档较多时可能耗时较长
+	migrated, err := tokenRepo.MigrateTokenHashes(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 迁移失败（已迁移 %d 个文档，可直接重新执行）: %v\n", migrated, err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ 已迁移 %d 个 token 文档\n", migrated)
+
+	if err := tokenRepo.CreateIndexes(context.Background()); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  创建索引失败: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("✅ token_hash 索引已创建")
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// ========================================
+// 服务生命周期管理（优雅关闭）
+// ========================================
+
+// shutdownStep 关闭流程中的一个步骤
+type shutdownStep struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// lifecycle 管理服务的关闭流程
+// 关闭顺序：标记排空（就绪检查失败）→ 等待负载均衡摘除 → 按注册顺序执行关闭步骤
+type lifecycle struct {
+	draining atomic.Bool
+	steps    []shutdownStep
+}
+
+// onShutdown 注册关闭步骤（按注册顺序执行）
+func (l *lifecycle) onShutdown(name string, fn func(ctx context.Context) error) {
+	l.steps = append(l.steps, shutdownStep{name: name, fn: fn})
+}
+
+// isDraining 是否正在关闭（此时就绪检查应返回失败）
+func (l *lifecycle) isDraining() bool {
+	return l.draining.Load()
+}
+
+// shutdown 执行关闭流程
+// drainDelay 为标记排空后到开始关闭前的等待时长；单个步骤失败不影响后续步骤
+func (l *lifecycle) shutdown(ctx context.Context, drainDelay time.Duration) {
+	l.draining.Store(true)
+
+	if drainDelay > 0 {
+		slog.Info("Draining, waiting for load balancers to deregister", slog.Duration("delay", drainDelay))
+		select {
+		case <-time.After(drainDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	for _, step := range l.steps {
+		start := time.Now()
+		if err := step.fn(ctx); err != nil {
+			slog.Warn("Shutdown step failed",
+				slog.String("step", step.name),
+				slog.String("error", err.Error()))
+			continue
+		}
+		slog.Info("Shutdown step completed",
+			slog.String("step", step.name),
+			slog.Duration("duration", time.Since(start)))
+	}
+}
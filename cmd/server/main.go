@@ -2,20 +2,30 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/qiniu/bearer-token-service/v2/auth"
 	"github.com/qiniu/bearer-token-service/v2/cache"
 	"github.com/qiniu/bearer-token-service/v2/config"
+	"github.com/qiniu/bearer-token-service/v2/grpcserver"
 	"github.com/qiniu/bearer-token-service/v2/handlers"
 	"github.com/qiniu/bearer-token-service/v2/interfaces"
 	"github.com/qiniu/bearer-token-service/v2/observability"
+	"github.com/qiniu/bearer-token-service/v2/pkg/clientip"
 	"github.com/qiniu/bearer-token-service/v2/pkg/mysql"
+	"github.com/qiniu/bearer-token-service/v2/pkg/qconfapi"
+	"github.com/qiniu/bearer-token-service/v2/pkg/signedtoken"
 	"github.com/qiniu/bearer-token-service/v2/ratelimit"
 	"github.com/qiniu/bearer-token-service/v2/repository"
 	"github.com/qiniu/bearer-token-service/v2/service"
@@ -23,6 +33,8 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"google.golang.org/grpc"
 )
 
 // 版本信息，通过 ldflags 注入
@@ -71,6 +83,9 @@ func main() {
 
 	slog.Info("Bearer Token Service V2 starting...")
 
+	// 生命周期管理：收到退出信号后按顺序关闭各组件
+	lc := &lifecycle{}
+
 	// ========================================
 	// 1. MongoDB 连接
 	// ========================================
@@ -87,7 +102,6 @@ func main() {
 		slog.Error("Failed to connect to MongoDB", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
-	defer client.Disconnect(ctx)
 
 	// 验证连接
 	if err := client.Ping(ctx, nil); err != nil {
@@ -118,9 +132,26 @@ func main() {
 	// ========================================
 	// 2. 初始化 Repository 层
 	// ========================================
+	tokenConfig := config.LoadTokenConfig()
+	if tokenConfig.HashPepper == "" {
+		slog.Error("TOKEN_HASH_PEPPER is required (generate with: openssl rand -hex 32)")
+		os.Exit(1)
+	}
+
+	authConfig := config.LoadAuthConfig()
+	secretKeyCipher, err := repository.NewSecretKeyCipher(authConfig.SecretKeyEncryptionKey)
+	if err != nil {
+		slog.Error("ACCOUNT_SECRET_KEY_ENCRYPTION_KEY is required (generate with: openssl rand -hex 32)",
+			slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
 	accountRepo := repository.NewMongoAccountRepository(db)
-	tokenRepo := repository.NewMongoTokenRepository(db)
+	accountRepo.SetSecretKeyCipher(secretKeyCipher)
+	tokenRepo := repository.NewMongoTokenRepository(db, []byte(tokenConfig.HashPepper))
 	auditRepo := repository.NewMongoAuditLogRepository(db)
+	usageRepo := repository.NewMongoTokenUsageRepository(db, tokenConfig.UsageDailyRetention, tokenConfig.UsageHourlyRetention)
+	clientRepo := repository.NewMongoOAuth2ClientRepository(db)
 
 	// 创建索引（可通过环境变量跳过，用于多实例负载均衡部署）
 	skipIndexCreation := os.Getenv("SKIP_INDEX_CREATION") == "true"
@@ -138,75 +169,130 @@ func main() {
 		if err := auditRepo.CreateIndexes(context.Background()); err != nil {
 			slog.Warn("Failed to create audit log indexes", slog.String("error", err.Error()))
 		}
+		if err := usageRepo.CreateIndexes(context.Background()); err != nil {
+			slog.Warn("Failed to create token usage stats indexes", slog.String("error", err.Error()))
+		}
+		if err := clientRepo.CreateIndexes(context.Background()); err != nil {
+			slog.Warn("Failed to create OAuth2 client indexes", slog.String("error", err.Error()))
+		}
 		slog.Info("Database indexes created")
 	}
 
+	// 加密历史明文 SecretKey（幂等，读取时兼容明文，失败不影响启动）
+	if encrypted, err := accountRepo.EncryptPlaintextSecretKeys(context.Background()); err != nil {
+		slog.Warn("Failed to encrypt plaintext account secret keys", slog.String("error", err.Error()))
+	} else if encrypted > 0 {
+		slog.Info("Encrypted plaintext account secret keys", slog.Int64("count", encrypted))
+	}
+
+	// 未迁移的明文 Token 无法按哈希查到，继续启动会导致这些 Token 验证失败
+	unmigrated, err := tokenRepo.CountUnmigratedTokens(context.Background())
+	if err != nil {
+		slog.Error("Failed to check token hash migration", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	if unmigrated > 0 {
+		slog.Error("Tokens with plaintext values found, run cmd/migrate-token-hash before starting the server",
+			slog.Int64("count", unmigrated))
+		os.Exit(1)
+	}
+
 	// ========================================
 	// 3. 初始化用户信息查询（UserInfoRepository）
-	// 支持两种方式：qconfapi RPC（推荐）或 MySQL 直接查询（备选）
+	// 支持 qconfapi RPC 和 MySQL 直接查询，可同时启用：按 USER_INFO_BACKENDS 顺序逐个尝试，
+	// 每个后端独立超时和熔断，前一个不可用时自动切换到下一个
 	// ========================================
 	var userInfoRepo interfaces.UserInfoRepository
+	var mysqlClient *mysql.Client // 未启用 MySQL 时为 nil
+	userInfoConfig := config.LoadUserInfoConfig()
+
+	// 用户信息后端的健康检查（就绪检查中作为可降级依赖）
+	userInfoHealthChecks := make(map[string]func(ctx context.Context) error)
+	var rpcUserInfoRepo *repository.RPCUserInfoRepository
+	var mysqlUserInfoRepo *repository.MySQLUserInfoRepository
+	var qconfapiClient *qconfapi.Client // 未启用 qconfapi 时为 nil（七牛请求签名认证同样依赖）
 
-	// 优先尝试 qconfapi
 	qconfConfig := config.LoadQconfConfig()
 	if qconfConfig.IsValid() {
 		slog.Info("Initializing qconfapi connection...")
-		qconfapiClient, err := repository.InitQconfClient(qconfConfig.ToQconfapiConfig())
+		qconfapiClient, err = repository.InitQconfClient(qconfConfig.ToQconfapiConfig())
 		if err != nil {
 			slog.Error("Failed to initialize qconfapi", slog.String("error", err.Error()))
-			slog.Warn("qconfapi initialization failed, will try MySQL as fallback")
 		} else {
 			slog.Info("Connected to qconfapi",
 				slog.Int("master_hosts_count", len(qconfConfig.MasterHosts)),
 				slog.String("access_key", qconfConfig.AccessKey[:8]+"..."))
 
 			// 创建 RPC UserInfoRepository
-			userInfoRepo = repository.NewRPCUserInfoRepository(qconfapiClient)
-			slog.Info("UserInfoRepository initialized (qconfapi RPC)")
+			rpcUserInfoRepo = repository.NewRPCUserInfoRepository(qconfapiClient)
+			rpcUserInfoRepo.SetMasterHosts(qconfConfig.MasterHosts)
+			userInfoHealthChecks["qconfapi"] = rpcUserInfoRepo.HealthCheck
 		}
 	} else if !qconfConfig.Enabled {
 		slog.Info("qconfapi disabled (set QCONF_ENABLED=true to enable)")
 	}
 
-	// 如果 qconfapi 未配置或失败，尝试使用 MySQL
-	if userInfoRepo == nil {
+	if os.Getenv("MYSQL_ENABLED") == "true" {
 		mysqlConfig := config.LoadMySQLConfig()
-		mysqlEnabled := os.Getenv("MYSQL_ENABLED") == "true"
-
-		if mysqlEnabled {
-			slog.Info("Initializing MySQL connection...")
-			var mysqlClient *mysql.Client
-			var err error
-			mysqlClient, err = mysql.NewClient(mysqlConfig)
-			if err != nil {
-				slog.Error("Failed to connect to MySQL", slog.String("error", err.Error()))
-				slog.Warn("MySQL connection failed, /api/v2/validateu will return user_info: null")
-			} else {
-				defer mysqlClient.Close()
-				slog.Info("Connected to MySQL",
-					slog.String("host", mysqlConfig.Host),
-					slog.Int("port", mysqlConfig.Port),
-					slog.String("database", mysqlConfig.Database))
-
-				// 创建 MySQL UserInfoRepository
-				userInfoRepo = repository.NewMySQLUserInfoRepository(mysqlClient)
-				slog.Info("UserInfoRepository initialized (MySQL)")
-			}
+		slog.Info("Initializing MySQL connection...")
+		mysqlClient, err = mysql.NewClient(mysqlConfig)
+		if err != nil {
+			mysqlClient = nil
+			slog.Error("Failed to connect to MySQL", slog.String("error", err.Error()))
 		} else {
-			slog.Info("MySQL disabled (set MYSQL_ENABLED=true to enable extended user info)")
+			slog.Info("Connected to MySQL",
+				slog.String("host", mysqlConfig.Host),
+				slog.Int("port", mysqlConfig.Port),
+				slog.String("database", mysqlConfig.Database))
+
+			// 创建 MySQL UserInfoRepository
+			mysqlUserInfoRepo = repository.NewMySQLUserInfoRepository(mysqlClient)
+			userInfoHealthChecks["mysql"] = mysqlUserInfoRepo.HealthCheck
 		}
+	} else {
+		slog.Info("MySQL disabled (set MYSQL_ENABLED=true to enable extended user info)")
+	}
+
+	// 按配置顺序组成后端链
+	userInfoChain := repository.NewChainedUserInfoRepository(userInfoConfig.BreakerFailureThreshold, userInfoConfig.BreakerOpenDuration)
+	var userInfoBackends []string
+	for _, name := range userInfoConfig.Backends {
+		switch {
+		case name == "qconfapi" && rpcUserInfoRepo != nil:
+			userInfoChain.AddBackend(name, rpcUserInfoRepo, userInfoConfig.QconfTimeout)
+		case name == "mysql" && mysqlUserInfoRepo != nil:
+			userInfoChain.AddBackend(name, mysqlUserInfoRepo, userInfoConfig.MySQLTimeout)
+		case name != "qconfapi" && name != "mysql":
+			slog.Warn("Unknown user info backend, ignored", slog.String("backend", name))
+			continue
+		default:
+			continue
+		}
+		userInfoBackends = append(userInfoBackends, name)
+	}
+
+	if userInfoChain.Len() > 0 {
+		userInfoRepo = userInfoChain
+		slog.Info("UserInfoRepository initialized",
+			slog.Any("backends", userInfoBackends),
+			slog.Int("breaker_failure_threshold", userInfoConfig.BreakerFailureThreshold),
+			slog.Duration("breaker_open_duration", userInfoConfig.BreakerOpenDuration))
+	} else {
+		slog.Warn("No user info backend available, /api/v2/validateu will return user_info: null")
 	}
 
 	// ========================================
 	// 4. 初始化 Redis 和缓存层（可选）
 	// ========================================
 	redisConfig := cache.LoadRedisConfig()
+	var redisClient cache.RedisClient // 未启用 Redis 时为 nil
+	var tokenCache cache.TokenCache   // 未启用 Redis 时为 nil
 
 	if redisConfig.Enabled {
 		slog.Info("Initializing Redis cache...")
 
 		// 创建 Redis 客户端
-		redisClient, err := cache.NewRedisClient(
+		redisClient, err = cache.NewRedisClient(
 			redisConfig.Addr,
 			redisConfig.Password,
 			redisConfig.DB,
@@ -218,12 +304,11 @@ func main() {
 			slog.Error("Failed to connect to Redis", slog.String("error", err.Error()))
 			os.Exit(1)
 		}
-		defer redisClient.Close()
 
 		slog.Info("Connected to Redis", slog.String("addr", redisConfig.Addr))
 
 		// 初始化 Token 缓存
-		tokenCache := cache.NewTokenCache(redisClient, tokenRepo, redisConfig.TokenCacheTTL)
+		tokenCache = cache.NewTokenCache(redisClient, tokenRepo, redisConfig.TokenCacheTTL)
 
 		// 注入缓存到 Repository
 		tokenRepo.SetCache(tokenCache)
@@ -233,35 +318,170 @@ func main() {
 		slog.Info("Redis cache disabled (set REDIS_ENABLED=true to enable)")
 	}
 
+	// 用户信息缓存：进程内 LRU + 可选 Redis，装饰 qconfapi / MySQL 查询
+	var userInfoCache *cache.UserInfoCache // 未配置用户信息后端或关闭缓存时为 nil
+	if userInfoRepo != nil && userInfoConfig.CacheEnabled {
+		var userInfoRedis cache.RedisClient
+		if userInfoConfig.CacheRedisEnabled {
+			userInfoRedis = redisClient
+		}
+		userInfoCache = cache.NewUserInfoCache(userInfoRepo, userInfoRedis, cache.UserInfoCacheConfig{
+			Size:        userInfoConfig.CacheSize,
+			TTL:         userInfoConfig.CacheTTL,
+			StaleTTL:    userInfoConfig.CacheStaleTTL,
+			NegativeTTL: userInfoConfig.CacheNegativeTTL,
+		})
+		userInfoRepo = userInfoCache
+		slog.Info("UserInfo cache enabled",
+			slog.Int("size", userInfoConfig.CacheSize),
+			slog.Duration("ttl", userInfoConfig.CacheTTL),
+			slog.Duration("stale_ttl", userInfoConfig.CacheStaleTTL),
+			slog.Bool("redis", userInfoRedis != nil))
+	}
+
 	// ========================================
 	// 5. 初始化 Service 层
 	// ========================================
 	tokenService := service.NewTokenService(tokenRepo, auditRepo)
+	tokenService.SetRotationGracePeriod(tokenConfig.RotationGracePeriod, tokenConfig.MaxRotationGracePeriod)
+	tokenService.SetUsageRepository(usageRepo)
 
 	// 根据是否有 UserInfoRepository 创建不同的 ValidationService
-	var validationService interfaces.ValidationService
+	var validationServiceImpl *service.ValidationServiceImpl
 	if userInfoRepo != nil {
-		validationService = service.NewValidationServiceWithUserInfo(tokenRepo, userInfoRepo)
+		validationServiceImpl = service.NewValidationServiceWithUserInfo(tokenRepo, userInfoRepo)
 		slog.Info("ValidationService initialized with UserInfo support")
 	} else {
-		validationService = service.NewValidationService(tokenRepo)
+		validationServiceImpl = service.NewValidationService(tokenRepo)
 		slog.Info("ValidationService initialized (basic mode)")
 	}
 
-	_ = service.NewAuditService(auditRepo) // 预留用于未来的审计日志查询
+	// 使用计数聚合器：合并成功验证的使用记录，批量写入 MongoDB
+	usageAggregator := service.NewUsageAggregator(tokenRepo, usageRepo,
+		tokenConfig.UsageFlushInterval, tokenConfig.UsageFlushThreshold, tokenConfig.UsageQueueSize)
+	usageAggregator.Start()
+	validationServiceImpl.SetUsageAggregator(usageAggregator)
+
+	// 已停用账户集合：验证时拒绝这些账户的 Token（后台定期刷新，管理员操作后本实例立即生效）
+	suspendedAccounts := service.NewSuspendedAccountCache(accountRepo, authConfig.SuspendedAccountsRefreshInterval)
+	suspendedAccounts.Start()
+	validationServiceImpl.SetSuspendedAccountCache(suspendedAccounts)
+
+	// 签名 Token（JWT）：配置私钥后支持创建 format=signed 的 Token，验证时离线校验签名并检查吊销列表
+	var signingKeys *signedtoken.KeySet
+	var revokedTokens *service.RevokedTokenCache
+	if tokenConfig.SignedTokenEnabled() {
+		signingKeys, err = signedtoken.LoadKeySet(tokenConfig.SignedTokenIssuer, tokenConfig.SignedTokenKeyFiles)
+		if err != nil {
+			slog.Error("Invalid SIGNED_TOKEN_PRIVATE_KEYS", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		revokedTokens = service.NewRevokedTokenCache(tokenRepo, tokenConfig.SignedTokenRevocationRefreshInterval)
+		revokedTokens.Start()
+		tokenService.SetSignedTokenKeys(signingKeys, tokenConfig.SignedTokenMaxTTL)
+		tokenService.SetRevokedTokenCache(revokedTokens)
+		validationServiceImpl.SetSignedTokenVerifier(signingKeys, revokedTokens)
+		slog.Info("Signed tokens enabled",
+			slog.String("kid", signingKeys.SigningKeyID()),
+			slog.Int("keys", len(tokenConfig.SignedTokenKeyFiles)),
+			slog.Duration("max_ttl", tokenConfig.SignedTokenMaxTTL))
+	}
+
+	// 七牛用户状态检查：拒绝冻结 / 未激活七牛用户的 Token（需要 UserInfoRepository）
+	if userInfoConfig.StatusCheckEnabled {
+		if userInfoRepo != nil {
+			validationServiceImpl.SetUserStatusPolicy(service.NewUserStatusPolicy(userInfoRepo,
+				userInfoConfig.StatusCheckRejectUnactivated, userInfoConfig.StatusCheckFailOpen, userInfoConfig.StatusCacheTTL))
+			slog.Info("User status check enabled",
+				slog.Bool("reject_unactivated", userInfoConfig.StatusCheckRejectUnactivated),
+				slog.Bool("fail_open", userInfoConfig.StatusCheckFailOpen),
+				slog.Duration("cache_ttl", userInfoConfig.StatusCacheTTL))
+		} else {
+			slog.Warn("USER_STATUS_CHECK_ENABLED is set but no UserInfoRepository is configured, user status check disabled")
+		}
+	}
+	var validationService interfaces.ValidationService = validationServiceImpl
+
+	auditService := service.NewAuditService(auditRepo)
+	accountService := service.NewAccountService(accountRepo, auditRepo)
+	adminService := service.NewAdminService(accountRepo, tokenRepo, clientRepo, auditRepo)
+	adminService.SetSuspendedAccountCache(suspendedAccounts)
+
+	// OAuth 2.0 Token 内省 / 吊销：复用验证服务，调用方使用账户创建的 OAuth 2.0 客户端凭证认证
+	oauth2Config := config.LoadOAuth2Config()
+	oauth2Service := service.NewOAuth2Service(validationServiceImpl, tokenRepo, accountRepo, clientRepo, auditRepo)
+	oauth2Service.SetRevokedTokenCache(revokedTokens)
+
+	// /oauth2/token：机器负载用客户端凭证换取短期 Token（client_credentials），或对已有 Token 降权（token exchange）
+	// 未指定格式时优先签发签名 Token（验证不查库），签发记录过期后自动清理
+	if oauth2Config.TokenFormat == "" {
+		oauth2Config.TokenFormat = interfaces.TokenFormatOpaque
+		if signingKeys != nil && oauth2Config.TokenTTL <= tokenConfig.SignedTokenMaxTTL {
+			oauth2Config.TokenFormat = interfaces.TokenFormatSigned
+		}
+	}
+	switch {
+	case oauth2Config.TokenTTL <= 0:
+		slog.Error("OAUTH2_TOKEN_TTL must be positive", slog.Duration("ttl", oauth2Config.TokenTTL))
+		os.Exit(1)
+	case oauth2Config.TokenFormat != interfaces.TokenFormatOpaque && oauth2Config.TokenFormat != interfaces.TokenFormatSigned:
+		slog.Error("Invalid OAUTH2_TOKEN_FORMAT, must be opaque or signed", slog.String("format", oauth2Config.TokenFormat))
+		os.Exit(1)
+	case oauth2Config.TokenFormat == interfaces.TokenFormatSigned && signingKeys == nil:
+		slog.Error("OAUTH2_TOKEN_FORMAT=signed requires SIGNED_TOKEN_PRIVATE_KEYS")
+		os.Exit(1)
+	case oauth2Config.TokenFormat == interfaces.TokenFormatSigned && oauth2Config.TokenTTL > tokenConfig.SignedTokenMaxTTL:
+		slog.Error("OAUTH2_TOKEN_TTL exceeds SIGNED_TOKEN_MAX_TTL",
+			slog.Duration("ttl", oauth2Config.TokenTTL),
+			slog.Duration("max_ttl", tokenConfig.SignedTokenMaxTTL))
+		os.Exit(1)
+	}
+	oauth2Service.SetTokenIssuer(tokenService, oauth2Config.TokenTTL, oauth2Config.TokenFormat)
+	slog.Info("OAuth2 endpoints enabled",
+		slog.Duration("token_ttl", oauth2Config.TokenTTL),
+		slog.String("token_format", oauth2Config.TokenFormat))
 
 	slog.Info("Services initialized")
 
 	// ========================================
 	// 5. 初始化 Handler 层
 	// ========================================
+	serverConfig := config.LoadServerConfig()
+
+	// 客户端 IP 解析（Token allowed_cidrs 校验）：只有直连地址属于可信代理时才读取转发头，
+	// 只有直连地址属于网关白名单时才读取调用方 IP 头
+	clientIPResolver, err := clientip.New(serverConfig.TrustedProxies, serverConfig.ClientIPHeader, serverConfig.ClientIPHeaderSources)
+	if err != nil {
+		slog.Error("Invalid TRUSTED_PROXIES or CLIENT_IP_HEADER_SOURCES", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
 	tokenHandler := handlers.NewTokenHandler(tokenService)
 	validationHandler := handlers.NewValidationHandler(validationService)
+	validationHandler.SetBatchMaxTokens(tokenConfig.ValidateBatchMaxTokens)
+	validationHandler.SetClientIPResolver(clientIPResolver)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	accountHandler := handlers.NewAccountHandler(accountService)
+	adminHandler := handlers.NewAdminHandler(adminService)
+	oauth2Handler := handlers.NewOAuth2Handler(oauth2Service)
+	oauth2Handler.SetClientIPResolver(clientIPResolver)
+
+	// 健康检查：MongoDB 为关键依赖；Redis（缓存可回源、限流可降级）和用户信息后端为可降级依赖
+	healthHandler := handlers.NewHealthHandler(version, gitCommit, buildTime, serverConfig.HealthCheckTimeout, lc.isDraining)
+	healthHandler.AddDependency("mongodb", true, func(ctx context.Context) error {
+		return client.Ping(ctx, readpref.Primary())
+	})
+	if redisClient != nil {
+		healthHandler.AddDependency("redis", false, redisClient.Ping)
+	}
+	for _, name := range userInfoBackends {
+		healthHandler.AddDependency(name, false, userInfoHealthChecks[name])
+	}
 
 	slog.Info("Handlers initialized")
 
 	// ========================================
-	// 6. 创建 QiniuStub 认证中间件
+	// 6. 创建管理接口认证中间件（QiniuStub / HMAC / 七牛请求签名）
 	// ========================================
 	// 配置七牛 UID 映射器
 	var qiniuUIDMapper auth.QiniuUIDMapper
@@ -278,21 +498,63 @@ func main() {
 		slog.Info("Using SimpleQiniuUIDMapper (format: qiniu_{uid})")
 	}
 
+	// 签名 nonce 存储（HMAC 与 QiniuStub 签名头共用）：多副本部署时 nonce 需共享，启用 Redis 时记录在 Redis 中
+	var nonceStore auth.NonceStore = auth.NewMemoryNonceStore()
+	if redisClient != nil {
+		nonceStore = auth.NewRedisNonceStore(redisClient)
+	}
+
 	// 创建 QiniuStub 认证中间件
+	// QiniuStub 头不含凭证，按可信来源策略校验请求来源；管理员接口可配置更严格的策略
 	qstubMiddleware := auth.NewQstubAuthMiddleware(qiniuUIDMapper)
+	qstubMiddleware.SetTrustPolicy(newQstubTrustPolicy("QSTUB_", authConfig.QstubTrust, serverConfig, clientIPResolver, nonceStore))
+	adminQstubMiddleware := qstubMiddleware.WithTrustPolicy(newQstubTrustPolicy("QSTUB_ADMIN_", authConfig.QstubAdminTrust, serverConfig, clientIPResolver, nonceStore))
 	slog.Info("QiniuStub authentication middleware initialized")
 
+	// 管理接口按 Authorization 前缀分派：QiniuStub（七牛网关）或 QINIU AK:Signature（HMAC）
+	managementAuth := auth.NewMultiAuthMiddleware().Register("QiniuStub ", qstubMiddleware)
+
+	if authConfig.HMACEnabled {
+		hmacAuthenticator := auth.NewHMACAuthenticator(accountRepo, authConfig.HMACTimestampTolerance)
+		hmacMiddleware := auth.NewHMACAuthMiddleware(hmacAuthenticator, nonceStore, authConfig.HMACNonceRequired)
+		managementAuth.Register(auth.HMACAuthScheme, hmacMiddleware)
+		slog.Info("HMAC authentication middleware initialized",
+			slog.Duration("timestamp_tolerance", authConfig.HMACTimestampTolerance),
+			slog.Bool("nonce_required", authConfig.HMACNonceRequired),
+			slog.Bool("shared_nonce_store", redisClient != nil))
+	}
+
+	// 七牛客户直接使用 AK/SK 签名（Authorization: Qiniu AK:Sign），SK 和 UID 通过 qconfapi 查询
+	if authConfig.QiniuMACEnabled && qconfapiClient != nil {
+		managementAuth.Register(auth.QiniuMACAuthScheme, auth.NewQiniuMACAuthMiddleware(qconfapiClient, qiniuUIDMapper, authConfig.QiniuMACDateTolerance))
+		slog.Info("Qiniu MAC authentication middleware initialized",
+			slog.Duration("date_tolerance", authConfig.QiniuMACDateTolerance))
+	} else if authConfig.QiniuMACEnabled {
+		slog.Info("Qiniu MAC authentication disabled (requires qconfapi)")
+	}
+
 	// ========================================
 	// 7. 初始化限流中间件（可选）
 	// ========================================
 	rateLimitConfig := config.LoadRateLimitConfig()
 
-	// 创建限流器
-	limiter := ratelimit.NewMemoryLimiter()
+	// 创建限流器（redis 后端多副本共享计数，Redis 不可用时降级到内存限流器）
+	memoryLimiter := ratelimit.NewMemoryLimiter()
+	var limiter ratelimit.Limiter = memoryLimiter
+	switch {
+	case rateLimitConfig.Backend == config.RateLimitBackendRedis && redisClient != nil:
+		limiter = ratelimit.NewRedisLimiter(redisClient, limiter)
+		slog.Info("Rate limiter backend: redis (fallback: memory)")
+	case rateLimitConfig.Backend == config.RateLimitBackendRedis:
+		slog.Warn("RATE_LIMIT_BACKEND=redis requires REDIS_ENABLED=true, using memory rate limiter")
+	default:
+		slog.Info("Rate limiter backend: memory (set RATE_LIMIT_BACKEND=redis to share limits across replicas)")
+	}
 
 	// 创建限流管理器
 	rateLimitManager := ratelimit.NewRateLimitManager(limiter, ratelimit.RateLimitConfig{
 		AppLimit:           rateLimitConfig.GetAppRateLimit(),
+		BatchValidateLimit: rateLimitConfig.GetBatchValidateRateLimit(),
 		EnableAppLimit:     rateLimitConfig.EnableAppLimit,
 		EnableAccountLimit: rateLimitConfig.EnableAccountLimit,
 		EnableTokenLimit:   rateLimitConfig.EnableTokenLimit,
@@ -318,11 +580,19 @@ func main() {
 	}
 
 	if rateLimitConfig.EnableTokenLimit {
+		// 批量验证不经过 TokenLimitMiddleware，由验证服务逐个 token 检查限流
+		validationServiceImpl.SetTokenLimitChecker(rateLimitManager)
 		slog.Info("Token rate limit enabled")
 	} else {
 		slog.Info("Token rate limit disabled (set ENABLE_TOKEN_RATE_LIMIT=true to enable)")
 	}
 
+	if rateLimitConfig.BatchValidateLimitPerMinute > 0 {
+		slog.Info("Batch validation rate limit enabled", slog.Int("per_minute_per_ip", rateLimitConfig.BatchValidateLimitPerMinute))
+	} else {
+		slog.Warn("Batch validation rate limit disabled, /api/v2/validate/batch is unauthenticated and unlimited")
+	}
+
 	// ========================================
 	// 8. 设置路由
 	// ========================================
@@ -342,9 +612,18 @@ func main() {
 		router.Use(rateLimitMiddleware.AccountLimitMiddleware)
 	}
 
-	// 健康检查
+	// 存活 / 就绪检查（就绪检查失败时负载均衡摘除本实例）
+	router.HandleFunc("/health/live", healthHandler.Live).Methods("GET")
+	router.HandleFunc("/health/ready", healthHandler.Ready).Methods("GET")
+
+	// 兼容旧的健康检查（不检查依赖；关闭过程中返回 503）
 	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
+		if lc.isDraining() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(w, `{"status":"draining","version":"%s","commit":"%s","built":"%s"}`, version, gitCommit, buildTime)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintf(w, `{"status":"ok","version":"%s","commit":"%s","built":"%s"}`, version, gitCommit, buildTime)
 	}).Methods("GET")
@@ -352,13 +631,47 @@ func main() {
 	// Prometheus metrics 端点
 	router.Handle("/metrics", promhttp.Handler()).Methods("GET")
 
-	// Token 管理（需要 QiniuStub 认证）
-	router.HandleFunc("/api/v2/tokens", qstubMiddleware.Authenticate(tokenHandler.CreateToken)).Methods("POST")
-	router.HandleFunc("/api/v2/tokens", qstubMiddleware.Authenticate(tokenHandler.ListTokens)).Methods("GET")
-	router.HandleFunc("/api/v2/tokens/{id}", qstubMiddleware.Authenticate(tokenHandler.GetTokenInfo)).Methods("GET")
-	router.HandleFunc("/api/v2/tokens/{id}/status", qstubMiddleware.Authenticate(tokenHandler.UpdateTokenStatus)).Methods("PUT")
-	router.HandleFunc("/api/v2/tokens/{id}", qstubMiddleware.Authenticate(tokenHandler.DeleteToken)).Methods("DELETE")
-	router.HandleFunc("/api/v2/tokens/{id}/stats", qstubMiddleware.Authenticate(tokenHandler.GetTokenStats)).Methods("GET")
+	// 签名 Token 公钥（JWKS），资源服务据此离线验证
+	if signingKeys != nil {
+		jwksHandler := handlers.NewJWKSHandler(signingKeys, tokenConfig.SignedTokenJWKSMaxAge)
+		router.HandleFunc("/.well-known/jwks.json", jwksHandler.GetJWKS).Methods("GET")
+	}
+
+	// 账户自助服务：注册无需认证（可通过 ACCOUNT_REGISTRATION_ENABLED 关闭），其余需要 QiniuStub 或 HMAC 认证
+	if authConfig.RegistrationEnabled {
+		router.HandleFunc("/api/v2/accounts/register", accountHandler.Register).Methods("POST")
+	}
+	router.HandleFunc("/api/v2/accounts/me", managementAuth.Authenticate(accountHandler.GetAccountInfo)).Methods("GET")
+	router.HandleFunc("/api/v2/accounts/regenerate-sk", managementAuth.Authenticate(accountHandler.RegenerateSecretKey)).Methods("POST")
+
+	// 管理员接口（需要 QiniuStub 认证且 ut 包含管理员 / sudoers 位）
+	adminAuth := func(next http.HandlerFunc) http.HandlerFunc {
+		return adminQstubMiddleware.Authenticate(auth.RequireAdmin(next))
+	}
+	router.HandleFunc("/api/v2/admin/accounts", adminAuth(adminHandler.ListAccounts)).Methods("GET")
+	router.HandleFunc("/api/v2/admin/accounts/{id}/suspend", adminAuth(adminHandler.SuspendAccount)).Methods("POST")
+	router.HandleFunc("/api/v2/admin/accounts/{id}/activate", adminAuth(adminHandler.ActivateAccount)).Methods("POST")
+	router.HandleFunc("/api/v2/admin/accounts/{id}/tokens/disable", adminAuth(adminHandler.DisableAccountTokens)).Methods("POST")
+	router.HandleFunc("/api/v2/admin/clients/{client_id}/tokens/disable", adminAuth(adminHandler.DisableClientTokens)).Methods("POST")
+	router.HandleFunc("/api/v2/admin/clients/{client_id}/resource-accounts", adminAuth(adminHandler.SetClientResourceAccounts)).Methods("PUT")
+
+	// Token 管理（需要 QiniuStub 或 HMAC 认证）
+	router.HandleFunc("/api/v2/tokens", managementAuth.Authenticate(tokenHandler.CreateToken)).Methods("POST")
+	router.HandleFunc("/api/v2/tokens", managementAuth.Authenticate(tokenHandler.ListTokens)).Methods("GET")
+	router.HandleFunc("/api/v2/tokens/{id}", managementAuth.Authenticate(tokenHandler.GetTokenInfo)).Methods("GET")
+	router.HandleFunc("/api/v2/tokens/{id}/status", managementAuth.Authenticate(tokenHandler.UpdateTokenStatus)).Methods("PUT")
+	router.HandleFunc("/api/v2/tokens/{id}", managementAuth.Authenticate(tokenHandler.UpdateToken)).Methods("PATCH")
+	router.HandleFunc("/api/v2/tokens/{id}", managementAuth.Authenticate(tokenHandler.DeleteToken)).Methods("DELETE")
+	router.HandleFunc("/api/v2/tokens/{id}/rotate", managementAuth.Authenticate(tokenHandler.RotateToken)).Methods("POST")
+	router.HandleFunc("/api/v2/tokens/{id}/stats", managementAuth.Authenticate(tokenHandler.GetTokenStats)).Methods("GET")
+
+	// OAuth 2.0 客户端管理（需要 QiniuStub 或 HMAC 认证）
+	router.HandleFunc("/api/v2/oauth2/clients", managementAuth.Authenticate(oauth2Handler.CreateClient)).Methods("POST")
+	router.HandleFunc("/api/v2/oauth2/clients", managementAuth.Authenticate(oauth2Handler.ListClients)).Methods("GET")
+	router.HandleFunc("/api/v2/oauth2/clients/{client_id}", managementAuth.Authenticate(oauth2Handler.DeleteClient)).Methods("DELETE")
+
+	// 审计日志查询（需要 QiniuStub 或 HMAC 认证，子账号只能查看自己的操作记录）
+	router.HandleFunc("/api/v2/audit-logs", managementAuth.Authenticate(auditHandler.QueryAuditLogs)).Methods("GET")
 
 	// Token 验证（使用 Bearer Token 认证）
 	// 为 Token 层限流包装验证 handler
@@ -369,6 +682,13 @@ func main() {
 	}
 	router.Handle("/api/v2/validate", validateTokenHandler).Methods("POST")
 
+	// Token 验证（GET 方式，通过 ?scope= 检查权限）
+	var validateWithScopeHandler http.Handler = http.HandlerFunc(validationHandler.ValidateWithScope)
+	if rateLimitConfig.EnableTokenLimit {
+		validateWithScopeHandler = extractTokenMiddleware(rateLimitMiddleware.TokenLimitMiddleware(validateWithScopeHandler))
+	}
+	router.Handle("/api/v2/validate", validateWithScopeHandler).Methods("GET")
+
 	// Token 验证（扩展版，包含用户信息）
 	var validateTokenUHandler http.Handler = http.HandlerFunc(validationHandler.ValidateTokenU)
 	if rateLimitConfig.EnableTokenLimit {
@@ -377,25 +697,138 @@ func main() {
 	}
 	router.Handle("/api/v2/validateu", validateTokenUHandler).Methods("POST")
 
+	// 批量 Token 验证（token 放在请求体中，Token 层限流在验证服务中逐个应用）
+	// 接口未认证，按调用方 IP 限流（BATCH_VALIDATE_RATE_LIMIT_PER_MINUTE）
+	router.Handle("/api/v2/validate/batch", rateLimitMiddleware.BatchValidateLimitMiddleware(clientIPResolver.CallerIP,
+		http.HandlerFunc(validationHandler.ValidateTokenBatch))).Methods("POST")
+
+	// 反向代理鉴权（nginx auth_request / Envoy ext_authz），接受任意方法和路径
+	// Token 层限流同样在验证服务中应用，超限返回 429
+	router.PathPrefix("/api/v2/auth/check").HandlerFunc(validationHandler.AuthCheck)
+	router.PathPrefix("/api/v2/auth/envoy").HandlerFunc(validationHandler.EnvoyAuthCheck)
+
+	// OAuth 2.0 Token 签发 / 内省（RFC 7662）/ 吊销（RFC 7009），供 Kong、APISIX、oauth2-proxy 等网关和机器负载接入
+	// 调用方使用 client_secret_basic 或 client_secret_post（OAuth 2.0 客户端凭证）认证
+	router.HandleFunc("/oauth2/token", oauth2Handler.Token).Methods("POST")
+	router.HandleFunc("/oauth2/introspect", oauth2Handler.Introspect).Methods("POST")
+	router.HandleFunc("/oauth2/revoke", oauth2Handler.Revoke).Methods("POST")
+
 	slog.Info("Routes configured")
 
 	// ========================================
 	// 9. 启动服务器
 	// ========================================
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	server := &http.Server{
+		Addr:              ":" + serverConfig.Port,
+		Handler:           router,
+		ReadTimeout:       serverConfig.ReadTimeout,
+		ReadHeaderTimeout: serverConfig.ReadHeaderTimeout,
+		WriteTimeout:      serverConfig.WriteTimeout,
+		IdleTimeout:       serverConfig.IdleTimeout,
+	}
+	if serverConfig.TLSEnabled() {
+		tlsConfig, err := serverTLSConfig(serverConfig)
+		if err != nil {
+			slog.Error("Invalid TLS configuration", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		server.TLSConfig = tlsConfig
+	}
+
+	// gRPC 验证服务（可选，独立端口）：与 HTTP 共用验证服务、限流、指标和 Request ID 处理
+	var grpcServer *grpcserver.Server
+	if serverConfig.GRPCEnabled {
+		grpcServer = grpcserver.NewServer(validationService, lc.isDraining,
+			observability.RequestTrackingUnaryInterceptor,
+			observability.MetricsUnaryInterceptor,
+			rateLimitMiddleware.UnaryServerInterceptor,
+		)
+		grpcServer.SetClientIPResolver(clientIPResolver)
+	}
+
+	// 注册关闭步骤（按顺序执行）：
+	// 停止接收请求并等待在途请求 → 刷新后台写入 → 关闭 MongoDB / Redis / MySQL 客户端
+	lc.onShutdown("http server", server.Shutdown)
+	if grpcServer != nil {
+		lc.onShutdown("grpc server", grpcServer.Shutdown)
+	}
+	lc.onShutdown("usage aggregator", usageAggregator.Stop)
+	lc.onShutdown("suspended accounts refresher", suspendedAccounts.Stop)
+	if revokedTokens != nil {
+		lc.onShutdown("revoked tokens refresher", revokedTokens.Stop)
+	}
+	if tokenCache != nil {
+		lc.onShutdown("token cache writers", tokenCache.Wait)
+	}
+	if userInfoCache != nil {
+		lc.onShutdown("userinfo cache writers", userInfoCache.Wait)
+	}
+	lc.onShutdown("rate limiter", func(ctx context.Context) error {
+		memoryLimiter.Stop()
+		return nil
+	})
+	lc.onShutdown("mongodb", client.Disconnect)
+	if redisClient != nil {
+		lc.onShutdown("redis", func(ctx context.Context) error {
+			return redisClient.Close()
+		})
+	}
+	if mysqlClient != nil {
+		lc.onShutdown("mysql", func(ctx context.Context) error {
+			return mysqlClient.Close()
+		})
+	}
+
+	serverErr := make(chan error, 2)
+	go func() {
+		var err error
+		if serverConfig.TLSEnabled() {
+			err = server.ListenAndServeTLS(serverConfig.TLSCertFile, serverConfig.TLSKeyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+	if grpcServer != nil {
+		go func() {
+			lis, err := net.Listen("tcp", ":"+serverConfig.GRPCPort)
+			if err != nil {
+				serverErr <- err
+				return
+			}
+			if err := grpcServer.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+				serverErr <- err
+			}
+		}()
+		slog.Info("gRPC validation service enabled", slog.String("port", serverConfig.GRPCPort))
 	}
 
 	slog.Info("Bearer Token Service V2 is ready",
-		slog.String("port", port),
+		slog.String("port", serverConfig.Port),
+		slog.Bool("tls", serverConfig.TLSEnabled()),
 		slog.String("metrics_endpoint", "/metrics"),
-		slog.String("health_endpoint", "/health"))
+		slog.String("health_endpoint", "/health/ready"))
+
+	// 等待退出信号（Kubernetes 发送 SIGTERM）
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
-	if err := http.ListenAndServe(":"+port, router); err != nil {
+	select {
+	case err := <-serverErr:
 		slog.Error("Server failed to start", slog.String("error", err.Error()))
 		os.Exit(1)
+	case sig := <-sigCh:
+		slog.Info("Shutting down", slog.String("signal", sig.String()),
+			slog.Duration("timeout", serverConfig.ShutdownTimeout))
 	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), serverConfig.ShutdownTimeout)
+	defer shutdownCancel()
+	lc.shutdown(shutdownCtx, serverConfig.ShutdownDrainDelay)
+
+	slog.Info("Bearer Token Service V2 stopped")
 }
 
 // ========================================
@@ -435,6 +868,65 @@ func extractDatabaseFromURI(uri string) string {
 	return ""
 }
 
+// newQstubTrustPolicy 根据配置创建 QiniuStub 可信来源策略，配置无效时退出
+func newQstubTrustPolicy(prefix string, cfg config.QstubTrustConfig, serverConfig config.ServerConfig, resolver *clientip.Resolver, nonces auth.NonceStore) *auth.QstubTrustPolicy {
+	if cfg.RequireClientCert && (!serverConfig.TLSEnabled() || serverConfig.TLSClientCAFile == "") {
+		slog.Error(prefix + "REQUIRE_CLIENT_CERT requires TLS_CERT_FILE, TLS_KEY_FILE and TLS_CLIENT_CA_FILE")
+		os.Exit(1)
+	}
+
+	policy, err := auth.NewQstubTrustPolicy(auth.QstubTrustOptions{
+		TrustedSources:     cfg.TrustedSources,
+		RequireClientCert:  cfg.RequireClientCert,
+		ClientCertSubjects: cfg.ClientCertSubjects,
+		SigningSecrets:     cfg.SigningSecrets,
+		SignatureTolerance: cfg.SignatureTolerance,
+		AllowAnySource:     cfg.AllowAnySource,
+	}, resolver)
+	if err != nil {
+		slog.Error("Invalid "+prefix+"TRUSTED_SOURCES", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	policy.SetNonceStore(nonces)
+
+	switch {
+	case policy.IsOpen():
+		slog.Warn("QiniuStub headers are trusted from any source ("+prefix+"ALLOW_ANY_SOURCE=true)",
+			slog.String("hint", "set "+prefix+"TRUSTED_SOURCES, "+prefix+"REQUIRE_CLIENT_CERT or "+prefix+"SIGNING_SECRETS"))
+	case policy.IsClosed():
+		slog.Warn("QiniuStub authentication rejects all requests: no trust policy configured",
+			slog.String("prefix", prefix),
+			slog.String("hint", "set "+prefix+"TRUSTED_SOURCES, "+prefix+"REQUIRE_CLIENT_CERT or "+prefix+"SIGNING_SECRETS, or "+prefix+"ALLOW_ANY_SOURCE=true for development"))
+	default:
+		slog.Info("QiniuStub trust policy configured",
+			slog.String("prefix", prefix),
+			slog.Int("trusted_sources", len(cfg.TrustedSources)),
+			slog.Bool("require_client_cert", cfg.RequireClientCert),
+			slog.Bool("signed_header", len(cfg.SigningSecrets) > 0))
+	}
+	return policy
+}
+
+// serverTLSConfig HTTPS 配置：配置 TLS_CLIENT_CA_FILE 时验证客户端证书（客户端可不带证书，由认证方式决定是否要求）
+func serverTLSConfig(cfg config.ServerConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.TLSClientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(cfg.TLSClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TLS_CLIENT_CA_FILE: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in TLS_CLIENT_CA_FILE")
+	}
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	return tlsConfig, nil
+}
+
 // ========================================
 // 辅助中间件：从 Authorization 头提取 Token 到上下文
 // ========================================
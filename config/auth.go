@@ -0,0 +1,106 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// ========================================
+// 管理接口认证配置
+// ========================================
+
+// AuthConfig 管理接口认证配置
+type AuthConfig struct {
+	// HMAC AK/SK 认证开关（关闭时管理接口只接受 QiniuStub）
+	HMACEnabled bool
+
+	// 请求时间戳与服务器时间的最大偏差
+	HMACTimestampTolerance time.Duration
+
+	// 是否要求请求携带 X-Qiniu-Nonce，默认开启（关闭后不带 nonce 的请求在容忍窗口内可被重放）
+	HMACNonceRequired bool
+
+	// 七牛 AK/SK 请求签名认证开关（Authorization: Qiniu {AK}:{Sign}，需要启用 qconfapi），默认关闭
+	QiniuMACEnabled bool
+
+	// 七牛请求签名的 X-Qiniu-Date 与服务器时间的最大偏差
+	QiniuMACDateTolerance time.Duration
+
+	// QiniuStub 头可信来源（管理接口）
+	QstubTrust QstubTrustConfig
+
+	// QiniuStub 头可信来源（管理员接口），未单独配置的项沿用 QstubTrust
+	QstubAdminTrust QstubTrustConfig
+
+	// 是否开放账户自助注册（POST /api/v2/accounts/register），默认关闭
+	RegistrationEnabled bool
+
+	// 账户 SecretKey 静态加密密钥（AES-256，64 位十六进制），所有实例必须一致
+	SecretKeyEncryptionKey string
+
+	// 已停用账户列表刷新间隔（其他实例上的停用 / 恢复在该间隔内生效）
+	SuspendedAccountsRefreshInterval time.Duration
+}
+
+// QstubTrustConfig QiniuStub 头可信来源配置（已配置的检查必须全部通过，均未配置时拒绝所有 QiniuStub 请求，除非设置 AllowAnySource）
+type QstubTrustConfig struct {
+	// 允许设置 QiniuStub 头的来源地址（CIDR 或 IP，可经 TRUSTED_PROXIES 中的代理转发）
+	TrustedSources []string
+
+	// 是否要求 mTLS 客户端证书（需配置 TLS_CLIENT_CA_FILE）
+	RequireClientCert bool
+
+	// 允许的客户端证书主体（CN 或 DNS SAN），为空时接受任何通过验证的证书
+	ClientCertSubjects []string
+
+	// QiniuStub 签名头共享密钥（多个用于轮换）
+	SigningSecrets []string
+
+	// 签名时间戳容忍度
+	SignatureTolerance time.Duration
+
+	// 未配置任何检查时信任所有来源（显式关闭校验，仅用于开发 / 测试）
+	AllowAnySource bool
+}
+
+// IsEmpty 是否未配置任何检查
+func (c QstubTrustConfig) IsEmpty() bool {
+	return len(c.TrustedSources) == 0 && !c.RequireClientCert && len(c.SigningSecrets) == 0
+}
+
+// LoadAuthConfig 从环境变量加载管理接口认证配置
+func LoadAuthConfig() AuthConfig {
+	qstubTrust := loadQstubTrustConfig("QSTUB_", QstubTrustConfig{SignatureTolerance: 5 * time.Minute})
+
+	return AuthConfig{
+		HMACEnabled:            parseBool(os.Getenv("HMAC_AUTH_ENABLED"), true),
+		HMACTimestampTolerance: getEnvAsDuration("HMAC_TIMESTAMP_TOLERANCE", 15*time.Minute),
+		HMACNonceRequired:      parseBool(os.Getenv("HMAC_NONCE_REQUIRED"), true),
+		QiniuMACEnabled:        parseBool(os.Getenv("QINIU_MAC_AUTH_ENABLED"), false),
+		QiniuMACDateTolerance:  getEnvAsDuration("QINIU_MAC_DATE_TOLERANCE", 15*time.Minute),
+		RegistrationEnabled:    parseBool(os.Getenv("ACCOUNT_REGISTRATION_ENABLED"), false),
+		SecretKeyEncryptionKey: os.Getenv("ACCOUNT_SECRET_KEY_ENCRYPTION_KEY"),
+		QstubTrust:             qstubTrust,
+		QstubAdminTrust:        loadQstubTrustConfig("QSTUB_ADMIN_", qstubTrust),
+
+		SuspendedAccountsRefreshInterval: getEnvAsDuration("SUSPENDED_ACCOUNTS_REFRESH_INTERVAL", 30*time.Second),
+	}
+}
+
+// loadQstubTrustConfig 加载指定前缀的 QiniuStub 可信来源配置，未设置的项使用 defaults
+func loadQstubTrustConfig(prefix string, defaults QstubTrustConfig) QstubTrustConfig {
+	cfg := defaults
+	if v, ok := os.LookupEnv(prefix + "TRUSTED_SOURCES"); ok {
+		cfg.TrustedSources = parseCommaSeparated(v)
+	}
+	if v, ok := os.LookupEnv(prefix + "CLIENT_CERT_SUBJECTS"); ok {
+		cfg.ClientCertSubjects = parseCommaSeparated(v)
+	}
+	if v, ok := os.LookupEnv(prefix + "SIGNING_SECRETS"); ok {
+		cfg.SigningSecrets = parseCommaSeparated(v)
+	}
+	cfg.RequireClientCert = parseBool(os.Getenv(prefix+"REQUIRE_CLIENT_CERT"), defaults.RequireClientCert)
+	cfg.SignatureTolerance = getEnvAsDuration(prefix+"SIGNATURE_TOLERANCE", defaults.SignatureTolerance)
+	cfg.AllowAnySource = parseBool(os.Getenv(prefix+"ALLOW_ANY_SOURCE"), defaults.AllowAnySource)
+	return cfg
+}
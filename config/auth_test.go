@@ -0,0 +1,19 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLoadAuthConfig_HMACNonceRequiredByDefault(t *testing.T) {
+	os.Unsetenv("HMAC_NONCE_REQUIRED")
+	if !LoadAuthConfig().HMACNonceRequired {
+		t.Error("HMACNonceRequired should default to true")
+	}
+
+	os.Setenv("HMAC_NONCE_REQUIRED", "false")
+	defer os.Unsetenv("HMAC_NONCE_REQUIRED")
+	if LoadAuthConfig().HMACNonceRequired {
+		t.Error("HMAC_NONCE_REQUIRED=false should disable the nonce requirement")
+	}
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"time"
+)
+
+// ========================================
+// OAuth 2.0 兼容接口配置
+// ========================================
+
+// OAuth2Config OAuth 2.0 兼容接口（/oauth2/*）配置
+type OAuth2Config struct {
+	// /oauth2/token 签发的 Token 有效期（token exchange 时不超过原 Token 的剩余有效期）
+	TokenTTL time.Duration
+
+	// /oauth2/token 签发的 Token 格式：opaque 或 signed（需要配置 SIGNED_TOKEN_PRIVATE_KEYS）；
+	// 为空时自动选择：配置了签名密钥且有效期不超过 SIGNED_TOKEN_MAX_TTL 时签发签名 Token，否则签发不透明 Token
+	TokenFormat string
+}
+
+// LoadOAuth2Config 从环境变量加载 OAuth 2.0 兼容接口配置
+func LoadOAuth2Config() OAuth2Config {
+	return OAuth2Config{
+		TokenTTL:    getEnvAsDuration("OAUTH2_TOKEN_TTL", time.Hour),
+		TokenFormat: getEnv("OAUTH2_TOKEN_FORMAT", ""),
+	}
+}
@@ -11,8 +11,17 @@ import (
 // 限流配置管理
 // ========================================
 
+// 限流计数存储后端
+const (
+	RateLimitBackendMemory = "memory" // 进程内存（多副本时每个副本独立计数）
+	RateLimitBackendRedis  = "redis"  // Redis（多副本共享计数，需启用 Redis）
+)
+
 // RateLimitConfig 限流配置
 type RateLimitConfig struct {
+	// 限流计数存储后端：memory / redis
+	Backend string
+
 	// 应用层限流开关
 	EnableAppLimit bool
 
@@ -26,11 +35,21 @@ type RateLimitConfig struct {
 	AppLimitPerMinute int
 	AppLimitPerHour   int
 	AppLimitPerDay    int
+
+	// 批量验证接口按调用方 IP 的限流（每分钟请求数，0 关闭）
+	BatchValidateLimitPerMinute int
 }
 
 // LoadRateLimitConfig 从环境变量加载限流配置
 func LoadRateLimitConfig() RateLimitConfig {
+	backend := os.Getenv("RATE_LIMIT_BACKEND")
+	if backend == "" {
+		backend = RateLimitBackendMemory
+	}
+
 	return RateLimitConfig{
+		Backend: backend,
+
 		// 限流功能开关（默认全部关闭）
 		EnableAppLimit:     parseBool(os.Getenv("ENABLE_APP_RATE_LIMIT"), false),
 		EnableAccountLimit: parseBool(os.Getenv("ENABLE_ACCOUNT_RATE_LIMIT"), false),
@@ -40,6 +59,9 @@ func LoadRateLimitConfig() RateLimitConfig {
 		AppLimitPerMinute: parseInt(os.Getenv("APP_RATE_LIMIT_PER_MINUTE"), 1000),  // 默认 1000 req/min
 		AppLimitPerHour:   parseInt(os.Getenv("APP_RATE_LIMIT_PER_HOUR"), 50000),   // 默认 50000 req/hour
 		AppLimitPerDay:    parseInt(os.Getenv("APP_RATE_LIMIT_PER_DAY"), 1000000),  // 默认 1000000 req/day
+
+		// 批量验证接口未认证且单次最多 100 个 token，默认按调用方 IP 限流
+		BatchValidateLimitPerMinute: parseInt(os.Getenv("BATCH_VALIDATE_RATE_LIMIT_PER_MINUTE"), 600),
 	}
 }
 
@@ -56,6 +78,15 @@ func (c RateLimitConfig) GetAppRateLimit() *interfaces.RateLimit {
 	}
 }
 
+// GetBatchValidateRateLimit 获取批量验证接口的调用方 IP 限流配置（未开启时返回 nil）
+func (c RateLimitConfig) GetBatchValidateRateLimit() *interfaces.RateLimit {
+	if c.BatchValidateLimitPerMinute <= 0 {
+		return nil
+	}
+
+	return &interfaces.RateLimit{RequestsPerMinute: c.BatchValidateLimitPerMinute}
+}
+
 // parseBool 解析布尔值（带默认值）
 func parseBool(s string, defaultValue bool) bool {
 	if s == "" {
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// ========================================
+// HTTP / gRPC 服务器与生命周期配置
+// ========================================
+
+// ServerConfig HTTP 服务器配置
+type ServerConfig struct {
+	// 监听端口
+	Port string
+
+	// 连接超时
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+
+	// 收到退出信号后、停止接收新连接前的等待时长（就绪检查已失败，等待负载均衡摘除本实例）
+	ShutdownDrainDelay time.Duration
+
+	// 优雅关闭总超时（等待在途请求、刷新后台写入、关闭客户端）
+	ShutdownTimeout time.Duration
+
+	// 就绪检查中单个依赖的超时
+	HealthCheckTimeout time.Duration
+
+	// gRPC 验证服务（独立端口）
+	GRPCEnabled bool
+	GRPCPort    string
+
+	// 可信代理（CIDR 或 IP）：只有直连地址属于可信代理时才读取 X-Forwarded-For / X-Real-IP
+	TrustedProxies []string
+
+	// 网关带外调用验证接口时转发调用方 IP 的请求头
+	ClientIPHeader string
+
+	// 允许设置 ClientIPHeader 的网关地址（CIDR 或 IP，按直连地址匹配）；为空时不读取 ClientIPHeader
+	ClientIPHeaderSources []string
+
+	// HTTPS 证书和私钥（均配置时以 TLS 方式监听）
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// 验证 mTLS 客户端证书的 CA（可选，客户端可不带证书，是否要求证书由各认证方式决定）
+	TLSClientCAFile string
+}
+
+// TLSEnabled 是否以 HTTPS 方式监听
+func (c ServerConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// LoadServerConfig 从环境变量加载 HTTP 服务器配置
+func LoadServerConfig() ServerConfig {
+	return ServerConfig{
+		Port:                  getEnv("PORT", "8080"),
+		ReadTimeout:           getEnvAsDuration("SERVER_READ_TIMEOUT", 15*time.Second),
+		ReadHeaderTimeout:     getEnvAsDuration("SERVER_READ_HEADER_TIMEOUT", 5*time.Second),
+		WriteTimeout:          getEnvAsDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:           getEnvAsDuration("SERVER_IDLE_TIMEOUT", 120*time.Second),
+		ShutdownDrainDelay:    getEnvAsDuration("SHUTDOWN_DRAIN_DELAY", 5*time.Second),
+		ShutdownTimeout:       getEnvAsDuration("SHUTDOWN_TIMEOUT", 25*time.Second),
+		HealthCheckTimeout:    getEnvAsDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+		GRPCEnabled:           parseBool(os.Getenv("GRPC_ENABLED"), false),
+		GRPCPort:              getEnv("GRPC_PORT", "9090"),
+		TrustedProxies:        parseCommaSeparated(os.Getenv("TRUSTED_PROXIES")),
+		ClientIPHeader:        getEnv("CLIENT_IP_HEADER", "X-Auth-Client-IP"),
+		ClientIPHeaderSources: parseCommaSeparated(os.Getenv("CLIENT_IP_HEADER_SOURCES")),
+		TLSCertFile:           os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:            os.Getenv("TLS_KEY_FILE"),
+		TLSClientCAFile:       os.Getenv("TLS_CLIENT_CA_FILE"),
+	}
+}
+
+// parseCommaSeparated 解析逗号分隔的列表（去空白、忽略空项）
+func parseCommaSeparated(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
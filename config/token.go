@@ -0,0 +1,75 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// ========================================
+// Token 管理配置
+// ========================================
+
+// TokenConfig Token 管理配置
+type TokenConfig struct {
+	// 轮换时旧 token 默认保留时长（请求未指定 grace_period_seconds 时使用）
+	RotationGracePeriod time.Duration
+
+	// 轮换宽限期上限
+	MaxRotationGracePeriod time.Duration
+
+	// token 哈希密钥（HMAC-SHA256 pepper），修改后所有已签发 token 失效
+	HashPepper string
+
+	// 使用统计保留时长（按天 / 按小时计数桶，过期由 TTL 索引清理）
+	UsageDailyRetention  time.Duration
+	UsageHourlyRetention time.Duration
+
+	// 使用计数聚合：刷新间隔、触发立即刷新的合并条目数、事件队列容量
+	UsageFlushInterval  time.Duration
+	UsageFlushThreshold int
+	UsageQueueSize      int
+
+	// 批量验证单次请求最多包含的 token 数
+	ValidateBatchMaxTokens int
+
+	// 签名 Token（JWT）私钥文件（PEM，Ed25519 或 ECDSA P-256），第一个用于签发，全部发布到 JWKS；为空时不支持签名 Token
+	SignedTokenKeyFiles []string
+
+	// 签名 Token 的 iss 声明（为空时不写入也不校验）
+	SignedTokenIssuer string
+
+	// 签名 Token 有效期上限（离线验证方感知吊销的最长延迟）
+	SignedTokenMaxTTL time.Duration
+
+	// 签名 Token 吊销列表刷新间隔（其他实例上的停用 / 删除在该间隔内生效）
+	SignedTokenRevocationRefreshInterval time.Duration
+
+	// /.well-known/jwks.json 的缓存时长（Cache-Control max-age）
+	SignedTokenJWKSMaxAge time.Duration
+}
+
+// SignedTokenEnabled 是否配置了签名 Token 私钥
+func (c TokenConfig) SignedTokenEnabled() bool {
+	return len(c.SignedTokenKeyFiles) > 0
+}
+
+// LoadTokenConfig 从环境变量加载 Token 管理配置
+func LoadTokenConfig() TokenConfig {
+	return TokenConfig{
+		RotationGracePeriod:    getEnvAsDuration("TOKEN_ROTATION_GRACE_PERIOD", 24*time.Hour),       // 默认 24 小时
+		MaxRotationGracePeriod: getEnvAsDuration("TOKEN_ROTATION_MAX_GRACE_PERIOD", 7*24*time.Hour), // 默认 7 天
+		HashPepper:             os.Getenv("TOKEN_HASH_PEPPER"),
+		UsageDailyRetention:    getEnvAsDuration("TOKEN_USAGE_DAILY_RETENTION", 90*24*time.Hour), // 默认 90 天
+		UsageHourlyRetention:   getEnvAsDuration("TOKEN_USAGE_HOURLY_RETENTION", 7*24*time.Hour), // 默认 7 天
+		UsageFlushInterval:     getEnvAsDuration("TOKEN_USAGE_FLUSH_INTERVAL", 1*time.Second),
+		UsageFlushThreshold:    getEnvAsInt("TOKEN_USAGE_FLUSH_THRESHOLD", 1000),
+		UsageQueueSize:         getEnvAsInt("TOKEN_USAGE_QUEUE_SIZE", 10000),
+		ValidateBatchMaxTokens: getEnvAsInt("TOKEN_VALIDATE_BATCH_MAX_TOKENS", 100),
+
+		SignedTokenKeyFiles:                  parseCommaSeparated(os.Getenv("SIGNED_TOKEN_PRIVATE_KEYS")),
+		SignedTokenIssuer:                    os.Getenv("SIGNED_TOKEN_ISSUER"),
+		SignedTokenMaxTTL:                    getEnvAsDuration("SIGNED_TOKEN_MAX_TTL", 24*time.Hour),
+		SignedTokenRevocationRefreshInterval: getEnvAsDuration("SIGNED_TOKEN_REVOCATION_REFRESH_INTERVAL", 30*time.Second),
+		SignedTokenJWKSMaxAge:                getEnvAsDuration("SIGNED_TOKEN_JWKS_MAX_AGE", 5*time.Minute),
+	}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+// ========================================
+// 七牛用户信息配置
+// ========================================
+
+// UserInfoConfig 七牛用户信息查询配置
+type UserInfoConfig struct {
+	// 用户信息后端查询顺序（qconfapi / mysql，逗号分隔），只使用已启用的后端
+	Backends []string
+
+	// 各后端单次查询超时
+	QconfTimeout time.Duration
+	MySQLTimeout time.Duration
+
+	// 熔断：连续失败多少次后跳过该后端，以及跳过持续时间
+	BreakerFailureThreshold int
+	BreakerOpenDuration     time.Duration
+
+	// 是否在 Token 验证时检查七牛用户状态（冻结 / 未激活的用户的 Token 验证失败）
+	StatusCheckEnabled bool
+
+	// 是否拒绝未激活用户的 Token
+	StatusCheckRejectUnactivated bool
+
+	// 用户信息查询失败时是否放行（false 时验证返回内部错误）
+	StatusCheckFailOpen bool
+
+	// 用户状态本地缓存时长（冻结 / 解冻在该时长内生效）
+	StatusCacheTTL time.Duration
+
+	// 用户信息缓存：开关、进程内 LRU 容量、新鲜时长、过期后仍可返回旧值的时长、用户不存在结果的缓存时长
+	CacheEnabled     bool
+	CacheSize        int
+	CacheTTL         time.Duration
+	CacheStaleTTL    time.Duration
+	CacheNegativeTTL time.Duration
+
+	// 是否使用 Redis 作为第二级缓存（需启用 Redis，多实例共享）
+	CacheRedisEnabled bool
+}
+
+// LoadUserInfoConfig 从环境变量加载七牛用户信息查询配置
+func LoadUserInfoConfig() UserInfoConfig {
+	return UserInfoConfig{
+		Backends:                parseBackendList(getEnv("USER_INFO_BACKENDS", "qconfapi,mysql")),
+		QconfTimeout:            getEnvAsDuration("USER_INFO_QCONF_TIMEOUT", 2*time.Second),
+		MySQLTimeout:            getEnvAsDuration("USER_INFO_MYSQL_TIMEOUT", 3*time.Second),
+		BreakerFailureThreshold: getEnvAsInt("USER_INFO_BREAKER_FAILURE_THRESHOLD", 5),
+		BreakerOpenDuration:     getEnvAsDuration("USER_INFO_BREAKER_OPEN_DURATION", 30*time.Second),
+
+		StatusCheckEnabled:           parseBool(os.Getenv("USER_STATUS_CHECK_ENABLED"), false),
+		StatusCheckRejectUnactivated: parseBool(os.Getenv("USER_STATUS_CHECK_REJECT_UNACTIVATED"), true),
+		StatusCheckFailOpen:          parseBool(os.Getenv("USER_STATUS_CHECK_FAIL_OPEN"), true),
+		StatusCacheTTL:               getEnvAsDuration("USER_STATUS_CACHE_TTL", time.Minute),
+
+		CacheEnabled:      parseBool(os.Getenv("USER_INFO_CACHE_ENABLED"), true),
+		CacheSize:         getEnvAsInt("USER_INFO_CACHE_SIZE", 10000),
+		CacheTTL:          getEnvAsDuration("USER_INFO_CACHE_TTL", 5*time.Minute),
+		CacheStaleTTL:     getEnvAsDuration("USER_INFO_CACHE_STALE_TTL", 10*time.Minute),
+		CacheNegativeTTL:  getEnvAsDuration("USER_INFO_CACHE_NEGATIVE_TTL", time.Minute),
+		CacheRedisEnabled: parseBool(os.Getenv("USER_INFO_CACHE_REDIS_ENABLED"), false),
+	}
+}
+
+// parseBackendList 解析逗号分隔的后端列表（去空白、转小写、去重）
+func parseBackendList(s string) []string {
+	var backends []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		backends = append(backends, name)
+	}
+	return backends
+}
@@ -30,11 +30,15 @@ func (c CommaSep) String() string { return string(c) }
 
 // AppYAML YAML 配置文件结构
 type AppYAML struct {
-	Mongo  MongoYAML  `yaml:"mongo"`
-	Redis  RedisYAML  `yaml:"redis"`
-	Qconf  QconfYAML  `yaml:"qconf"`
-	Server ServerYAML `yaml:"server"`
-	Rate   RateYAML   `yaml:"rate_limit"`
+	Mongo    MongoYAML    `yaml:"mongo"`
+	Redis    RedisYAML    `yaml:"redis"`
+	Qconf    QconfYAML    `yaml:"qconf"`
+	Server   ServerYAML   `yaml:"server"`
+	Rate     RateYAML     `yaml:"rate_limit"`
+	Token    TokenYAML    `yaml:"token"`
+	Auth     AuthYAML     `yaml:"auth"`
+	UserInfo UserInfoYAML `yaml:"userinfo"`
+	OAuth2   OAuth2YAML   `yaml:"oauth2"`
 }
 
 type MongoYAML struct {
@@ -73,12 +77,97 @@ type ServerYAML struct {
 	QiniuUIDMapperMode   string `yaml:"qiniu_uid_mapper_mode"`
 	QiniuUIDAutoCreate   string `yaml:"qiniu_uid_auto_create"`
 	SkipIndexCreation    string `yaml:"skip_index_creation"`
+	ReadTimeout          string `yaml:"read_timeout"`
+	ReadHeaderTimeout    string `yaml:"read_header_timeout"`
+	WriteTimeout         string `yaml:"write_timeout"`
+	IdleTimeout          string `yaml:"idle_timeout"`
+	ShutdownDrainDelay   string `yaml:"shutdown_drain_delay"`
+	ShutdownTimeout      string `yaml:"shutdown_timeout"`
+	HealthCheckTimeout   string `yaml:"health_check_timeout"`
+	GRPCEnabled          string `yaml:"grpc_enabled"`
+	GRPCPort             string `yaml:"grpc_port"`
+	TrustedProxies       CommaSep `yaml:"trusted_proxies"`
+	ClientIPHeader       string `yaml:"client_ip_header"`
+	ClientIPHeaderSources CommaSep `yaml:"client_ip_header_sources"`
+	TLSCertFile          string `yaml:"tls_cert_file"`
+	TLSKeyFile           string `yaml:"tls_key_file"`
+	TLSClientCAFile      string `yaml:"tls_client_ca_file"`
+}
+
+type TokenYAML struct {
+	RotationGracePeriod    string `yaml:"rotation_grace_period"`
+	MaxRotationGracePeriod string `yaml:"max_rotation_grace_period"`
+	HashPepper             string `yaml:"hash_pepper"`
+	UsageDailyRetention    string `yaml:"usage_daily_retention"`
+	UsageHourlyRetention   string `yaml:"usage_hourly_retention"`
+	UsageFlushInterval     string `yaml:"usage_flush_interval"`
+	UsageFlushThreshold    string `yaml:"usage_flush_threshold"`
+	UsageQueueSize         string `yaml:"usage_queue_size"`
+	ValidateBatchMaxTokens string `yaml:"validate_batch_max_tokens"`
+
+	SignedTokenPrivateKeys               CommaSep `yaml:"signed_token_private_keys"`
+	SignedTokenIssuer                    string   `yaml:"signed_token_issuer"`
+	SignedTokenMaxTTL                    string   `yaml:"signed_token_max_ttl"`
+	SignedTokenRevocationRefreshInterval string   `yaml:"signed_token_revocation_refresh_interval"`
+	SignedTokenJWKSMaxAge                string   `yaml:"signed_token_jwks_max_age"`
+}
+
+type AuthYAML struct {
+	HMACEnabled                      string `yaml:"hmac_enabled"`
+	HMACTimestampTolerance           string `yaml:"hmac_timestamp_tolerance"`
+	HMACNonceRequired                string `yaml:"hmac_nonce_required"`
+	QiniuMACEnabled                  string `yaml:"qiniu_mac_enabled"` // 默认 false
+	QiniuMACDateTolerance            string `yaml:"qiniu_mac_date_tolerance"`
+	RegistrationEnabled              string `yaml:"registration_enabled"`
+	SecretKeyEncryptionKey           string `yaml:"secret_key_encryption_key"`
+	SuspendedAccountsRefreshInterval string `yaml:"suspended_accounts_refresh_interval"`
+	QstubTrust                       QstubTrustYAML `yaml:"qstub_trust"`
+	QstubAdminTrust                  QstubTrustYAML `yaml:"qstub_admin_trust"`
+}
+
+type QstubTrustYAML struct {
+	TrustedSources     CommaSep `yaml:"trusted_sources"`
+	RequireClientCert  string   `yaml:"require_client_cert"`
+	ClientCertSubjects CommaSep `yaml:"client_cert_subjects"`
+	SigningSecrets     CommaSep `yaml:"signing_secrets"`
+	SignatureTolerance string   `yaml:"signature_tolerance"`
+	AllowAnySource     string   `yaml:"allow_any_source"` // 默认 false：未配置任何检查时拒绝 QiniuStub 请求
+}
+
+
+type UserInfoYAML struct {
+	Backends                     CommaSep `yaml:"backends"`
+	QconfTimeout                 string   `yaml:"qconf_timeout"`
+	MySQLTimeout                 string   `yaml:"mysql_timeout"`
+	BreakerFailureThreshold      string   `yaml:"breaker_failure_threshold"`
+	BreakerOpenDuration          string   `yaml:"breaker_open_duration"`
+	StatusCheckEnabled           string   `yaml:"status_check_enabled"`
+	StatusCheckRejectUnactivated string   `yaml:"status_check_reject_unactivated"`
+	StatusCheckFailOpen          string   `yaml:"status_check_fail_open"`
+	StatusCacheTTL               string   `yaml:"status_cache_ttl"`
+	CacheEnabled                 string   `yaml:"cache_enabled"`
+	CacheSize                    string   `yaml:"cache_size"`
+	CacheTTL                     string   `yaml:"cache_ttl"`
+	CacheStaleTTL                string   `yaml:"cache_stale_ttl"`
+	CacheNegativeTTL             string   `yaml:"cache_negative_ttl"`
+	CacheRedisEnabled            string   `yaml:"cache_redis_enabled"`
+}
+
+type OAuth2YAML struct {
+	TokenTTL    string `yaml:"token_ttl"`
+	TokenFormat string `yaml:"token_format"`
 }
 
 type RateYAML struct {
-	App     RateAppYAML `yaml:"app"`
-	Account EnabledYAML `yaml:"account"`
-	Token   EnabledYAML `yaml:"token"`
+	Backend string        `yaml:"backend"`
+	App     RateAppYAML   `yaml:"app"`
+	Account EnabledYAML   `yaml:"account"`
+	Token   EnabledYAML   `yaml:"token"`
+	Batch   RateBatchYAML `yaml:"batch_validate"`
+}
+
+type RateBatchYAML struct {
+	PerMinute int `yaml:"per_minute"`
 }
 
 type RateAppYAML struct {
@@ -127,6 +216,21 @@ func LoadFromYAML(path string) {
 	setDefaultEnv("QINIU_UID_MAPPER_MODE", cfg.Server.QiniuUIDMapperMode)
 	setDefaultEnv("QINIU_UID_AUTO_CREATE", cfg.Server.QiniuUIDAutoCreate)
 	setDefaultEnv("SKIP_INDEX_CREATION", cfg.Server.SkipIndexCreation)
+	setDefaultEnv("SERVER_READ_TIMEOUT", cfg.Server.ReadTimeout)
+	setDefaultEnv("SERVER_READ_HEADER_TIMEOUT", cfg.Server.ReadHeaderTimeout)
+	setDefaultEnv("SERVER_WRITE_TIMEOUT", cfg.Server.WriteTimeout)
+	setDefaultEnv("SERVER_IDLE_TIMEOUT", cfg.Server.IdleTimeout)
+	setDefaultEnv("SHUTDOWN_DRAIN_DELAY", cfg.Server.ShutdownDrainDelay)
+	setDefaultEnv("SHUTDOWN_TIMEOUT", cfg.Server.ShutdownTimeout)
+	setDefaultEnv("HEALTH_CHECK_TIMEOUT", cfg.Server.HealthCheckTimeout)
+	setDefaultEnv("GRPC_ENABLED", cfg.Server.GRPCEnabled)
+	setDefaultEnv("GRPC_PORT", cfg.Server.GRPCPort)
+	setDefaultEnv("TRUSTED_PROXIES", cfg.Server.TrustedProxies.String())
+	setDefaultEnv("CLIENT_IP_HEADER", cfg.Server.ClientIPHeader)
+	setDefaultEnv("CLIENT_IP_HEADER_SOURCES", cfg.Server.ClientIPHeaderSources.String())
+	setDefaultEnv("TLS_CERT_FILE", cfg.Server.TLSCertFile)
+	setDefaultEnv("TLS_KEY_FILE", cfg.Server.TLSKeyFile)
+	setDefaultEnv("TLS_CLIENT_CA_FILE", cfg.Server.TLSClientCAFile)
 
 	// MongoDB
 	setDefaultEnv("MONGO_URI", cfg.Mongo.URI)
@@ -176,6 +280,7 @@ func LoadFromYAML(path string) {
 	}
 
 	// Rate limit
+	setDefaultEnv("RATE_LIMIT_BACKEND", cfg.Rate.Backend)
 	if cfg.Rate.App.Enabled {
 		setDefaultEnv("ENABLE_APP_RATE_LIMIT", "true")
 	}
@@ -194,6 +299,68 @@ func LoadFromYAML(path string) {
 	if cfg.Rate.Token.Enabled {
 		setDefaultEnv("ENABLE_TOKEN_RATE_LIMIT", "true")
 	}
+	if cfg.Rate.Batch.PerMinute != 0 {
+		setDefaultEnv("BATCH_VALIDATE_RATE_LIMIT_PER_MINUTE", strconv.Itoa(cfg.Rate.Batch.PerMinute))
+	}
+
+	// Token
+	setDefaultEnv("TOKEN_ROTATION_GRACE_PERIOD", cfg.Token.RotationGracePeriod)
+	setDefaultEnv("TOKEN_ROTATION_MAX_GRACE_PERIOD", cfg.Token.MaxRotationGracePeriod)
+	setDefaultEnv("TOKEN_HASH_PEPPER", cfg.Token.HashPepper)
+	setDefaultEnv("TOKEN_USAGE_DAILY_RETENTION", cfg.Token.UsageDailyRetention)
+	setDefaultEnv("TOKEN_USAGE_HOURLY_RETENTION", cfg.Token.UsageHourlyRetention)
+	setDefaultEnv("TOKEN_USAGE_FLUSH_INTERVAL", cfg.Token.UsageFlushInterval)
+	setDefaultEnv("TOKEN_USAGE_FLUSH_THRESHOLD", cfg.Token.UsageFlushThreshold)
+	setDefaultEnv("TOKEN_USAGE_QUEUE_SIZE", cfg.Token.UsageQueueSize)
+	setDefaultEnv("TOKEN_VALIDATE_BATCH_MAX_TOKENS", cfg.Token.ValidateBatchMaxTokens)
+	setDefaultEnv("SIGNED_TOKEN_PRIVATE_KEYS", cfg.Token.SignedTokenPrivateKeys.String())
+	setDefaultEnv("SIGNED_TOKEN_ISSUER", cfg.Token.SignedTokenIssuer)
+	setDefaultEnv("SIGNED_TOKEN_MAX_TTL", cfg.Token.SignedTokenMaxTTL)
+	setDefaultEnv("SIGNED_TOKEN_REVOCATION_REFRESH_INTERVAL", cfg.Token.SignedTokenRevocationRefreshInterval)
+	setDefaultEnv("SIGNED_TOKEN_JWKS_MAX_AGE", cfg.Token.SignedTokenJWKSMaxAge)
+
+	// Auth
+	setDefaultEnv("HMAC_AUTH_ENABLED", cfg.Auth.HMACEnabled)
+	setDefaultEnv("HMAC_TIMESTAMP_TOLERANCE", cfg.Auth.HMACTimestampTolerance)
+	setDefaultEnv("HMAC_NONCE_REQUIRED", cfg.Auth.HMACNonceRequired)
+	setDefaultEnv("QINIU_MAC_AUTH_ENABLED", cfg.Auth.QiniuMACEnabled)
+	setDefaultEnv("QINIU_MAC_DATE_TOLERANCE", cfg.Auth.QiniuMACDateTolerance)
+	setDefaultEnv("ACCOUNT_REGISTRATION_ENABLED", cfg.Auth.RegistrationEnabled)
+	setDefaultEnv("ACCOUNT_SECRET_KEY_ENCRYPTION_KEY", cfg.Auth.SecretKeyEncryptionKey)
+	setDefaultEnv("SUSPENDED_ACCOUNTS_REFRESH_INTERVAL", cfg.Auth.SuspendedAccountsRefreshInterval)
+	setQstubTrustDefaultEnv("QSTUB_", cfg.Auth.QstubTrust)
+	setQstubTrustDefaultEnv("QSTUB_ADMIN_", cfg.Auth.QstubAdminTrust)
+
+	// UserInfo
+	setDefaultEnv("USER_INFO_BACKENDS", cfg.UserInfo.Backends.String())
+	setDefaultEnv("USER_INFO_QCONF_TIMEOUT", cfg.UserInfo.QconfTimeout)
+	setDefaultEnv("USER_INFO_MYSQL_TIMEOUT", cfg.UserInfo.MySQLTimeout)
+	setDefaultEnv("USER_INFO_BREAKER_FAILURE_THRESHOLD", cfg.UserInfo.BreakerFailureThreshold)
+	setDefaultEnv("USER_INFO_BREAKER_OPEN_DURATION", cfg.UserInfo.BreakerOpenDuration)
+	setDefaultEnv("USER_STATUS_CHECK_ENABLED", cfg.UserInfo.StatusCheckEnabled)
+	setDefaultEnv("USER_STATUS_CHECK_REJECT_UNACTIVATED", cfg.UserInfo.StatusCheckRejectUnactivated)
+	setDefaultEnv("USER_STATUS_CHECK_FAIL_OPEN", cfg.UserInfo.StatusCheckFailOpen)
+	setDefaultEnv("USER_STATUS_CACHE_TTL", cfg.UserInfo.StatusCacheTTL)
+	setDefaultEnv("USER_INFO_CACHE_ENABLED", cfg.UserInfo.CacheEnabled)
+	setDefaultEnv("USER_INFO_CACHE_SIZE", cfg.UserInfo.CacheSize)
+	setDefaultEnv("USER_INFO_CACHE_TTL", cfg.UserInfo.CacheTTL)
+	setDefaultEnv("USER_INFO_CACHE_STALE_TTL", cfg.UserInfo.CacheStaleTTL)
+	setDefaultEnv("USER_INFO_CACHE_NEGATIVE_TTL", cfg.UserInfo.CacheNegativeTTL)
+	setDefaultEnv("USER_INFO_CACHE_REDIS_ENABLED", cfg.UserInfo.CacheRedisEnabled)
+
+	// OAuth2
+	setDefaultEnv("OAUTH2_TOKEN_TTL", cfg.OAuth2.TokenTTL)
+	setDefaultEnv("OAUTH2_TOKEN_FORMAT", cfg.OAuth2.TokenFormat)
+}
+
+// setQstubTrustDefaultEnv 设置指定前缀的 QiniuStub 可信来源默认环境变量
+func setQstubTrustDefaultEnv(prefix string, cfg QstubTrustYAML) {
+	setDefaultEnv(prefix+"TRUSTED_SOURCES", cfg.TrustedSources.String())
+	setDefaultEnv(prefix+"REQUIRE_CLIENT_CERT", cfg.RequireClientCert)
+	setDefaultEnv(prefix+"CLIENT_CERT_SUBJECTS", cfg.ClientCertSubjects.String())
+	setDefaultEnv(prefix+"SIGNING_SECRETS", cfg.SigningSecrets.String())
+	setDefaultEnv(prefix+"SIGNATURE_TOLERANCE", cfg.SignatureTolerance)
+	setDefaultEnv(prefix+"ALLOW_ANY_SOURCE", cfg.AllowAnySource)
 }
 
 // setDefaultEnv 如果 key 的环境变量未设置，则设为 value
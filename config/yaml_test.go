@@ -11,7 +11,7 @@ func TestLoadFromYAML(t *testing.T) {
 		"MONGO_URI", "MONGO_DATABASE",
 		"REDIS_ENABLED", "REDIS_ADDR", "REDIS_PASSWORD",
 		"QCONF_ENABLED", "QCONF_ACCESS_KEY", "QCONF_SECRET_KEY", "QCONF_MASTER_HOSTS",
-		"ENABLE_APP_RATE_LIMIT", "APP_RATE_LIMIT_PER_MINUTE",
+		"RATE_LIMIT_BACKEND", "ENABLE_APP_RATE_LIMIT", "APP_RATE_LIMIT_PER_MINUTE",
 		"ENABLE_ACCOUNT_RATE_LIMIT", "ENABLE_TOKEN_RATE_LIMIT",
 	}
 	for _, k := range envKeys {
@@ -42,6 +42,7 @@ qconf:
     - "http://h1:8510"
     - "http://h2:8510"
 rate_limit:
+  backend: "redis"
   app:
     enabled: true
     per_minute: 500
@@ -67,6 +68,7 @@ rate_limit:
 		{"QCONF_ACCESS_KEY", "ak"},
 		{"QCONF_SECRET_KEY", "sk"},
 		{"QCONF_MASTER_HOSTS", "http://h1:8510,http://h2:8510"},
+		{"RATE_LIMIT_BACKEND", "redis"},
 		{"ENABLE_APP_RATE_LIMIT", "true"},
 		{"APP_RATE_LIMIT_PER_MINUTE", "500"},
 		{"ENABLE_ACCOUNT_RATE_LIMIT", "true"},
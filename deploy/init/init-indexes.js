@@ -65,12 +65,12 @@ print("");
 print("📊 创建 tokens 集合索引...");
 
 try {
-    // 2.1 token 唯一索引（核心索引）
+    // 2.1 token_hash 唯一索引（核心索引，只存储 token 的 HMAC-SHA256）
     db.tokens.createIndex(
-        { token: 1 },
-        { unique: true, name: "idx_token_unique" }
+        { token_hash: 1 },
+        { unique: true, sparse: true, name: "idx_token_hash_unique" }
     );
-    print("  ✅ 创建 token 唯一索引");
+    print("  ✅ 创建 token_hash 唯一索引");
 
     // 2.2 租户隔离复合索引（最重要！）
     db.tokens.createIndex(
@@ -100,6 +100,13 @@ try {
     );
     print("  ✅ 创建 last_used_at 索引（统计分析）");
 
+    // 2.6 previous_token_hash 稀疏索引（轮换宽限期内的旧值查询）
+    db.tokens.createIndex(
+        { previous_token_hash: 1 },
+        { sparse: true, name: "idx_previous_token_hash" }
+    );
+    print("  ✅ 创建 previous_token_hash 稀疏索引（Token 轮换）");
+
     print("✅ tokens 集合索引创建完成");
 } catch (e) {
     print("⚠️  tokens 集合索引创建警告: " + e.message);
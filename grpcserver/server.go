@@ -0,0 +1,240 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/pkg/clientip"
+	"github.com/qiniu/bearer-token-service/v2/proto/validationpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ========================================
+// gRPC 服务器
+// ========================================
+
+// Server gRPC 服务器（Token 验证服务 + 标准健康检查）
+type Server struct {
+	server     *grpc.Server
+	validation *ValidationServer
+}
+
+// NewServer 创建 gRPC 服务器
+// interceptors 按顺序执行（通常为请求追踪 → 指标 → 限流，与 HTTP 中间件顺序一致）；
+// draining 返回 true 时健康检查返回 NOT_SERVING
+func NewServer(validationService interfaces.ValidationService, draining func() bool, interceptors ...grpc.UnaryServerInterceptor) *Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+	validation := NewValidationServer(validationService)
+	validationpb.RegisterValidationServiceServer(server, validation)
+	grpc_health_v1.RegisterHealthServer(server, &healthServer{draining: draining})
+
+	return &Server{server: server, validation: validation}
+}
+
+// SetClientIPResolver 设置客户端 IP 解析器（需在 Serve 之前调用）
+func (s *Server) SetClientIPResolver(resolver *clientip.Resolver) {
+	s.validation.SetClientIPResolver(resolver)
+}
+
+// Serve 在 lis 上提供服务，直到 Shutdown 被调用
+func (s *Server) Serve(lis net.Listener) error {
+	return s.server.Serve(lis)
+}
+
+// Shutdown 优雅关闭：停止接收新请求并等待在途请求完成；ctx 到期时强制关闭
+func (s *Server) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
+}
+
+// healthServer 标准 gRPC 健康检查（grpc.health.v1.Health/Check），关闭过程中返回 NOT_SERVING
+type healthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	draining func() bool
+}
+
+func (h *healthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if h.draining != nil && h.draining() {
+		return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_NOT_SERVING}, nil
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+// ========================================
+// ValidationServer
+// ========================================
+
+// ValidationServer gRPC Token 验证服务（与 HTTP ValidationHandler 共用 ValidationService）
+type ValidationServer struct {
+	validationpb.UnimplementedValidationServiceServer
+	validationService interfaces.ValidationService
+	clientIP          *clientip.Resolver // 解析客户端 IP（用于 Token allowed_cidrs 校验），nil 时使用对端地址
+}
+
+// NewValidationServer 创建 gRPC 验证服务实例
+func NewValidationServer(validationService interfaces.ValidationService) *ValidationServer {
+	return &ValidationServer{
+		validationService: validationService,
+	}
+}
+
+// SetClientIPResolver 设置客户端 IP 解析器
+// 对端属于可信代理时读取 metadata 中网关转发的调用方 IP（与 HTTP 请求头同名，小写）
+func (s *ValidationServer) SetClientIPResolver(resolver *clientip.Resolver) {
+	s.clientIP = resolver
+}
+
+// ValidateToken 验证 Token
+// 验证失败返回 valid=false 和业务错误码；只有参数错误和内部错误作为 gRPC 错误返回
+func (s *ValidationServer) ValidateToken(ctx context.Context, req *validationpb.ValidateTokenRequest) (*validationpb.ValidateTokenResponse, error) {
+	validateReq, err := toValidateRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	validateReq.ClientIP = s.clientIPFromContext(ctx)
+
+	resp, err := s.validationService.ValidateToken(ctx, validateReq)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &validationpb.ValidateTokenResponse{
+		Valid:     resp.Valid,
+		Message:   resp.Message,
+		Code:      int32(resp.Code),
+		TokenInfo: toProtoTokenInfo(resp.TokenInfo),
+	}, nil
+}
+
+// ValidateTokenWithUserInfo 验证 Token 并返回扩展用户信息
+func (s *ValidationServer) ValidateTokenWithUserInfo(ctx context.Context, req *validationpb.ValidateTokenRequest) (*validationpb.ValidateTokenUResponse, error) {
+	validateReq, err := toValidateRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	validateReq.ClientIP = s.clientIPFromContext(ctx)
+
+	resp, err := s.validationService.ValidateTokenWithUserInfo(ctx, validateReq)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &validationpb.ValidateTokenUResponse{
+		Valid:     resp.Valid,
+		Message:   resp.Message,
+		Code:      int32(resp.Code),
+		TokenInfo: toProtoTokenInfoU(resp.TokenInfo),
+	}, nil
+}
+
+// clientIPFromContext 根据对端地址和 metadata 解析客户端 IP
+func (s *ValidationServer) clientIPFromContext(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	return s.clientIP.Resolve(p.Addr.String(), md.Get)
+}
+
+// ========================================
+// 消息转换
+// ========================================
+
+// toValidateRequest 校验并转换请求（与 HTTP 接口相同的参数校验）
+func toValidateRequest(req *validationpb.ValidateTokenRequest) (*interfaces.TokenValidateRequest, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.Unauthenticated, "token is required")
+	}
+	if req.GetRequiredScope() != "" && !interfaces.IsValidRequiredScope(req.GetRequiredScope()) {
+		return nil, status.Error(codes.InvalidArgument, "invalid scope format, expected 'resource:action'")
+	}
+
+	return &interfaces.TokenValidateRequest{
+		Token:         req.GetToken(),
+		RequiredScope: req.GetRequiredScope(),
+	}, nil
+}
+
+func toProtoTokenInfo(info *interfaces.TokenInfo) *validationpb.TokenInfo {
+	if info == nil {
+		return nil
+	}
+	return &validationpb.TokenInfo{
+		TokenId:    info.TokenID,
+		AccountId:  info.AccountID,
+		Uid:        info.UID,
+		Iuid:       info.IUID,
+		IamAlias:   info.IamAlias,
+		Scopes:     info.Scopes,
+		IsActive:   info.IsActive,
+		ExpiresAt:  toTimestamp(info.ExpiresAt),
+		LastUsedAt: toTimestamp(info.LastUsedAt),
+	}
+}
+
+func toProtoTokenInfoU(info *interfaces.TokenInfoU) *validationpb.TokenInfoU {
+	if info == nil {
+		return nil
+	}
+	return &validationpb.TokenInfoU{
+		TokenId:    info.TokenID,
+		AccountId:  info.AccountID,
+		Uid:        info.UID,
+		Iuid:       info.IUID,
+		IamAlias:   info.IamAlias,
+		Scopes:     info.Scopes,
+		IsActive:   info.IsActive,
+		ExpiresAt:  toTimestamp(info.ExpiresAt),
+		LastUsedAt: toTimestamp(info.LastUsedAt),
+		UserInfo:   toProtoUserInfo(info.UserInfo),
+	}
+}
+
+func toProtoUserInfo(info *interfaces.UserInfo) *validationpb.UserInfo {
+	if info == nil {
+		return nil
+	}
+	return &validationpb.UserInfo{
+		Uid:            info.UID,
+		Iuid:           info.IUID,
+		Email:          info.Email,
+		Username:       info.Username,
+		Utype:          info.Utype,
+		Activated:      info.Activated,
+		DisabledType:   int32(info.DisabledType),
+		DisabledReason: info.DisabledReason,
+		DisabledAt:     toTimestamp(info.DisabledAt),
+		ParentUid:      info.ParentUID,
+		CreatedAt:      info.CreatedAt,
+		UpdatedAt:      info.UpdatedAt,
+		LastLoginAt:    info.LastLoginAt,
+	}
+}
+
+// toTimestamp nil 时间转换为未设置的字段
+func toTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
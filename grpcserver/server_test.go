@@ -0,0 +1,230 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/observability"
+	"github.com/qiniu/bearer-token-service/v2/proto/validationpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// ========================================
+// Mock ValidationService
+// ========================================
+
+type MockValidationService struct {
+	mock.Mock
+}
+
+func (m *MockValidationService) ValidateToken(ctx context.Context, req *interfaces.TokenValidateRequest) (*interfaces.TokenValidateResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.TokenValidateResponse), args.Error(1)
+}
+
+func (m *MockValidationService) ValidateTokenWithUserInfo(ctx context.Context, req *interfaces.TokenValidateRequest) (*interfaces.TokenValidateUResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.TokenValidateUResponse), args.Error(1)
+}
+
+func (m *MockValidationService) ValidateTokens(ctx context.Context, req *interfaces.TokenBatchValidateRequest) (*interfaces.TokenBatchValidateResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.TokenBatchValidateResponse), args.Error(1)
+}
+
+func (m *MockValidationService) ValidateTokenWithLimit(ctx context.Context, req *interfaces.TokenValidateRequest) (*interfaces.TokenValidateResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.TokenValidateResponse), args.Error(1)
+}
+
+// startTestServer 在内存连接上启动 gRPC 服务器，返回客户端连接
+func startTestServer(t *testing.T, svc interfaces.ValidationService, draining func() bool) *grpc.ClientConn {
+	lis := bufconn.Listen(1024 * 1024)
+	server := NewServer(svc, draining,
+		observability.RequestTrackingUnaryInterceptor,
+		observability.MetricsUnaryInterceptor,
+	)
+	go server.Serve(lis)
+	t.Cleanup(func() { server.Shutdown(context.Background()) })
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// ========================================
+// TestValidateToken
+// ========================================
+
+func TestValidateToken_Success(t *testing.T) {
+	mockService := new(MockValidationService)
+	client := validationpb.NewValidationServiceClient(startTestServer(t, mockService, nil))
+
+	expiresAt := time.Date(2026, 3, 25, 10, 0, 0, 0, time.UTC)
+	mockService.On("ValidateToken", mock.Anything, &interfaces.TokenValidateRequest{
+		Token:         "sk-valid-token",
+		RequiredScope: "storage:read",
+	}).Return(&interfaces.TokenValidateResponse{
+		Valid:   true,
+		Message: "Token is valid",
+		TokenInfo: &interfaces.TokenInfo{
+			TokenID:   "tk_123",
+			UID:       "1369077332",
+			Scopes:    []string{"storage:read"},
+			IsActive:  true,
+			ExpiresAt: &expiresAt,
+		},
+	}, nil)
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), observability.RequestIDMetadataKey, "req_test")
+	resp, err := client.ValidateToken(ctx, &validationpb.ValidateTokenRequest{
+		Token:         "sk-valid-token",
+		RequiredScope: "storage:read",
+	}, grpc.Header(&header))
+
+	require.NoError(t, err)
+	assert.True(t, resp.Valid)
+	assert.Equal(t, "tk_123", resp.TokenInfo.TokenId)
+	assert.Equal(t, "1369077332", resp.TokenInfo.Uid)
+	assert.Equal(t, []string{"storage:read"}, resp.TokenInfo.Scopes)
+	assert.True(t, resp.TokenInfo.ExpiresAt.AsTime().Equal(expiresAt))
+	assert.Nil(t, resp.TokenInfo.LastUsedAt)
+
+	// Request ID 透传到响应头
+	assert.Equal(t, []string{"req_test"}, header.Get(observability.RequestIDMetadataKey))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestValidateToken_Invalid(t *testing.T) {
+	mockService := new(MockValidationService)
+	client := validationpb.NewValidationServiceClient(startTestServer(t, mockService, nil))
+
+	mockService.On("ValidateToken", mock.Anything, &interfaces.TokenValidateRequest{Token: "sk-expired"}).
+		Return(&interfaces.TokenValidateResponse{
+			Valid:   false,
+			Message: "Token has expired",
+			Code:    interfaces.ErrCodeTokenExpired,
+		}, nil)
+
+	resp, err := client.ValidateToken(context.Background(), &validationpb.ValidateTokenRequest{Token: "sk-expired"})
+
+	// 验证失败不是 gRPC 错误
+	require.NoError(t, err)
+	assert.False(t, resp.Valid)
+	assert.Equal(t, int32(interfaces.ErrCodeTokenExpired), resp.Code)
+	assert.Nil(t, resp.TokenInfo)
+}
+
+func TestValidateToken_Errors(t *testing.T) {
+	mockService := new(MockValidationService)
+	client := validationpb.NewValidationServiceClient(startTestServer(t, mockService, nil))
+
+	mockService.On("ValidateToken", mock.Anything, &interfaces.TokenValidateRequest{Token: "sk-db-down"}).
+		Return(&interfaces.TokenValidateResponse{Valid: false, Message: "internal error"}, errors.New("database connection failed"))
+
+	tests := []struct {
+		name string
+		req  *validationpb.ValidateTokenRequest
+		want codes.Code
+	}{
+		{"missing token", &validationpb.ValidateTokenRequest{}, codes.Unauthenticated},
+		{"invalid scope", &validationpb.ValidateTokenRequest{Token: "sk-abc", RequiredScope: "storage"}, codes.InvalidArgument},
+		{"service error", &validationpb.ValidateTokenRequest{Token: "sk-db-down"}, codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.ValidateToken(context.Background(), tt.req)
+			assert.Equal(t, tt.want, status.Code(err))
+		})
+	}
+}
+
+// ========================================
+// TestValidateTokenWithUserInfo
+// ========================================
+
+func TestValidateTokenWithUserInfo_Success(t *testing.T) {
+	mockService := new(MockValidationService)
+	client := validationpb.NewValidationServiceClient(startTestServer(t, mockService, nil))
+
+	mockService.On("ValidateTokenWithUserInfo", mock.Anything, &interfaces.TokenValidateRequest{Token: "sk-valid-token"}).
+		Return(&interfaces.TokenValidateUResponse{
+			Valid:   true,
+			Message: "Token is valid",
+			TokenInfo: &interfaces.TokenInfoU{
+				TokenID:  "tk_123",
+				UID:      "1369077332",
+				IUID:     "8901234",
+				IsActive: true,
+				UserInfo: &interfaces.UserInfo{
+					UID:       1369077332,
+					IUID:      8901234,
+					Email:     "user@example.com",
+					Utype:     4,
+					Activated: true,
+					CreatedAt: 1609459200,
+				},
+			},
+		}, nil)
+
+	resp, err := client.ValidateTokenWithUserInfo(context.Background(), &validationpb.ValidateTokenRequest{Token: "sk-valid-token"})
+
+	require.NoError(t, err)
+	assert.True(t, resp.Valid)
+	assert.Equal(t, "8901234", resp.TokenInfo.Iuid)
+	require.NotNil(t, resp.TokenInfo.UserInfo)
+	assert.Equal(t, uint32(1369077332), resp.TokenInfo.UserInfo.Uid)
+	assert.Equal(t, uint32(8901234), resp.TokenInfo.UserInfo.Iuid)
+	assert.Equal(t, "user@example.com", resp.TokenInfo.UserInfo.Email)
+	assert.Equal(t, int64(1609459200), resp.TokenInfo.UserInfo.CreatedAt)
+	assert.Nil(t, resp.TokenInfo.UserInfo.DisabledAt)
+}
+
+// ========================================
+// TestHealthCheck
+// ========================================
+
+func TestHealthCheck_Draining(t *testing.T) {
+	draining := false
+	client := grpc_health_v1.NewHealthClient(startTestServer(t, new(MockValidationService), func() bool { return draining }))
+
+	resp, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.Status)
+
+	draining = true
+	resp, err = client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, resp.Status)
+}
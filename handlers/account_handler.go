@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/qiniu/bearer-token-service/v2/auth"
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+)
+
+// AccountHandlerImpl 账户管理 Handler 实现
+type AccountHandlerImpl struct {
+	accountService interfaces.AccountService
+}
+
+// NewAccountHandler 创建账户 Handler 实例
+func NewAccountHandler(accountService interfaces.AccountService) *AccountHandlerImpl {
+	return &AccountHandlerImpl{
+		accountService: accountService,
+	}
+}
+
+// Register 注册新账户
+// POST /api/v2/accounts/register
+func (h *AccountHandlerImpl) Register(w http.ResponseWriter, r *http.Request) {
+	var req interfaces.AccountRegisterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	resp, err := h.accountService.Register(r.Context(), &req)
+	if err != nil {
+		respondAccountError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, resp)
+}
+
+// GetAccountInfo 获取当前账户信息
+// GET /api/v2/accounts/me
+func (h *AccountHandlerImpl) GetAccountInfo(w http.ResponseWriter, r *http.Request) {
+	accountID, err := auth.ExtractAccountIDFromContext(r.Context())
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	account, err := h.accountService.GetAccountInfo(r.Context(), accountID)
+	if err != nil {
+		respondAccountError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, account)
+}
+
+// RegenerateSecretKey 重新生成 SecretKey（旧 SecretKey 立即失效）
+// POST /api/v2/accounts/regenerate-sk
+func (h *AccountHandlerImpl) RegenerateSecretKey(w http.ResponseWriter, r *http.Request) {
+	accountID, err := auth.ExtractAccountIDFromContext(r.Context())
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	resp, err := h.accountService.RegenerateSecretKey(r.Context(), accountID)
+	if err != nil {
+		respondAccountError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// respondAccountError 将账户 service 层错误映射为响应
+func respondAccountError(w http.ResponseWriter, err error) {
+	if errors.Is(err, interfaces.ErrDuplicateEmail) {
+		respondErrorCode(w, http.StatusConflict, interfaces.ErrCodeDuplicateEmail, "email already exists")
+		return
+	}
+
+	status := tokenErrStatus(err)
+	if status == http.StatusInternalServerError {
+		respondError(w, status, "internal error")
+		return
+	}
+	respondError(w, status, err.Error())
+}
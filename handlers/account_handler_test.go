@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ========================================
+// Mock AccountService
+// ========================================
+
+type MockAccountService struct {
+	mock.Mock
+}
+
+func (m *MockAccountService) Register(ctx context.Context, req *interfaces.AccountRegisterRequest) (*interfaces.AccountRegisterResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.AccountRegisterResponse), args.Error(1)
+}
+
+func (m *MockAccountService) GetAccountInfo(ctx context.Context, accountID string) (*interfaces.Account, error) {
+	args := m.Called(ctx, accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.Account), args.Error(1)
+}
+
+func (m *MockAccountService) RegenerateSecretKey(ctx context.Context, accountID string) (*interfaces.RegenerateSecretKeyResponse, error) {
+	args := m.Called(ctx, accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.RegenerateSecretKeyResponse), args.Error(1)
+}
+
+func (m *MockAccountService) SuspendAccount(ctx context.Context, accountID string) error {
+	args := m.Called(ctx, accountID)
+	return args.Error(0)
+}
+
+func (m *MockAccountService) ActivateAccount(ctx context.Context, accountID string) error {
+	args := m.Called(ctx, accountID)
+	return args.Error(0)
+}
+
+// ========================================
+// TestRegister
+// ========================================
+
+func TestRegister_Created(t *testing.T) {
+	mockService := new(MockAccountService)
+	handler := NewAccountHandler(mockService)
+
+	mockService.On("Register", mock.Anything, &interfaces.AccountRegisterRequest{
+		Email: "user@example.com", Company: "Acme", Password: "s3cret-password",
+	}).Return(&interfaces.AccountRegisterResponse{AccountID: "acc_1", AccessKey: "AK_x", SecretKey: "SK_y"}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/accounts/register",
+		strings.NewReader(`{"email":"user@example.com","company":"Acme","password":"s3cret-password"}`))
+	w := httptest.NewRecorder()
+	handler.Register(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"secret_key":"SK_y"`)
+}
+
+func TestRegister_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{"duplicate email", interfaces.ErrDuplicateEmail, http.StatusConflict, `"code":5001`},
+		{"duplicate access key", interfaces.ErrDuplicateAccessKey, http.StatusConflict, `"conflict: access key already exists"`},
+		{"invalid input", fmt.Errorf("%w: email is not a valid address", interfaces.ErrInvalidArgument), http.StatusBadRequest, `"invalid argument: email is not a valid address"`},
+		{"internal error", errors.New("connection refused"), http.StatusInternalServerError, `"internal error"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAccountService)
+			handler := NewAccountHandler(mockService)
+			mockService.On("Register", mock.Anything, mock.Anything).Return(nil, tt.err)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v2/accounts/register", strings.NewReader(`{}`))
+			w := httptest.NewRecorder()
+			handler.Register(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+}
+
+// ========================================
+// TestGetAccountInfo
+// ========================================
+
+func TestGetAccountInfo_HidesSecrets(t *testing.T) {
+	mockService := new(MockAccountService)
+	handler := NewAccountHandler(mockService)
+
+	mockService.On("GetAccountInfo", mock.Anything, "acc_1").Return(&interfaces.Account{
+		ID: "acc_1", Email: "user@example.com", AccessKey: "AK_x", SecretKey: "SK_y", PasswordHash: "$2a$10$hash",
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/accounts/me", nil)
+	req = req.WithContext(context.WithValue(req.Context(), "account_id", "acc_1"))
+	w := httptest.NewRecorder()
+	handler.GetAccountInfo(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"access_key":"AK_x"`)
+	assert.NotContains(t, w.Body.String(), "SK_y")
+	assert.NotContains(t, w.Body.String(), "$2a$10$hash")
+}
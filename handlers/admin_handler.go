@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/observability"
+)
+
+const (
+	defaultAdminListLimit = 50
+	maxAdminListLimit     = 200
+)
+
+// AdminHandlerImpl 管理员 Handler 实现
+type AdminHandlerImpl struct {
+	adminService interfaces.AdminService
+}
+
+// NewAdminHandler 创建管理员 Handler 实例
+func NewAdminHandler(adminService interfaces.AdminService) *AdminHandlerImpl {
+	return &AdminHandlerImpl{
+		adminService: adminService,
+	}
+}
+
+// ListAccounts 列出 / 搜索账户
+// GET /api/v2/admin/accounts?keyword=example.com&status=suspended&limit=50&offset=0
+func (h *AdminHandlerImpl) ListAccounts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := &interfaces.AccountListQuery{
+		Keyword: q.Get("keyword"),
+		Status:  q.Get("status"),
+		Limit:   defaultAdminListLimit,
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			respondError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		if limit > maxAdminListLimit {
+			limit = maxAdminListLimit
+		}
+		query.Limit = limit
+	}
+	if v := q.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			respondError(w, http.StatusBadRequest, "invalid offset")
+			return
+		}
+		query.Offset = offset
+	}
+
+	resp, err := h.adminService.ListAccounts(r.Context(), query)
+	if err != nil {
+		respondAdminError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// SuspendAccount 停用账户
+// POST /api/v2/admin/accounts/{id}/suspend
+func (h *AdminHandlerImpl) SuspendAccount(w http.ResponseWriter, r *http.Request) {
+	accountID := mux.Vars(r)["id"]
+
+	if err := h.adminService.SuspendAccount(r.Context(), accountID); err != nil {
+		respondAdminError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"account_id": accountID,
+		"status":     interfaces.AccountStatusSuspended,
+	})
+}
+
+// ActivateAccount 恢复账户
+// POST /api/v2/admin/accounts/{id}/activate
+func (h *AdminHandlerImpl) ActivateAccount(w http.ResponseWriter, r *http.Request) {
+	accountID := mux.Vars(r)["id"]
+
+	if err := h.adminService.ActivateAccount(r.Context(), accountID); err != nil {
+		respondAdminError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"account_id": accountID,
+		"status":     interfaces.AccountStatusActive,
+	})
+}
+
+// DisableAccountTokens 停用账户下所有 Token
+// POST /api/v2/admin/accounts/{id}/tokens/disable
+func (h *AdminHandlerImpl) DisableAccountTokens(w http.ResponseWriter, r *http.Request) {
+	accountID := mux.Vars(r)["id"]
+
+	count, err := h.adminService.DisableAccountTokens(r.Context(), accountID)
+	if err != nil {
+		respondAdminError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, &interfaces.AccountTokensDisableResponse{
+		AccountID:     accountID,
+		DisabledCount: count,
+	})
+}
+
+// DisableClientTokens 停用 OAuth 2.0 客户端签发的所有 Token
+// POST /api/v2/admin/clients/{client_id}/tokens/disable
+func (h *AdminHandlerImpl) DisableClientTokens(w http.ResponseWriter, r *http.Request) {
+	clientID := mux.Vars(r)["client_id"]
+
+	count, err := h.adminService.DisableClientTokens(r.Context(), clientID)
+	if err != nil {
+		respondAdminError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, &interfaces.ClientTokensDisableResponse{
+		ClientID:      clientID,
+		DisabledCount: count,
+	})
+}
+
+// SetClientResourceAccounts 授予 OAuth 2.0 客户端访问其他账户 Token 的权限（覆盖原有授权）
+// PUT /api/v2/admin/clients/{client_id}/resource-accounts
+// Request Body: {"account_ids": ["acc_xxx"]}
+func (h *AdminHandlerImpl) SetClientResourceAccounts(w http.ResponseWriter, r *http.Request) {
+	clientID := mux.Vars(r)["client_id"]
+
+	var req interfaces.OAuth2ClientResourceAccountsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, interfaces.ErrCodeBadRequest, "invalid request body")
+		return
+	}
+
+	client, err := h.adminService.SetClientResourceAccounts(r.Context(), clientID, req.AccountIDs)
+	if err != nil {
+		respondAdminError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, client)
+}
+
+// respondAdminError 将管理员 service 层错误映射为响应
+// 只有 interfaces 中定义的业务错误会返回错误信息，其他错误只记录日志，避免泄露存储层细节
+func respondAdminError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, interfaces.ErrNotFound):
+		respondErrorCode(w, http.StatusNotFound, interfaces.ErrCodeNotFound, err.Error())
+	case errors.Is(err, interfaces.ErrInvalidArgument):
+		respondErrorCode(w, http.StatusBadRequest, interfaces.ErrCodeBadRequest, err.Error())
+	default:
+		observability.LogError(r.Context(), "Admin operation failed", err)
+		respondErrorCode(w, http.StatusInternalServerError, interfaces.ErrCodeInternalServerError, "internal error")
+	}
+}
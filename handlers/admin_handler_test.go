@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ========================================
+// Mock AdminService
+// ========================================
+
+type MockAdminService struct {
+	mock.Mock
+}
+
+func (m *MockAdminService) ListAccounts(ctx context.Context, query *interfaces.AccountListQuery) (*interfaces.AccountListResponse, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.AccountListResponse), args.Error(1)
+}
+
+func (m *MockAdminService) SuspendAccount(ctx context.Context, accountID string) error {
+	return m.Called(ctx, accountID).Error(0)
+}
+
+func (m *MockAdminService) ActivateAccount(ctx context.Context, accountID string) error {
+	return m.Called(ctx, accountID).Error(0)
+}
+
+func (m *MockAdminService) DisableAccountTokens(ctx context.Context, accountID string) (int64, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAdminService) DisableClientTokens(ctx context.Context, clientID string) (int64, error) {
+	args := m.Called(ctx, clientID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAdminService) SetClientResourceAccounts(ctx context.Context, clientID string, accountIDs []string) (*interfaces.OAuth2Client, error) {
+	args := m.Called(ctx, clientID, accountIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.OAuth2Client), args.Error(1)
+}
+
+// ========================================
+// TestAdminHandler
+// ========================================
+
+func TestAdminHandler_ErrorMapping(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{"account not found", interfaces.ErrAccountNotFound, http.StatusNotFound, `"error":"account not found"`},
+		{"invalid argument", fmt.Errorf("%w: status must be active or suspended", interfaces.ErrInvalidArgument), http.StatusBadRequest, `"code":400`},
+		{"internal error", errors.New("server selection timeout: mongo-0.internal:27017"), http.StatusInternalServerError, `"error":"internal error"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAdminService)
+			handler := NewAdminHandler(mockService)
+			mockService.On("SuspendAccount", mock.Anything, "acc_1").Return(tt.err)
+
+			req := mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/api/v2/admin/accounts/acc_1/suspend", nil), map[string]string{"id": "acc_1"})
+			w := httptest.NewRecorder()
+			handler.SuspendAccount(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+			assert.NotContains(t, w.Body.String(), "mongo-0.internal")
+		})
+	}
+}
+
+func TestAdminHandler_DisableAccountTokens(t *testing.T) {
+	mockService := new(MockAdminService)
+	handler := NewAdminHandler(mockService)
+	mockService.On("DisableAccountTokens", mock.Anything, "acc_1").Return(int64(3), nil)
+	mockService.On("DisableAccountTokens", mock.Anything, "acc_2").Return(int64(0), errors.New("write concern timeout"))
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/api/v2/admin/accounts/acc_1/tokens/disable", nil), map[string]string{"id": "acc_1"})
+	w := httptest.NewRecorder()
+	handler.DisableAccountTokens(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"disabled_count":3`)
+
+	req = mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/api/v2/admin/accounts/acc_2/tokens/disable", nil), map[string]string{"id": "acc_2"})
+	w = httptest.NewRecorder()
+	handler.DisableAccountTokens(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "write concern")
+}
+
+func TestAdminHandler_SetClientResourceAccounts(t *testing.T) {
+	mockService := new(MockAdminService)
+	handler := NewAdminHandler(mockService)
+	mockService.On("SetClientResourceAccounts", mock.Anything, "oc_gw", []string{"acc_1"}).Return(&interfaces.OAuth2Client{
+		ClientID: "oc_gw", AccountID: "acc_gw", ResourceAccounts: []string{"acc_1"},
+	}, nil)
+	mockService.On("SetClientResourceAccounts", mock.Anything, "oc_missing", []string{"acc_1"}).Return(nil, interfaces.ErrOAuth2ClientNotFound)
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodPut, "/api/v2/admin/clients/oc_gw/resource-accounts", strings.NewReader(`{"account_ids":["acc_1"]}`)), map[string]string{"client_id": "oc_gw"})
+	w := httptest.NewRecorder()
+	handler.SetClientResourceAccounts(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"resource_accounts":["acc_1"]`)
+	assert.NotContains(t, w.Body.String(), "secret")
+
+	req = mux.SetURLVars(httptest.NewRequest(http.MethodPut, "/api/v2/admin/clients/oc_missing/resource-accounts", strings.NewReader(`{"account_ids":["acc_1"]}`)), map[string]string{"client_id": "oc_missing"})
+	w = httptest.NewRecorder()
+	handler.SetClientResourceAccounts(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/auth"
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+)
+
+// AuditHandlerImpl 审计日志 Handler 实现
+type AuditHandlerImpl struct {
+	auditService interfaces.AuditService
+}
+
+// NewAuditHandler 创建审计日志 Handler 实例
+func NewAuditHandler(auditService interfaces.AuditService) *AuditHandlerImpl {
+	return &AuditHandlerImpl{
+		auditService: auditService,
+	}
+}
+
+// QueryAuditLogs 查询当前账户的审计日志
+// GET /api/v2/audit-logs?action=create_token&resource_id=tk_xxx&start_time=2025-01-01T00:00:00Z&end_time=...&limit=50&cursor=xxx
+func (h *AuditHandlerImpl) QueryAuditLogs(w http.ResponseWriter, r *http.Request) {
+	accountID, err := auth.ExtractAccountIDFromContext(r.Context())
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// 解析查询参数
+	q := r.URL.Query()
+	query := &interfaces.AuditLogQuery{
+		Action:     q.Get("action"),
+		ResourceID: q.Get("resource_id"),
+		Cursor:     q.Get("cursor"),
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			respondError(w, http.StatusBadRequest, "invalid limit, expected a positive integer")
+			return
+		}
+		query.Limit = n
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			respondError(w, http.StatusBadRequest, "invalid offset, expected a non-negative integer")
+			return
+		}
+		query.Offset = n
+	}
+
+	// 时间范围（RFC3339）
+	if v := q.Get("start_time"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid start_time, expected RFC3339 format")
+			return
+		}
+		query.StartTime = t
+	}
+	if v := q.Get("end_time"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid end_time, expected RFC3339 format")
+			return
+		}
+		query.EndTime = t
+	}
+	if !query.StartTime.IsZero() && !query.EndTime.IsZero() && query.StartTime.After(query.EndTime) {
+		respondError(w, http.StatusBadRequest, "start_time must not be after end_time")
+		return
+	}
+
+	resp, err := h.auditService.QueryLogs(r.Context(), accountID, query)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrInvalidArgument) {
+			respondError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ========================================
+// Mock AuditService
+// ========================================
+
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) Log(ctx context.Context, log *interfaces.AuditLog) error {
+	args := m.Called(ctx, log)
+	return args.Error(0)
+}
+
+func (m *MockAuditService) LogAction(ctx context.Context, accountID string, action string, resourceID string, result string, errorMsg string, requestData map[string]interface{}) error {
+	args := m.Called(ctx, accountID, action, resourceID, result, errorMsg, requestData)
+	return args.Error(0)
+}
+
+func (m *MockAuditService) QueryLogs(ctx context.Context, accountID string, query *interfaces.AuditLogQuery) (*interfaces.AuditLogResponse, error) {
+	args := m.Called(ctx, accountID, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.AuditLogResponse), args.Error(1)
+}
+
+func newAuditRequest(target string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	return req.WithContext(context.WithValue(req.Context(), "account_id", "acc_1"))
+}
+
+// ========================================
+// TestQueryAuditLogs
+// ========================================
+
+func TestQueryAuditLogs_Success(t *testing.T) {
+	mockService := new(MockAuditService)
+	handler := NewAuditHandler(mockService)
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockService.On("QueryLogs", mock.Anything, "acc_1", &interfaces.AuditLogQuery{
+		Action:    "create_token",
+		StartTime: start,
+		Limit:     10,
+		Cursor:    "abc",
+	}).Return(&interfaces.AuditLogResponse{Logs: []interfaces.AuditLog{}, NextCursor: "def"}, nil)
+
+	w := httptest.NewRecorder()
+	handler.QueryAuditLogs(w, newAuditRequest("/api/v2/audit-logs?action=create_token&start_time=2025-01-01T00:00:00Z&limit=10&cursor=abc"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"next_cursor":"def"`)
+	mockService.AssertExpectations(t)
+}
+
+func TestQueryAuditLogs_BadRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"invalid start_time", "/api/v2/audit-logs?start_time=yesterday"},
+		{"invalid end_time", "/api/v2/audit-logs?end_time=2025-01-01"},
+		{"reversed range", "/api/v2/audit-logs?start_time=2025-02-01T00:00:00Z&end_time=2025-01-01T00:00:00Z"},
+		{"non-numeric limit", "/api/v2/audit-logs?limit=abc"},
+		{"zero limit", "/api/v2/audit-logs?limit=0"},
+		{"negative limit", "/api/v2/audit-logs?limit=-5"},
+		{"non-numeric offset", "/api/v2/audit-logs?offset=ten"},
+		{"negative offset", "/api/v2/audit-logs?offset=-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAuditService)
+			handler := NewAuditHandler(mockService)
+
+			w := httptest.NewRecorder()
+			handler.QueryAuditLogs(w, newAuditRequest(tt.target))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			mockService.AssertNotCalled(t, "QueryLogs", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestQueryAuditLogs_InvalidCursor(t *testing.T) {
+	mockService := new(MockAuditService)
+	handler := NewAuditHandler(mockService)
+
+	mockService.On("QueryLogs", mock.Anything, "acc_1", mock.Anything).
+		Return(nil, fmt.Errorf("%w: invalid cursor", interfaces.ErrInvalidArgument))
+
+	w := httptest.NewRecorder()
+	handler.QueryAuditLogs(w, newAuditRequest("/api/v2/audit-logs?cursor=!!!"))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid cursor")
+}
+
+func TestQueryAuditLogs_InternalError(t *testing.T) {
+	mockService := new(MockAuditService)
+	handler := NewAuditHandler(mockService)
+
+	mockService.On("QueryLogs", mock.Anything, "acc_1", mock.Anything).
+		Return(nil, errors.New("connection reset"))
+
+	w := httptest.NewRecorder()
+	handler.QueryAuditLogs(w, newAuditRequest("/api/v2/audit-logs"))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+)
+
+// 反向代理鉴权使用的请求头 / 响应头
+const (
+	// HeaderAuthRequiredScope 请求头：要求 Token 具备的权限（由代理配置设置，如 storage:read）
+	HeaderAuthRequiredScope = "X-Auth-Required-Scope"
+
+	// 验证通过时返回的身份头，由代理转发给上游服务
+	HeaderAuthUID       = "X-Auth-UID"
+	HeaderAuthIUID      = "X-Auth-IUID"
+	HeaderAuthIamAlias  = "X-Auth-IAM-Alias"
+	HeaderAuthAccountID = "X-Auth-Account-ID"
+	HeaderAuthTokenID   = "X-Auth-Token-ID"
+	HeaderAuthScopes    = "X-Auth-Scopes" // 逗号分隔，Token 未限制权限时为空
+)
+
+// AuthCheck nginx auth_request 兼容的鉴权接口
+// ANY /api/v2/auth/check[/...]
+// 读取 Authorization: Bearer 头，返回 200 / 401 / 403 / 429，不返回响应体；验证通过时返回身份头
+func (h *ValidationHandlerImpl) AuthCheck(w http.ResponseWriter, r *http.Request) {
+	h.authCheck(w, r, false)
+}
+
+// EnvoyAuthCheck Envoy ext_authz HTTP 服务模式的鉴权接口
+// ANY /api/v2/auth/envoy/...（Envoy 将原始请求路径追加在 path_prefix 之后）
+// 状态码和身份头与 AuthCheck 相同；拒绝时额外返回 JSON 错误体，由 Envoy 直接返回给客户端
+func (h *ValidationHandlerImpl) EnvoyAuthCheck(w http.ResponseWriter, r *http.Request) {
+	h.authCheck(w, r, true)
+}
+
+// authCheck 执行鉴权（AuthCheck / EnvoyAuthCheck 共用）
+// withBody 为 false 时拒绝响应不带响应体（nginx auth_request 会丢弃子请求的响应体）
+func (h *ValidationHandlerImpl) authCheck(w http.ResponseWriter, r *http.Request, withBody bool) {
+	deny := func(statusCode int, message string) {
+		if withBody {
+			respondError(w, statusCode, message)
+			return
+		}
+		w.WriteHeader(statusCode)
+	}
+
+	// 1. 提取 Bearer Token
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		deny(http.StatusUnauthorized, "invalid authorization header")
+		return
+	}
+	tokenValue := strings.TrimPrefix(authHeader, "Bearer ")
+
+	// 2. 读取代理配置的 required_scope
+	requiredScope := r.Header.Get(HeaderAuthRequiredScope)
+	if requiredScope != "" && !interfaces.IsValidRequiredScope(requiredScope) {
+		deny(http.StatusBadRequest, "invalid scope format, expected 'resource:action'")
+		return
+	}
+
+	// 3. 调用验证服务（由验证服务应用 Token 层限流，超限结果以 code 429 返回）
+	result, err := h.validationService.ValidateTokenWithLimit(r.Context(), &interfaces.TokenValidateRequest{
+		Token:         tokenValue,
+		RequiredScope: requiredScope,
+		ClientIP:      h.clientIP.FromRequest(r),
+	})
+	if err != nil || result == nil {
+		deny(http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	// 4. 验证失败：401 / 403 / 429
+	if !result.Valid {
+		statusCode := authCheckFailureStatus(result.Code)
+		switch statusCode {
+		case http.StatusUnauthorized:
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		case http.StatusForbidden:
+			if result.Code == interfaces.ErrCodeScopeNotGranted {
+				w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+requiredScope+`"`)
+			}
+		}
+		deny(statusCode, result.Message)
+		return
+	}
+
+	// 5. 验证通过：返回身份头
+	info := result.TokenInfo
+	w.Header().Set(HeaderAuthTokenID, info.TokenID)
+	w.Header().Set(HeaderAuthScopes, strings.Join(info.Scopes, ","))
+	if info.UID != "" {
+		w.Header().Set(HeaderAuthUID, info.UID)
+	}
+	if info.IUID != "" {
+		w.Header().Set(HeaderAuthIUID, info.IUID)
+	}
+	if info.IamAlias != "" {
+		w.Header().Set(HeaderAuthIamAlias, info.IamAlias)
+	}
+	if info.AccountID != "" {
+		w.Header().Set(HeaderAuthAccountID, info.AccountID)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// authCheckFailureStatus 将验证失败的业务错误码映射为 HTTP 状态码（在 validateFailureStatus 基础上增加 429）
+func authCheckFailureStatus(code int) int {
+	if code == interfaces.ErrCodeTooManyRequests {
+		return http.StatusTooManyRequests
+	}
+	return validateFailureStatus(code)
+}
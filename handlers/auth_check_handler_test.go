@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========================================
+// TestAuthCheck
+// ========================================
+
+func TestAuthCheck_Success(t *testing.T) {
+	// Arrange
+	mockService := new(MockValidationService)
+	handler := NewValidationHandler(mockService)
+
+	mockService.On("ValidateTokenWithLimit", mock.Anything, &interfaces.TokenValidateRequest{
+		Token:         "sk-valid-token",
+		RequiredScope: "storage:read",
+		ClientIP:      "192.0.2.1",
+	}).Return(&interfaces.TokenValidateResponse{
+		Valid:   true,
+		Message: "valid",
+		TokenInfo: &interfaces.TokenInfo{
+			TokenID:   "tk_123",
+			AccountID: "qiniu_1369077332",
+			UID:       "1369077332",
+			IUID:      "8901234",
+			Scopes:    []string{"storage:read", "cdn:refresh"},
+			IsActive:  true,
+		},
+	}, nil)
+
+	req := httptest.NewRequest("PUT", "/api/v2/auth/check/bucket/object", nil)
+	req.Header.Set("Authorization", "Bearer sk-valid-token")
+	req.Header.Set(HeaderAuthRequiredScope, "storage:read")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.AuthCheck(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, "1369077332", w.Header().Get(HeaderAuthUID))
+	assert.Equal(t, "8901234", w.Header().Get(HeaderAuthIUID))
+	assert.Equal(t, "qiniu_1369077332", w.Header().Get(HeaderAuthAccountID))
+	assert.Equal(t, "tk_123", w.Header().Get(HeaderAuthTokenID))
+	assert.Equal(t, "storage:read,cdn:refresh", w.Header().Get(HeaderAuthScopes))
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthCheck_Denied(t *testing.T) {
+	tests := []struct {
+		name          string
+		authHeader    string
+		requiredScope string
+		result        *interfaces.TokenValidateResponse
+		serviceErr    error
+		wantStatus    int
+	}{
+		{name: "missing token", wantStatus: http.StatusUnauthorized},
+		{name: "invalid scope header", authHeader: "Bearer sk-token", requiredScope: "storage:*", wantStatus: http.StatusBadRequest},
+		{
+			name:       "token not found",
+			authHeader: "Bearer sk-token",
+			result:     &interfaces.TokenValidateResponse{Valid: false, Code: interfaces.ErrCodeTokenNotFound},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "scope not granted",
+			authHeader:    "Bearer sk-token",
+			requiredScope: "storage:write",
+			result:        &interfaces.TokenValidateResponse{Valid: false, Code: interfaces.ErrCodeScopeNotGranted},
+			wantStatus:    http.StatusForbidden,
+		},
+		{
+			name:       "token rate limited",
+			authHeader: "Bearer sk-token",
+			result:     &interfaces.TokenValidateResponse{Valid: false, Code: interfaces.ErrCodeTooManyRequests},
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{name: "service error", authHeader: "Bearer sk-token", serviceErr: errors.New("db down"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockValidationService)
+			handler := NewValidationHandler(mockService)
+			if tt.result != nil || tt.serviceErr != nil {
+				mockService.On("ValidateTokenWithLimit", mock.Anything, mock.Anything).Return(tt.result, tt.serviceErr)
+			}
+
+			req := httptest.NewRequest("GET", "/api/v2/auth/check", nil)
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			if tt.requiredScope != "" {
+				req.Header.Set(HeaderAuthRequiredScope, tt.requiredScope)
+			}
+			w := httptest.NewRecorder()
+
+			handler.AuthCheck(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Empty(t, w.Body.String())
+			assert.Empty(t, w.Header().Get(HeaderAuthUID))
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestEnvoyAuthCheck_DeniedWithBody(t *testing.T) {
+	// Arrange
+	mockService := new(MockValidationService)
+	handler := NewValidationHandler(mockService)
+
+	mockService.On("ValidateTokenWithLimit", mock.Anything, mock.Anything).Return(&interfaces.TokenValidateResponse{
+		Valid:   false,
+		Message: "Token does not have required scope: storage:write",
+		Code:    interfaces.ErrCodeScopeNotGranted,
+	}, nil)
+
+	req := httptest.NewRequest("POST", "/api/v2/auth/envoy/bucket/object", nil)
+	req.Header.Set("Authorization", "Bearer sk-token")
+	req.Header.Set(HeaderAuthRequiredScope, "storage:write")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.EnvoyAuthCheck(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "insufficient_scope")
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "Token does not have required scope: storage:write", body["error"])
+	mockService.AssertExpectations(t)
+}
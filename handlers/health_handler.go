@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/observability"
+)
+
+// DefaultHealthCheckTimeout 单个依赖检查的默认超时
+const DefaultHealthCheckTimeout = 2 * time.Second
+
+// healthDependency 就绪检查中的一个依赖
+type healthDependency struct {
+	name     string
+	critical bool
+	check    func(ctx context.Context) error
+}
+
+// HealthHandlerImpl 健康检查 Handler 实现
+// /health/live 只表示进程存活；/health/ready 检查依赖，关键依赖异常或正在关闭时返回 503
+type HealthHandlerImpl struct {
+	version  string
+	commit   string
+	built    string
+	timeout  time.Duration
+	draining func() bool
+	deps     []healthDependency
+}
+
+// NewHealthHandler 创建健康检查 Handler 实例
+// draining 返回 true 时就绪检查直接失败（用于优雅关闭），可为 nil
+func NewHealthHandler(version, commit, built string, timeout time.Duration, draining func() bool) *HealthHandlerImpl {
+	if timeout <= 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+	return &HealthHandlerImpl{
+		version:  version,
+		commit:   commit,
+		built:    built,
+		timeout:  timeout,
+		draining: draining,
+	}
+}
+
+// AddDependency 注册依赖检查
+// critical 为 true 表示关键依赖（如 MongoDB），异常时实例不就绪；
+// 否则为可降级依赖（如用户信息后端），异常时只标记 degraded
+func (h *HealthHandlerImpl) AddDependency(name string, critical bool, check func(ctx context.Context) error) {
+	h.deps = append(h.deps, healthDependency{name: name, critical: critical, check: check})
+}
+
+// Live 存活检查
+// GET /health/live
+func (h *HealthHandlerImpl) Live(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, &interfaces.HealthResponse{
+		Status:  interfaces.HealthStatusOK,
+		Version: h.version,
+		Commit:  h.commit,
+		Built:   h.built,
+	})
+}
+
+// Ready 就绪检查（并发检查所有依赖）
+// GET /health/ready
+func (h *HealthHandlerImpl) Ready(w http.ResponseWriter, r *http.Request) {
+	resp := &interfaces.HealthResponse{
+		Status:  interfaces.HealthStatusOK,
+		Version: h.version,
+		Commit:  h.commit,
+		Built:   h.built,
+	}
+
+	if h.draining != nil && h.draining() {
+		resp.Status = interfaces.HealthStatusDraining
+		respondJSON(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+
+	results := make([]interfaces.DependencyHealth, len(h.deps))
+	var wg sync.WaitGroup
+	for i, dep := range h.deps {
+		wg.Add(1)
+		go func(i int, dep healthDependency) {
+			defer wg.Done()
+			results[i] = h.checkDependency(r.Context(), dep)
+		}(i, dep)
+	}
+	wg.Wait()
+
+	resp.Dependencies = make(map[string]interfaces.DependencyHealth, len(h.deps))
+	for i, dep := range h.deps {
+		result := results[i]
+		resp.Dependencies[dep.name] = result
+
+		if result.Status == interfaces.DependencyStatusUp {
+			observability.DependencyUp.WithLabelValues(dep.name).Set(1)
+			continue
+		}
+		observability.DependencyUp.WithLabelValues(dep.name).Set(0)
+
+		if dep.critical {
+			resp.Status = interfaces.HealthStatusNotReady
+		} else if resp.Status == interfaces.HealthStatusOK {
+			resp.Status = interfaces.HealthStatusDegraded
+		}
+	}
+
+	statusCode := http.StatusOK
+	if resp.Status == interfaces.HealthStatusNotReady {
+		statusCode = http.StatusServiceUnavailable
+	}
+	respondJSON(w, statusCode, resp)
+}
+
+// checkDependency 执行单个依赖检查（带超时）
+func (h *HealthHandlerImpl) checkDependency(ctx context.Context, dep healthDependency) interfaces.DependencyHealth {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := dep.check(ctx)
+
+	result := interfaces.DependencyHealth{
+		Status:    interfaces.DependencyStatusUp,
+		Critical:  dep.critical,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		// 后端错误可能包含内部地址等信息，只记录日志，响应中只返回状态
+		result.Status = interfaces.DependencyStatusDown
+		observability.LogWarn(ctx, "Dependency health check failed",
+			slog.String("dependency", dep.name),
+			slog.String("error", err.Error()))
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func checkOK(ctx context.Context) error { return nil }
+
+func checkFail(ctx context.Context) error { return errors.New("connection refused") }
+
+func TestHealthReady(t *testing.T) {
+	tests := []struct {
+		name       string
+		mongo      func(ctx context.Context) error
+		mysql      func(ctx context.Context) error
+		draining   bool
+		wantCode   int
+		wantStatus string
+	}{
+		{"all up", checkOK, checkOK, false, http.StatusOK, interfaces.HealthStatusOK},
+		{"degradable dependency down", checkOK, checkFail, false, http.StatusOK, interfaces.HealthStatusDegraded},
+		{"critical dependency down", checkFail, checkOK, false, http.StatusServiceUnavailable, interfaces.HealthStatusNotReady},
+		{"draining", checkOK, checkOK, true, http.StatusServiceUnavailable, interfaces.HealthStatusDraining},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHealthHandler("v1", "abc", "now", time.Second, func() bool { return tt.draining })
+			handler.AddDependency("mongodb", true, tt.mongo)
+			handler.AddDependency("mysql", false, tt.mysql)
+
+			w := httptest.NewRecorder()
+			handler.Ready(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+
+			var resp interfaces.HealthResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.wantStatus, resp.Status)
+			if !tt.draining {
+				assert.Len(t, resp.Dependencies, 2)
+				assert.True(t, resp.Dependencies["mongodb"].Critical)
+			}
+			assert.NotContains(t, w.Body.String(), "connection refused")
+		})
+	}
+}
+
+func TestHealthReady_DependencyTimeout(t *testing.T) {
+	handler := NewHealthHandler("v1", "abc", "now", 20*time.Millisecond, nil)
+	handler.AddDependency("mongodb", true, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	w := httptest.NewRecorder()
+	handler.Ready(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.NotContains(t, w.Body.String(), "deadline exceeded")
+
+	var resp interfaces.HealthResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, interfaces.DependencyStatusDown, resp.Dependencies["mongodb"].Status)
+}
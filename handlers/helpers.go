@@ -23,3 +23,13 @@ func respondError(w http.ResponseWriter, statusCode int, message string) {
 		"code":  statusCode,
 	})
 }
+
+// respondErrorCode 返回带业务错误码的错误响应（如 ErrCodeDuplicateEmail）
+func respondErrorCode(w http.ResponseWriter, statusCode int, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": message,
+		"code":  code,
+	})
+}
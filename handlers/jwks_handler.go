@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/pkg/signedtoken"
+)
+
+// DefaultJWKSMaxAge 公钥集合默认缓存时长
+const DefaultJWKSMaxAge = 5 * time.Minute
+
+// JWKSHandlerImpl 签名 Token 公钥发布 Handler
+// 资源服务据此离线验证签名 Token；新密钥上线前应先加入密钥列表（不签发）发布一个缓存周期
+type JWKSHandlerImpl struct {
+	keys   *signedtoken.KeySet
+	maxAge time.Duration
+}
+
+// NewJWKSHandler 创建公钥发布 Handler 实例
+func NewJWKSHandler(keys *signedtoken.KeySet, maxAge time.Duration) *JWKSHandlerImpl {
+	if maxAge <= 0 {
+		maxAge = DefaultJWKSMaxAge
+	}
+	return &JWKSHandlerImpl{
+		keys:   keys,
+		maxAge: maxAge,
+	}
+}
+
+// GetJWKS 返回签名 Token 公钥集合
+// GET /.well-known/jwks.json
+func (h *JWKSHandlerImpl) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.maxAge.Seconds())))
+	respondJSON(w, http.StatusOK, h.keys.JWKS())
+}
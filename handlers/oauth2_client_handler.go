@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/qiniu/bearer-token-service/v2/auth"
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/observability"
+)
+
+// CreateClient 创建 OAuth 2.0 客户端（client_secret 只在响应中返回一次）
+// POST /api/v2/oauth2/clients
+// Request Body: {"name": "gateway", "scopes": ["storage:read"], "grant_types": ["client_credentials"]}
+func (h *OAuth2HandlerImpl) CreateClient(w http.ResponseWriter, r *http.Request) {
+	accountID, err := auth.ExtractAccountIDFromContext(r.Context())
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if isSubAccount(r) {
+		respondError(w, http.StatusForbidden, "IAM sub-accounts cannot manage OAuth2 clients")
+		return
+	}
+
+	var req interfaces.OAuth2ClientCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	resp, err := h.oauth2Service.CreateClient(r.Context(), accountID, &req)
+	if err != nil {
+		respondClientError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	respondJSON(w, http.StatusCreated, resp)
+}
+
+// ListClients 列出当前账户的 OAuth 2.0 客户端
+// GET /api/v2/oauth2/clients
+func (h *OAuth2HandlerImpl) ListClients(w http.ResponseWriter, r *http.Request) {
+	accountID, err := auth.ExtractAccountIDFromContext(r.Context())
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if isSubAccount(r) {
+		respondError(w, http.StatusForbidden, "IAM sub-accounts cannot manage OAuth2 clients")
+		return
+	}
+
+	resp, err := h.oauth2Service.ListClients(r.Context(), accountID)
+	if err != nil {
+		respondClientError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// DeleteClient 删除 OAuth 2.0 客户端，并停用其签发的所有 Token
+// DELETE /api/v2/oauth2/clients/{client_id}
+func (h *OAuth2HandlerImpl) DeleteClient(w http.ResponseWriter, r *http.Request) {
+	accountID, err := auth.ExtractAccountIDFromContext(r.Context())
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if isSubAccount(r) {
+		respondError(w, http.StatusForbidden, "IAM sub-accounts cannot manage OAuth2 clients")
+		return
+	}
+
+	clientID := mux.Vars(r)["client_id"]
+	if err := h.oauth2Service.DeleteClient(r.Context(), accountID, clientID); err != nil {
+		respondClientError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"message": "Client deleted successfully",
+	})
+}
+
+// isSubAccount 调用方是否为 IAM 子账号（QiniuStub 认证携带 IUID 或 IAM 别名）
+// 客户端可访问账户下所有 Token，只允许主账号管理
+func isSubAccount(r *http.Request) bool {
+	user := auth.ExtractQstubUser(r.Context())
+	return user != nil && (user.IamUid != "" || user.IamAlias != "")
+}
+
+// respondClientError 将客户端管理错误映射为响应，内部错误只记录日志
+func respondClientError(w http.ResponseWriter, r *http.Request, err error) {
+	status := tokenErrStatus(err)
+	if status == http.StatusInternalServerError {
+		observability.LogError(r.Context(), "OAuth2 client operation failed", err)
+		respondError(w, status, "internal error")
+		return
+	}
+	respondError(w, status, err.Error())
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/qiniu/bearer-token-service/v2/auth"
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func withAccount(req *http.Request, accountID string) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), "account_id", accountID))
+}
+
+func TestCreateClient(t *testing.T) {
+	mockService := new(MockOAuth2Service)
+	handler := NewOAuth2Handler(mockService)
+	mockService.On("CreateClient", mock.Anything, "acc_1", mock.MatchedBy(func(req *interfaces.OAuth2ClientCreateRequest) bool {
+		return req.Name == "gateway" && len(req.Scopes) == 1 && req.GrantTypes[0] == interfaces.OAuth2GrantClientCredentials
+	})).Return(&interfaces.OAuth2ClientCreateResponse{
+		OAuth2Client: interfaces.OAuth2Client{ClientID: "oc_1", AccountID: "acc_1", Name: "gateway", SecretHash: "deadbeef"},
+		ClientSecret: "ocs_1",
+	}, nil)
+
+	body := `{"name":"gateway","scopes":["storage:read"],"grant_types":["client_credentials"]}`
+	req := withAccount(httptest.NewRequest(http.MethodPost, "/api/v2/oauth2/clients", strings.NewReader(body)), "acc_1")
+	w := httptest.NewRecorder()
+	handler.CreateClient(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.Contains(t, w.Body.String(), `"client_id":"oc_1"`)
+	assert.Contains(t, w.Body.String(), `"client_secret":"ocs_1"`)
+	assert.NotContains(t, w.Body.String(), "deadbeef")
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateClient_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{"invalid argument", interfaces.ErrInvalidArgument, http.StatusBadRequest, "invalid argument"},
+		{"internal error", errors.New("server selection timeout: mongo-0.internal:27017"), http.StatusInternalServerError, "internal error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockOAuth2Service)
+			handler := NewOAuth2Handler(mockService)
+			mockService.On("CreateClient", mock.Anything, "acc_1", mock.Anything).Return(nil, tt.err)
+
+			req := withAccount(httptest.NewRequest(http.MethodPost, "/api/v2/oauth2/clients", strings.NewReader(`{"name":"gateway"}`)), "acc_1")
+			w := httptest.NewRecorder()
+			handler.CreateClient(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+			assert.NotContains(t, w.Body.String(), "mongo-0.internal")
+		})
+	}
+}
+
+func TestDeleteClient(t *testing.T) {
+	mockService := new(MockOAuth2Service)
+	handler := NewOAuth2Handler(mockService)
+	mockService.On("DeleteClient", mock.Anything, "acc_1", "oc_1").Return(nil)
+	mockService.On("DeleteClient", mock.Anything, "acc_1", "oc_other").Return(interfaces.ErrOAuth2ClientNotFound)
+
+	req := withAccount(mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/api/v2/oauth2/clients/oc_1", nil), map[string]string{"client_id": "oc_1"}), "acc_1")
+	w := httptest.NewRecorder()
+	handler.DeleteClient(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 其他账户的客户端
+	req = withAccount(mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/api/v2/oauth2/clients/oc_other", nil), map[string]string{"client_id": "oc_other"}), "acc_1")
+	w = httptest.NewRecorder()
+	handler.DeleteClient(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestClientManagement_RejectsSubAccounts(t *testing.T) {
+	mockService := new(MockOAuth2Service)
+	handler := NewOAuth2Handler(mockService)
+
+	subAccount := func(req *http.Request) *http.Request {
+		ctx := context.WithValue(req.Context(), "account_id", "qiniu_1369077332")
+		ctx = context.WithValue(ctx, "qstub_user", &auth.QstubUserInfo{UID: "1369077332", IamUid: "8901234"})
+		return req.WithContext(ctx)
+	}
+
+	body := `{"name":"gateway","scopes":["storage:read"],"grant_types":["client_credentials"]}`
+	w := httptest.NewRecorder()
+	handler.CreateClient(w, subAccount(httptest.NewRequest(http.MethodPost, "/api/v2/oauth2/clients", strings.NewReader(body))))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ListClients(w, subAccount(httptest.NewRequest(http.MethodGet, "/api/v2/oauth2/clients", nil)))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/api/v2/oauth2/clients/oc_1", nil), map[string]string{"client_id": "oc_1"})
+	handler.DeleteClient(w, subAccount(req))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	mockService.AssertNotCalled(t, "CreateClient", mock.Anything, mock.Anything, mock.Anything)
+	mockService.AssertNotCalled(t, "ListClients", mock.Anything, mock.Anything)
+	mockService.AssertNotCalled(t, "DeleteClient", mock.Anything, mock.Anything, mock.Anything)
+}
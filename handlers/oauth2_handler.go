@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/pkg/clientip"
+)
+
+// maxOAuth2RequestBody OAuth 2.0 表单请求体上限
+const maxOAuth2RequestBody = 64 << 10
+
+// OAuth2HandlerImpl OAuth 2.0 Token 签发 / 内省 / 吊销 Handler 实现
+// 请求和响应格式遵循 RFC 6749 / RFC 7662 / RFC 7009（表单请求、{"error": "..."} 错误响应），
+// 供 Kong、APISIX、oauth2-proxy 等只支持标准协议的网关和 OAuth 2.0 客户端库直接接入
+type OAuth2HandlerImpl struct {
+	oauth2Service interfaces.OAuth2Service
+	clientIP      *clientip.Resolver // 解析调用方 IP（token exchange 时校验原 Token 的 allowed_cidrs），nil 时使用直连地址
+}
+
+// NewOAuth2Handler 创建 OAuth 2.0 Handler 实例
+func NewOAuth2Handler(oauth2Service interfaces.OAuth2Service) *OAuth2HandlerImpl {
+	return &OAuth2HandlerImpl{
+		oauth2Service: oauth2Service,
+	}
+}
+
+// SetClientIPResolver 设置客户端 IP 解析器（可信代理链、网关转发的调用方 IP 头）
+func (h *OAuth2HandlerImpl) SetClientIPResolver(resolver *clientip.Resolver) {
+	h.clientIP = resolver
+}
+
+// Token 签发短期 Token
+// POST /oauth2/token
+// Request Body (form): grant_type=client_credentials[&scope=storage:read cdn:*]
+// 或 grant_type=urn:ietf:params:oauth:grant-type:token-exchange&subject_token=xxx&subject_token_type=urn:ietf:params:oauth:token-type:access_token[&scope=...]
+func (h *OAuth2HandlerImpl) Token(w http.ResponseWriter, r *http.Request) {
+	client, ok := h.authenticateClient(w, r)
+	if !ok {
+		return
+	}
+
+	req := &interfaces.OAuth2TokenRequest{
+		GrantType:        r.PostForm.Get("grant_type"),
+		Scope:            r.PostForm.Get("scope"),
+		SubjectToken:     r.PostForm.Get("subject_token"),
+		SubjectTokenType: r.PostForm.Get("subject_token_type"),
+		ClientIP:         h.clientIP.FromRequest(r),
+	}
+	resp, err := h.oauth2Service.IssueToken(r.Context(), client, req)
+	if err != nil {
+		respondOAuth2ServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// Introspect Token 内省
+// POST /oauth2/introspect
+// Request Body (form): token=xxx[&token_type_hint=access_token][&client_ip=1.2.3.4]
+func (h *OAuth2HandlerImpl) Introspect(w http.ResponseWriter, r *http.Request) {
+	client, tokenValue, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	// client_ip 为扩展参数：网关转发的最终用户 IP，用于 allowed_cidrs 校验（未传时限制了 IP 的 Token 视为无效）
+	resp, err := h.oauth2Service.IntrospectToken(r.Context(), client, tokenValue, r.PostForm.Get("client_ip"))
+	if err != nil {
+		respondOAuth2Error(w, http.StatusInternalServerError, "server_error", "internal error")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// Revoke Token 吊销
+// POST /oauth2/revoke
+// Request Body (form): token=xxx[&token_type_hint=access_token]
+func (h *OAuth2HandlerImpl) Revoke(w http.ResponseWriter, r *http.Request) {
+	client, tokenValue, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.oauth2Service.RevokeToken(r.Context(), client, tokenValue); err != nil {
+		respondOAuth2ServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
+
+// authenticate 解析表单并认证调用方，返回客户端和 token 参数；失败时已写回错误响应
+// token_type_hint 只是提示，所有 Token 都按 access_token 查找，忽略该参数
+func (h *OAuth2HandlerImpl) authenticate(w http.ResponseWriter, r *http.Request) (*interfaces.OAuth2Client, string, bool) {
+	client, ok := h.authenticateClient(w, r)
+	if !ok {
+		return nil, "", false
+	}
+
+	tokenValue := r.PostForm.Get("token")
+	if tokenValue == "" {
+		respondOAuth2Error(w, http.StatusBadRequest, "invalid_request", "token is required")
+		return nil, "", false
+	}
+	return client, tokenValue, true
+}
+
+// authenticateClient 解析表单并认证调用方；失败时已写回错误响应
+func (h *OAuth2HandlerImpl) authenticateClient(w http.ResponseWriter, r *http.Request) (*interfaces.OAuth2Client, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxOAuth2RequestBody)
+	if err := r.ParseForm(); err != nil {
+		respondOAuth2Error(w, http.StatusBadRequest, "invalid_request", "invalid form body")
+		return nil, false
+	}
+
+	clientID, clientSecret, err := clientCredentials(r)
+	if err != nil {
+		respondOAuth2Error(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return nil, false
+	}
+
+	client, err := h.oauth2Service.AuthenticateClient(r.Context(), clientID, clientSecret)
+	if err != nil {
+		respondOAuth2ServiceError(w, err)
+		return nil, false
+	}
+	return client, true
+}
+
+// clientCredentials 提取客户端凭证：client_secret_basic（HTTP Basic，值经过表单编码）
+// 或 client_secret_post（表单参数），不允许同时使用两种方式（RFC 6749 2.3）
+func clientCredentials(r *http.Request) (string, string, error) {
+	formID := r.PostForm.Get("client_id")
+	basicID, basicSecret, hasBasic := r.BasicAuth()
+	if !hasBasic {
+		return formID, r.PostForm.Get("client_secret"), nil
+	}
+	if formID != "" || r.PostForm.Get("client_secret") != "" {
+		return "", "", errors.New("multiple client authentication methods are not allowed")
+	}
+
+	clientID, err := url.QueryUnescape(basicID)
+	if err != nil {
+		return "", "", errors.New("invalid client_id encoding")
+	}
+	clientSecret, err := url.QueryUnescape(basicSecret)
+	if err != nil {
+		return "", "", errors.New("invalid client_secret encoding")
+	}
+	return clientID, clientSecret, nil
+}
+
+// respondOAuth2ServiceError 将 OAuth2Service 返回的错误映射为 OAuth 2.0 标准错误码（RFC 6749 5.2）
+// 未知错误统一返回 server_error，不暴露内部错误信息
+func respondOAuth2ServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, interfaces.ErrInvalidClient):
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth2"`)
+		respondOAuth2Error(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+	case errors.Is(err, interfaces.ErrUnsupportedGrantType):
+		respondOAuth2Error(w, http.StatusBadRequest, "unsupported_grant_type", err.Error())
+	case errors.Is(err, interfaces.ErrUnauthorizedClient), errors.Is(err, interfaces.ErrPermissionDenied):
+		respondOAuth2Error(w, http.StatusBadRequest, "unauthorized_client", err.Error())
+	case errors.Is(err, interfaces.ErrInvalidScope):
+		respondOAuth2Error(w, http.StatusBadRequest, "invalid_scope", err.Error())
+	case errors.Is(err, interfaces.ErrInvalidGrant):
+		respondOAuth2Error(w, http.StatusBadRequest, "invalid_grant", err.Error())
+	case errors.Is(err, interfaces.ErrInvalidArgument):
+		respondOAuth2Error(w, http.StatusBadRequest, "invalid_request", err.Error())
+	default:
+		respondOAuth2Error(w, http.StatusInternalServerError, "server_error", "internal error")
+	}
+}
+
+// respondOAuth2Error 返回 OAuth 2.0 标准错误响应（RFC 6749 5.2）
+func respondOAuth2Error(w http.ResponseWriter, statusCode int, errorCode, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":             errorCode,
+		"error_description": description,
+	})
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ========================================
+// Mock OAuth2Service
+// ========================================
+
+type MockOAuth2Service struct {
+	mock.Mock
+}
+
+func (m *MockOAuth2Service) AuthenticateClient(ctx context.Context, clientID, clientSecret string) (*interfaces.OAuth2Client, error) {
+	args := m.Called(ctx, clientID, clientSecret)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.OAuth2Client), args.Error(1)
+}
+
+func (m *MockOAuth2Service) IntrospectToken(ctx context.Context, client *interfaces.OAuth2Client, tokenValue, clientIP string) (*interfaces.TokenIntrospectionResponse, error) {
+	args := m.Called(ctx, client, tokenValue, clientIP)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.TokenIntrospectionResponse), args.Error(1)
+}
+
+func (m *MockOAuth2Service) RevokeToken(ctx context.Context, client *interfaces.OAuth2Client, tokenValue string) error {
+	args := m.Called(ctx, client, tokenValue)
+	return args.Error(0)
+}
+
+func (m *MockOAuth2Service) IssueToken(ctx context.Context, client *interfaces.OAuth2Client, req *interfaces.OAuth2TokenRequest) (*interfaces.OAuth2TokenResponse, error) {
+	args := m.Called(ctx, client, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.OAuth2TokenResponse), args.Error(1)
+}
+
+func (m *MockOAuth2Service) CreateClient(ctx context.Context, accountID string, req *interfaces.OAuth2ClientCreateRequest) (*interfaces.OAuth2ClientCreateResponse, error) {
+	args := m.Called(ctx, accountID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.OAuth2ClientCreateResponse), args.Error(1)
+}
+
+func (m *MockOAuth2Service) ListClients(ctx context.Context, accountID string) (*interfaces.OAuth2ClientListResponse, error) {
+	args := m.Called(ctx, accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.OAuth2ClientListResponse), args.Error(1)
+}
+
+func (m *MockOAuth2Service) DeleteClient(ctx context.Context, accountID, clientID string) error {
+	args := m.Called(ctx, accountID, clientID)
+	return args.Error(0)
+}
+
+func newOAuth2Request(path, form string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+// ========================================
+// TestToken
+// ========================================
+
+func TestToken_ClientCredentials(t *testing.T) {
+	mockService := new(MockOAuth2Service)
+	handler := NewOAuth2Handler(mockService)
+
+	client := &interfaces.OAuth2Client{ClientID: "oc_x", AccountID: "acc_x"}
+	mockService.On("AuthenticateClient", mock.Anything, "oc_x", "ocs_x").Return(client, nil)
+	mockService.On("IssueToken", mock.Anything, client, mock.MatchedBy(func(req *interfaces.OAuth2TokenRequest) bool {
+		return req.GrantType == interfaces.OAuth2GrantClientCredentials && req.Scope == "storage:read cdn:*"
+	})).Return(&interfaces.OAuth2TokenResponse{
+		AccessToken: "sk-new", TokenType: "Bearer", ExpiresIn: 3600, Scope: "storage:read cdn:*",
+	}, nil)
+
+	req := newOAuth2Request("/oauth2/token", "grant_type=client_credentials&scope=storage%3Aread+cdn%3A*")
+	req.SetBasicAuth("oc_x", "ocs_x")
+	w := httptest.NewRecorder()
+	handler.Token(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.Contains(t, w.Body.String(), `"access_token":"sk-new"`)
+	assert.Contains(t, w.Body.String(), `"expires_in":3600`)
+	mockService.AssertExpectations(t)
+}
+
+func TestToken_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		issueErr   error
+		wantStatus int
+		wantError  string
+	}{
+		{"unsupported grant", fmt.Errorf("%w: password", interfaces.ErrUnsupportedGrantType), http.StatusBadRequest, "unsupported_grant_type"},
+		{"grant not allowed", fmt.Errorf("%w: grant type not allowed", interfaces.ErrUnauthorizedClient), http.StatusBadRequest, "unauthorized_client"},
+		{"invalid scope", fmt.Errorf("%w: cdn:* exceeds subject token", interfaces.ErrInvalidScope), http.StatusBadRequest, "invalid_scope"},
+		{"inactive subject", fmt.Errorf("%w: subject token is not active", interfaces.ErrInvalidGrant), http.StatusBadRequest, "invalid_grant"},
+		{"missing subject", fmt.Errorf("%w: subject_token is required", interfaces.ErrInvalidArgument), http.StatusBadRequest, "invalid_request"},
+		{"backend error", errors.New("invalid scope in mongo query: connection refused"), http.StatusInternalServerError, "server_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockOAuth2Service)
+			handler := NewOAuth2Handler(mockService)
+
+			client := &interfaces.OAuth2Client{ClientID: "oc_x", AccountID: "acc_x"}
+			mockService.On("AuthenticateClient", mock.Anything, "oc_x", "ocs_x").Return(client, nil)
+			mockService.On("IssueToken", mock.Anything, client, mock.Anything).Return(nil, tt.issueErr)
+
+			req := newOAuth2Request("/oauth2/token", "grant_type=client_credentials&client_id=oc_x&client_secret=ocs_x")
+			w := httptest.NewRecorder()
+			handler.Token(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), `"error":"`+tt.wantError+`"`)
+		})
+	}
+}
+
+// ========================================
+// TestIntrospect
+// ========================================
+
+func TestIntrospect_Active(t *testing.T) {
+	mockService := new(MockOAuth2Service)
+	handler := NewOAuth2Handler(mockService)
+
+	client := &interfaces.OAuth2Client{ClientID: "oc_gw", AccountID: "acc_gw", ResourceAccounts: []string{"acc_1"}}
+	mockService.On("AuthenticateClient", mock.Anything, "oc_gw", "ocs/gw+1").Return(client, nil)
+	mockService.On("IntrospectToken", mock.Anything, client, "sk-abc", "10.0.0.1").Return(&interfaces.TokenIntrospectionResponse{
+		Active: true, Scope: "storage:read", Subject: "acc_1", TokenID: "tk_1", TokenType: "Bearer",
+	}, nil)
+
+	// client_secret_basic：凭证先经过表单编码
+	req := newOAuth2Request("/oauth2/introspect", "token=sk-abc&token_type_hint=access_token&client_ip=10.0.0.1")
+	req.SetBasicAuth("oc_gw", "ocs%2Fgw%2B1")
+	w := httptest.NewRecorder()
+	handler.Introspect(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.Contains(t, w.Body.String(), `"active":true`)
+	assert.Contains(t, w.Body.String(), `"scope":"storage:read"`)
+	mockService.AssertExpectations(t)
+}
+
+func TestIntrospect_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		form       string
+		basicAuth  bool
+		authErr    error
+		wantStatus int
+		wantError  string
+	}{
+		{"invalid client", "token=sk-abc&client_id=oc_x&client_secret=bad", false, fmt.Errorf("%w: client authentication failed", interfaces.ErrInvalidClient), http.StatusUnauthorized, "invalid_client"},
+		{"multiple auth methods", "token=sk-abc&client_id=oc_x&client_secret=ocs_x", true, nil, http.StatusBadRequest, "invalid_request"},
+		{"missing token", "client_id=oc_x&client_secret=ocs_x", false, nil, http.StatusBadRequest, "invalid_request"},
+		{"backend error", "token=sk-abc&client_id=oc_x&client_secret=ocs_x", false, errors.New("connection refused"), http.StatusInternalServerError, "server_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockOAuth2Service)
+			handler := NewOAuth2Handler(mockService)
+			if tt.authErr != nil {
+				mockService.On("AuthenticateClient", mock.Anything, mock.Anything, mock.Anything).Return(nil, tt.authErr)
+			} else {
+				mockService.On("AuthenticateClient", mock.Anything, mock.Anything, mock.Anything).Return(&interfaces.OAuth2Client{ClientID: "oc_x"}, nil)
+			}
+
+			req := newOAuth2Request("/oauth2/introspect", tt.form)
+			if tt.basicAuth {
+				req.SetBasicAuth("oc_x", "ocs_x")
+			}
+			w := httptest.NewRecorder()
+			handler.Introspect(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), `"error":"`+tt.wantError+`"`)
+			if tt.wantStatus == http.StatusUnauthorized {
+				assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+			}
+			mockService.AssertNotCalled(t, "IntrospectToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// ========================================
+// TestRevoke
+// ========================================
+
+func TestRevoke(t *testing.T) {
+	tests := []struct {
+		name       string
+		revokeErr  error
+		wantStatus int
+		wantBody   string
+	}{
+		{"revoked", nil, http.StatusOK, ""},
+		{"not issued to client", fmt.Errorf("%w: token was not issued to this client's accounts", interfaces.ErrPermissionDenied), http.StatusBadRequest, `"error":"unauthorized_client"`},
+		{"internal error", errors.New("connection refused"), http.StatusInternalServerError, `"error":"server_error"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockOAuth2Service)
+			handler := NewOAuth2Handler(mockService)
+
+			client := &interfaces.OAuth2Client{ClientID: "oc_x", AccountID: "acc_x"}
+			mockService.On("AuthenticateClient", mock.Anything, "oc_x", "ocs_x").Return(client, nil)
+			mockService.On("RevokeToken", mock.Anything, client, "sk-abc").Return(tt.revokeErr)
+
+			req := newOAuth2Request("/oauth2/revoke", "token=sk-abc&client_id=oc_x&client_secret=ocs_x")
+			w := httptest.NewRecorder()
+			handler.Revoke(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.Contains(t, w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -2,29 +2,40 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"regexp"
 	"strconv"
-	"strings"
+	"time"
 
 	"github.com/qiniu/bearer-token-service/v2/auth"
 	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/pkg/clientip"
 	"github.com/gorilla/mux"
 )
 
 // prefixRegex 校验 prefix：只允许小写字母、数字、下划线
 var prefixRegex = regexp.MustCompile(`^[a-z0-9_]+$`)
 
+// maxAllowedCIDRs 单个 Token 最多允许配置的 IP 范围数
+const maxAllowedCIDRs = 100
+
 // tokenErrStatus 将 service 层错误映射为 HTTP 状态码
 func tokenErrStatus(err error) int {
-	msg := err.Error()
-	if strings.Contains(msg, "permission denied") {
+	switch {
+	case errors.Is(err, interfaces.ErrPermissionDenied):
 		return http.StatusForbidden
-	}
-	if strings.Contains(msg, "not found") {
+	case errors.Is(err, interfaces.ErrNotFound):
 		return http.StatusNotFound
+	case errors.Is(err, interfaces.ErrInvalidArgument):
+		return http.StatusBadRequest
+	case errors.Is(err, interfaces.ErrConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
 	}
-	return http.StatusInternalServerError
 }
 
 // TokenHandlerImpl Token 管理 Handler 实现
@@ -66,6 +77,28 @@ func (h *TokenHandlerImpl) CreateToken(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// 校验 scopes 参数
+	for _, scope := range req.Scopes {
+		if !interfaces.IsValidScope(scope) {
+			respondError(w, http.StatusBadRequest, "invalid scope format: "+scope+", expected 'resource:action'")
+			return
+		}
+	}
+
+	// 校验 allowed_cidrs 参数（单个 IP 视为 /32 或 /128，统一保存为规范化的 CIDR）
+	if len(req.AllowedCIDRs) > maxAllowedCIDRs {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("too many allowed_cidrs, at most %d", maxAllowedCIDRs))
+		return
+	}
+	for i, cidr := range req.AllowedCIDRs {
+		prefix, err := clientip.ParsePrefix(cidr)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid allowed_cidrs entry: "+cidr)
+			return
+		}
+		req.AllowedCIDRs[i] = prefix.String()
+	}
+
 	resp, err := h.tokenService.CreateToken(r.Context(), accountID, &req)
 	if err != nil {
 		respondError(w, http.StatusBadRequest, err.Error())
@@ -152,6 +185,34 @@ func (h *TokenHandlerImpl) UpdateTokenStatus(w http.ResponseWriter, r *http.Requ
 	})
 }
 
+// UpdateToken 部分更新 Token（描述、过期时间、限流）
+// PATCH /api/v2/tokens/{id}
+// Request Body: {"description": "...", "expires_in_seconds": 3600, "rate_limit": {"requests_per_minute": 100}}
+func (h *TokenHandlerImpl) UpdateToken(w http.ResponseWriter, r *http.Request) {
+	accountID, err := auth.ExtractAccountIDFromContext(r.Context())
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	tokenID := vars["id"]
+
+	var req interfaces.TokenUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	token, err := h.tokenService.UpdateToken(r.Context(), accountID, tokenID, &req)
+	if err != nil {
+		respondError(w, tokenErrStatus(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, token)
+}
+
 // DeleteToken 删除 Token
 // DELETE /api/v2/tokens/{id}
 func (h *TokenHandlerImpl) DeleteToken(w http.ResponseWriter, r *http.Request) {
@@ -175,8 +236,37 @@ func (h *TokenHandlerImpl) DeleteToken(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// RotateToken 轮换 Token 值
+// POST /api/v2/tokens/{id}/rotate
+func (h *TokenHandlerImpl) RotateToken(w http.ResponseWriter, r *http.Request) {
+	accountID, err := auth.ExtractAccountIDFromContext(r.Context())
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	tokenID := vars["id"]
+
+	// 请求体可选（为空时使用默认宽限期）
+	var req interfaces.TokenRotateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	resp, err := h.tokenService.RotateToken(r.Context(), accountID, tokenID, &req)
+	if err != nil {
+		respondError(w, tokenErrStatus(err), err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
 // GetTokenStats 获取 Token 使用统计
-// GET /api/v2/tokens/{id}/stats
+// GET /api/v2/tokens/{id}/stats?from=2025-01-01&to=2025-01-31&granularity=day
+// from / to 支持 RFC3339 或 YYYY-MM-DD（UTC），granularity 为 day（默认）或 hour
 func (h *TokenHandlerImpl) GetTokenStats(w http.ResponseWriter, r *http.Request) {
 	accountID, err := auth.ExtractAccountIDFromContext(r.Context())
 	if err != nil {
@@ -187,7 +277,20 @@ func (h *TokenHandlerImpl) GetTokenStats(w http.ResponseWriter, r *http.Request)
 	vars := mux.Vars(r)
 	tokenID := vars["id"]
 
-	stats, err := h.tokenService.GetTokenStats(r.Context(), accountID, tokenID)
+	q := r.URL.Query()
+	query := &interfaces.TokenStatsQuery{
+		Granularity: q.Get("granularity"),
+	}
+	if query.From, err = parseStatsTime(q.Get("from")); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid from, expected RFC3339 or YYYY-MM-DD")
+		return
+	}
+	if query.To, err = parseStatsTime(q.Get("to")); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid to, expected RFC3339 or YYYY-MM-DD")
+		return
+	}
+
+	stats, err := h.tokenService.GetTokenStats(r.Context(), accountID, tokenID, query)
 	if err != nil {
 		respondError(w, tokenErrStatus(err), err.Error())
 		return
@@ -195,3 +298,14 @@ func (h *TokenHandlerImpl) GetTokenStats(w http.ResponseWriter, r *http.Request)
 
 	respondJSON(w, http.StatusOK, stats)
 }
+
+// parseStatsTime 解析统计查询时间参数（RFC3339 或 YYYY-MM-DD），空字符串返回零值
+func parseStatsTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
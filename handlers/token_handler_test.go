@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenErrStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"token not found", interfaces.ErrTokenNotFound, http.StatusNotFound},
+		{"account not found", interfaces.ErrAccountNotFound, http.StatusNotFound},
+		{"permission denied", fmt.Errorf("%w: sub-accounts cannot regenerate the secret key", interfaces.ErrPermissionDenied), http.StatusForbidden},
+		{"invalid argument", fmt.Errorf("%w: grace period must not be negative", interfaces.ErrInvalidArgument), http.StatusBadRequest},
+		{"conflict", fmt.Errorf("%w: token was rotated concurrently, please retry", interfaces.ErrConflict), http.StatusConflict},
+		// 只按错误类型映射，不匹配错误文本
+		{"unclassified", errors.New("invalid memory address, record not found"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tokenErrStatus(tt.err))
+		})
+	}
+}
@@ -1,28 +1,51 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strings"
 
 	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/pkg/clientip"
 )
 
+// defaultBatchMaxTokens 批量验证单次请求默认最多包含的 token 数
+const defaultBatchMaxTokens = 100
+
 // ValidationHandlerImpl Token 验证 Handler 实现
 type ValidationHandlerImpl struct {
 	validationService interfaces.ValidationService
+	batchMaxTokens    int
+	clientIP          *clientip.Resolver // 解析客户端 IP（用于 Token allowed_cidrs 校验），nil 时使用直连地址
 }
 
 // NewValidationHandler 创建验证 Handler 实例
 func NewValidationHandler(validationService interfaces.ValidationService) *ValidationHandlerImpl {
 	return &ValidationHandlerImpl{
 		validationService: validationService,
+		batchMaxTokens:    defaultBatchMaxTokens,
+	}
+}
+
+// SetBatchMaxTokens 设置批量验证单次请求最多包含的 token 数（<= 0 时使用默认值）
+func (h *ValidationHandlerImpl) SetBatchMaxTokens(n int) {
+	if n <= 0 {
+		n = defaultBatchMaxTokens
 	}
+	h.batchMaxTokens = n
+}
+
+// SetClientIPResolver 设置客户端 IP 解析器（可信代理链、网关转发的调用方 IP 头）
+func (h *ValidationHandlerImpl) SetClientIPResolver(resolver *clientip.Resolver) {
+	h.clientIP = resolver
 }
 
 // ValidateToken 验证 Bearer Token
 // POST /api/v2/validate
+// Request Body (optional): {"required_scope": "storage:read"}
 func (h *ValidationHandlerImpl) ValidateToken(w http.ResponseWriter, r *http.Request) {
 	// 1. 提取 Bearer Token
 	authHeader := r.Header.Get("Authorization")
@@ -33,9 +56,49 @@ func (h *ValidationHandlerImpl) ValidateToken(w http.ResponseWriter, r *http.Req
 
 	tokenValue := strings.TrimPrefix(authHeader, "Bearer ")
 
-	// 2. 调用验证服务
+	// 2. 解析可选的 required_scope
+	requiredScope, err := readRequiredScope(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.validate(w, r, tokenValue, requiredScope)
+}
+
+// ValidateWithScope 验证 Bearer Token 并检查特定权限
+// GET /api/v2/validate?scope=storage:read
+func (h *ValidationHandlerImpl) ValidateWithScope(w http.ResponseWriter, r *http.Request) {
+	// 1. 提取 Bearer Token
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		respondError(w, http.StatusUnauthorized, "invalid authorization header")
+		return
+	}
+
+	tokenValue := strings.TrimPrefix(authHeader, "Bearer ")
+
+	// 2. scope 参数必填
+	requiredScope := r.URL.Query().Get("scope")
+	if requiredScope == "" {
+		respondError(w, http.StatusBadRequest, "scope is required")
+		return
+	}
+	if !interfaces.IsValidRequiredScope(requiredScope) {
+		respondError(w, http.StatusBadRequest, "invalid scope format, expected 'resource:action'")
+		return
+	}
+
+	h.validate(w, r, tokenValue, requiredScope)
+}
+
+// validate 执行验证并写回响应（ValidateToken / ValidateWithScope 共用）
+func (h *ValidationHandlerImpl) validate(w http.ResponseWriter, r *http.Request, tokenValue, requiredScope string) {
+	// 1. 调用验证服务
 	req := &interfaces.TokenValidateRequest{
-		Token: tokenValue,
+		Token:         tokenValue,
+		RequiredScope: requiredScope,
+		ClientIP:      h.clientIP.FromRequest(r),
 	}
 
 	resp, err := h.validationService.ValidateToken(r.Context(), req)
@@ -44,19 +107,52 @@ func (h *ValidationHandlerImpl) ValidateToken(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	// 3. 如果验证失败，返回 401
+	// 2. 如果验证失败，返回 401（权限不足返回 403）
 	if !resp.Valid {
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
+		w.WriteHeader(validateFailureStatus(resp.Code))
 		json.NewEncoder(w).Encode(resp)
 		return
 	}
 
-	// 4. 记录使用（异步，不影响响应）
-	// 使用 context.Background() 因为 r.Context() 会在响应后取消
-	go h.validationService.RecordTokenUsage(context.Background(), tokenValue)
+	// 3. 返回成功响应（使用记录已在 ValidateToken 中异步入队）
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// ValidateTokenBatch 批量验证 Token
+// POST /api/v2/validate/batch
+// Request Body: {"tokens": ["sk-...", "sk-..."], "required_scope": "storage:read"}
+// 始终返回 200，每个 token 的验证结果按请求顺序放在 results 中
+func (h *ValidationHandlerImpl) ValidateTokenBatch(w http.ResponseWriter, r *http.Request) {
+	// 1. 解析请求
+	var req interfaces.TokenBatchValidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	// 2. 校验参数
+	if len(req.Tokens) == 0 {
+		respondError(w, http.StatusBadRequest, "tokens is required")
+		return
+	}
+	if len(req.Tokens) > h.batchMaxTokens {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("too many tokens, at most %d per request", h.batchMaxTokens))
+		return
+	}
+	if req.RequiredScope != "" && !interfaces.IsValidRequiredScope(req.RequiredScope) {
+		respondError(w, http.StatusBadRequest, "invalid scope format, expected 'resource:action'")
+		return
+	}
+
+	// 3. 调用验证服务
+	req.ClientIP = h.clientIP.FromRequest(r)
+	resp, err := h.validationService.ValidateTokens(r.Context(), &req)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
 
-	// 5. 返回成功响应
 	respondJSON(w, http.StatusOK, resp)
 }
 
@@ -72,9 +168,17 @@ func (h *ValidationHandlerImpl) ValidateTokenU(w http.ResponseWriter, r *http.Re
 
 	tokenValue := strings.TrimPrefix(authHeader, "Bearer ")
 
+	requiredScope, err := readRequiredScope(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// 2. 调用验证服务（带用户信息）
 	req := &interfaces.TokenValidateRequest{
-		Token: tokenValue,
+		Token:         tokenValue,
+		RequiredScope: requiredScope,
+		ClientIP:      h.clientIP.FromRequest(r),
 	}
 
 	resp, err := h.validationService.ValidateTokenWithUserInfo(r.Context(), req)
@@ -83,18 +187,44 @@ func (h *ValidationHandlerImpl) ValidateTokenU(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// 3. 如果验证失败，返回 401
+	// 3. 如果验证失败，返回 401（权限不足返回 403）
 	if !resp.Valid {
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
+		w.WriteHeader(validateFailureStatus(resp.Code))
 		json.NewEncoder(w).Encode(resp)
 		return
 	}
 
-	// 4. 记录使用（异步，不影响响应）
-	// 使用 context.Background() 因为 r.Context() 会在响应后取消
-	go h.validationService.RecordTokenUsage(context.Background(), tokenValue)
-
-	// 5. 返回成功响应
+	// 4. 返回成功响应（使用记录已在 ValidateToken 中异步入队）
 	respondJSON(w, http.StatusOK, resp)
 }
+
+// readRequiredScope 读取可选的 required_scope
+// 优先取 ?scope= 查询参数，其次取 JSON body 中的 required_scope；body 为空时视为未指定
+func readRequiredScope(r *http.Request) (string, error) {
+	scope := r.URL.Query().Get("scope")
+
+	if scope == "" && r.Body != nil {
+		var body struct {
+			RequiredScope string `json:"required_scope"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			return "", errors.New("invalid request body")
+		}
+		scope = body.RequiredScope
+	}
+
+	if scope != "" && !interfaces.IsValidRequiredScope(scope) {
+		return "", errors.New("invalid scope format, expected 'resource:action'")
+	}
+	return scope, nil
+}
+
+// validateFailureStatus 将验证失败的业务错误码映射为 HTTP 状态码
+func validateFailureStatus(code int) int {
+	switch code {
+	case interfaces.ErrCodeScopeNotGranted, interfaces.ErrCodeIPNotAllowed:
+		return http.StatusForbidden
+	}
+	return http.StatusUnauthorized
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -38,9 +39,20 @@ func (m *MockValidationService) ValidateTokenWithUserInfo(ctx context.Context, r
 	return args.Get(0).(*interfaces.TokenValidateUResponse), args.Error(1)
 }
 
-func (m *MockValidationService) RecordTokenUsage(ctx context.Context, token string) error {
-	args := m.Called(ctx, token)
-	return args.Error(0)
+func (m *MockValidationService) ValidateTokens(ctx context.Context, req *interfaces.TokenBatchValidateRequest) (*interfaces.TokenBatchValidateResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.TokenBatchValidateResponse), args.Error(1)
+}
+
+func (m *MockValidationService) ValidateTokenWithLimit(ctx context.Context, req *interfaces.TokenValidateRequest) (*interfaces.TokenValidateResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.TokenValidateResponse), args.Error(1)
 }
 
 // ========================================
@@ -56,7 +68,8 @@ func TestValidateToken_Success(t *testing.T) {
 	lastUsedAt := time.Now().Add(-1 * time.Hour)
 
 	mockService.On("ValidateToken", mock.Anything, &interfaces.TokenValidateRequest{
-		Token: "sk-valid-token",
+		Token:    "sk-valid-token",
+		ClientIP: "192.0.2.1",
 	}).Return(&interfaces.TokenValidateResponse{
 		Valid:   true,
 		Message: "valid",
@@ -71,8 +84,6 @@ func TestValidateToken_Success(t *testing.T) {
 		},
 	}, nil)
 
-	mockService.On("RecordTokenUsage", mock.Anything, "sk-valid-token").Return(nil)
-
 	req := httptest.NewRequest("POST", "/api/v2/validate", nil)
 	req.Header.Set("Authorization", "Bearer sk-valid-token")
 	w := httptest.NewRecorder()
@@ -102,7 +113,8 @@ func TestValidateToken_InvalidToken(t *testing.T) {
 	handler := NewValidationHandler(mockService)
 
 	mockService.On("ValidateToken", mock.Anything, &interfaces.TokenValidateRequest{
-		Token: "sk-invalid-token",
+		Token:    "sk-invalid-token",
+		ClientIP: "192.0.2.1",
 	}).Return(&interfaces.TokenValidateResponse{
 		Valid:   false,
 		Message: "token not found",
@@ -125,8 +137,6 @@ func TestValidateToken_InvalidToken(t *testing.T) {
 	assert.False(t, resp.Valid)
 	assert.Equal(t, "token not found", resp.Message)
 
-	// RecordTokenUsage should NOT be called for invalid tokens
-	mockService.AssertNotCalled(t, "RecordTokenUsage", mock.Anything, mock.Anything)
 	mockService.AssertExpectations(t)
 }
 
@@ -177,6 +187,57 @@ func TestValidateToken_InvalidAuthHeaderFormat(t *testing.T) {
 	mockService.AssertNotCalled(t, "ValidateToken", mock.Anything, mock.Anything)
 }
 
+func TestValidateToken_ScopeNotGranted(t *testing.T) {
+	// Arrange
+	mockService := new(MockValidationService)
+	handler := NewValidationHandler(mockService)
+
+	mockService.On("ValidateToken", mock.Anything, &interfaces.TokenValidateRequest{
+		Token:         "sk-valid-token",
+		RequiredScope: "storage:write",
+		ClientIP:      "192.0.2.1",
+	}).Return(&interfaces.TokenValidateResponse{
+		Valid:   false,
+		Message: "Token does not have required scope: storage:write",
+		Code:    interfaces.ErrCodeScopeNotGranted,
+	}, nil)
+
+	req := httptest.NewRequest("POST", "/api/v2/validate", strings.NewReader(`{"required_scope":"storage:write"}`))
+	req.Header.Set("Authorization", "Bearer sk-valid-token")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ValidateToken(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var resp interfaces.TokenValidateResponse
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+
+	assert.False(t, resp.Valid)
+	assert.Equal(t, interfaces.ErrCodeScopeNotGranted, resp.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestValidateWithScope_InvalidScopeFormat(t *testing.T) {
+	// Arrange
+	mockService := new(MockValidationService)
+	handler := NewValidationHandler(mockService)
+
+	req := httptest.NewRequest("GET", "/api/v2/validate?scope=storage:*", nil)
+	req.Header.Set("Authorization", "Bearer sk-valid-token")
+	w := httptest.NewRecorder()
+
+	// Act
+	handler.ValidateWithScope(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "ValidateToken", mock.Anything, mock.Anything)
+}
+
 // ========================================
 // TestValidateTokenU - Extended validation with user info
 // ========================================
@@ -193,7 +254,8 @@ func TestValidateTokenU_Success(t *testing.T) {
 	lastLoginAt := int64(1700000200)
 
 	mockService.On("ValidateTokenWithUserInfo", mock.Anything, &interfaces.TokenValidateRequest{
-		Token: "sk-valid-token",
+		Token:    "sk-valid-token",
+		ClientIP: "192.0.2.1",
 	}).Return(&interfaces.TokenValidateUResponse{
 		Valid:   true,
 		Message: "valid",
@@ -222,8 +284,6 @@ func TestValidateTokenU_Success(t *testing.T) {
 		},
 	}, nil)
 
-	mockService.On("RecordTokenUsage", mock.Anything, "sk-valid-token").Return(nil)
-
 	req := httptest.NewRequest("POST", "/api/v2/validateu", nil)
 	req.Header.Set("Authorization", "Bearer sk-valid-token")
 	w := httptest.NewRecorder()
@@ -267,7 +327,8 @@ func TestValidateTokenU_MySQLFailure_GracefulDegradation(t *testing.T) {
 
 	// Service returns valid token but user_info is nil due to MySQL failure
 	mockService.On("ValidateTokenWithUserInfo", mock.Anything, &interfaces.TokenValidateRequest{
-		Token: "sk-valid-token",
+		Token:    "sk-valid-token",
+		ClientIP: "192.0.2.1",
 	}).Return(&interfaces.TokenValidateUResponse{
 		Valid:   true,
 		Message: "valid",
@@ -283,8 +344,6 @@ func TestValidateTokenU_MySQLFailure_GracefulDegradation(t *testing.T) {
 		},
 	}, nil)
 
-	mockService.On("RecordTokenUsage", mock.Anything, "sk-valid-token").Return(nil)
-
 	req := httptest.NewRequest("POST", "/api/v2/validateu", nil)
 	req.Header.Set("Authorization", "Bearer sk-valid-token")
 	w := httptest.NewRecorder()
@@ -319,7 +378,8 @@ func TestValidateTokenU_InvalidToken(t *testing.T) {
 	handler := NewValidationHandler(mockService)
 
 	mockService.On("ValidateTokenWithUserInfo", mock.Anything, &interfaces.TokenValidateRequest{
-		Token: "sk-invalid-token",
+		Token:    "sk-invalid-token",
+		ClientIP: "192.0.2.1",
 	}).Return(&interfaces.TokenValidateUResponse{
 		Valid:   false,
 		Message: "token not found",
@@ -342,8 +402,6 @@ func TestValidateTokenU_InvalidToken(t *testing.T) {
 	assert.False(t, resp.Valid)
 	assert.Equal(t, "token not found", resp.Message)
 
-	// RecordTokenUsage should NOT be called for invalid tokens
-	mockService.AssertNotCalled(t, "RecordTokenUsage", mock.Anything, mock.Anything)
 	mockService.AssertExpectations(t)
 }
 
@@ -380,7 +438,8 @@ func TestValidateTokenU_HMACUser_NoUserInfo(t *testing.T) {
 
 	// HMAC user (non-QiniuStub) - user_info is nil
 	mockService.On("ValidateTokenWithUserInfo", mock.Anything, &interfaces.TokenValidateRequest{
-		Token: "sk-hmac-token",
+		Token:    "sk-hmac-token",
+		ClientIP: "192.0.2.1",
 	}).Return(&interfaces.TokenValidateUResponse{
 		Valid:   true,
 		Message: "valid",
@@ -395,8 +454,6 @@ func TestValidateTokenU_HMACUser_NoUserInfo(t *testing.T) {
 		},
 	}, nil)
 
-	mockService.On("RecordTokenUsage", mock.Anything, "sk-hmac-token").Return(nil)
-
 	req := httptest.NewRequest("POST", "/api/v2/validateu", nil)
 	req.Header.Set("Authorization", "Bearer sk-hmac-token")
 	w := httptest.NewRecorder()
@@ -423,6 +480,70 @@ func TestValidateTokenU_HMACUser_NoUserInfo(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+// ========================================
+// TestValidateTokenBatch - Batch validation endpoint
+// ========================================
+
+func TestValidateTokenBatch_Success(t *testing.T) {
+	mockService := new(MockValidationService)
+	handler := NewValidationHandler(mockService)
+
+	mockService.On("ValidateTokens", mock.Anything, &interfaces.TokenBatchValidateRequest{
+		Tokens:        []string{"sk-valid", "sk-invalid"},
+		RequiredScope: "storage:read",
+		ClientIP:      "192.0.2.1",
+	}).Return(&interfaces.TokenBatchValidateResponse{
+		Results: []*interfaces.TokenValidateResponse{
+			{Valid: true, Message: "Token is valid", TokenInfo: &interfaces.TokenInfo{TokenID: "tk_1", IsActive: true}},
+			{Valid: false, Message: "Token not found", Code: interfaces.ErrCodeTokenNotFound},
+		},
+	}, nil)
+
+	body := `{"tokens": ["sk-valid", "sk-invalid"], "required_scope": "storage:read"}`
+	req := httptest.NewRequest("POST", "/api/v2/validate/batch", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.ValidateTokenBatch(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp interfaces.TokenBatchValidateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Results, 2)
+	assert.True(t, resp.Results[0].Valid)
+	assert.Equal(t, interfaces.ErrCodeTokenNotFound, resp.Results[1].Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestValidateTokenBatch_BadRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid json", `{"tokens":`},
+		{"empty tokens", `{"tokens": []}`},
+		{"too many tokens", `{"tokens": ["sk-1", "sk-2", "sk-3"]}`},
+		{"invalid scope", `{"tokens": ["sk-1"], "required_scope": "storage"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockValidationService)
+			handler := NewValidationHandler(mockService)
+			handler.SetBatchMaxTokens(2)
+
+			req := httptest.NewRequest("POST", "/api/v2/validate/batch", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.ValidateTokenBatch(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			mockService.AssertNotCalled(t, "ValidateTokens", mock.Anything, mock.Anything)
+		})
+	}
+}
+
 // ========================================
 // Test Helpers
 // ========================================
@@ -53,6 +53,20 @@ type TokenHandler interface {
 	// Response: Token
 	UpdateTokenStatus(w ResponseWriter, r *Request)
 
+	// UpdateToken 部分更新 Token（描述、过期时间、限流）
+	// PATCH /api/v2/tokens/{id}
+	// Auth: HMAC
+	// Request Body: TokenUpdateRequest
+	// Response: Token
+	UpdateToken(w ResponseWriter, r *Request)
+
+	// RotateToken 轮换 Token 值（保留 ID 及元数据，旧值在宽限期内仍可使用）
+	// POST /api/v2/tokens/{id}/rotate
+	// Auth: HMAC
+	// Request Body (optional): TokenRotateRequest
+	// Response: TokenRotateResponse
+	RotateToken(w ResponseWriter, r *Request)
+
 	// DeleteToken 删除 Token
 	// DELETE /api/v2/tokens/{id}
 	// Auth: HMAC
@@ -83,11 +97,54 @@ type ValidationHandler interface {
 	ValidateWithScope(w ResponseWriter, r *Request)
 }
 
+// OAuth2Handler OAuth 2.0 Token 签发 / 内省 / 吊销 API 处理器接口（供 Kong、APISIX、oauth2-proxy 等网关和机器负载使用）
+type OAuth2Handler interface {
+	// Token 签发短期 Token（client_credentials 或 RFC 8693 token exchange）
+	// POST /oauth2/token
+	// Auth: client_secret_basic 或 client_secret_post（OAuth 2.0 客户端凭证）
+	// Request Body (form): grant_type=client_credentials&scope=storage:read
+	// Response: OAuth2TokenResponse
+	Token(w ResponseWriter, r *Request)
+
+	// Introspect Token 内省（RFC 7662）
+	// POST /oauth2/introspect
+	// Auth: client_secret_basic 或 client_secret_post（OAuth 2.0 客户端凭证）
+	// Request Body (form): token=xxx&token_type_hint=access_token
+	// Response: TokenIntrospectionResponse
+	Introspect(w ResponseWriter, r *Request)
+
+	// Revoke Token 吊销（RFC 7009）
+	// POST /oauth2/revoke
+	// Auth: client_secret_basic 或 client_secret_post（OAuth 2.0 客户端凭证）
+	// Request Body (form): token=xxx&token_type_hint=access_token
+	// Response: 200（Token 不存在时同样返回 200）
+	Revoke(w ResponseWriter, r *Request)
+
+	// CreateClient 创建 OAuth 2.0 客户端
+	// POST /api/v2/oauth2/clients
+	// Auth: HMAC
+	// Request Body: OAuth2ClientCreateRequest
+	// Response: OAuth2ClientCreateResponse（client_secret 只返回一次）
+	CreateClient(w ResponseWriter, r *Request)
+
+	// ListClients 列出当前账户的 OAuth 2.0 客户端
+	// GET /api/v2/oauth2/clients
+	// Auth: HMAC
+	// Response: OAuth2ClientListResponse
+	ListClients(w ResponseWriter, r *Request)
+
+	// DeleteClient 删除 OAuth 2.0 客户端并停用其签发的 Token
+	// DELETE /api/v2/oauth2/clients/{client_id}
+	// Auth: HMAC
+	// Response: {"message": "Client deleted successfully"}
+	DeleteClient(w ResponseWriter, r *Request)
+}
+
 // AuditHandler 审计日志 API 处理器接口
 type AuditHandler interface {
 	// QueryAuditLogs 查询审计日志
-	// GET /api/v2/audit-logs?action=create_token&start_time=2025-01-01T00:00:00Z&limit=50
-	// Auth: HMAC
+	// GET /api/v2/audit-logs?action=create_token&start_time=2025-01-01T00:00:00Z&limit=50&cursor=xxx
+	// Auth: QiniuStub
 	// Response: AuditLogResponse
 	QueryAuditLogs(w ResponseWriter, r *Request)
 }
@@ -185,11 +242,16 @@ const (
 	ErrCodeAccessKeyNotFound    = 4003
 	ErrCodeAccountSuspended     = 4004
 	ErrCodeInvalidAuthHeader    = 4005
+	ErrCodeNonceReused          = 4006
+	ErrCodeUserDisabled         = 4007 // 七牛用户已冻结
+	ErrCodeUserUnactivated      = 4008 // 七牛用户未激活
+	ErrCodeUserNotFound         = 4009 // 七牛用户不存在
 
 	// 权限错误 (4031-4099)
 	ErrCodePermissionDenied     = 4031
 	ErrCodeScopeNotGranted      = 4032
 	ErrCodeInvalidScope         = 4033
+	ErrCodeIPNotAllowed         = 4034 // 客户端 IP 不在 Token 允许的范围内
 
 	// Token 错误 (4041-4099)
 	ErrCodeTokenNotFound        = 4041
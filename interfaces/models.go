@@ -1,6 +1,11 @@
 package interfaces
 
-import "time"
+import (
+	"net/netip"
+	"regexp"
+	"strings"
+	"time"
+)
 
 // ========================================
 // 数据模型定义
@@ -12,7 +17,8 @@ type Account struct {
 	Email     string     `bson:"email" json:"email"`
 	Company   string     `bson:"company" json:"company"`
 	AccessKey string     `bson:"access_key" json:"access_key"` // AK_xxx
-	SecretKey string     `bson:"secret_key" json:"-"`          // bcrypt 加密，不返回客户端
+	SecretKey string     `bson:"secret_key" json:"-"`          // HMAC 验签需要原文，不返回客户端
+	PasswordHash string  `bson:"password_hash,omitempty" json:"-"` // 注册密码（bcrypt），不返回客户端
 	Status    string     `bson:"status" json:"status"`         // active, suspended
 	RateLimit *RateLimit `bson:"rate_limit,omitempty" json:"rate_limit,omitempty"` // 账户级限流配置
 	QiniuUID  uint32     `bson:"qiniu_uid,omitempty" json:"qiniu_uid,omitempty"` // 七牛 UID（可选）
@@ -22,23 +28,111 @@ type Account struct {
 
 // Token Bearer Token 模型
 type Token struct {
-	ID          string     `bson:"_id,omitempty" json:"token_id"`
-	AccountID   string     `bson:"account_id" json:"account_id"`         // 关联到账户
-	Token       string     `bson:"token" json:"token"`                   // 实际的 token 值
-	Description string     `bson:"description" json:"description"`       // Token 描述
-	RateLimit   *RateLimit `bson:"rate_limit,omitempty" json:"rate_limit,omitempty"`
-	IUID        string     `bson:"iuid,omitempty" json:"iuid,omitempty"`           // IAM 用户ID（从 QiniuStub 认证中提取）
-	IamAlias    string     `bson:"iam_alias,omitempty" json:"iam_alias,omitempty"` // IAM 子账号名（从 QiniuStub 认证中提取）
-	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
-	ExpiresAt   *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"` // nil 表示永不过期
-	IsActive    bool       `bson:"is_active" json:"is_active"`
-	Prefix      string     `bson:"-" json:"-"`                           // 自定义前缀（不存储到数据库）
+	ID           string     `bson:"_id,omitempty" json:"token_id"`
+	AccountID    string     `bson:"account_id" json:"account_id"`           // 关联到账户
+	Token        string     `bson:"-" json:"token,omitempty"`               // 明文 token 值，仅在创建/轮换时由 Repository 填充，不落库
+	TokenHash    string     `bson:"token_hash" json:"token_hash,omitempty"` // token 值的 HMAC-SHA256（十六进制），用于查询
+	TokenPreview string     `bson:"token_preview" json:"token_preview"`     // 脱敏展示，如 "sk-a1b2c3d4****e5f6g7h8"
+	Description  string     `bson:"description" json:"description"`         // Token 描述
+	RateLimit    *RateLimit `bson:"rate_limit,omitempty" json:"rate_limit,omitempty"`
+	IUID         string     `bson:"iuid,omitempty" json:"iuid,omitempty"`                   // IAM 用户ID（从 QiniuStub 认证中提取）
+	IamAlias     string     `bson:"iam_alias,omitempty" json:"iam_alias,omitempty"`         // IAM 子账号名（从 QiniuStub 认证中提取）
+	Scopes       []string   `bson:"scopes,omitempty" json:"scopes,omitempty"`               // 授权范围（resource:action），为空表示不限制
+	AllowedCIDRs []string   `bson:"allowed_cidrs,omitempty" json:"allowed_cidrs,omitempty"` // 允许使用的客户端 IP 范围（CIDR），为空表示不限制
+	CreatedAt    time.Time  `bson:"created_at" json:"created_at"`
+	ExpiresAt    *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"` // nil 表示永不过期
+	IsActive     bool       `bson:"is_active" json:"is_active"`
+	Prefix       string     `bson:"-" json:"-"` // 自定义前缀（不存储到数据库）
+	Format       string     `bson:"format,omitempty" json:"format,omitempty"` // Token 格式，空表示不透明 Token，signed 表示签名 Token（JWT）
+	ClientID     string     `bson:"client_id,omitempty" json:"client_id,omitempty"` // 签发 Token 的 OAuth 2.0 客户端（通过 /oauth2/token 签发时设置）
+	PurgeAt      *time.Time `bson:"purge_at,omitempty" json:"-"`                    // 到期后由 TTL 索引删除记录（只有 /oauth2/token 签发的短期 Token 设置）
+
+	// 共享限流额度的 Token ID：Token 交换签发的 Token 计入原 Token 的额度，为空时使用自身 ID
+	RateLimitTokenID string `bson:"rate_limit_token_id,omitempty" json:"rate_limit_token_id,omitempty"`
+
+	// 轮换信息：轮换后旧 token 值在宽限期内仍然有效
+	PreviousTokenHash      string     `bson:"previous_token_hash,omitempty" json:"previous_token_hash,omitempty"`
+	PreviousTokenExpiresAt *time.Time `bson:"previous_token_expires_at,omitempty" json:"previous_token_expires_at,omitempty"`
+	RotatedAt              *time.Time `bson:"rotated_at,omitempty" json:"rotated_at,omitempty"`
+	MatchedPrevious        bool       `bson:"-" json:"-"` // 本次查询是否通过轮换前的旧值命中（由 Repository 填充）
 
 	// 使用统计
 	TotalRequests int64      `bson:"total_requests" json:"total_requests"`
 	LastUsedAt    *time.Time `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"` // nil 表示从未使用
 }
 
+// HasScope 检查 Token 是否被授予指定权限
+// 未配置 Scopes 的 Token（历史 Token）视为不限制权限
+// 支持通配符：storage:* 匹配 storage 下所有操作，* 或 *:* 匹配全部
+func (t *Token) HasScope(required string) bool {
+	return len(t.Scopes) == 0 || scopesGrant(t.Scopes, required)
+}
+
+// scopesGrant 已授予的 scopes 是否包含 required（支持通配符）
+func scopesGrant(scopes []string, required string) bool {
+	resource, action, ok := strings.Cut(required, ":")
+	if !ok {
+		return false
+	}
+	for _, granted := range scopes {
+		if granted == ScopeWildcard || granted == required {
+			return true
+		}
+		gResource, gAction, ok := strings.Cut(granted, ":")
+		if !ok {
+			continue
+		}
+		if (gResource == ScopeWildcard || gResource == resource) && (gAction == ScopeWildcard || gAction == action) {
+			return true
+		}
+	}
+	return false
+}
+
+// LimiterKey Token 层限流计数使用的 Token ID
+func (t *Token) LimiterKey() string {
+	if t.RateLimitTokenID != "" {
+		return t.RateLimitTokenID
+	}
+	return t.ID
+}
+
+// AllowsIP 检查客户端 IP 是否在 Token 允许的范围内
+// 未配置 AllowedCIDRs 的 Token 不限制来源；配置了但无法确定客户端 IP 时拒绝
+func (t *Token) AllowsIP(clientIP string) bool {
+	if len(t.AllowedCIDRs) == 0 {
+		return true
+	}
+	ip, err := netip.ParseAddr(clientIP)
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, cidr := range t.AllowedCIDRs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			continue
+		}
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeRegex Scope 格式：resource:action，允许通配符 *
+var scopeRegex = regexp.MustCompile(`^([a-z0-9_-]+|\*):([a-z0-9_-]+|\*)$`)
+
+// IsValidScope 检查授权范围格式是否合法（创建 Token 时使用，允许通配符）
+func IsValidScope(scope string) bool {
+	return scope == ScopeWildcard || scopeRegex.MatchString(scope)
+}
+
+// IsValidRequiredScope 检查待校验的权限格式是否合法（验证时使用，不允许通配符）
+func IsValidRequiredScope(scope string) bool {
+	return scopeRegex.MatchString(scope) && !strings.Contains(scope, ScopeWildcard)
+}
+
 // RateLimit API 频率限制
 type RateLimit struct {
 	RequestsPerMinute int `bson:"requests_per_minute" json:"requests_per_minute"`
@@ -52,6 +146,8 @@ type AuditLog struct {
 	AccountID   string                 `bson:"account_id" json:"account_id"`
 	Action      string                 `bson:"action" json:"action"`           // create_token, delete_token, validate_token
 	ResourceID  string                 `bson:"resource_id" json:"resource_id"` // token_id
+	IUID        string                 `bson:"iuid,omitempty" json:"iuid,omitempty"`           // 操作者 IAM 用户ID（子账号操作时记录）
+	IamAlias    string                 `bson:"iam_alias,omitempty" json:"iam_alias,omitempty"` // 操作者 IAM 子账号名
 	IP          string                 `bson:"ip" json:"ip"`
 	UserAgent   string                 `bson:"user_agent" json:"user_agent"`
 	RequestData map[string]interface{} `bson:"request_data,omitempty" json:"request_data,omitempty"`
@@ -88,24 +184,63 @@ type RegenerateSecretKeyResponse struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// AccountListQuery 账户列表查询条件（管理员）
+type AccountListQuery struct {
+	Keyword string // 邮箱或公司名包含该关键字（不区分大小写）
+	Status  string // active / suspended，为空表示不过滤
+	Limit   int
+	Offset  int
+}
+
+// AccountListResponse 账户列表响应（管理员）
+type AccountListResponse struct {
+	Accounts []Account `json:"accounts"`
+	Total    int64     `json:"total"`
+}
+
+// AccountTokensDisableResponse 批量停用账户 Token 响应（管理员）
+type AccountTokensDisableResponse struct {
+	AccountID     string `json:"account_id"`
+	DisabledCount int64  `json:"disabled_count"`
+}
+
+// ClientTokensDisableResponse 批量停用 OAuth 2.0 客户端签发的 Token 响应（管理员）
+type ClientTokensDisableResponse struct {
+	ClientID      string `json:"client_id"`
+	DisabledCount int64  `json:"disabled_count"`
+}
+
 // TokenCreateRequest 创建 Token 请求
 type TokenCreateRequest struct {
 	Description      string     `json:"description" binding:"required"`
-	ExpiresInSeconds int64      `json:"expires_in_seconds,omitempty"`              // 0 表示永不过期，支持秒级精度
+	ExpiresInSeconds int64      `json:"expires_in_seconds,omitempty"` // 0 表示永不过期，支持秒级精度
 	RateLimit        *RateLimit `json:"rate_limit,omitempty"`
-	Prefix           string     `json:"prefix,omitempty"`                          // 自定义 Token 前缀，默认 "sk-"
+	Prefix           string     `json:"prefix,omitempty"`        // 自定义 Token 前缀，默认 "sk-"
+	Scopes           []string   `json:"scopes,omitempty"`        // 授权范围，如 ["storage:read", "cdn:*"]
+	AllowedCIDRs     []string   `json:"allowed_cidrs,omitempty"` // 允许使用的客户端 IP 范围，如 ["10.0.0.0/8", "2001:db8::/32"]
+	Format           string     `json:"format,omitempty"`        // Token 格式：opaque（默认）或 signed（可离线验证的 JWT，必须设置过期时间）
+
+	// 以下字段只由服务内部设置（/oauth2/token 签发），不接受请求传入
+	ClientID string `json:"-"` // 签发 Token 的 OAuth 2.0 客户端
+	IUID     string `json:"-"` // Token 交换时继承原 Token 的 IAM 子账号（为空时从认证信息中提取）
+	IamAlias string `json:"-"`
+
+	RateLimitTokenID string `json:"-"` // Token 交换时与原 Token 共享限流额度
 }
 
 // TokenCreateResponse 创建 Token 响应
 type TokenCreateResponse struct {
-	TokenID     string     `json:"token_id"`
-	Token       string     `json:"token"`       // 完整 token，仅在创建时返回
-	AccountID   string     `json:"account_id"`
-	Description string     `json:"description"`
-	RateLimit   *RateLimit `json:"rate_limit,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
-	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // nil 表示永不过期
-	IsActive    bool       `json:"is_active"`
+	TokenID      string     `json:"token_id"`
+	Token        string     `json:"token"` // 完整 token，仅在创建时返回
+	AccountID    string     `json:"account_id"`
+	Description  string     `json:"description"`
+	RateLimit    *RateLimit `json:"rate_limit,omitempty"`
+	Scopes       []string   `json:"scopes,omitempty"`
+	AllowedCIDRs []string   `json:"allowed_cidrs,omitempty"`
+	Format       string     `json:"format,omitempty"` // signed 表示签名 Token
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"` // nil 表示永不过期
+	IsActive     bool       `json:"is_active"`
 }
 
 // TokenListResponse Token 列表响应
@@ -121,6 +256,10 @@ type TokenBrief struct {
 	TokenPreview  string     `json:"token_preview"`  // 中间隐藏，如 "sk-a1b2c3d4****e5f6g7h8"
 	Description   string     `json:"description"`
 	RateLimit     *RateLimit `json:"rate_limit,omitempty"`
+	Scopes        []string   `json:"scopes,omitempty"`
+	AllowedCIDRs  []string   `json:"allowed_cidrs,omitempty"`
+	Format        string     `json:"format,omitempty"` // signed 表示签名 Token
+	ClientID      string     `json:"client_id,omitempty"` // 签发 Token 的 OAuth 2.0 客户端
 	CreatedAt     time.Time  `json:"created_at"`
 	ExpiresAt     *time.Time `json:"expires_at,omitempty"`  // nil 表示永不过期
 	IsActive      bool       `json:"is_active"`
@@ -129,23 +268,69 @@ type TokenBrief struct {
 	LastUsedAt    *time.Time `json:"last_used_at,omitempty"` // nil 表示从未使用
 }
 
+// TokenRotateRequest 轮换 Token 请求
+type TokenRotateRequest struct {
+	GracePeriodSeconds *int64 `json:"grace_period_seconds,omitempty"` // 旧 token 保留时长，nil 使用服务端默认值，0 表示立即失效
+}
+
+// TokenRotateResponse 轮换 Token 响应
+type TokenRotateResponse struct {
+	TokenID                string     `json:"token_id"`
+	Token                  string     `json:"token"` // 新的完整 token，仅在轮换时返回
+	PreviousTokenExpiresAt *time.Time `json:"previous_token_expires_at,omitempty"` // 旧 token 失效时间，nil 表示已立即失效
+	RotatedAt              time.Time  `json:"rotated_at"`
+}
+
 // TokenUpdateStatusRequest 更新 Token 状态请求
 type TokenUpdateStatusRequest struct {
 	IsActive bool `json:"is_active"`
 }
 
+// TokenUpdateRequest 更新 Token 请求（部分更新，未提供的字段保持不变）
+type TokenUpdateRequest struct {
+	Description      *string    `json:"description,omitempty"`
+	ExpiresInSeconds *int64     `json:"expires_in_seconds,omitempty"` // 从当前时间起重新计算过期时间，0 表示永不过期
+	RateLimit        *RateLimit `json:"rate_limit,omitempty"`         // 各字段均为 0 时清除 Token 级限流
+}
+
+// TokenUpdate Token 部分更新内容（Repository 层使用）
+type TokenUpdate struct {
+	Description *string // nil 表示不修改
+
+	SetExpiresAt bool       // 是否修改过期时间
+	ExpiresAt    *time.Time // SetExpiresAt 为 true 时生效，nil 表示永不过期
+
+	SetRateLimit bool       // 是否修改限流配置
+	RateLimit    *RateLimit // SetRateLimit 为 true 时生效，nil 表示清除
+}
+
 // TokenValidateRequest Token 验证请求
 type TokenValidateRequest struct {
-	Token string `json:"-"` // 从 Authorization header 提取
+	Token         string `json:"-"`                        // 从 Authorization header 提取
+	RequiredScope string `json:"required_scope,omitempty"` // 可选：要求 Token 具备的权限，如 "storage:read"
+	ClientIP      string `json:"-"`                        // 使用 Token 的客户端 IP（由 handler 按可信代理链解析），用于 allowed_cidrs 校验
 }
 
 // TokenValidateResponse Token 验证响应
 type TokenValidateResponse struct {
 	Valid     bool       `json:"valid"`
 	Message   string     `json:"message"`
+	Code      int        `json:"code,omitempty"` // 验证失败时的业务错误码（ErrCodeTokenNotFound 等）
 	TokenInfo *TokenInfo `json:"token_info,omitempty"`
 }
 
+// TokenBatchValidateRequest 批量 Token 验证请求
+type TokenBatchValidateRequest struct {
+	Tokens        []string `json:"tokens"`                   // 待验证的 token 值
+	RequiredScope string   `json:"required_scope,omitempty"` // 可选：要求每个 Token 具备的权限
+	ClientIP      string   `json:"-"`                        // 使用 Token 的客户端 IP（所有 token 共用）
+}
+
+// TokenBatchValidateResponse 批量 Token 验证响应
+type TokenBatchValidateResponse struct {
+	Results []*TokenValidateResponse `json:"results"` // 与请求中的 tokens 一一对应
+}
+
 // TokenInfo Token 基本信息（用于验证响应）
 type TokenInfo struct {
 	TokenID    string     `json:"token_id"`
@@ -153,26 +338,176 @@ type TokenInfo struct {
 	UID        string     `json:"uid,omitempty"`         // QiniuStub 用户使用（从 account_id 提取）
 	IUID       string     `json:"iuid,omitempty"`        // IAM 用户ID（当请求中包含 iuid 时返回，用于标识IAM用户）
 	IamAlias   string     `json:"iam_alias,omitempty"`   // IAM 子账号名
+	Scopes     []string   `json:"scopes,omitempty"`      // 授权范围，为空表示不限制
 	IsActive   bool       `json:"is_active"`
 	ExpiresAt  *time.Time `json:"expires_at,omitempty"`  // nil 表示永不过期
 	LastUsedAt *time.Time `json:"last_used_at,omitempty"` // nil 表示从未使用
 }
 
+// OAuth2Client OAuth 2.0 客户端（/oauth2/* 的调用方），由账户创建，凭证与账户 AK/SK 相互独立
+// client_secret 只在创建时返回一次，库中保存 SHA-256 哈希
+type OAuth2Client struct {
+	ClientID         string    `bson:"_id" json:"client_id"` // oc_xxx
+	AccountID        string    `bson:"account_id" json:"account_id"`
+	Name             string    `bson:"name" json:"name"`
+	SecretHash       string    `bson:"secret_hash" json:"-"`
+	Scopes           []string  `bson:"scopes" json:"scopes"`                                           // 可签发的授权范围（签发的 Token 不能超出）
+	GrantTypes       []string  `bson:"grant_types" json:"grant_types"`                                 // 允许的 grant_type
+	ResourceAccounts []string  `bson:"resource_accounts,omitempty" json:"resource_accounts,omitempty"` // 资源服务：另外可内省 / 吊销 / 交换其 Token 的账户（只能由管理员设置）
+	CreatedAt        time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt        time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// HasScope 客户端是否可以签发包含指定权限的 Token（支持通配符，规则与 Token.HasScope 相同）
+func (c *OAuth2Client) HasScope(required string) bool {
+	return scopesGrant(c.Scopes, required)
+}
+
+// HasGrantType 客户端是否允许使用指定的 grant_type
+func (c *OAuth2Client) HasGrantType(grantType string) bool {
+	for _, g := range c.GrantTypes {
+		if g == grantType {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessAccount 客户端是否可以内省 / 吊销 / 交换该账户的 Token（本账户或管理员授予的资源账户）
+func (c *OAuth2Client) CanAccessAccount(accountID string) bool {
+	if accountID == c.AccountID {
+		return true
+	}
+	for _, id := range c.ResourceAccounts {
+		if id == accountID {
+			return true
+		}
+	}
+	return false
+}
+
+// OAuth2ClientCreateRequest 创建 OAuth 2.0 客户端请求
+type OAuth2ClientCreateRequest struct {
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`      // 必填，如 ["storage:read"]，"*" 表示不限制
+	GrantTypes []string `json:"grant_types"` // 必填，client_credentials 和 / 或 token exchange
+}
+
+// OAuth2ClientCreateResponse 创建 OAuth 2.0 客户端响应
+type OAuth2ClientCreateResponse struct {
+	OAuth2Client
+	ClientSecret string `json:"client_secret"` // 仅在创建时返回
+}
+
+// OAuth2ClientListResponse OAuth 2.0 客户端列表响应
+type OAuth2ClientListResponse struct {
+	Clients []OAuth2Client `json:"clients"`
+}
+
+// OAuth2ClientResourceAccountsRequest 设置资源服务可访问的账户（管理员）
+type OAuth2ClientResourceAccountsRequest struct {
+	AccountIDs []string `json:"account_ids"` // 为空表示撤销全部授权
+}
+
+// OAuth 2.0 授权类型与 Token 类型
+const (
+	OAuth2GrantClientCredentials = "client_credentials"
+	OAuth2GrantTokenExchange     = "urn:ietf:params:oauth:grant-type:token-exchange" // RFC 8693
+	OAuth2TokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// OAuth2TokenRequest /oauth2/token 请求（已完成客户端认证）
+type OAuth2TokenRequest struct {
+	GrantType        string
+	Scope            string // 空格分隔的授权范围；token exchange 时不能超出原 Token
+	SubjectToken     string // token exchange：被降权的原 Token
+	SubjectTokenType string // token exchange：只支持 OAuth2TokenTypeAccessToken
+	ClientIP         string // 调用方 IP（token exchange 时用于原 Token 的 allowed_cidrs 校验）
+}
+
+// OAuth2TokenResponse /oauth2/token 响应（RFC 6749 5.1 / RFC 8693 2.2.1）
+type OAuth2TokenResponse struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type,omitempty"` // 仅 token exchange
+	TokenType       string `json:"token_type"`                  // 固定为 Bearer
+	ExpiresIn       int64  `json:"expires_in"`
+	Scope           string `json:"scope,omitempty"`
+}
+
+// TokenIntrospectionResponse Token 内省响应（RFC 7662）
+// Token 无效或调用方无权查看时只返回 {"active": false}
+type TokenIntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`      // 空格分隔的授权范围，为空表示不限制
+	ClientID  string `json:"client_id,omitempty"`  // 创建 Token 的客户端
+	Subject   string `json:"sub,omitempty"`        // account_id
+	ExpiresAt int64  `json:"exp,omitempty"`        // 未设置表示永不过期
+	IssuedAt  int64  `json:"iat,omitempty"`
+	TokenID   string `json:"jti,omitempty"`
+	TokenType string `json:"token_type,omitempty"` // 固定为 Bearer
+	UID       string `json:"uid,omitempty"`        // QiniuStub 用户使用（从 account_id 提取）
+	IUID      string `json:"iuid,omitempty"`       // IAM 子账号 ID
+	IamAlias  string `json:"iam_alias,omitempty"`  // IAM 子账号名
+}
+
+// 使用统计粒度
+const (
+	UsageGranularityDay  = "day"
+	UsageGranularityHour = "hour"
+)
+
+// TokenStatsQuery Token 使用统计查询参数
+type TokenStatsQuery struct {
+	From        time.Time // 起始时间（包含），零值表示由服务端决定
+	To          time.Time // 结束时间（包含），零值表示当前时间
+	Granularity string    // day / hour，默认 day
+}
+
 // TokenStatsResponse Token 使用统计响应
 type TokenStatsResponse struct {
-	TokenID       string     `json:"token_id"`
-	TotalRequests int64      `json:"total_requests"`
-	LastUsedAt    *time.Time `json:"last_used_at,omitempty"` // nil 表示从未使用
-	CreatedAt     time.Time  `json:"created_at"`
-	DailyStats    []DailyStat `json:"daily_stats,omitempty"` // 未来：每日统计
+	TokenID       string       `json:"token_id"`
+	TotalRequests int64        `json:"total_requests"`
+	LastUsedAt    *time.Time   `json:"last_used_at,omitempty"` // nil 表示从未使用
+	CreatedAt     time.Time    `json:"created_at"`
+	From          time.Time    `json:"from"`
+	To            time.Time    `json:"to"`
+	Granularity   string       `json:"granularity"`
+	DailyStats    []DailyStat  `json:"daily_stats,omitempty"`  // granularity=day 时返回
+	HourlyStats   []HourlyStat `json:"hourly_stats,omitempty"` // granularity=hour 时返回
 }
 
-// DailyStat 每日统计（未来扩展）
+// DailyStat 每日统计（UTC 自然日）
 type DailyStat struct {
 	Date     string `json:"date"`     // YYYY-MM-DD
 	Requests int64  `json:"requests"`
 }
 
+// HourlyStat 每小时统计（UTC）
+type HourlyStat struct {
+	Hour     string `json:"hour"`     // YYYY-MM-DDTHH:00:00Z
+	Requests int64  `json:"requests"`
+}
+
+// TokenUsageIncrement 单个 token 的批量使用增量（由使用聚合器按 token + 小时合并产生）
+type TokenUsageIncrement struct {
+	TokenID    string
+	AccountID  string
+	Count      int64
+	LastUsedAt time.Time // 合并窗口内最后一次使用时间，同时决定统计桶
+}
+
+// TokenUsageBucket Token 使用计数桶（token_usage_stats 集合）
+// 每个 token 每个时间桶一条文档，_id 为 {token_id}:{granularity}:{period}
+type TokenUsageBucket struct {
+	ID          string    `bson:"_id" json:"id"`
+	TokenID     string    `bson:"token_id" json:"token_id"`
+	AccountID   string    `bson:"account_id" json:"account_id"`
+	Granularity string    `bson:"granularity" json:"granularity"` // day / hour
+	Period      time.Time `bson:"period" json:"period"`           // 时间桶起点（UTC）
+	Requests    int64     `bson:"requests" json:"requests"`
+	ExpireAt    time.Time `bson:"expire_at" json:"-"`             // TTL 索引字段
+}
+
 // ========================================
 // /api/v2/validateu 扩展模型
 // ========================================
@@ -223,6 +558,7 @@ func (u *UserInfo) IsEnterprise() bool {
 type TokenValidateUResponse struct {
 	Valid     bool      `json:"valid"`
 	Message   string    `json:"message"`
+	Code      int       `json:"code,omitempty"` // 验证失败时的业务错误码
 	TokenInfo *TokenInfoU `json:"token_info,omitempty"`
 }
 
@@ -233,6 +569,7 @@ type TokenInfoU struct {
 	UID        string     `json:"uid,omitempty"`         // QiniuStub 用户使用（从 account_id 提取）
 	IUID       string     `json:"iuid,omitempty"`        // IAM 用户ID
 	IamAlias   string     `json:"iam_alias,omitempty"`   // IAM 子账号名
+	Scopes     []string   `json:"scopes,omitempty"`      // 授权范围
 	IsActive   bool       `json:"is_active"`
 	ExpiresAt  *time.Time `json:"expires_at,omitempty"`  // nil 表示永不过期
 	LastUsedAt *time.Time `json:"last_used_at,omitempty"` // nil 表示从未使用
@@ -248,14 +585,47 @@ type AuditLogQuery struct {
 	StartTime  time.Time `form:"start_time"`  // 开始时间
 	EndTime    time.Time `form:"end_time"`    // 结束时间
 	Limit      int       `form:"limit"`       // 返回数量，默认 50
-	Offset     int       `form:"offset"`      // 偏移量
+	Offset     int       `form:"offset"`      // 偏移量（指定 cursor 时忽略）
+	Cursor     string    `form:"cursor"`      // 分页游标，取上一页响应中的 next_cursor
 }
 
 // AuditLogResponse 审计日志响应
 type AuditLogResponse struct {
-	AccountID string      `json:"account_id"`
-	Logs      []AuditLog  `json:"logs"`
-	Total     int         `json:"total"`
+	AccountID  string     `json:"account_id"`
+	Logs       []AuditLog `json:"logs"`
+	Total      int        `json:"total"`
+	NextCursor string     `json:"next_cursor,omitempty"` // 为空表示没有更多数据
+}
+
+// ========================================
+// 健康检查模型
+// ========================================
+
+// 健康状态
+const (
+	HealthStatusOK       = "ok"        // 所有依赖正常
+	HealthStatusDegraded = "degraded"  // 关键依赖正常，可降级依赖异常（仍可接收流量）
+	HealthStatusNotReady = "not_ready" // 关键依赖异常
+	HealthStatusDraining = "draining"  // 正在关闭
+
+	DependencyStatusUp   = "up"
+	DependencyStatusDown = "down"
+)
+
+// HealthResponse 健康检查响应
+type HealthResponse struct {
+	Status       string                      `json:"status"`
+	Version      string                      `json:"version,omitempty"`
+	Commit       string                      `json:"commit,omitempty"`
+	Built        string                      `json:"built,omitempty"`
+	Dependencies map[string]DependencyHealth `json:"dependencies,omitempty"`
+}
+
+// DependencyHealth 单个依赖的检查结果
+type DependencyHealth struct {
+	Status    string  `json:"status"`     // up / down（异常原因只写日志，不对外返回）
+	Critical  bool    `json:"critical"`   // 关键依赖异常时实例不就绪
+	LatencyMS float64 `json:"latency_ms"` // 检查耗时（毫秒）
 }
 
 // ========================================
@@ -272,9 +642,16 @@ const (
 	TokenStatusExpired  = "expired"  // 已过期
 	TokenStatusDisabled = "disabled" // 已停用
 
+	// Scope 通配符
+	ScopeWildcard = "*"
+
 	// Token Prefix (保持与 V1 兼容)
 	TokenPrefix = "sk-"
 
+	// Token Format
+	TokenFormatOpaque = "opaque" // 不透明 Token（默认），只能在线验证
+	TokenFormatSigned = "signed" // 签名 Token（JWT），可通过 JWKS 离线验证
+
 	// AccessKey Prefix (通用前缀)
 	AccessKeyPrefix = "AK_"
 
@@ -285,8 +662,18 @@ const (
 	AuditActionCreateToken    = "create_token"
 	AuditActionDeleteToken    = "delete_token"
 	AuditActionUpdateToken    = "update_token"
+	AuditActionRotateToken    = "rotate_token"
 	AuditActionValidateToken  = "validate_token"
 	AuditActionRegenerateKey  = "regenerate_secret_key"
+	AuditActionRegister       = "register_account"
+	AuditActionSuspendAccount  = "suspend_account"
+	AuditActionActivateAccount = "activate_account"
+	AuditActionDisableTokens   = "disable_account_tokens"
+	AuditActionRevokeToken     = "revoke_token"
+	AuditActionDisableClientTokens = "disable_client_tokens"
+	AuditActionCreateOAuth2Client  = "create_oauth2_client"
+	AuditActionDeleteOAuth2Client  = "delete_oauth2_client"
+	AuditActionGrantResourceAccounts = "grant_resource_accounts"
 
 	// Audit Results
 	AuditResultSuccess = "success"
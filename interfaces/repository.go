@@ -2,9 +2,39 @@ package interfaces
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 )
 
+// ErrUserNotFound 用户不存在（UserInfoRepository 返回的错误包装该值，可用 errors.Is 判断）
+var ErrUserNotFound = errors.New("user not found")
+
+// 业务错误分类：Service / Repository 直接返回或用 %w 包装这些值，Handler 通过 errors.Is 映射 HTTP 状态码
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrInvalidArgument  = errors.New("invalid argument")
+	ErrConflict         = errors.New("conflict")
+
+	ErrTokenNotFound        = fmt.Errorf("token %w", ErrNotFound)
+	ErrAccountNotFound      = fmt.Errorf("account %w", ErrNotFound)
+	ErrOAuth2ClientNotFound = fmt.Errorf("oauth2 client %w", ErrNotFound)
+
+	ErrDuplicateEmail     = fmt.Errorf("%w: email already exists", ErrConflict)
+	ErrDuplicateAccessKey = fmt.Errorf("%w: access key already exists", ErrConflict)
+)
+
+// OAuth 2.0 协议错误（RFC 6749 5.2）：OAuth2Service 直接返回或用 %w 包装，OAuth2Handler 映射为标准 error 码
+// invalid_request 使用 ErrInvalidArgument，吊销无权访问的 Token 使用 ErrPermissionDenied
+var (
+	ErrInvalidClient        = errors.New("invalid client")
+	ErrInvalidGrant         = errors.New("invalid grant")
+	ErrInvalidScope         = errors.New("invalid scope")
+	ErrUnauthorizedClient   = errors.New("unauthorized client")
+	ErrUnsupportedGrantType = errors.New("unsupported grant_type")
+)
+
 // ========================================
 // Repository 接口定义
 // ========================================
@@ -29,11 +59,14 @@ type AccountRepository interface {
 	// UpdateStatus 更新账户状态
 	UpdateStatus(ctx context.Context, accountID string, status string) error
 
-	// List 列出所有账户（管理员功能）
-	List(ctx context.Context, limit, offset int) ([]Account, error)
+	// List 按条件列出账户（管理员功能），按创建时间倒序
+	List(ctx context.Context, query *AccountListQuery) ([]Account, error)
+
+	// Count 统计符合条件的账户数量
+	Count(ctx context.Context, query *AccountListQuery) (int64, error)
 
-	// Count 统计账户数量
-	Count(ctx context.Context) (int64, error)
+	// ListSuspendedIDs 列出所有已停用账户的 ID（验证时拒绝这些账户的 Token）
+	ListSuspendedIDs(ctx context.Context) ([]string, error)
 }
 
 // TokenRepository Token 数据访问接口
@@ -47,6 +80,9 @@ type TokenRepository interface {
 	// GetByTokenValue 根据 token 值查询 Token
 	GetByTokenValue(ctx context.Context, tokenValue string) (*Token, error)
 
+	// GetByTokenValues 批量根据 token 值查询 Token，返回以 token 值为键的结果（不存在的值不在结果中）
+	GetByTokenValues(ctx context.Context, tokenValues []string) (map[string]*Token, error)
+
 	// ListByAccountID 查询账户的所有 Tokens（租户隔离）
 	// iuid/iamAlias 非空时只返回该子账号创建的 token
 	ListByAccountID(ctx context.Context, accountID string, activeOnly bool, limit, offset int, iuid, iamAlias string) ([]Token, error)
@@ -58,17 +94,36 @@ type TokenRepository interface {
 	// UpdateStatus 更新 Token 状态
 	UpdateStatus(ctx context.Context, tokenID string, isActive bool) error
 
+	// Update 部分更新 Token（描述、过期时间、限流），返回更新后的 Token
+	Update(ctx context.Context, tokenID string, update *TokenUpdate) (*Token, error)
+
+	// DisableByAccountID 停用账户下所有 Token，返回被停用的数量
+	DisableByAccountID(ctx context.Context, accountID string) (int64, error)
+
+	// DisableByClientID 停用 OAuth 2.0 客户端签发的所有 Token，返回被停用的数量
+	DisableByClientID(ctx context.Context, clientID string) (int64, error)
+
 	// Delete 删除 Token
 	Delete(ctx context.Context, tokenID string) error
 
+	// Rotate 为 Token 生成新的 token 值，旧值在 previousExpiresAt 之前仍可使用
+	// previousExpiresAt 为 nil 时旧值立即失效
+	Rotate(ctx context.Context, tokenID string, previousExpiresAt *time.Time) (*Token, error)
+
 	// IncrementUsage 增加使用次数
 	IncrementUsage(ctx context.Context, tokenID string) error
 
+	// IncrementUsageBatch 批量增加使用次数并更新最后使用时间
+	IncrementUsageBatch(ctx context.Context, increments []TokenUsageIncrement) error
+
 	// UpdateLastUsed 更新最后使用时间
 	UpdateLastUsed(ctx context.Context, tokenID string, lastUsedAt time.Time) error
 
 	// DeleteExpired 删除过期的 Tokens
 	DeleteExpired(ctx context.Context) (int64, error)
+
+	// ListRevokedSignedIDs 列出已停用或已删除、但尚未过期的签名 Token ID（签名 Token 吊销列表）
+	ListRevokedSignedIDs(ctx context.Context) ([]string, error)
 }
 
 // AuditLogRepository 审计日志数据访问接口
@@ -76,19 +131,47 @@ type AuditLogRepository interface {
 	// Create 创建审计日志
 	Create(ctx context.Context, log *AuditLog) error
 
-	// ListByAccountID 查询账户的审计日志
-	ListByAccountID(ctx context.Context, accountID string, query *AuditLogQuery) ([]AuditLog, error)
+	// ListByAccountID 查询账户的审计日志，返回下一页游标（没有更多数据时为空）
+	// iuid/iamAlias 非空时只返回该子账号的操作记录
+	ListByAccountID(ctx context.Context, accountID string, query *AuditLogQuery, iuid, iamAlias string) ([]AuditLog, string, error)
 
 	// CountByAccountID 统计账户的审计日志数量
-	CountByAccountID(ctx context.Context, accountID string, query *AuditLogQuery) (int64, error)
+	CountByAccountID(ctx context.Context, accountID string, query *AuditLogQuery, iuid, iamAlias string) (int64, error)
 
 	// DeleteOldLogs 删除旧日志（数据清理）
 	DeleteOldLogs(ctx context.Context, olderThan time.Time) (int64, error)
 }
 
+// TokenUsageRepository Token 使用统计数据访问接口（按天 / 按小时计数）
+type TokenUsageRepository interface {
+	// IncrementBatch 将每个增量计入 LastUsedAt 所在的天桶和小时桶
+	IncrementBatch(ctx context.Context, increments []TokenUsageIncrement) error
+
+	// ListByTokenID 查询 [from, to] 时间范围内指定粒度的计数桶，按时间升序
+	ListByTokenID(ctx context.Context, tokenID string, granularity string, from, to time.Time) ([]TokenUsageBucket, error)
+}
+
+// OAuth2ClientRepository OAuth 2.0 客户端数据访问接口
+type OAuth2ClientRepository interface {
+	// Create 创建客户端（由调用方生成 client_id 和 secret 哈希）
+	Create(ctx context.Context, client *OAuth2Client) error
+
+	// GetByID 根据 client_id 查询客户端，不存在时返回 nil
+	GetByID(ctx context.Context, clientID string) (*OAuth2Client, error)
+
+	// ListByAccount 列出账户的所有客户端，按创建时间倒序
+	ListByAccount(ctx context.Context, accountID string) ([]OAuth2Client, error)
+
+	// Delete 删除账户的客户端（不存在或不属于该账户时返回 ErrOAuth2ClientNotFound）
+	Delete(ctx context.Context, accountID, clientID string) error
+
+	// UpdateResourceAccounts 设置资源服务可访问的账户（不存在时返回 ErrOAuth2ClientNotFound）
+	UpdateResourceAccounts(ctx context.Context, clientID string, accountIDs []string) (*OAuth2Client, error)
+}
+
 // UserInfoRepository 用户信息数据访问接口（支持 qconfapi RPC 或 MySQL）
 type UserInfoRepository interface {
-	// GetUserInfoByUID 根据 UID 查询用户信息
+	// GetUserInfoByUID 根据 UID 查询用户信息（用户不存在时返回包装 ErrUserNotFound 的错误）
 	GetUserInfoByUID(ctx context.Context, uid uint32) (*UserInfo, error)
 }
 
@@ -115,6 +198,27 @@ type AccountService interface {
 	ActivateAccount(ctx context.Context, accountID string) error
 }
 
+// AdminService 管理员服务接口（跨租户操作）
+type AdminService interface {
+	// ListAccounts 按条件列出账户
+	ListAccounts(ctx context.Context, query *AccountListQuery) (*AccountListResponse, error)
+
+	// SuspendAccount 停用账户（该账户的 Token 验证失败、HMAC 认证被拒绝）
+	SuspendAccount(ctx context.Context, accountID string) error
+
+	// ActivateAccount 恢复账户
+	ActivateAccount(ctx context.Context, accountID string) error
+
+	// DisableAccountTokens 停用账户下所有 Token，返回被停用的数量
+	DisableAccountTokens(ctx context.Context, accountID string) (int64, error)
+
+	// DisableClientTokens 停用 OAuth 2.0 客户端签发的所有 Token，返回被停用的数量
+	DisableClientTokens(ctx context.Context, clientID string) (int64, error)
+
+	// SetClientResourceAccounts 授予 OAuth 2.0 客户端访问其他账户 Token 的权限（资源服务 / 网关），覆盖原有授权
+	SetClientResourceAccounts(ctx context.Context, clientID string, accountIDs []string) (*OAuth2Client, error)
+}
+
 // TokenService Token 管理服务接口
 type TokenService interface {
 	// CreateToken 创建新 Token
@@ -129,11 +233,17 @@ type TokenService interface {
 	// UpdateTokenStatus 更新 Token 状态
 	UpdateTokenStatus(ctx context.Context, accountID string, tokenID string, isActive bool) error
 
+	// UpdateToken 部分更新 Token 的描述、过期时间和限流配置（token 值不变）
+	UpdateToken(ctx context.Context, accountID string, tokenID string, req *TokenUpdateRequest) (*Token, error)
+
 	// DeleteToken 删除 Token
 	DeleteToken(ctx context.Context, accountID string, tokenID string) error
 
-	// GetTokenStats 获取 Token 使用统计
-	GetTokenStats(ctx context.Context, accountID string, tokenID string) (*TokenStatsResponse, error)
+	// RotateToken 轮换 Token 值（旧值在宽限期内仍然有效）
+	RotateToken(ctx context.Context, accountID string, tokenID string, req *TokenRotateRequest) (*TokenRotateResponse, error)
+
+	// GetTokenStats 获取 Token 使用统计（包含按天 / 按小时的时间序列）
+	GetTokenStats(ctx context.Context, accountID string, tokenID string, query *TokenStatsQuery) (*TokenStatsResponse, error)
 }
 
 // ValidationService Token 验证服务接口
@@ -144,8 +254,36 @@ type ValidationService interface {
 	// ValidateTokenWithUserInfo 验证 Token 并返回扩展用户信息
 	ValidateTokenWithUserInfo(ctx context.Context, req *TokenValidateRequest) (*TokenValidateUResponse, error)
 
-	// RecordTokenUsage 记录 Token 使用
-	RecordTokenUsage(ctx context.Context, tokenValue string) error
+	// ValidateTokens 批量验证 Token，结果顺序与请求中的 tokens 一致
+	ValidateTokens(ctx context.Context, req *TokenBatchValidateRequest) (*TokenBatchValidateResponse, error)
+
+	// ValidateTokenWithLimit 验证 Token 并检查 Token 层限流（超限时返回 code 429 的验证结果）
+	// 供不经过 TokenLimitMiddleware 的入口使用（如反向代理鉴权）
+	ValidateTokenWithLimit(ctx context.Context, req *TokenValidateRequest) (*TokenValidateResponse, error)
+}
+
+// OAuth2Service OAuth 2.0 Token 签发、内省（RFC 7662）与吊销（RFC 7009）服务接口
+type OAuth2Service interface {
+	// AuthenticateClient 校验调用方的 client_id / client_secret（OAuth 2.0 客户端凭证）
+	AuthenticateClient(ctx context.Context, clientID, clientSecret string) (*OAuth2Client, error)
+
+	// IntrospectToken 内省 Token；clientIP 为使用 Token 的客户端 IP（可选，用于 allowed_cidrs 校验）
+	IntrospectToken(ctx context.Context, client *OAuth2Client, tokenValue, clientIP string) (*TokenIntrospectionResponse, error)
+
+	// RevokeToken 吊销 Token（停用），Token 不存在时视为成功
+	RevokeToken(ctx context.Context, client *OAuth2Client, tokenValue string) error
+
+	// IssueToken 签发短期 Token（client_credentials 或 RFC 8693 token exchange）
+	IssueToken(ctx context.Context, client *OAuth2Client, req *OAuth2TokenRequest) (*OAuth2TokenResponse, error)
+
+	// CreateClient 为账户创建 OAuth 2.0 客户端，client_secret 只在响应中返回一次
+	CreateClient(ctx context.Context, accountID string, req *OAuth2ClientCreateRequest) (*OAuth2ClientCreateResponse, error)
+
+	// ListClients 列出账户的 OAuth 2.0 客户端
+	ListClients(ctx context.Context, accountID string) (*OAuth2ClientListResponse, error)
+
+	// DeleteClient 删除账户的 OAuth 2.0 客户端，并停用其签发的所有 Token
+	DeleteClient(ctx context.Context, accountID, clientID string) error
 }
 
 // AuditService 审计服务接口
@@ -0,0 +1,94 @@
+package observability
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDMetadataKey gRPC metadata 中的 Request ID 键（对应 HTTP 头 X-Request-ID）
+const RequestIDMetadataKey = "x-request-id"
+
+// MetricsUnaryInterceptor Prometheus 指标收集拦截器（gRPC 版 MetricsMiddleware）
+func MetricsUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+
+	// 在途请求计数
+	GRPCRequestsInFlight.Inc()
+	defer GRPCRequestsInFlight.Dec()
+
+	resp, err := handler(ctx, req)
+
+	// 记录指标（method 为完整方法名，基数固定）
+	GRPCRequestsTotal.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+	GRPCRequestDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+
+	return resp, err
+}
+
+// RequestTrackingUnaryInterceptor 请求追踪拦截器（gRPC 版 RequestTrackingMiddleware）
+// 读取或生成 Request ID 并通过响应头 metadata 返回，记录请求开始和结束日志
+func RequestTrackingUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+
+	// 获取或生成 Request ID
+	md, _ := metadata.FromIncomingContext(ctx)
+	requestID := firstMetadataValue(md, RequestIDMetadataKey)
+	if requestID == "" {
+		requestID = generateRequestID()
+	}
+
+	// 设置响应头
+	grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadataKey, requestID))
+
+	// 注入到 context
+	ctx = SetRequestIDToContext(ctx, requestID)
+
+	remoteAddr := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+
+	// 请求开始日志
+	WithContext(ctx).Debug("gRPC request started",
+		slog.String("method", info.FullMethod),
+		slog.String("remote_addr", remoteAddr),
+		slog.String("user_agent", firstMetadataValue(md, "user-agent")),
+	)
+
+	resp, err := handler(ctx, req)
+
+	// 请求结束日志
+	duration := time.Since(start)
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK:
+	case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+
+	WithContext(ctx).Log(ctx, level, "gRPC request completed",
+		slog.String("method", info.FullMethod),
+		slog.String("code", code.String()),
+		slog.Float64("duration_ms", float64(duration.Microseconds())/1000),
+	)
+
+	return resp, err
+}
+
+// firstMetadataValue 返回 metadata 中 key 的第一个值
+func firstMetadataValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -37,6 +37,37 @@ var (
 		},
 	)
 
+	// ========================================
+	// gRPC 请求指标
+	// ========================================
+
+	// GRPCRequestsTotal gRPC 请求总数
+	GRPCRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "grpc_requests_total",
+			Help: "Total number of gRPC requests",
+		},
+		[]string{"method", "code"}, // method: /bearertoken.v2.ValidationService/ValidateToken 等; code: OK, ResourceExhausted, Internal...
+	)
+
+	// GRPCRequestDuration gRPC 请求延迟分布
+	GRPCRequestDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "grpc_request_duration_seconds",
+			Help:    "gRPC request latency in seconds",
+			Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+		},
+		[]string{"method"},
+	)
+
+	// GRPCRequestsInFlight 当前在途 gRPC 请求数
+	GRPCRequestsInFlight = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "grpc_requests_in_flight",
+			Help: "Current number of gRPC requests being processed",
+		},
+	)
+
 	// ========================================
 	// Token 验证指标
 	// ========================================
@@ -47,7 +78,7 @@ var (
 			Name: "token_validations_total",
 			Help: "Total number of token validation requests",
 		},
-		[]string{"result"}, // valid, invalid, expired, inactive, not_found, error
+		[]string{"result"}, // valid, invalid, expired, inactive, not_found, scope_denied, ip_denied, rotated, account_suspended, user_disabled, user_unactivated, user_status_error, error
 	)
 
 	// TokenValidationDuration Token 验证延迟
@@ -59,6 +90,19 @@ var (
 		},
 	)
 
+	// ========================================
+	// 认证指标
+	// ========================================
+
+	// AuthFailuresTotal 管理接口认证失败次数
+	AuthFailuresTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_failures_total",
+			Help: "Total number of failed management API authentications",
+		},
+		[]string{"method", "reason"}, // method: hmac, qstub, qiniu_mac, none（不支持的认证方式）; reason: invalid_header, timestamp, access_key, signature, nonce, suspended, not_admin, untrusted_source, client_cert, stub_signature, error
+	)
+
 	// ========================================
 	// 限流指标
 	// ========================================
@@ -72,6 +116,14 @@ var (
 		[]string{"level"}, // app, account, token
 	)
 
+	// RateLimitFallbackTotal Redis 限流器不可用时降级到内存限流器的次数
+	RateLimitFallbackTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rate_limit_fallback_total",
+			Help: "Total number of rate limit checks served by the memory fallback limiter",
+		},
+	)
+
 	// RateLimitCheckDuration 限流检查延迟
 	RateLimitCheckDuration = promauto.NewHistogram(
 		prometheus.HistogramOpts{
@@ -104,6 +156,119 @@ var (
 		[]string{"operation"},
 	)
 
+	// UserInfoCacheLookupsTotal 用户信息缓存查询总数（按层级）
+	UserInfoCacheLookupsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "userinfo_cache_lookups_total",
+			Help: "Total number of user info cache lookups by tier",
+		},
+		[]string{"tier", "result"}, // memory/redis, hit/negative_hit/stale/miss/error
+	)
+
+	// UserInfoCacheEntries 进程内用户信息缓存条目数
+	UserInfoCacheEntries = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "userinfo_cache_entries",
+			Help: "Current number of entries in the in-memory user info cache",
+		},
+	)
+
+	// UserInfoBackendRequestsTotal 用户信息后端查询总数（链式查询中每个后端的结果）
+	UserInfoBackendRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "userinfo_backend_requests_total",
+			Help: "Total number of user info backend lookups by backend and result",
+		},
+		[]string{"backend", "result"}, // qconfapi/mysql, success/not_found/error/skipped
+	)
+
+	// UserInfoBackendDuration 用户信息后端查询延迟
+	UserInfoBackendDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "userinfo_backend_duration_seconds",
+			Help:    "User info backend lookup latency in seconds",
+			Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+		},
+		[]string{"backend"},
+	)
+
+	// UserInfoBackendCircuitOpen 用户信息后端熔断器是否打开（1 打开，0 关闭）
+	UserInfoBackendCircuitOpen = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "userinfo_backend_circuit_open",
+			Help: "Whether the circuit breaker of a user info backend is open (1 = open, 0 = closed)",
+		},
+		[]string{"backend"},
+	)
+
+	// ========================================
+	// 依赖健康指标
+	// ========================================
+
+	// DependencyUp 最近一次就绪检查中依赖是否可用（1 可用，0 不可用）
+	DependencyUp = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "dependency_up",
+			Help: "Whether a dependency was reachable in the last readiness check (1 = up, 0 = down)",
+		},
+		[]string{"dependency"}, // mongodb, redis, mysql, qconfapi
+	)
+
+	// ========================================
+	// 使用计数聚合指标
+	// ========================================
+
+	// UsageQueueDepth 使用事件队列中待处理的事件数
+	UsageQueueDepth = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "token_usage_queue_depth",
+			Help: "Current number of token usage events waiting in the aggregator queue",
+		},
+	)
+
+	// UsagePendingTokens 已合并、等待写入的 token 计数条目数
+	UsagePendingTokens = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "token_usage_pending_tokens",
+			Help: "Current number of coalesced token usage entries waiting to be flushed",
+		},
+	)
+
+	// UsageEventsDroppedTotal 队列已满或聚合器已停止时丢弃的使用事件数
+	UsageEventsDroppedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "token_usage_events_dropped_total",
+			Help: "Total number of token usage events dropped because the aggregator queue was full or stopped",
+		},
+	)
+
+	// UsageFlushesTotal 批量写入次数
+	UsageFlushesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "token_usage_flushes_total",
+			Help: "Total number of token usage batch flushes",
+		},
+		[]string{"result"}, // success, error
+	)
+
+	// UsageFlushDuration 批量写入延迟
+	UsageFlushDuration = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "token_usage_flush_duration_seconds",
+			Help:    "Token usage batch flush latency in seconds",
+			Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+		},
+	)
+
+	// UsageFlushBatchSize 每次批量写入的 token 条目数
+	UsageFlushBatchSize = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "token_usage_flush_batch_size",
+			Help:    "Number of coalesced token entries per usage flush",
+			Buckets: []float64{1, 10, 50, 100, 250, 500, 1000, 2500, 5000},
+		},
+	)
+
 	// ========================================
 	// 业务指标
 	// ========================================
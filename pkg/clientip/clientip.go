@@ -0,0 +1,216 @@
+// Package clientip 根据可信代理链解析请求的真实客户端 IP
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// DefaultHeader 网关带外调用验证接口时转发调用方 IP 的默认请求头
+const DefaultHeader = "X-Auth-Client-IP"
+
+// Resolver 客户端 IP 解析器
+//
+// 按以下顺序解析：
+//  1. 调用方 IP 头（如 X-Auth-Client-IP）：仅当直连地址属于网关白名单（headerSources）时读取，
+//     可信代理透传的同名头不可信（代理通常不会清除客户端发送的该头）
+//  2. 直连地址不属于可信代理时直接使用直连地址（防止客户端伪造 X-Forwarded-For）
+//  3. X-Forwarded-For 从右向左跳过可信代理后的第一个地址
+//  4. X-Real-IP
+//
+// nil Resolver 等价于未配置可信代理和网关白名单
+type Resolver struct {
+	trusted       []netip.Prefix
+	header        string
+	headerSources []netip.Prefix
+}
+
+// New 创建解析器
+// trustedProxies: 可信代理地址（CIDR 或单个 IP）；header: 调用方 IP 请求头；
+// headerSources: 允许设置调用方 IP 头的网关地址（CIDR 或单个 IP），header 或 headerSources 为空时不读取该头
+func New(trustedProxies []string, header string, headerSources []string) (*Resolver, error) {
+	r := &Resolver{header: header}
+	for _, s := range trustedProxies {
+		prefix, err := ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", s, err)
+		}
+		r.trusted = append(r.trusted, prefix)
+	}
+	for _, s := range headerSources {
+		prefix, err := ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client IP header source %q: %w", s, err)
+		}
+		r.headerSources = append(r.headerSources, prefix)
+	}
+	return r, nil
+}
+
+// FromRequest 解析 HTTP 请求的客户端 IP，无法解析时返回空字符串
+func (r *Resolver) FromRequest(req *http.Request) string {
+	return r.Resolve(req.RemoteAddr, req.Header.Values)
+}
+
+// Resolve 根据直连地址（host:port 或 IP）和请求头解析客户端 IP
+// values 返回指定请求头的所有值（http.Header.Values、metadata.MD.Get 等）
+func (r *Resolver) Resolve(remoteAddr string, values func(name string) []string) string {
+	remote, ok := parseRemoteAddr(remoteAddr)
+	if !ok {
+		return ""
+	}
+
+	// 1. 网关显式转发的调用方 IP（只接受网关白名单中的直连地址设置的值）
+	if r.isHeaderSource(remote) {
+		if ip, ok := firstAddr(values(r.header)); ok {
+			return ip.String()
+		}
+	}
+
+	if !r.isTrusted(remote) {
+		return remote.String()
+	}
+
+	// 3. X-Forwarded-For：从右向左，第一个非可信代理的地址即客户端
+	var hops []string
+	for _, v := range values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	if len(hops) > 0 {
+		client := remote
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				// 无法解析的条目及其左侧内容不可信
+				return client.String()
+			}
+			client = ip.Unmap()
+			if !r.isTrusted(client) {
+				return client.String()
+			}
+		}
+		// 全部是可信代理，取最左侧地址
+		return client.String()
+	}
+
+	// 4. X-Real-IP
+	if ip, ok := firstAddr(values("X-Real-IP")); ok {
+		return ip.String()
+	}
+
+	return remote.String()
+}
+
+// Hops 返回请求经过的可验证地址链：直连地址，以及沿 X-Forwarded-For 从右向左的地址
+// 只有上一跳属于可信代理时才继续向左（不可信的一跳可以伪造其左侧的全部内容）
+// 用于判断请求是否经由某个可信来源（如内部网关）转发；直连地址无法解析时返回 nil
+func (r *Resolver) Hops(req *http.Request) []netip.Addr {
+	remote, ok := parseRemoteAddr(req.RemoteAddr)
+	if !ok {
+		return nil
+	}
+	hops := []netip.Addr{remote}
+	if !r.isTrusted(remote) {
+		return hops
+	}
+
+	var forwarded []string
+	for _, v := range req.Header.Values("X-Forwarded-For") {
+		forwarded = append(forwarded, strings.Split(v, ",")...)
+	}
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+		hops = append(hops, ip.Unmap())
+		if !r.isTrusted(ip.Unmap()) {
+			break
+		}
+	}
+	return hops
+}
+
+// CallerIP 返回直接调用本服务的一方的 IP：Hops 链的最后一跳（不读取调用方 IP 头），无法解析时返回空字符串
+// 用于按调用方限流（经可信代理转发时为代理之前的地址）
+func (r *Resolver) CallerIP(req *http.Request) string {
+	hops := r.Hops(req)
+	if len(hops) == 0 {
+		return ""
+	}
+	return hops[len(hops)-1].String()
+}
+
+// isTrusted 地址是否属于可信代理
+func (r *Resolver) isTrusted(ip netip.Addr) bool {
+	if r == nil {
+		return false
+	}
+	for _, prefix := range r.trusted {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHeaderSource 直连地址是否属于允许设置调用方 IP 头的网关
+func (r *Resolver) isHeaderSource(ip netip.Addr) bool {
+	if r == nil || r.header == "" {
+		return false
+	}
+	for _, prefix := range r.headerSources {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePrefix 解析 CIDR（如 10.0.0.0/8、2001:db8::/32），单个 IP 视为 /32 或 /128
+// 返回规范化（主机位清零）后的网段
+func ParsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		ip, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		ip = ip.Unmap()
+		return netip.PrefixFrom(ip, ip.BitLen()), nil
+	}
+
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return prefix.Masked(), nil
+}
+
+// parseRemoteAddr 解析直连地址（host:port 或不带端口的 IP）
+func parseRemoteAddr(addr string) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
+
+// firstAddr 解析请求头的第一个值
+func firstAddr(values []string) (netip.Addr, bool) {
+	if len(values) == 0 {
+		return netip.Addr{}, false
+	}
+	ip, err := netip.ParseAddr(strings.TrimSpace(values[0]))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
@@ -0,0 +1,124 @@
+package clientip
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolver_FromRequest(t *testing.T) {
+	resolver, err := New([]string{"10.0.0.0/8", "192.168.1.1"}, DefaultHeader, []string{"172.16.0.10"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{"Direct client", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"Untrusted peer cannot spoof", "203.0.113.7:5000", map[string]string{"X-Forwarded-For": "10.1.1.1", DefaultHeader: "10.1.1.1"}, "203.0.113.7"},
+		{"Forwarded by trusted proxy", "10.0.0.2:5000", map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.7"}, "203.0.113.7"},
+		{"Skip trusted hops", "10.0.0.2:5000", map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.7, 192.168.1.1"}, "203.0.113.7"},
+		{"All hops trusted", "10.0.0.2:5000", map[string]string{"X-Forwarded-For": "10.0.0.5, 10.0.0.4"}, "10.0.0.5"},
+		{"Invalid hop", "10.0.0.2:5000", map[string]string{"X-Forwarded-For": "203.0.113.7, garbage, 10.0.0.3"}, "10.0.0.3"},
+		{"X-Real-IP", "10.0.0.2:5000", map[string]string{"X-Real-IP": "203.0.113.7"}, "203.0.113.7"},
+		{"Caller IP header from gateway", "172.16.0.10:5000", map[string]string{"X-Forwarded-For": "10.0.0.9", DefaultHeader: "2001:db8::1"}, "2001:db8::1"},
+		{"Gateway without caller IP header", "172.16.0.10:5000", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "172.16.0.10"},
+		{"Trusted proxy passes through spoofed header", "10.0.0.2:5000", map[string]string{"X-Forwarded-For": "203.0.113.7", DefaultHeader: "10.9.9.9"}, "203.0.113.7"},
+		{"Spoofed header without forwarding", "10.0.0.2:5000", map[string]string{DefaultHeader: "10.9.9.9"}, "10.0.0.2"},
+		{"IPv6 peer", "[2001:db8::2]:5000", nil, "2001:db8::2"},
+		{"IPv4-mapped peer", "[::ffff:203.0.113.7]:5000", nil, "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v2/validate", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			assert.Equal(t, tt.want, resolver.FromRequest(req))
+		})
+	}
+}
+
+func TestResolver_NilUsesRemoteAddr(t *testing.T) {
+	var resolver *Resolver
+	req := httptest.NewRequest("POST", "/api/v2/validate", nil)
+	req.RemoteAddr = "10.0.0.2:5000"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+	assert.Equal(t, "10.0.0.2", resolver.FromRequest(req))
+}
+
+func TestResolver_Hops(t *testing.T) {
+	resolver, err := New([]string{"10.0.0.0/8"}, DefaultHeader, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		want       []string
+	}{
+		{"Untrusted peer stops the chain", "203.0.113.7:5000", "172.16.0.1", []string{"203.0.113.7"}},
+		{"Stop after first untrusted hop", "10.0.0.2:5000", "198.51.100.1, 172.16.0.1, 10.0.0.3", []string{"10.0.0.2", "10.0.0.3", "172.16.0.1"}},
+		{"Invalid hop", "10.0.0.2:5000", "172.16.0.1, garbage", []string{"10.0.0.2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v2/tokens", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", tt.forwarded)
+
+			var got []string
+			for _, hop := range resolver.Hops(req) {
+				got = append(got, hop.String())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResolver_CallerIP(t *testing.T) {
+	resolver, err := New([]string{"10.0.0.0/8"}, DefaultHeader, []string{"10.0.0.2"})
+	require.NoError(t, err)
+
+	// 调用方 IP 头不影响结果
+	req := httptest.NewRequest("POST", "/api/v2/validate/batch", nil)
+	req.RemoteAddr = "10.0.0.2:5000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1, 172.16.0.1")
+	req.Header.Set(DefaultHeader, "192.0.2.9")
+	assert.Equal(t, "172.16.0.1", resolver.CallerIP(req))
+
+	req.RemoteAddr = "203.0.113.7:5000"
+	assert.Equal(t, "203.0.113.7", resolver.CallerIP(req))
+
+	req.RemoteAddr = "garbage"
+	assert.Empty(t, resolver.CallerIP(req))
+}
+
+func TestParsePrefix(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"10.1.2.3/8", "10.0.0.0/8"},
+		{"192.168.1.10", "192.168.1.10/32"},
+		{"2001:db8::1", "2001:db8::1/128"},
+		{" 2001:db8::/32 ", "2001:db8::/32"},
+	}
+	for _, tt := range tests {
+		prefix, err := ParsePrefix(tt.in)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, prefix.String())
+	}
+
+	_, err := ParsePrefix("10.0.0.0/33")
+	assert.Error(t, err)
+	_, err = New([]string{"not-an-ip"}, "", nil)
+	assert.Error(t, err)
+	_, err = New(nil, DefaultHeader, []string{"not-an-ip"})
+	assert.Error(t, err)
+}
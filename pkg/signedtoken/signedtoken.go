@@ -0,0 +1,348 @@
+// Package signedtoken 签发和验证自包含的签名 Token（JWT，RFC 7519）
+//
+// 支持 EdDSA（Ed25519）和 ES256（ECDSA P-256）两种算法，公钥以 JWKS（RFC 7517）格式发布，
+// 资源服务可离线验证 Token；密钥轮换时新旧公钥同时发布，直到旧密钥签发的 Token 全部过期
+package signedtoken
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// 签名算法（JWS alg）
+const (
+	AlgEdDSA = "EdDSA"
+	AlgES256 = "ES256"
+)
+
+// TokenType JWS 头中的 typ（OAuth 2.0 Access Token JWT，RFC 9068）
+const TokenType = "at+jwt"
+
+// 验证错误
+var (
+	ErrMalformed        = errors.New("malformed signed token")
+	ErrUnknownKey       = errors.New("signed token key not found")
+	ErrInvalidSignature = errors.New("invalid signed token signature")
+	ErrInvalidIssuer    = errors.New("invalid signed token issuer")
+	ErrExpired          = errors.New("signed token has expired")
+)
+
+// Claims Token 携带的声明
+type Claims struct {
+	Issuer       string     `json:"iss,omitempty"`
+	Subject      string     `json:"sub"`                  // account_id
+	TokenID      string     `json:"jti"`                  // Token ID（吊销以此为准）
+	UID          string     `json:"uid,omitempty"`        // 七牛 UID（QiniuStub 用户）
+	IUID         string     `json:"iuid,omitempty"`       // IAM 子账号 ID
+	IamAlias     string     `json:"iam_alias,omitempty"`  // IAM 子账号名
+	ClientID     string     `json:"client_id,omitempty"`  // 签发 Token 的 OAuth 2.0 客户端（RFC 9068）
+	Scope        string     `json:"scope,omitempty"`      // 空格分隔的授权范围（RFC 8693）
+	AllowedCIDRs []string   `json:"cidrs,omitempty"`      // 允许使用的客户端 IP 范围
+	RateLimit    *RateLimit `json:"rate_limit,omitempty"` // Token 层限流配置（签名 Token 验证不查询数据库）
+	RateLimitKey string     `json:"rlk,omitempty"`        // 共享限流额度的 Token ID（Token 交换签发的 Token），为空时使用 jti
+	IssuedAt     int64      `json:"iat"`
+	ExpiresAt    int64      `json:"exp"`
+}
+
+// RateLimit Token 层限流配置（与 interfaces.RateLimit 对应，0 表示该窗口不限制）
+type RateLimit struct {
+	RequestsPerMinute int `json:"rpm,omitempty"`
+	RequestsPerHour   int `json:"rph,omitempty"`
+	RequestsPerDay    int `json:"rpd,omitempty"`
+}
+
+// Scopes 授权范围列表
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// JWK 公钥（RFC 7517）
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y,omitempty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+}
+
+// JWKS 公钥集合，即 /.well-known/jwks.json 的响应
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// key 签名密钥
+type key struct {
+	id     string
+	alg    string
+	signer crypto.Signer
+	jwk    JWK
+}
+
+// KeySet 签名密钥集合：第一个密钥用于签发，所有密钥都用于验证并发布到 JWKS
+type KeySet struct {
+	issuer string
+	keys   []*key
+	byID   map[string]*key
+}
+
+// jwsHeader JWS 头
+type jwsHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+	KeyID     string `json:"kid"`
+}
+
+// LoadKeySet 从 PEM 私钥文件加载密钥集合（PKCS#8 或 SEC 1 格式）
+// issuer 非空时写入 iss 并在验证时校验
+func LoadKeySet(issuer string, paths []string) (*KeySet, error) {
+	signers := make([]crypto.Signer, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read signing key %s: %w", path, err)
+		}
+		signer, err := parsePrivateKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse signing key %s: %w", path, err)
+		}
+		signers = append(signers, signer)
+	}
+	return NewKeySet(issuer, signers...)
+}
+
+// NewKeySet 创建密钥集合，支持 ed25519.PrivateKey 和 P-256 *ecdsa.PrivateKey
+func NewKeySet(issuer string, signers ...crypto.Signer) (*KeySet, error) {
+	if len(signers) == 0 {
+		return nil, errors.New("at least one signing key is required")
+	}
+
+	ks := &KeySet{issuer: issuer, byID: make(map[string]*key, len(signers))}
+	for _, signer := range signers {
+		k, err := newKey(signer)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := ks.byID[k.id]; ok {
+			return nil, fmt.Errorf("duplicate signing key %s", k.id)
+		}
+		ks.keys = append(ks.keys, k)
+		ks.byID[k.id] = k
+	}
+	return ks, nil
+}
+
+// SigningKeyID 当前签发密钥的 kid
+func (ks *KeySet) SigningKeyID() string {
+	return ks.keys[0].id
+}
+
+// JWKS 返回所有公钥
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(ks.keys))}
+	for _, k := range ks.keys {
+		set.Keys = append(set.Keys, k.jwk)
+	}
+	return set
+}
+
+// Sign 使用当前签发密钥签名
+func (ks *KeySet) Sign(claims *Claims) (string, error) {
+	k := ks.keys[0]
+	if ks.issuer != "" {
+		claims.Issuer = ks.issuer
+	}
+
+	header, err := json.Marshal(jwsHeader{Algorithm: k.alg, Type: TokenType, KeyID: k.id})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := encodeSegment(header) + "." + encodeSegment(payload)
+	signature, err := k.sign([]byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + encodeSegment(signature), nil
+}
+
+// Verify 验证签名并解析声明
+// Token 已过期时同时返回声明和 ErrExpired（签名有效），便于调用方区分过期与伪造
+func (ks *KeySet) Verify(value string, now time.Time) (*Claims, error) {
+	parts := strings.Split(value, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	headerJSON, err := decodeSegment(parts[0])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	var header jwsHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, ErrMalformed
+	}
+
+	k, ok := ks.byID[header.KeyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	// 算法由密钥决定，不信任头中的 alg（防止算法混淆）
+	if header.Algorithm != k.alg {
+		return nil, ErrInvalidSignature
+	}
+
+	signature, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if !k.verify([]byte(parts[0]+"."+parts[1]), signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	payload, err := decodeSegment(parts[1])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrMalformed
+	}
+	if claims.TokenID == "" || claims.Subject == "" || claims.ExpiresAt == 0 {
+		return nil, ErrMalformed
+	}
+	if ks.issuer != "" && claims.Issuer != ks.issuer {
+		return nil, ErrInvalidIssuer
+	}
+	if !now.Before(time.Unix(claims.ExpiresAt, 0)) {
+		return &claims, ErrExpired
+	}
+	return &claims, nil
+}
+
+// LooksSigned 值是否具有 JWS 紧凑格式（用于区分签名 Token 和不透明 Token，不做任何验证）
+// 不透明 Token 由前缀和十六进制字符组成，不含 "."
+func LooksSigned(value string) bool {
+	return strings.HasPrefix(value, "eyJ") && strings.Count(value, ".") == 2
+}
+
+// ========================================
+// 辅助函数
+// ========================================
+
+// newKey 根据私钥类型确定算法并计算 kid（RFC 7638 JWK 指纹）
+func newKey(signer crypto.Signer) (*key, error) {
+	var jwk JWK
+	var alg string
+	switch pub := signer.Public().(type) {
+	case ed25519.PublicKey:
+		alg = AlgEdDSA
+		jwk = JWK{KeyType: "OKP", Curve: "Ed25519", X: encodeSegment(pub)}
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("unsupported ECDSA curve %s, only P-256 is supported", pub.Curve.Params().Name)
+		}
+		alg = AlgES256
+		jwk = JWK{KeyType: "EC", Curve: "P-256", X: encodeSegment(pub.X.FillBytes(make([]byte, 32))), Y: encodeSegment(pub.Y.FillBytes(make([]byte, 32)))}
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %T, expected Ed25519 or ECDSA P-256", pub)
+	}
+
+	// 指纹只包含必需成员，按字典序排列
+	var thumbprintInput string
+	if jwk.KeyType == "OKP" {
+		thumbprintInput = fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q}`, jwk.Curve, jwk.KeyType, jwk.X)
+	} else {
+		thumbprintInput = fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q,"y":%q}`, jwk.Curve, jwk.KeyType, jwk.X, jwk.Y)
+	}
+	sum := sha256.Sum256([]byte(thumbprintInput))
+
+	jwk.KeyID = encodeSegment(sum[:])
+	jwk.Algorithm = alg
+	jwk.Use = "sig"
+	return &key{id: jwk.KeyID, alg: alg, signer: signer, jwk: jwk}, nil
+}
+
+// sign 签名；ES256 使用 JWS 规定的 R || S 定长格式（RFC 7518 3.4）而非 ASN.1
+func (k *key) sign(input []byte) ([]byte, error) {
+	if k.alg == AlgEdDSA {
+		return k.signer.Sign(rand.Reader, input, crypto.Hash(0))
+	}
+
+	digest := sha256.Sum256(input)
+	r, s, err := ecdsa.Sign(rand.Reader, k.signer.(*ecdsa.PrivateKey), digest[:])
+	if err != nil {
+		return nil, err
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return signature, nil
+}
+
+// verify 验证签名
+func (k *key) verify(input, signature []byte) bool {
+	if k.alg == AlgEdDSA {
+		return ed25519.Verify(k.signer.Public().(ed25519.PublicKey), input, signature)
+	}
+
+	if len(signature) != 64 {
+		return false
+	}
+	digest := sha256.Sum256(input)
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	return ecdsa.Verify(k.signer.Public().(*ecdsa.PublicKey), digest[:], r, s)
+}
+
+// parsePrivateKey 解析 PEM 私钥
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSegment(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
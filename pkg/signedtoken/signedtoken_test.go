@@ -0,0 +1,166 @@
+package signedtoken
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEd25519Key(t *testing.T) crypto.Signer {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return priv
+}
+
+func newES256Key(t *testing.T) crypto.Signer {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return priv
+}
+
+func testClaims(expiresAt time.Time) *Claims {
+	return &Claims{
+		Subject:   "qiniu_12345",
+		TokenID:   "tk_abc",
+		UID:       "12345",
+		Scope:     "storage:read cdn:*",
+		IssuedAt:  time.Now().Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	}
+}
+
+func TestKeySet_SignAndVerify(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		key  crypto.Signer
+		alg  string
+	}{
+		{"EdDSA", newEd25519Key(t), AlgEdDSA},
+		{"ES256", newES256Key(t), AlgES256},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ks, err := NewKeySet("https://auth.example.com", tt.key)
+			require.NoError(t, err)
+
+			value, err := ks.Sign(testClaims(time.Now().Add(time.Hour)))
+			require.NoError(t, err)
+			assert.True(t, LooksSigned(value))
+
+			claims, err := ks.Verify(value, time.Now())
+			require.NoError(t, err)
+			assert.Equal(t, "tk_abc", claims.TokenID)
+			assert.Equal(t, "qiniu_12345", claims.Subject)
+			assert.Equal(t, "https://auth.example.com", claims.Issuer)
+			assert.Equal(t, []string{"storage:read", "cdn:*"}, claims.Scopes())
+
+			jwks := ks.JWKS()
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, tt.alg, jwks.Keys[0].Algorithm)
+			assert.Equal(t, ks.SigningKeyID(), jwks.Keys[0].KeyID)
+		})
+	}
+}
+
+func TestKeySet_VerifyRejects(t *testing.T) {
+	ks, err := NewKeySet("", newEd25519Key(t))
+	require.NoError(t, err)
+	other, err := NewKeySet("", newEd25519Key(t))
+	require.NoError(t, err)
+	issued, err := NewKeySet("other-issuer", ks.keys[0].signer)
+	require.NoError(t, err)
+	strict, err := NewKeySet("expected-issuer", ks.keys[0].signer)
+	require.NoError(t, err)
+
+	valid, err := ks.Sign(testClaims(time.Now().Add(time.Hour)))
+	require.NoError(t, err)
+	expired, err := ks.Sign(testClaims(time.Now().Add(-time.Minute)))
+	require.NoError(t, err)
+	foreign, err := other.Sign(testClaims(time.Now().Add(time.Hour)))
+	require.NoError(t, err)
+	wrongIssuer, err := issued.Sign(testClaims(time.Now().Add(time.Hour)))
+	require.NoError(t, err)
+
+	// 篡改载荷（签名不变）
+	parts := strings.Split(valid, ".")
+	tamperedClaims := testClaims(time.Now().Add(time.Hour))
+	tamperedClaims.Scope = "*"
+	forged, err := ks.Sign(tamperedClaims)
+	require.NoError(t, err)
+	tampered := parts[0] + "." + strings.Split(forged, ".")[1] + "." + parts[2]
+
+	tests := []struct {
+		name    string
+		ks      *KeySet
+		value   string
+		wantErr error
+	}{
+		{"opaque token", ks, "sk-0123456789abcdef", ErrMalformed},
+		{"tampered payload", ks, tampered, ErrInvalidSignature},
+		{"signed by unknown key", ks, foreign, ErrUnknownKey},
+		{"expired", ks, expired, ErrExpired},
+		{"issuer mismatch", strict, wrongIssuer, ErrInvalidIssuer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.ks.Verify(tt.value, time.Now())
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldKey, newKey := newEd25519Key(t), newES256Key(t)
+
+	before, err := NewKeySet("", oldKey)
+	require.NoError(t, err)
+	issuedBefore, err := before.Sign(testClaims(time.Now().Add(time.Hour)))
+	require.NoError(t, err)
+
+	// 新密钥放在第一位签发，旧密钥保留用于验证
+	after, err := NewKeySet("", newKey, oldKey)
+	require.NoError(t, err)
+	issuedAfter, err := after.Sign(testClaims(time.Now().Add(time.Hour)))
+	require.NoError(t, err)
+
+	_, err = after.Verify(issuedBefore, time.Now())
+	assert.NoError(t, err)
+	_, err = after.Verify(issuedAfter, time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, after.JWKS().Keys, 2)
+	assert.NotEqual(t, before.SigningKeyID(), after.SigningKeyID())
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+
+	edDER, err := x509.MarshalPKCS8PrivateKey(newEd25519Key(t))
+	require.NoError(t, err)
+	edPath := filepath.Join(dir, "ed25519.pem")
+	require.NoError(t, os.WriteFile(edPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: edDER}), 0o600))
+
+	ecDER, err := x509.MarshalECPrivateKey(newES256Key(t).(*ecdsa.PrivateKey))
+	require.NoError(t, err)
+	ecPath := filepath.Join(dir, "es256.pem")
+	require.NoError(t, os.WriteFile(ecPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER}), 0o600))
+
+	ks, err := LoadKeySet("", []string{edPath, ecPath})
+	require.NoError(t, err)
+	assert.Equal(t, AlgEdDSA, ks.JWKS().Keys[0].Algorithm)
+	assert.Equal(t, AlgES256, ks.JWKS().Keys[1].Algorithm)
+
+	_, err = LoadKeySet("", []string{filepath.Join(dir, "missing.pem")})
+	assert.Error(t, err)
+}
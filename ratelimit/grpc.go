@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/observability"
+	"github.com/qiniu/bearer-token-service/v2/pkg/signedtoken"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ========================================
+// gRPC 限流拦截器
+// ========================================
+
+// tokenRequest 携带 token 值的请求消息（如 validationpb.ValidateTokenRequest）
+type tokenRequest interface {
+	GetToken() string
+}
+
+// UnaryServerInterceptor gRPC 限流拦截器
+// 与 HTTP 路径使用同一个 RateLimitManager：先检查应用层限流，再对携带不透明 token 的请求检查 Token 层限流
+// 超限时返回 codes.ResourceExhausted，并通过响应头 metadata 返回 retry-after（秒）
+func (m *Middleware) UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	// 1. 应用层限流
+	if m.manager.IsAppLimitEnabled() {
+		start := time.Now()
+		allowed, _, resetTime, err := m.manager.CheckAppLimit(ctx)
+		observability.RateLimitCheckDuration.Observe(time.Since(start).Seconds())
+
+		if err != nil {
+			return nil, status.Error(codes.Internal, "Rate limit check failed")
+		}
+		if !allowed {
+			observability.RateLimitHitsTotal.WithLabelValues("app").Inc()
+			return nil, rateLimitExceeded(ctx, resetTime, "Application rate limit exceeded")
+		}
+	}
+
+	// 2. Token 层限流
+	if m.manager.IsTokenLimitEnabled() {
+		// 签名 Token 不查询数据库，由验证服务按声明中的配置限流
+		if r, ok := req.(tokenRequest); ok && r.GetToken() != "" && !signedtoken.LooksSigned(r.GetToken()) {
+			// 无法获取 Token 信息时跳过限流（让后续验证逻辑处理）
+			token, err := m.tokenRepo.GetByTokenValue(ctx, r.GetToken())
+			if err == nil && token != nil {
+				start := time.Now()
+				allowed, _, resetTime, err := m.manager.CheckTokenLimit(ctx, token.LimiterKey(), token.RateLimit)
+				observability.RateLimitCheckDuration.Observe(time.Since(start).Seconds())
+
+				if err != nil {
+					return nil, status.Error(codes.Internal, "Rate limit check failed")
+				}
+				if !allowed {
+					observability.RateLimitHitsTotal.WithLabelValues("token").Inc()
+					return nil, rateLimitExceeded(ctx, resetTime, "Token rate limit exceeded")
+				}
+			}
+		}
+	}
+
+	return handler(ctx, req)
+}
+
+// rateLimitExceeded 设置 retry-after 响应头并返回 ResourceExhausted 错误
+func rateLimitExceeded(ctx context.Context, resetTime time.Time, message string) error {
+	retryAfter := time.Until(resetTime).Seconds()
+	if retryAfter < 0 {
+		retryAfter = 0
+	}
+	grpc.SetHeader(ctx, metadata.Pairs("retry-after", fmt.Sprintf("%.0f", retryAfter)))
+	return status.Error(codes.ResourceExhausted, message)
+}
@@ -347,6 +347,9 @@ type RateLimitManager struct {
 	// 应用层限流配置
 	appLimit *interfaces.RateLimit
 
+	// 批量验证接口按调用方 IP 的限流配置（nil 表示关闭）
+	batchValidateLimit *interfaces.RateLimit
+
 	// 功能开关
 	enableAppLimit     bool
 	enableAccountLimit bool
@@ -358,6 +361,9 @@ type RateLimitConfig struct {
 	// 应用层限流配置
 	AppLimit *interfaces.RateLimit
 
+	// 批量验证接口按调用方 IP 的限流配置（nil 表示关闭）
+	BatchValidateLimit *interfaces.RateLimit
+
 	// 功能开关
 	EnableAppLimit     bool
 	EnableAccountLimit bool
@@ -369,6 +375,7 @@ func NewRateLimitManager(limiter Limiter, config RateLimitConfig) *RateLimitMana
 	return &RateLimitManager{
 		limiter:            limiter,
 		appLimit:           config.AppLimit,
+		batchValidateLimit: config.BatchValidateLimit,
 		enableAppLimit:     config.EnableAppLimit,
 		enableAccountLimit: config.EnableAccountLimit,
 		enableTokenLimit:   config.EnableTokenLimit,
@@ -404,6 +411,16 @@ func (m *RateLimitManager) CheckTokenLimit(ctx context.Context, tokenID string,
 	return m.limiter.Allow(ctx, key, limit)
 }
 
+// CheckBatchValidateLimit 检查批量验证接口的调用方 IP 限流
+func (m *RateLimitManager) CheckBatchValidateLimit(ctx context.Context, callerIP string) (bool, int, time.Time, error) {
+	if m.batchValidateLimit == nil {
+		return true, -1, time.Time{}, nil
+	}
+
+	key := fmt.Sprintf("batch:ip:%s", callerIP)
+	return m.limiter.Allow(ctx, key, m.batchValidateLimit)
+}
+
 // IsAppLimitEnabled 应用层限流是否启用
 func (m *RateLimitManager) IsAppLimitEnabled() bool {
 	return m.enableAppLimit
@@ -414,6 +431,11 @@ func (m *RateLimitManager) IsAccountLimitEnabled() bool {
 	return m.enableAccountLimit
 }
 
+// IsBatchValidateLimitEnabled 批量验证接口的调用方 IP 限流是否启用
+func (m *RateLimitManager) IsBatchValidateLimitEnabled() bool {
+	return m.batchValidateLimit != nil
+}
+
 // IsTokenLimitEnabled Token层限流是否启用
 func (m *RateLimitManager) IsTokenLimitEnabled() bool {
 	return m.enableTokenLimit
@@ -9,6 +9,7 @@ import (
 
 	"github.com/qiniu/bearer-token-service/v2/interfaces"
 	"github.com/qiniu/bearer-token-service/v2/observability"
+	"github.com/qiniu/bearer-token-service/v2/pkg/signedtoken"
 )
 
 // ========================================
@@ -159,6 +160,12 @@ func (m *Middleware) TokenLimitMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// 签名 Token 不查询数据库，由验证服务按声明中的配置限流
+		if signedtoken.LooksSigned(tokenValue) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		// 获取 Token 信息
 		token, err := m.tokenRepo.GetByTokenValue(ctx, tokenValue)
 		if err != nil || token == nil {
@@ -169,7 +176,7 @@ func (m *Middleware) TokenLimitMiddleware(next http.Handler) http.Handler {
 
 		// 检查 Token 限流
 		start := time.Now()
-		allowed, remaining, resetTime, err := m.manager.CheckTokenLimit(ctx, token.ID, token.RateLimit)
+		allowed, remaining, resetTime, err := m.manager.CheckTokenLimit(ctx, token.LimiterKey(), token.RateLimit)
 
 		// 记录限流检查耗时
 		observability.RateLimitCheckDuration.Observe(time.Since(start).Seconds())
@@ -205,6 +212,59 @@ func (m *Middleware) TokenLimitMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// BatchValidateLimitMiddleware 批量验证接口的调用方 IP 限流中间件（用于 /validate/batch 接口）
+// 该接口未认证且单次可验证多个 token，按调用方 IP 限制请求数；callerIP 解析调用方地址
+func (m *Middleware) BatchValidateLimitMiddleware(callerIP func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.manager.IsBatchValidateLimitEnabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		ip := callerIP(r)
+		if ip == "" {
+			m.respondError(w, http.StatusBadRequest, "Unable to determine caller address")
+			return
+		}
+
+		start := time.Now()
+		allowed, remaining, resetTime, err := m.manager.CheckBatchValidateLimit(ctx, ip)
+
+		// 记录限流检查耗时
+		observability.RateLimitCheckDuration.Observe(time.Since(start).Seconds())
+
+		// 设置限流响应头
+		if remaining >= 0 {
+			w.Header().Set("X-RateLimit-Limit-Batch", fmt.Sprintf("%d", m.manager.batchValidateLimit.RequestsPerMinute))
+			w.Header().Set("X-RateLimit-Remaining-Batch", fmt.Sprintf("%d", remaining))
+			if !resetTime.IsZero() {
+				w.Header().Set("X-RateLimit-Reset-Batch", fmt.Sprintf("%d", resetTime.Unix()))
+			}
+		}
+
+		if err != nil {
+			m.respondError(w, http.StatusInternalServerError, "Rate limit check failed")
+			return
+		}
+
+		if !allowed {
+			// 记录限流命中
+			observability.RateLimitHitsTotal.WithLabelValues("batch_validate").Inc()
+
+			retryAfter := time.Until(resetTime).Seconds()
+			if retryAfter < 0 {
+				retryAfter = 0
+			}
+			w.Header().Set("Retry-After", fmt.Sprintf("%.0f", retryAfter))
+			m.respondError(w, http.StatusTooManyRequests, "Batch validation rate limit exceeded")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 // respondError 返回错误响应
 func (m *Middleware) respondError(w http.ResponseWriter, statusCode int, message string) {
 	w.Header().Set("Content-Type", "application/json")
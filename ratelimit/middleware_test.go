@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/proto/validationpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// stubTokenRepo 只实现 GetByTokenValue，记录查询过的 token 值
+type stubTokenRepo struct {
+	interfaces.TokenRepository
+
+	tokens  map[string]*interfaces.Token
+	lookups []string
+}
+
+func (r *stubTokenRepo) GetByTokenValue(ctx context.Context, tokenValue string) (*interfaces.Token, error) {
+	r.lookups = append(r.lookups, tokenValue)
+	return r.tokens[tokenValue], nil
+}
+
+// testSignedValue JWS 紧凑格式的 token 值（中间件只按格式区分，不验证签名）
+const testSignedValue = "eyJhbGciOiJFZERTQSJ9.eyJqdGkiOiJ0a18xIn0.c2ln"
+
+func TestBatchValidateLimitMiddleware(t *testing.T) {
+	limiter := NewMemoryLimiter()
+	defer limiter.Stop()
+	manager := NewRateLimitManager(limiter, RateLimitConfig{
+		BatchValidateLimit: &interfaces.RateLimit{RequestsPerMinute: 2},
+	})
+	m := NewMiddleware(manager, nil, nil)
+
+	callerIP := func(r *http.Request) string { return r.Header.Get("X-Test-Caller") }
+	handler := m.BatchValidateLimitMiddleware(callerIP, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	call := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v2/validate/batch", nil)
+		req.Header.Set("X-Test-Caller", ip)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, call("203.0.113.7").Code)
+	assert.Equal(t, http.StatusOK, call("203.0.113.7").Code)
+
+	w := call("203.0.113.7")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit-Batch"))
+
+	// 各调用方独立计数
+	assert.Equal(t, http.StatusOK, call("198.51.100.1").Code)
+
+	// 无法解析调用方地址时拒绝
+	assert.Equal(t, http.StatusBadRequest, call("").Code)
+}
+
+func TestBatchValidateLimitMiddleware_Disabled(t *testing.T) {
+	limiter := NewMemoryLimiter()
+	defer limiter.Stop()
+	m := NewMiddleware(NewRateLimitManager(limiter, RateLimitConfig{}), nil, nil)
+
+	handler := m.BatchValidateLimitMiddleware(func(*http.Request) string { return "" }, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v2/validate/batch", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+}
+
+func TestTokenLimitMiddleware_SkipsSignedTokens(t *testing.T) {
+	limiter := NewMemoryLimiter()
+	defer limiter.Stop()
+	repo := &stubTokenRepo{tokens: map[string]*interfaces.Token{
+		"sk-a": {ID: "tk_1", RateLimit: &interfaces.RateLimit{RequestsPerMinute: 1}},
+	}}
+	m := NewMiddleware(NewRateLimitManager(limiter, RateLimitConfig{EnableTokenLimit: true}), nil, repo)
+
+	handler := m.TokenLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	call := func(value string) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, SetTokenToContext(httptest.NewRequest("POST", "/api/v2/validate", nil), value))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, call("sk-a"))
+	assert.Equal(t, http.StatusTooManyRequests, call("sk-a"))
+
+	// 签名 Token 不查询数据库，交给验证服务限流
+	assert.Equal(t, http.StatusOK, call(testSignedValue))
+	assert.Equal(t, []string{"sk-a", "sk-a"}, repo.lookups)
+}
+
+func TestUnaryServerInterceptor_SkipsSignedTokens(t *testing.T) {
+	limiter := NewMemoryLimiter()
+	defer limiter.Stop()
+	repo := &stubTokenRepo{tokens: map[string]*interfaces.Token{
+		"sk-a": {ID: "tk_1", RateLimit: &interfaces.RateLimit{RequestsPerMinute: 1}},
+	}}
+	m := NewMiddleware(NewRateLimitManager(limiter, RateLimitConfig{EnableTokenLimit: true}), nil, repo)
+
+	handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+	call := func(value string) error {
+		_, err := m.UnaryServerInterceptor(context.Background(), &validationpb.ValidateTokenRequest{Token: value}, &grpc.UnaryServerInfo{}, handler)
+		return err
+	}
+
+	require.NoError(t, call("sk-a"))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(call("sk-a")))
+
+	require.NoError(t, call(testSignedValue))
+	assert.Equal(t, []string{"sk-a", "sk-a"}, repo.lookups)
+}
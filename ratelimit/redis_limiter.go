@@ -0,0 +1,203 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/observability"
+)
+
+// ========================================
+// Redis 分布式限流器实现
+// 分钟窗口使用有序集合滑动窗口（内存受分钟限额约束）
+// 小时/天窗口使用固定窗口计数器（每个窗口一个整数，按 UTC 对齐）
+// ========================================
+
+// RedisScripter Redis 脚本执行接口（cache.RedisClient 满足该接口，避免循环依赖）
+type RedisScripter interface {
+	Eval(ctx context.Context, script string, keys []string, args ...any) (any, error)
+}
+
+// redisRetryInterval Redis 出错后降级到内存限流器的时长，期间不再访问 Redis
+const redisRetryInterval = 5 * time.Second
+
+// 窗口类型（与 limitScript 中的 kind 参数对应）
+const (
+	windowSliding = 0 // 有序集合滑动窗口，成员数不超过窗口限额
+	windowFixed   = 1 // 固定窗口计数器，key 中带窗口序号，窗口结束时过期
+)
+
+// limitScript 原子地检查并记录多个时间窗口
+// KEYS: 每个窗口一个 key（滑动窗口为有序集合，score 为请求时间戳毫秒；固定窗口为计数器）
+// ARGV[1]: 当前时间（毫秒），ARGV[2]: 本次请求的唯一成员（仅滑动窗口使用）
+// ARGV[3..]: 每个窗口的 (limit, window_ms, kind)，与 KEYS 一一对应
+// 返回: {allowed(0/1), remaining, reset_ms}
+const limitScript = `
+local now = tonumber(ARGV[1])
+local member = ARGV[2]
+
+-- 1. 检查是否超限（任一窗口超限即拒绝，不记录本次请求）
+for i = 1, #KEYS do
+	local limit = tonumber(ARGV[3 * i])
+	local window = tonumber(ARGV[3 * i + 1])
+	if ARGV[3 * i + 2] == '1' then
+		local count = tonumber(redis.call('GET', KEYS[i]) or '0')
+		if count >= limit then
+			return {0, 0, (math.floor(now / window) + 1) * window}
+		end
+	else
+		redis.call('ZREMRANGEBYSCORE', KEYS[i], '-inf', now - window)
+		if redis.call('ZCARD', KEYS[i]) >= limit then
+			local reset = now
+			local oldest = redis.call('ZRANGE', KEYS[i], 0, 0, 'WITHSCORES')
+			if oldest[2] then
+				reset = tonumber(oldest[2]) + window
+			end
+			return {0, 0, reset}
+		end
+	end
+end
+
+-- 2. 所有窗口通过，记录请求并计算剩余数（取最小值）和最早重置时间
+local remaining = -1
+local reset = 0
+for i = 1, #KEYS do
+	local limit = tonumber(ARGV[3 * i])
+	local window = tonumber(ARGV[3 * i + 1])
+	local r, t
+	if ARGV[3 * i + 2] == '1' then
+		t = (math.floor(now / window) + 1) * window
+		r = limit - redis.call('INCR', KEYS[i])
+		redis.call('PEXPIREAT', KEYS[i], t)
+	else
+		redis.call('ZADD', KEYS[i], now, member)
+		redis.call('PEXPIRE', KEYS[i], window)
+		r = limit - redis.call('ZCARD', KEYS[i])
+		local oldest = redis.call('ZRANGE', KEYS[i], 0, 0, 'WITHSCORES')
+		t = tonumber(oldest[2]) + window
+	end
+
+	if remaining == -1 or r < remaining then
+		remaining = r
+	end
+	if reset == 0 or t < reset then
+		reset = t
+	end
+end
+return {1, remaining, reset}
+`
+
+// RedisLimiter Redis 分布式限流器
+// 多副本共享同一组计数：分钟级为滑动窗口（与 MemoryLimiter 一致），
+// 小时/天级为固定窗口（在 UTC 整点/零点重置），避免为每个请求保存一条记录
+// Redis 不可用时降级到本地 fallback 限流器
+type RedisLimiter struct {
+	redis    RedisScripter
+	fallback Limiter
+	now      func() time.Time // 便于测试注入时钟
+
+	// Redis 出错后在该时间点（UnixNano）之前直接使用 fallback
+	degradedUntil atomic.Int64
+}
+
+// NewRedisLimiter 创建 Redis 限流器
+// fallback 为 Redis 不可用时使用的限流器（通常是 MemoryLimiter）
+func NewRedisLimiter(redis RedisScripter, fallback Limiter) *RedisLimiter {
+	return &RedisLimiter{
+		redis:    redis,
+		fallback: fallback,
+		now:      time.Now,
+	}
+}
+
+// Allow 检查是否允许请求
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit *interfaces.RateLimit) (bool, int, time.Time, error) {
+	if limit == nil {
+		// 没有限流配置，直接允许
+		return true, -1, time.Time{}, nil
+	}
+
+	now := l.now()
+
+	// 收集已配置的窗口；使用 hash tag 保证集群模式下同一 key 的窗口位于同一 slot
+	// 固定窗口的 key 带窗口序号，窗口切换时自然使用新计数器
+	var keys []string
+	var windowArgs []any
+	addWindow := func(name string, n int, d time.Duration, kind int) {
+		if n <= 0 {
+			return
+		}
+		k := fmt.Sprintf("ratelimit:{%s}:%s", key, name)
+		if kind == windowFixed {
+			k += ":" + strconv.FormatInt(now.UnixMilli()/d.Milliseconds(), 10)
+		}
+		keys = append(keys, k)
+		windowArgs = append(windowArgs, n, d.Milliseconds(), kind)
+	}
+	addWindow("minute", limit.RequestsPerMinute, 1*time.Minute, windowSliding)
+	addWindow("hour", limit.RequestsPerHour, 1*time.Hour, windowFixed)
+	addWindow("day", limit.RequestsPerDay, 24*time.Hour, windowFixed)
+
+	if len(keys) == 0 {
+		return true, -1, time.Time{}, nil
+	}
+
+	// 降级期间直接使用本地限流器
+	if now.UnixNano() < l.degradedUntil.Load() {
+		return l.fallback.Allow(ctx, key, limit)
+	}
+
+	args := append([]any{now.UnixMilli(), requestMember(now)}, windowArgs...)
+
+	res, err := l.redis.Eval(ctx, limitScript, keys, args...)
+	if err == nil {
+		allowed, remaining, reset, parseErr := parseScriptResult(res)
+		if parseErr == nil {
+			return allowed, remaining, time.UnixMilli(reset), nil
+		}
+		err = parseErr
+	}
+
+	// Redis 不可用：进入降级期并使用本地限流器
+	if l.degradedUntil.Swap(now.Add(redisRetryInterval).UnixNano()) < now.UnixNano() {
+		observability.LogWarn(ctx, "Redis rate limiter unavailable, falling back to memory limiter",
+			slog.String("error", err.Error()),
+			slog.Duration("retry_after", redisRetryInterval))
+	}
+	observability.RateLimitFallbackTotal.Inc()
+
+	return l.fallback.Allow(ctx, key, limit)
+}
+
+// requestMember 生成有序集合成员（同一毫秒内的多个请求需要区分）
+func requestMember(now time.Time) string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return strconv.FormatInt(now.UnixNano(), 10) + "-" + hex.EncodeToString(b)
+}
+
+// parseScriptResult 解析脚本返回值 {allowed, remaining, reset_ms}
+func parseScriptResult(res any) (bool, int, int64, error) {
+	values, ok := res.([]any)
+	if !ok || len(values) != 3 {
+		return false, 0, 0, fmt.Errorf("unexpected rate limit script result: %v", res)
+	}
+
+	nums := make([]int64, len(values))
+	for i, v := range values {
+		n, ok := v.(int64)
+		if !ok {
+			return false, 0, 0, fmt.Errorf("unexpected rate limit script result: %v", res)
+		}
+		nums[i] = n
+	}
+
+	return nums[0] == 1, int(nums[1]), nums[2], nil
+}
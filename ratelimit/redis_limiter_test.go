@@ -0,0 +1,221 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+)
+
+// goRedisScripter 将 go-redis 客户端适配为 RedisScripter
+type goRedisScripter struct {
+	client *redis.Client
+}
+
+func (s *goRedisScripter) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+	return s.client.Eval(ctx, script, keys, args...).Result()
+}
+
+// failingScripter 始终返回错误，模拟 Redis 不可用
+type failingScripter struct{ calls int }
+
+func (s *failingScripter) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+	s.calls++
+	return nil, errors.New("connection refused")
+}
+
+// fakeClock 可手动推进的时钟，同时推进 miniredis 的过期时间
+type fakeClock struct {
+	now time.Time
+	mr  *miniredis.Miniredis
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	c.mr.SetTime(c.now)
+	c.mr.FastForward(d)
+}
+
+func newTestRedisLimiter(t *testing.T) (*RedisLimiter, *miniredis.Miniredis, *fakeClock) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	// miniredis 不支持 CLIENT SETINFO
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), DisableIndentity: true})
+	t.Cleanup(func() { client.Close() })
+
+	// 从某个 UTC 整点后 10 分钟开始，便于构造窗口切换
+	start := time.Date(2024, 3, 1, 10, 10, 0, 0, time.UTC)
+	clock := &fakeClock{now: start, mr: mr}
+	mr.SetTime(start)
+
+	limiter := NewRedisLimiter(&goRedisScripter{client: client}, NewMemoryLimiter())
+	limiter.now = clock.Now
+	return limiter, mr, clock
+}
+
+func TestRedisLimiter_MinuteSlidingWindow(t *testing.T) {
+	limiter, _, clock := newTestRedisLimiter(t)
+	ctx := context.Background()
+	limit := &interfaces.RateLimit{RequestsPerMinute: 2}
+
+	allowed, remaining, _, err := limiter.Allow(ctx, "tk", limit)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 1, remaining)
+
+	clock.Advance(30 * time.Second)
+	allowed, remaining, _, err = limiter.Allow(ctx, "tk", limit)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 0, remaining)
+
+	allowed, _, reset, err := limiter.Allow(ctx, "tk", limit)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	// 最早的请求在 30 秒前，滑动窗口在其 1 分钟后释放
+	assert.Equal(t, clock.now.Add(30*time.Second).UnixMilli(), reset.UnixMilli())
+
+	// 最早的请求滑出窗口后释放一个配额
+	clock.Advance(31 * time.Second)
+	allowed, _, _, err = limiter.Allow(ctx, "tk", limit)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestRedisLimiter_HourFixedWindowRollover(t *testing.T) {
+	limiter, mr, clock := newTestRedisLimiter(t)
+	ctx := context.Background()
+	limit := &interfaces.RateLimit{RequestsPerHour: 2}
+	nextHour := time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		allowed, _, reset, err := limiter.Allow(ctx, "tk", limit)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, nextHour, reset.UTC())
+	}
+
+	allowed, remaining, reset, err := limiter.Allow(ctx, "tk", limit)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 0, remaining)
+	assert.Equal(t, nextHour, reset.UTC())
+
+	// 固定窗口只保存一个计数器，并在窗口结束时过期
+	keys := mr.Keys()
+	require.Len(t, keys, 1)
+	assert.True(t, strings.HasPrefix(keys[0], "ratelimit:{tk}:hour:"))
+	count, err := mr.Get(keys[0])
+	require.NoError(t, err)
+	assert.Equal(t, "2", count)
+	assert.Equal(t, 50*time.Minute, mr.TTL(keys[0]))
+
+	// 同一窗口内仍然拒绝
+	clock.Advance(49 * time.Minute)
+	allowed, _, _, err = limiter.Allow(ctx, "tk", limit)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	// 跨过整点后使用新的窗口，旧计数器已过期
+	clock.Advance(1 * time.Minute)
+	allowed, remaining, _, err = limiter.Allow(ctx, "tk", limit)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 1, remaining)
+	assert.False(t, mr.Exists(keys[0]))
+}
+
+func TestRedisLimiter_DayFixedWindowRollover(t *testing.T) {
+	limiter, mr, clock := newTestRedisLimiter(t)
+	ctx := context.Background()
+	limit := &interfaces.RateLimit{RequestsPerDay: 3}
+	nextDay := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		allowed, remaining, reset, err := limiter.Allow(ctx, "tk", limit)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, 2-i, remaining)
+		assert.Equal(t, nextDay, reset.UTC())
+	}
+
+	allowed, _, _, err := limiter.Allow(ctx, "tk", limit)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	// 无论请求多少次，天窗口只占用一个计数器
+	keys := mr.Keys()
+	require.Len(t, keys, 1)
+	assert.True(t, strings.HasPrefix(keys[0], "ratelimit:{tk}:day:"))
+
+	clock.Advance(nextDay.Sub(clock.now))
+	allowed, remaining, _, err := limiter.Allow(ctx, "tk", limit)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 2, remaining)
+}
+
+func TestRedisLimiter_RejectedRequestNotCounted(t *testing.T) {
+	limiter, mr, _ := newTestRedisLimiter(t)
+	ctx := context.Background()
+	// 分钟窗口先拒绝时，小时窗口不应计数
+	limit := &interfaces.RateLimit{RequestsPerMinute: 1, RequestsPerHour: 10}
+
+	allowed, remaining, _, err := limiter.Allow(ctx, "tk", limit)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 0, remaining)
+
+	allowed, _, _, err = limiter.Allow(ctx, "tk", limit)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	for _, k := range mr.Keys() {
+		if strings.Contains(k, ":hour:") {
+			count, err := mr.Get(k)
+			require.NoError(t, err)
+			assert.Equal(t, "1", count)
+		}
+	}
+}
+
+func TestRedisLimiter_NoLimit(t *testing.T) {
+	limiter, mr, _ := newTestRedisLimiter(t)
+
+	allowed, remaining, _, err := limiter.Allow(context.Background(), "tk", nil)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, -1, remaining)
+
+	allowed, _, _, err = limiter.Allow(context.Background(), "tk", &interfaces.RateLimit{})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Empty(t, mr.Keys())
+}
+
+func TestRedisLimiter_FallbackWhenRedisUnavailable(t *testing.T) {
+	scripter := &failingScripter{}
+	limiter := NewRedisLimiter(scripter, NewMemoryLimiter())
+	ctx := context.Background()
+	limit := &interfaces.RateLimit{RequestsPerMinute: 1}
+
+	allowed, _, _, err := limiter.Allow(ctx, "tk", limit)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// 降级期内不再访问 Redis，由内存限流器计数
+	allowed, _, _, err = limiter.Allow(ctx, "tk", limit)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 1, scripter.calls)
+}
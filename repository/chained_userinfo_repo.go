@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/observability"
+)
+
+// ErrAllUserInfoBackendsFailed 所有用户信息后端均不可用
+var ErrAllUserInfoBackendsFailed = errors.New("all user info backends failed")
+
+// ========================================
+// 熔断器
+// ========================================
+
+// circuitBreaker 连续失败计数熔断器
+// closed：正常调用；连续失败达到阈值后 open，openDuration 内直接跳过；
+// 之后 half-open：放行一次探测，成功则关闭，失败则重新打开
+type circuitBreaker struct {
+	failureThreshold int
+	openDuration     time.Duration
+
+	mu                  sync.Mutex
+	consecutiveFailures int
+	openUntil           time.Time
+	probing             bool
+}
+
+// allow 是否允许调用
+func (b *circuitBreaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.consecutiveFailures < b.failureThreshold {
+		return true
+	}
+	if now.Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// success 记录成功，返回熔断器是否由打开变为关闭
+func (b *circuitBreaker) success() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasOpen := b.consecutiveFailures >= b.failureThreshold
+	b.consecutiveFailures = 0
+	b.probing = false
+	return wasOpen
+}
+
+// failure 记录失败，返回熔断器是否由关闭变为打开
+func (b *circuitBreaker) failure(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.consecutiveFailures++
+	b.probing = false
+	if b.consecutiveFailures < b.failureThreshold {
+		return false
+	}
+	b.openUntil = now.Add(b.openDuration)
+	return b.consecutiveFailures == b.failureThreshold
+}
+
+// abort 调用被调用方取消（不计成功或失败），释放半开探测名额
+func (b *circuitBreaker) abort() {
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+// ========================================
+// ChainedUserInfoRepository
+// ========================================
+
+// userInfoBackend 链中的一个用户信息后端
+type userInfoBackend struct {
+	name    string
+	repo    interfaces.UserInfoRepository
+	timeout time.Duration
+	breaker *circuitBreaker
+}
+
+// ChainedUserInfoRepository 按顺序尝试多个用户信息后端（如 qconfapi → MySQL）
+// 每个后端有独立的超时和熔断器；后端返回"用户不存在"视为有效结果，不再尝试后续后端
+type ChainedUserInfoRepository struct {
+	backends         []*userInfoBackend
+	failureThreshold int
+	openDuration     time.Duration
+}
+
+// NewChainedUserInfoRepository 创建链式用户信息存储库
+// failureThreshold: 连续失败多少次后熔断；openDuration: 熔断持续时间
+func NewChainedUserInfoRepository(failureThreshold int, openDuration time.Duration) *ChainedUserInfoRepository {
+	if failureThreshold <= 0 {
+		failureThreshold = 5
+	}
+	if openDuration <= 0 {
+		openDuration = 30 * time.Second
+	}
+
+	return &ChainedUserInfoRepository{
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+	}
+}
+
+// AddBackend 追加后端（按添加顺序尝试），timeout <= 0 表示不额外限制
+func (r *ChainedUserInfoRepository) AddBackend(name string, repo interfaces.UserInfoRepository, timeout time.Duration) *ChainedUserInfoRepository {
+	r.backends = append(r.backends, &userInfoBackend{
+		name:    name,
+		repo:    repo,
+		timeout: timeout,
+		breaker: &circuitBreaker{
+			failureThreshold: r.failureThreshold,
+			openDuration:     r.openDuration,
+		},
+	})
+	observability.UserInfoBackendCircuitOpen.WithLabelValues(name).Set(0)
+	return r
+}
+
+// Len 后端数量
+func (r *ChainedUserInfoRepository) Len() int {
+	return len(r.backends)
+}
+
+// GetUserInfoByUID 按顺序查询各后端，返回第一个成功（或确认用户不存在）的结果
+func (r *ChainedUserInfoRepository) GetUserInfoByUID(ctx context.Context, uid uint32) (*interfaces.UserInfo, error) {
+	var errs []error
+
+	for _, backend := range r.backends {
+		if !backend.breaker.allow(time.Now()) {
+			observability.UserInfoBackendRequestsTotal.WithLabelValues(backend.name, "skipped").Inc()
+			errs = append(errs, fmt.Errorf("%s: circuit open", backend.name))
+			continue
+		}
+
+		start := time.Now()
+		info, err := backend.call(ctx, uid)
+		observability.UserInfoBackendDuration.WithLabelValues(backend.name).Observe(time.Since(start).Seconds())
+
+		if err == nil || errors.Is(err, interfaces.ErrUserNotFound) {
+			if backend.breaker.success() {
+				observability.UserInfoBackendCircuitOpen.WithLabelValues(backend.name).Set(0)
+				observability.LogInfo(ctx, "User info backend recovered", slog.String("backend", backend.name))
+			}
+
+			result := "success"
+			if err != nil {
+				result = "not_found"
+			}
+			observability.UserInfoBackendRequestsTotal.WithLabelValues(backend.name, result).Inc()
+			observability.LogDebug(ctx, "User info lookup served",
+				slog.String("backend", backend.name),
+				slog.Uint64("uid", uint64(uid)),
+				slog.String("result", result))
+			return info, err
+		}
+
+		// 调用方取消时不计入后端失败，也不再尝试后续后端
+		if ctx.Err() != nil {
+			backend.breaker.abort()
+			return nil, ctx.Err()
+		}
+
+		observability.UserInfoBackendRequestsTotal.WithLabelValues(backend.name, "error").Inc()
+		if backend.breaker.failure(time.Now()) {
+			observability.UserInfoBackendCircuitOpen.WithLabelValues(backend.name).Set(1)
+			observability.LogWarn(ctx, "User info backend circuit opened",
+				slog.String("backend", backend.name),
+				slog.String("error", err.Error()))
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", backend.name, err))
+	}
+
+	return nil, fmt.Errorf("%w: %w", ErrAllUserInfoBackendsFailed, errors.Join(errs...))
+}
+
+// call 带超时调用后端
+// 部分后端（qconfapi）不支持 context，超时后放弃等待结果，调用在后台自然结束
+func (b *userInfoBackend) call(ctx context.Context, uid uint32) (*interfaces.UserInfo, error) {
+	if b.timeout <= 0 {
+		return b.repo.GetUserInfoByUID(ctx, uid)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+
+	type result struct {
+		info *interfaces.UserInfo
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		info, err := b.repo.GetUserInfoByUID(ctx, uid)
+		done <- result{info, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.info, res.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s lookup timed out after %s: %w", b.name, b.timeout, ctx.Err())
+	}
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubUserInfoRepo 按函数返回结果的用户信息后端
+type stubUserInfoRepo struct {
+	calls atomic.Int32
+	fn    func(uid uint32) (*interfaces.UserInfo, error)
+}
+
+func (s *stubUserInfoRepo) GetUserInfoByUID(ctx context.Context, uid uint32) (*interfaces.UserInfo, error) {
+	s.calls.Add(1)
+	return s.fn(uid)
+}
+
+func okBackend() *stubUserInfoRepo {
+	return &stubUserInfoRepo{fn: func(uid uint32) (*interfaces.UserInfo, error) {
+		return &interfaces.UserInfo{UID: uid}, nil
+	}}
+}
+
+func failingBackend() *stubUserInfoRepo {
+	return &stubUserInfoRepo{fn: func(uid uint32) (*interfaces.UserInfo, error) {
+		return nil, errors.New("connection refused")
+	}}
+}
+
+// ========================================
+// TestChainedUserInfoRepository
+// ========================================
+
+func TestChainedUserInfoRepository_Failover(t *testing.T) {
+	primary, secondary := failingBackend(), okBackend()
+	chain := NewChainedUserInfoRepository(5, time.Minute).
+		AddBackend("qconfapi", primary, 0).
+		AddBackend("mysql", secondary, 0)
+
+	info, err := chain.GetUserInfoByUID(context.Background(), 1369077332)
+
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1369077332), info.UID)
+	assert.Equal(t, int32(1), primary.calls.Load())
+	assert.Equal(t, int32(1), secondary.calls.Load())
+}
+
+func TestChainedUserInfoRepository_NotFoundIsAuthoritative(t *testing.T) {
+	primary := &stubUserInfoRepo{fn: func(uid uint32) (*interfaces.UserInfo, error) {
+		return nil, fmt.Errorf("%w: uid=%d", interfaces.ErrUserNotFound, uid)
+	}}
+	secondary := okBackend()
+	chain := NewChainedUserInfoRepository(5, time.Minute).
+		AddBackend("qconfapi", primary, 0).
+		AddBackend("mysql", secondary, 0)
+
+	_, err := chain.GetUserInfoByUID(context.Background(), 1)
+
+	assert.ErrorIs(t, err, interfaces.ErrUserNotFound)
+	assert.Equal(t, int32(0), secondary.calls.Load())
+}
+
+func TestChainedUserInfoRepository_AllFailed(t *testing.T) {
+	chain := NewChainedUserInfoRepository(5, time.Minute).
+		AddBackend("qconfapi", failingBackend(), 0).
+		AddBackend("mysql", failingBackend(), 0)
+
+	_, err := chain.GetUserInfoByUID(context.Background(), 1)
+
+	assert.ErrorIs(t, err, ErrAllUserInfoBackendsFailed)
+	assert.ErrorContains(t, err, "qconfapi: connection refused")
+	assert.ErrorContains(t, err, "mysql: connection refused")
+}
+
+func TestChainedUserInfoRepository_CircuitBreaker(t *testing.T) {
+	var healthy atomic.Bool
+	primary := &stubUserInfoRepo{fn: func(uid uint32) (*interfaces.UserInfo, error) {
+		if healthy.Load() {
+			return &interfaces.UserInfo{UID: uid}, nil
+		}
+		return nil, errors.New("connection refused")
+	}}
+	secondary := okBackend()
+	chain := NewChainedUserInfoRepository(2, 50*time.Millisecond).
+		AddBackend("qconfapi", primary, 0).
+		AddBackend("mysql", secondary, 0)
+	ctx := context.Background()
+
+	// 连续失败 2 次后熔断，之后直接跳过主后端
+	for i := 0; i < 5; i++ {
+		_, err := chain.GetUserInfoByUID(ctx, 1)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), primary.calls.Load())
+	assert.Equal(t, int32(5), secondary.calls.Load())
+
+	// 熔断结束后探测成功，恢复使用主后端
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		_, err := chain.GetUserInfoByUID(ctx, 1)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(5), primary.calls.Load())
+	assert.Equal(t, int32(5), secondary.calls.Load())
+}
+
+func TestChainedUserInfoRepository_Timeout(t *testing.T) {
+	slow := &stubUserInfoRepo{fn: func(uid uint32) (*interfaces.UserInfo, error) {
+		time.Sleep(200 * time.Millisecond)
+		return &interfaces.UserInfo{UID: uid}, nil
+	}}
+	chain := NewChainedUserInfoRepository(5, time.Minute).
+		AddBackend("qconfapi", slow, 20*time.Millisecond).
+		AddBackend("mysql", okBackend(), 0)
+
+	start := time.Now()
+	info, err := chain.GetUserInfoByUID(context.Background(), 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), info.UID)
+	assert.Less(t, time.Since(start), 150*time.Millisecond)
+}
@@ -5,6 +5,9 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/qiniu/bearer-token-service/v2/interfaces"
@@ -21,6 +24,9 @@ const (
 // MongoAccountRepository MongoDB 实现的账户存储库
 type MongoAccountRepository struct {
 	collection *mongo.Collection
+
+	// SecretKey 静态加密（为 nil 时按明文存储，仅用于测试和工具）
+	secretCipher *SecretKeyCipher
 }
 
 // NewMongoAccountRepository 创建账户存储库实例
@@ -30,6 +36,11 @@ func NewMongoAccountRepository(db *mongo.Database) *MongoAccountRepository {
 	}
 }
 
+// SetSecretKeyCipher 设置 SecretKey 加密器（设置后写入的 SecretKey 均为密文，读取时自动解密）
+func (r *MongoAccountRepository) SetSecretKeyCipher(c *SecretKeyCipher) {
+	r.secretCipher = c
+}
+
 // Create 创建新账户
 func (r *MongoAccountRepository) Create(ctx context.Context, account *interfaces.Account) error {
 	// 生成 MongoDB ObjectID
@@ -51,14 +62,18 @@ func (r *MongoAccountRepository) Create(ctx context.Context, account *interfaces
 		account.AccessKey = accessKey
 	}
 
-	// SecretKey 应该已经加密（由 Service 层处理）
-	// 这里只负责存储
-
-	_, err := r.collection.InsertOne(ctx, account)
+	// 入库的是 SecretKey 密文，调用方的 account 保持原文
+	doc := *account
+	sealed, err := r.sealSecretKey(account.SecretKey)
 	if err != nil {
+		return err
+	}
+	doc.SecretKey = sealed
+
+	if _, err := r.collection.InsertOne(ctx, &doc); err != nil {
 		// 检查唯一索引冲突
 		if mongo.IsDuplicateKeyError(err) {
-			return errors.New("email or access_key already exists")
+			return duplicateAccountError(err)
 		}
 		return err
 	}
@@ -76,6 +91,9 @@ func (r *MongoAccountRepository) GetByAccessKey(ctx context.Context, accessKey s
 		}
 		return nil, err
 	}
+	if err := r.openSecretKey(&account); err != nil {
+		return nil, err
+	}
 	return &account, nil
 }
 
@@ -89,6 +107,9 @@ func (r *MongoAccountRepository) GetByEmail(ctx context.Context, email string) (
 		}
 		return nil, err
 	}
+	if err := r.openSecretKey(&account); err != nil {
+		return nil, err
+	}
 	return &account, nil
 }
 
@@ -102,18 +123,25 @@ func (r *MongoAccountRepository) GetByID(ctx context.Context, id string) (*inter
 		}
 		return nil, err
 	}
+	if err := r.openSecretKey(&account); err != nil {
+		return nil, err
+	}
 	return &account, nil
 }
 
 // UpdateSecretKey 更新 SecretKey
 func (r *MongoAccountRepository) UpdateSecretKey(ctx context.Context, accountID string, newSecretKey string) error {
-	// newSecretKey 应该已经加密（由 Service 层处理）
+	sealed, err := r.sealSecretKey(newSecretKey)
+	if err != nil {
+		return err
+	}
+
 	result, err := r.collection.UpdateOne(
 		ctx,
 		bson.M{"_id": accountID},
 		bson.M{
 			"$set": bson.M{
-				"secret_key": newSecretKey,
+				"secret_key": sealed,
 				"updated_at": time.Now(),
 			},
 		},
@@ -124,7 +152,7 @@ func (r *MongoAccountRepository) UpdateSecretKey(ctx context.Context, accountID
 	}
 
 	if result.MatchedCount == 0 {
-		return errors.New("account not found")
+		return interfaces.ErrAccountNotFound
 	}
 
 	return nil
@@ -148,24 +176,25 @@ func (r *MongoAccountRepository) UpdateStatus(ctx context.Context, accountID str
 	}
 
 	if result.MatchedCount == 0 {
-		return errors.New("account not found")
+		return interfaces.ErrAccountNotFound
 	}
 
 	return nil
 }
 
-// List 列出所有账户（管理员功能）
-func (r *MongoAccountRepository) List(ctx context.Context, limit, offset int) ([]interfaces.Account, error) {
+// List 按条件列出账户（管理员功能），按创建时间倒序
+func (r *MongoAccountRepository) List(ctx context.Context, query *interfaces.AccountListQuery) ([]interfaces.Account, error) {
+	limit := query.Limit
 	if limit <= 0 {
 		limit = 50
 	}
 
 	opts := options.Find().
 		SetLimit(int64(limit)).
-		SetSkip(int64(offset)).
+		SetSkip(int64(query.Offset)).
 		SetSort(bson.D{{Key: "created_at", Value: -1}})
 
-	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	cursor, err := r.collection.Find(ctx, accountListFilter(query), opts)
 	if err != nil {
 		return nil, err
 	}
@@ -175,13 +204,57 @@ func (r *MongoAccountRepository) List(ctx context.Context, limit, offset int) ([
 	if err := cursor.All(ctx, &accounts); err != nil {
 		return nil, err
 	}
+	for i := range accounts {
+		if err := r.openSecretKey(&accounts[i]); err != nil {
+			return nil, err
+		}
+	}
 
 	return accounts, nil
 }
 
-// Count 统计账户数量
-func (r *MongoAccountRepository) Count(ctx context.Context) (int64, error) {
-	return r.collection.CountDocuments(ctx, bson.M{})
+// Count 统计符合条件的账户数量
+func (r *MongoAccountRepository) Count(ctx context.Context, query *interfaces.AccountListQuery) (int64, error) {
+	return r.collection.CountDocuments(ctx, accountListFilter(query))
+}
+
+// ListSuspendedIDs 列出所有已停用账户的 ID
+func (r *MongoAccountRepository) ListSuspendedIDs(ctx context.Context) ([]string, error) {
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"status": interfaces.AccountStatusSuspended}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var ids []string
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID string `bson:"_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		ids = append(ids, doc.ID)
+	}
+	return ids, cursor.Err()
+}
+
+// accountListFilter 构建账户列表查询条件
+func accountListFilter(query *interfaces.AccountListQuery) bson.M {
+	filter := bson.M{}
+	if query.Status != "" {
+		filter["status"] = query.Status
+	}
+	if query.Keyword != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(query.Keyword), Options: "i"}
+		filter["$or"] = bson.A{
+			bson.M{"email": pattern},
+			bson.M{"company": pattern},
+		}
+	}
+	return filter
 }
 
 // GetAccountByQiniuUID 根据七牛 UID 查询账户 ID
@@ -217,6 +290,10 @@ func (r *MongoAccountRepository) CreateAccountForQiniuUID(ctx context.Context, q
 	if err != nil {
 		return "", err
 	}
+	sealedSecretKey, err := r.sealSecretKey(secretKey)
+	if err != nil {
+		return "", err
+	}
 
 	// 创建账户
 	now := time.Now()
@@ -224,7 +301,7 @@ func (r *MongoAccountRepository) CreateAccountForQiniuUID(ctx context.Context, q
 		ID:        accountID,
 		Email:     email,
 		AccessKey: accessKey,
-		SecretKey: secretKey,
+		SecretKey: sealedSecretKey,
 		Status:    "active",
 		QiniuUID:  qiniuUID, // 存储七牛 UID
 		CreatedAt: now,
@@ -234,7 +311,7 @@ func (r *MongoAccountRepository) CreateAccountForQiniuUID(ctx context.Context, q
 	_, err = r.collection.InsertOne(ctx, account)
 	if err != nil {
 		if mongo.IsDuplicateKeyError(err) {
-			return "", errors.New("account with this qiniu_uid already exists")
+			return "", fmt.Errorf("%w: account with this qiniu_uid already exists", interfaces.ErrConflict)
 		}
 		return "", err
 	}
@@ -242,6 +319,53 @@ func (r *MongoAccountRepository) CreateAccountForQiniuUID(ctx context.Context, q
 	return accountID, nil
 }
 
+// EncryptPlaintextSecretKeys 将尚未加密的 SecretKey 加密（幂等，可在多个实例上同时执行）
+// 返回本次加密的账户数
+func (r *MongoAccountRepository) EncryptPlaintextSecretKeys(ctx context.Context) (int64, error) {
+	if r.secretCipher == nil {
+		return 0, errors.New("secret key cipher not configured")
+	}
+
+	filter := bson.M{
+		"secret_key": bson.M{
+			"$exists": true,
+			"$not":    primitive.Regex{Pattern: "^" + regexp.QuoteMeta(encryptedSecretKeyPrefix)},
+		},
+	}
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "secret_key": 1})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var migrated int64
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID        string `bson:"_id"`
+			SecretKey string `bson:"secret_key"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return migrated, err
+		}
+		sealed, err := r.secretCipher.Encrypt(doc.SecretKey)
+		if err != nil {
+			return migrated, err
+		}
+
+		// 仅当值未被其他实例或重新生成 SK 改写时更新
+		result, err := r.collection.UpdateOne(ctx,
+			bson.M{"_id": doc.ID, "secret_key": doc.SecretKey},
+			bson.M{"$set": bson.M{"secret_key": sealed}})
+		if err != nil {
+			return migrated, err
+		}
+		migrated += result.ModifiedCount
+	}
+	return migrated, cursor.Err()
+}
+
 // CreateIndexes 创建索引
 func (r *MongoAccountRepository) CreateIndexes(ctx context.Context) error {
 	indexes := []mongo.IndexModel{
@@ -288,16 +412,39 @@ func GenerateSecretKey() (string, error) {
 	return interfaces.SecretKeyPrefix + hex.EncodeToString(b), nil
 }
 
-// HashSecretKey 不再加密 SecretKey，直接返回明文
-// HMAC 签名验证需要明文 SecretKey，不能使用单向哈希
-// 安全性依赖：MongoDB 传输层加密(TLS) + 访问控制 + 网络隔离
-func HashSecretKey(secretKey string) (string, error) {
-	// 直接返回明文 SecretKey（用于 HMAC 签名验证）
-	return secretKey, nil
+// sealSecretKey 生成 SecretKey 的存储值（配置了加密器时为密文）
+func (r *MongoAccountRepository) sealSecretKey(plain string) (string, error) {
+	if r.secretCipher == nil || plain == "" {
+		return plain, nil
+	}
+	return r.secretCipher.Encrypt(plain)
+}
+
+// openSecretKey 将读取到的 SecretKey 还原为原文（HMAC 验签需要）
+func (r *MongoAccountRepository) openSecretKey(account *interfaces.Account) error {
+	if !IsEncryptedSecretKey(account.SecretKey) {
+		return nil
+	}
+	if r.secretCipher == nil {
+		return errors.New("account secret key is encrypted but no encryption key is configured")
+	}
+	plain, err := r.secretCipher.Decrypt(account.SecretKey)
+	if err != nil {
+		return err
+	}
+	account.SecretKey = plain
+	return nil
 }
 
-// VerifySecretKey 验证 SecretKey（明文比较）
-func VerifySecretKey(storedSecretKey, plainSecretKey string) bool {
-	// 直接比较明文
-	return storedSecretKey == plainSecretKey
+// duplicateAccountError 按冲突的唯一索引返回对应的错误
+func duplicateAccountError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "access_key"):
+		return interfaces.ErrDuplicateAccessKey
+	case strings.Contains(msg, "email"):
+		return interfaces.ErrDuplicateEmail
+	default:
+		return fmt.Errorf("%w: %v", interfaces.ErrConflict, err)
+	}
 }
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func newTestAccountRepo(t *testing.T, mt *mtest.T) (*MongoAccountRepository, *SecretKeyCipher) {
+	t.Helper()
+	c, err := NewSecretKeyCipher(testSecretKeyEncryptionKey)
+	require.NoError(t, err)
+	repo := NewMongoAccountRepository(mt.DB)
+	repo.SetSecretKeyCipher(c)
+	return repo, c
+}
+
+func TestMongoAccountRepository_SecretKeyEncryption(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("create stores ciphertext", func(mt *mtest.T) {
+		repo, c := newTestAccountRepo(t, mt)
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		account := &interfaces.Account{Email: "a@example.com", SecretKey: "SK_plain"}
+		require.NoError(t, repo.Create(context.Background(), account))
+		assert.Equal(t, "SK_plain", account.SecretKey, "caller keeps the plaintext")
+
+		evt := mt.GetStartedEvent()
+		require.Equal(t, "insert", evt.CommandName)
+		stored := evt.Command.Lookup("documents").Array().Index(0).Value().Document().Lookup("secret_key").StringValue()
+		assert.True(t, IsEncryptedSecretKey(stored))
+		plain, err := c.Decrypt(stored)
+		require.NoError(t, err)
+		assert.Equal(t, "SK_plain", plain)
+	})
+
+	mt.Run("reads decrypt ciphertext and legacy plaintext", func(mt *mtest.T) {
+		repo, c := newTestAccountRepo(t, mt)
+		ns := mt.DB.Name() + "." + accountsCollection
+		sealed, err := c.Encrypt("SK_new")
+		require.NoError(t, err)
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: "acc_1"}, {Key: "access_key", Value: "AK_1"}, {Key: "secret_key", Value: sealed}}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: "acc_2"}, {Key: "access_key", Value: "AK_2"}, {Key: "secret_key", Value: "SK_legacy"}}),
+		)
+
+		account, err := repo.GetByAccessKey(context.Background(), "AK_1")
+		require.NoError(t, err)
+		assert.Equal(t, "SK_new", account.SecretKey)
+
+		account, err = repo.GetByAccessKey(context.Background(), "AK_2")
+		require.NoError(t, err)
+		assert.Equal(t, "SK_legacy", account.SecretKey)
+	})
+
+	mt.Run("ciphertext without key is an error", func(mt *mtest.T) {
+		_, c := newTestAccountRepo(t, mt)
+		repo := NewMongoAccountRepository(mt.DB)
+		ns := mt.DB.Name() + "." + accountsCollection
+		sealed, err := c.Encrypt("SK_new")
+		require.NoError(t, err)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: "acc_1"}, {Key: "secret_key", Value: sealed}}))
+
+		_, err = repo.GetByID(context.Background(), "acc_1")
+		assert.Error(t, err)
+	})
+
+	mt.Run("update secret key stores ciphertext", func(mt *mtest.T) {
+		repo, c := newTestAccountRepo(t, mt)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+		require.NoError(t, repo.UpdateSecretKey(context.Background(), "acc_1", "SK_rotated"))
+
+		evt := mt.GetStartedEvent()
+		require.Equal(t, "update", evt.CommandName)
+		stored := evt.Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u", "$set", "secret_key").StringValue()
+		plain, err := c.Decrypt(stored)
+		require.NoError(t, err)
+		assert.Equal(t, "SK_rotated", plain)
+	})
+
+	mt.Run("migrates plaintext secret keys", func(mt *mtest.T) {
+		repo, c := newTestAccountRepo(t, mt)
+		ns := mt.DB.Name() + "." + accountsCollection
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: "acc_1"}, {Key: "secret_key", Value: "SK_legacy"}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+		)
+
+		migrated, err := repo.EncryptPlaintextSecretKeys(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), migrated)
+
+		mt.GetStartedEvent() // find
+		evt := mt.GetStartedEvent()
+		require.Equal(t, "update", evt.CommandName)
+		update := evt.Command.Lookup("updates").Array().Index(0).Value().Document()
+		// 只在值未被改写时更新
+		assert.Equal(t, "SK_legacy", update.Lookup("q", "secret_key").StringValue())
+		plain, err := c.Decrypt(update.Lookup("u", "$set", "secret_key").StringValue())
+		require.NoError(t, err)
+		assert.Equal(t, "SK_legacy", plain)
+	})
+}
+
+func TestMongoAccountRepository_CreateDuplicate(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	tests := []struct {
+		name    string
+		message string
+		wantErr error
+	}{
+		{"email", "E11000 duplicate key error collection: db.accounts index: email_1 dup key: { email: \"a@example.com\" }", interfaces.ErrDuplicateEmail},
+		{"access key", "E11000 duplicate key error collection: db.accounts index: access_key_1 dup key: { access_key: \"AK_1\" }", interfaces.ErrDuplicateAccessKey},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			repo, _ := newTestAccountRepo(t, mt)
+			mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: tt.message}))
+
+			err := repo.Create(context.Background(), &interfaces.Account{Email: "a@example.com", SecretKey: "SK_x"})
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.ErrorIs(t, err, interfaces.ErrConflict)
+		})
+	}
+
+	// 兜底：无法识别索引时仍归为冲突
+	assert.ErrorIs(t, duplicateAccountError(mongo.WriteException{}), interfaces.ErrConflict)
+}
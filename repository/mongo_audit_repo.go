@@ -2,6 +2,10 @@ package repository
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/qiniu/bearer-token-service/v2/interfaces"
@@ -44,33 +48,9 @@ func (r *MongoAuditLogRepository) Create(ctx context.Context, log *interfaces.Au
 }
 
 // ListByAccountID 查询账户的审计日志
-func (r *MongoAuditLogRepository) ListByAccountID(ctx context.Context, accountID string, query *interfaces.AuditLogQuery) ([]interfaces.AuditLog, error) {
-	// 构建查询条件 - 租户隔离
-	filter := bson.M{
-		"account_id": accountID,
-	}
-
-	// 可选过滤条件
-	if query != nil {
-		if query.Action != "" {
-			filter["action"] = query.Action
-		}
-
-		if query.ResourceID != "" {
-			filter["resource_id"] = query.ResourceID
-		}
-
-		if !query.StartTime.IsZero() || !query.EndTime.IsZero() {
-			timeFilter := bson.M{}
-			if !query.StartTime.IsZero() {
-				timeFilter["$gte"] = query.StartTime
-			}
-			if !query.EndTime.IsZero() {
-				timeFilter["$lte"] = query.EndTime
-			}
-			filter["timestamp"] = timeFilter
-		}
-	}
+// 按 timestamp、_id 倒序排列；指定 query.Cursor 时从游标位置继续，返回下一页游标
+func (r *MongoAuditLogRepository) ListByAccountID(ctx context.Context, accountID string, query *interfaces.AuditLogQuery, iuid, iamAlias string) ([]interfaces.AuditLog, string, error) {
+	filter := auditLogFilter(accountID, query, iuid, iamAlias)
 
 	// 分页参数
 	limit := 50
@@ -85,56 +65,51 @@ func (r *MongoAuditLogRepository) ListByAccountID(ctx context.Context, accountID
 		if query.Offset > 0 {
 			offset = query.Offset
 		}
+
+		// 游标分页：只取游标位置之后（更早）的日志，忽略 offset
+		if query.Cursor != "" {
+			ts, id, err := decodeAuditCursor(query.Cursor)
+			if err != nil {
+				return nil, "", err
+			}
+			filter["$or"] = []bson.M{
+				{"timestamp": bson.M{"$lt": ts}},
+				{"timestamp": ts, "_id": bson.M{"$lt": id}},
+			}
+			offset = 0
+		}
 	}
 
+	// 多取一条用于判断是否还有下一页
 	opts := options.Find().
-		SetLimit(int64(limit)).
+		SetLimit(int64(limit + 1)).
 		SetSkip(int64(offset)).
-		SetSort(bson.D{{Key: "timestamp", Value: -1}}) // 最新的在前
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}) // 最新的在前
 
 	cursor, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer cursor.Close(ctx)
 
 	var logs []interfaces.AuditLog
 	if err := cursor.All(ctx, &logs); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return logs, nil
-}
-
-// CountByAccountID 统计账户的审计日志数量
-func (r *MongoAuditLogRepository) CountByAccountID(ctx context.Context, accountID string, query *interfaces.AuditLogQuery) (int64, error) {
-	filter := bson.M{
-		"account_id": accountID,
+	var nextCursor string
+	if len(logs) > limit {
+		logs = logs[:limit]
+		last := logs[limit-1]
+		nextCursor = encodeAuditCursor(last.Timestamp, last.ID)
 	}
 
-	// 可选过滤条件
-	if query != nil {
-		if query.Action != "" {
-			filter["action"] = query.Action
-		}
-
-		if query.ResourceID != "" {
-			filter["resource_id"] = query.ResourceID
-		}
-
-		if !query.StartTime.IsZero() || !query.EndTime.IsZero() {
-			timeFilter := bson.M{}
-			if !query.StartTime.IsZero() {
-				timeFilter["$gte"] = query.StartTime
-			}
-			if !query.EndTime.IsZero() {
-				timeFilter["$lte"] = query.EndTime
-			}
-			filter["timestamp"] = timeFilter
-		}
-	}
+	return logs, nextCursor, nil
+}
 
-	return r.collection.CountDocuments(ctx, filter)
+// CountByAccountID 统计账户的审计日志数量（不受游标影响）
+func (r *MongoAuditLogRepository) CountByAccountID(ctx context.Context, accountID string, query *interfaces.AuditLogQuery, iuid, iamAlias string) (int64, error) {
+	return r.collection.CountDocuments(ctx, auditLogFilter(accountID, query, iuid, iamAlias))
 }
 
 // DeleteOldLogs 删除旧日志（数据清理）
@@ -165,6 +140,14 @@ func (r *MongoAuditLogRepository) CreateIndexes(ctx context.Context) error {
 				{Key: "timestamp", Value: -1},
 			},
 		},
+		{
+			// 子账号隔离 + 时间排序
+			Keys: bson.D{
+				{Key: "account_id", Value: 1},
+				{Key: "iuid", Value: 1},
+				{Key: "timestamp", Value: -1},
+			},
+		},
 		{
 			// 按操作类型查询
 			Keys: bson.D{
@@ -193,3 +176,71 @@ func (r *MongoAuditLogRepository) CreateIndexes(ctx context.Context) error {
 	_, err := r.collection.Indexes().CreateMany(ctx, indexes)
 	return err
 }
+
+// ========================================
+// 辅助函数
+// ========================================
+
+// auditLogFilter 构建审计日志查询条件（租户隔离 + 子账号隔离 + 可选过滤）
+func auditLogFilter(accountID string, query *interfaces.AuditLogQuery, iuid, iamAlias string) bson.M {
+	filter := bson.M{
+		"account_id": accountID, // 租户隔离
+	}
+
+	// 子账号隔离：iuid 或 iamAlias 非空时只返回对应子账号的操作记录
+	if iuid != "" {
+		filter["iuid"] = iuid
+	} else if iamAlias != "" {
+		filter["iam_alias"] = iamAlias
+	}
+
+	// 可选过滤条件
+	if query != nil {
+		if query.Action != "" {
+			filter["action"] = query.Action
+		}
+
+		if query.ResourceID != "" {
+			filter["resource_id"] = query.ResourceID
+		}
+
+		if !query.StartTime.IsZero() || !query.EndTime.IsZero() {
+			timeFilter := bson.M{}
+			if !query.StartTime.IsZero() {
+				timeFilter["$gte"] = query.StartTime
+			}
+			if !query.EndTime.IsZero() {
+				timeFilter["$lte"] = query.EndTime
+			}
+			filter["timestamp"] = timeFilter
+		}
+	}
+
+	return filter
+}
+
+// errInvalidAuditCursor 游标无法解析（客户端参数错误）
+var errInvalidAuditCursor = fmt.Errorf("%w: invalid cursor", interfaces.ErrInvalidArgument)
+
+// encodeAuditCursor 编码分页游标（最后一条日志的时间戳和 ID）
+func encodeAuditCursor(ts time.Time, id string) string {
+	raw := strconv.FormatInt(ts.UnixMilli(), 10) + ":" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeAuditCursor 解析分页游标
+func decodeAuditCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", errInvalidAuditCursor
+	}
+	msStr, id, ok := strings.Cut(string(raw), ":")
+	if !ok || id == "" {
+		return time.Time{}, "", errInvalidAuditCursor
+	}
+	ms, err := strconv.ParseInt(msStr, 10, 64)
+	if err != nil {
+		return time.Time{}, "", errInvalidAuditCursor
+	}
+	return time.UnixMilli(ms), id, nil
+}
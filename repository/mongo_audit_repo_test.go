@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestAuditCursorRoundTrip(t *testing.T) {
+	ts := time.UnixMilli(1735689600123)
+	ts2, id, err := decodeAuditCursor(encodeAuditCursor(ts, "log_1"))
+	require.NoError(t, err)
+	assert.True(t, ts.Equal(ts2))
+	assert.Equal(t, "log_1", id)
+
+	for _, c := range []string{"!!!", "bm9jb2xvbg", "YWJjOmxvZ18x", "MTIzOg"} {
+		_, _, err := decodeAuditCursor(c)
+		assert.ErrorIs(t, err, interfaces.ErrInvalidArgument, c)
+	}
+}
+
+func TestListByAccountID_CursorPagination(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	auditDoc := func(id string, ts time.Time) bson.D {
+		return bson.D{
+			{Key: "_id", Value: id},
+			{Key: "account_id", Value: "acc_1"},
+			{Key: "action", Value: "create_token"},
+			{Key: "timestamp", Value: ts},
+		}
+	}
+
+	mt.Run("first page returns cursor of last item", func(mt *mtest.T) {
+		repo := NewMongoAuditLogRepository(mt.DB)
+		ns := mt.DB.Name() + "." + auditLogsCollection
+
+		// limit=2，多取的第 3 条表示还有下一页
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+			auditDoc("log_3", base.Add(2*time.Second)),
+			auditDoc("log_2", base.Add(time.Second)),
+			auditDoc("log_1", base),
+		))
+
+		logs, next, err := repo.ListByAccountID(context.Background(), "acc_1", &interfaces.AuditLogQuery{Limit: 2, Offset: 7}, "", "")
+		require.NoError(t, err)
+		require.Len(t, logs, 2)
+		assert.Equal(t, "log_3", logs[0].ID)
+		assert.Equal(t, "log_2", logs[1].ID)
+		assert.Equal(t, encodeAuditCursor(base.Add(time.Second), "log_2"), next)
+
+		evt := mt.GetStartedEvent()
+		require.Equal(t, "find", evt.CommandName)
+		assert.Equal(t, int64(3), evt.Command.Lookup("limit").AsInt64())
+		assert.Equal(t, int64(7), evt.Command.Lookup("skip").AsInt64())
+		assert.Equal(t, "acc_1", evt.Command.Lookup("filter", "account_id").StringValue())
+	})
+
+	mt.Run("next page continues after cursor and ignores offset", func(mt *mtest.T) {
+		repo := NewMongoAuditLogRepository(mt.DB)
+		ns := mt.DB.Name() + "." + auditLogsCollection
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+			auditDoc("log_1", base),
+		))
+
+		cursor := encodeAuditCursor(base.Add(time.Second), "log_2")
+		logs, next, err := repo.ListByAccountID(context.Background(), "acc_1", &interfaces.AuditLogQuery{Limit: 2, Offset: 7, Cursor: cursor}, "", "")
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+		assert.Equal(t, "log_1", logs[0].ID)
+		assert.Empty(t, next, "last page has no next cursor")
+
+		evt := mt.GetStartedEvent()
+		require.Equal(t, "find", evt.CommandName)
+		assert.Equal(t, int64(0), evt.Command.Lookup("skip").AsInt64())
+
+		// (timestamp < ts) OR (timestamp == ts AND _id < id)
+		or := evt.Command.Lookup("filter", "$or").Array()
+		before := or.Index(0).Value().Document().Lookup("timestamp", "$lt").Time()
+		assert.True(t, before.Equal(base.Add(time.Second)))
+		sameTs := or.Index(1).Value().Document()
+		assert.True(t, sameTs.Lookup("timestamp").Time().Equal(base.Add(time.Second)))
+		assert.Equal(t, "log_2", sameTs.Lookup("_id", "$lt").StringValue())
+	})
+
+	mt.Run("invalid cursor is rejected before querying", func(mt *mtest.T) {
+		repo := NewMongoAuditLogRepository(mt.DB)
+
+		_, _, err := repo.ListByAccountID(context.Background(), "acc_1", &interfaces.AuditLogQuery{Cursor: "!!!"}, "", "")
+		assert.ErrorIs(t, err, interfaces.ErrInvalidArgument)
+		assert.Nil(t, mt.GetStartedEvent())
+	})
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	oauth2ClientsCollection = "oauth2_clients"
+)
+
+// MongoOAuth2ClientRepository MongoDB 实现的 OAuth 2.0 客户端存储库
+type MongoOAuth2ClientRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoOAuth2ClientRepository 创建 OAuth 2.0 客户端存储库实例
+func NewMongoOAuth2ClientRepository(db *mongo.Database) *MongoOAuth2ClientRepository {
+	return &MongoOAuth2ClientRepository{
+		collection: db.Collection(oauth2ClientsCollection),
+	}
+}
+
+// Create 创建客户端
+func (r *MongoOAuth2ClientRepository) Create(ctx context.Context, client *interfaces.OAuth2Client) error {
+	now := time.Now()
+	client.CreatedAt = now
+	client.UpdatedAt = now
+
+	if _, err := r.collection.InsertOne(ctx, client); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("%w: client_id already exists", interfaces.ErrConflict)
+		}
+		return err
+	}
+	return nil
+}
+
+// GetByID 根据 client_id 查询客户端
+func (r *MongoOAuth2ClientRepository) GetByID(ctx context.Context, clientID string) (*interfaces.OAuth2Client, error) {
+	var client interfaces.OAuth2Client
+	err := r.collection.FindOne(ctx, bson.M{"_id": clientID}).Decode(&client)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &client, nil
+}
+
+// ListByAccount 列出账户的所有客户端
+func (r *MongoOAuth2ClientRepository) ListByAccount(ctx context.Context, accountID string) ([]interfaces.OAuth2Client, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"account_id": accountID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var clients []interfaces.OAuth2Client
+	if err := cursor.All(ctx, &clients); err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+// Delete 删除账户的客户端（租户隔离：只删除属于该账户的客户端）
+func (r *MongoOAuth2ClientRepository) Delete(ctx context.Context, accountID, clientID string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": clientID, "account_id": accountID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return interfaces.ErrOAuth2ClientNotFound
+	}
+	return nil
+}
+
+// UpdateResourceAccounts 设置资源服务可访问的账户，返回更新后的客户端
+func (r *MongoOAuth2ClientRepository) UpdateResourceAccounts(ctx context.Context, clientID string, accountIDs []string) (*interfaces.OAuth2Client, error) {
+	set := bson.M{"updated_at": time.Now()}
+	update := bson.M{"$set": set}
+	if len(accountIDs) == 0 {
+		update["$unset"] = bson.M{"resource_accounts": ""}
+	} else {
+		set["resource_accounts"] = accountIDs
+	}
+
+	var client interfaces.OAuth2Client
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": clientID}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&client)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, interfaces.ErrOAuth2ClientNotFound
+		}
+		return nil, err
+	}
+	return &client, nil
+}
+
+// CreateIndexes 创建索引
+func (r *MongoOAuth2ClientRepository) CreateIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		// 按账户列出客户端
+		Keys: bson.D{
+			{Key: "account_id", Value: 1},
+			{Key: "created_at", Value: -1},
+		},
+	})
+	return err
+}
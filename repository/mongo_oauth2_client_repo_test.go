@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestOAuth2ClientRepository_Delete(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("deletes only the account's client", func(mt *mtest.T) {
+		repo := NewMongoOAuth2ClientRepository(mt.DB)
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}})
+
+		require.NoError(t, repo.Delete(context.Background(), "acc_1", "oc_1"))
+
+		evt := mt.GetStartedEvent()
+		require.Equal(t, "delete", evt.CommandName)
+		filter := evt.Command.Lookup("deletes").Array().Index(0).Value().Document().Lookup("q").Document()
+		assert.Equal(t, "oc_1", filter.Lookup("_id").StringValue())
+		assert.Equal(t, "acc_1", filter.Lookup("account_id").StringValue())
+	})
+
+	mt.Run("other account's client is not found", func(mt *mtest.T) {
+		repo := NewMongoOAuth2ClientRepository(mt.DB)
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}})
+
+		err := repo.Delete(context.Background(), "acc_1", "oc_other")
+		assert.ErrorIs(t, err, interfaces.ErrOAuth2ClientNotFound)
+	})
+}
+
+func TestOAuth2ClientRepository_UpdateResourceAccounts(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("sets granted accounts", func(mt *mtest.T) {
+		repo := NewMongoOAuth2ClientRepository(mt.DB)
+		mt.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "value", Value: bson.D{
+				{Key: "_id", Value: "oc_gw"},
+				{Key: "account_id", Value: "acc_gw"},
+				{Key: "resource_accounts", Value: bson.A{"acc_1"}},
+			}},
+		})
+
+		client, err := repo.UpdateResourceAccounts(context.Background(), "oc_gw", []string{"acc_1"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"acc_1"}, client.ResourceAccounts)
+
+		evt := mt.GetStartedEvent()
+		require.Equal(t, "findAndModify", evt.CommandName)
+		set := evt.Command.Lookup("update", "$set").Document()
+		assert.Equal(t, "acc_1", set.Lookup("resource_accounts").Array().Index(0).Value().StringValue())
+	})
+
+	mt.Run("empty list removes grants", func(mt *mtest.T) {
+		repo := NewMongoOAuth2ClientRepository(mt.DB)
+		mt.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "value", Value: bson.D{{Key: "_id", Value: "oc_gw"}, {Key: "account_id", Value: "acc_gw"}}},
+		})
+
+		client, err := repo.UpdateResourceAccounts(context.Background(), "oc_gw", nil)
+		require.NoError(t, err)
+		assert.Empty(t, client.ResourceAccounts)
+
+		evt := mt.GetStartedEvent()
+		_, err = evt.Command.LookupErr("update", "$unset", "resource_accounts")
+		assert.NoError(t, err)
+	})
+
+	mt.Run("unknown client", func(mt *mtest.T) {
+		repo := NewMongoOAuth2ClientRepository(mt.DB)
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: nil}})
+
+		_, err := repo.UpdateResourceAccounts(context.Background(), "oc_unknown", []string{"acc_1"})
+		assert.ErrorIs(t, err, interfaces.ErrOAuth2ClientNotFound)
+	})
+}
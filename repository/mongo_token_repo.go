@@ -2,9 +2,13 @@ package repository
 
 import (
 	"context"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/qiniu/bearer-token-service/v2/interfaces"
@@ -14,27 +18,35 @@ import (
 )
 
 const (
-	tokensCollection = "tokens"
+	tokensCollection        = "tokens"
+	revokedTokensCollection = "revoked_tokens"
 )
 
 // TokenCache Token 缓存接口（避免循环依赖）
 type TokenCache interface {
-	GetByTokenValue(ctx context.Context, tokenValue string) (*interfaces.Token, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*interfaces.Token, error)
+	GetByTokenHashes(ctx context.Context, tokenHashes []string) (map[string]*interfaces.Token, error)
 	GetByID(ctx context.Context, tokenID string) (*interfaces.Token, error)
-	InvalidateByTokenValue(ctx context.Context, tokenValue string) error
+	InvalidateByTokenHash(ctx context.Context, tokenHash string) error
 	InvalidateByID(ctx context.Context, tokenID string) error
 }
 
 // MongoTokenRepository MongoDB 实现的 Token 存储库（带租户隔离）
+// token 值只以 HMAC-SHA256(pepper, token) 的形式存储和索引，数据库中不保存明文
 type MongoTokenRepository struct {
 	collection *mongo.Collection
-	cache      TokenCache // 可选的缓存层
+	revoked    *mongo.Collection // 已删除但尚未过期的签名 Token（吊销记录，过期后由 TTL 索引清理）
+	cache      TokenCache        // 可选的缓存层
+	pepper     []byte            // token 哈希密钥（服务端保存，不入库）
 }
 
 // NewMongoTokenRepository 创建 Token 存储库实例
-func NewMongoTokenRepository(db *mongo.Database) *MongoTokenRepository {
+// pepper 为 token 哈希密钥，所有实例及迁移工具必须使用同一个值
+func NewMongoTokenRepository(db *mongo.Database, pepper []byte) *MongoTokenRepository {
 	return &MongoTokenRepository{
 		collection: db.Collection(tokensCollection),
+		revoked:    db.Collection(revokedTokensCollection),
+		pepper:     pepper,
 	}
 }
 
@@ -43,10 +55,10 @@ func (r *MongoTokenRepository) SetCache(cache TokenCache) {
 	r.cache = cache
 }
 
-// GetByTokenValueDirect 直接从 MongoDB 查询（不经过缓存，供缓存层回调使用）
-func (r *MongoTokenRepository) GetByTokenValueDirect(ctx context.Context, tokenValue string) (*interfaces.Token, error) {
+// GetByTokenHashDirect 直接从 MongoDB 查询（不经过缓存，供缓存层回调使用）
+func (r *MongoTokenRepository) GetByTokenHashDirect(ctx context.Context, tokenHash string) (*interfaces.Token, error) {
 	var token interfaces.Token
-	err := r.collection.FindOne(ctx, bson.M{"token": tokenValue}).Decode(&token)
+	err := r.collection.FindOne(ctx, tokenHashFilter(tokenHash)).Decode(&token)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, nil
@@ -56,6 +68,58 @@ func (r *MongoTokenRepository) GetByTokenValueDirect(ctx context.Context, tokenV
 	return &token, nil
 }
 
+// GetByTokenHashesDirect 直接从 MongoDB 批量查询（不经过缓存，供缓存层回调使用）
+// 返回以哈希为键的结果；同一 Token 的当前值和宽限期内的旧值同时出现时，各自对应一份副本
+func (r *MongoTokenRepository) GetByTokenHashesDirect(ctx context.Context, tokenHashes []string) (map[string]*interfaces.Token, error) {
+	result := make(map[string]*interfaces.Token, len(tokenHashes))
+	if len(tokenHashes) == 0 {
+		return result, nil
+	}
+
+	wanted := make(map[string]struct{}, len(tokenHashes))
+	for _, h := range tokenHashes {
+		wanted[h] = struct{}{}
+	}
+
+	now := time.Now()
+	filter := bson.M{
+		"$or": []bson.M{
+			{"token_hash": bson.M{"$in": tokenHashes}},
+			{
+				"previous_token_hash":       bson.M{"$in": tokenHashes},
+				"previous_token_expires_at": bson.M{"$gt": now},
+			},
+		},
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tokens []interfaces.Token
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+
+	for i := range tokens {
+		token := tokens[i]
+		if _, ok := wanted[token.TokenHash]; ok {
+			t := token
+			result[token.TokenHash] = &t
+		}
+		if token.PreviousTokenHash == "" || token.PreviousTokenExpiresAt == nil || !token.PreviousTokenExpiresAt.After(now) {
+			continue
+		}
+		if _, ok := wanted[token.PreviousTokenHash]; ok {
+			t := token
+			result[token.PreviousTokenHash] = &t
+		}
+	}
+	return result, nil
+}
+
 // GetByIDDirect 直接从 MongoDB 查询（不经过缓存，供缓存层回调使用）
 func (r *MongoTokenRepository) GetByIDDirect(ctx context.Context, tokenID string) (*interfaces.Token, error) {
 	var token interfaces.Token
@@ -85,6 +149,14 @@ func (r *MongoTokenRepository) Create(ctx context.Context, token *interfaces.Tok
 		token.Token = tokenValue
 	}
 
+	// 只存储哈希和脱敏预览，明文仅保留在返回的对象中
+	token.TokenHash = r.hashTokenValue(token.Token)
+	if token.Format == interfaces.TokenFormatSigned {
+		token.TokenPreview = maskSignedTokenValue(token.Token)
+	} else {
+		token.TokenPreview = maskTokenValue(token.Token)
+	}
+
 	// 设置创建时间
 	token.CreatedAt = time.Now()
 
@@ -122,22 +194,60 @@ func (r *MongoTokenRepository) GetByID(ctx context.Context, tokenID string) (*in
 }
 
 // GetByTokenValue 根据 token 值查询 Token
+// 先计算哈希，之后的缓存和数据库查询都只使用哈希
 func (r *MongoTokenRepository) GetByTokenValue(ctx context.Context, tokenValue string) (*interfaces.Token, error) {
-	// 如果配置了缓存，优先从缓存读取
+	tokenHash := r.hashTokenValue(tokenValue)
+
+	var token *interfaces.Token
+	var err error
 	if r.cache != nil {
-		return r.cache.GetByTokenValue(ctx, tokenValue)
+		// 如果配置了缓存，优先从缓存读取
+		token, err = r.cache.GetByTokenHash(ctx, tokenHash)
+	} else {
+		// 降级到 MongoDB 直查
+		token, err = r.GetByTokenHashDirect(ctx, tokenHash)
+	}
+	if err != nil || token == nil {
+		return nil, err
 	}
 
-	// 降级到 MongoDB 直查
-	var token interfaces.Token
-	err := r.collection.FindOne(ctx, bson.M{"token": tokenValue}).Decode(&token)
-	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, nil
+	token.MatchedPrevious = token.TokenHash != tokenHash
+	return token, nil
+}
+
+// GetByTokenValues 批量根据 token 值查询 Token，返回以 token 值为键的结果
+// 先计算哈希（重复的值只查询一次），之后的缓存和数据库查询都只使用哈希
+func (r *MongoTokenRepository) GetByTokenValues(ctx context.Context, tokenValues []string) (map[string]*interfaces.Token, error) {
+	hashes := make([]string, 0, len(tokenValues))
+	hashByValue := make(map[string]string, len(tokenValues))
+	for _, value := range tokenValues {
+		if _, ok := hashByValue[value]; ok {
+			continue
 		}
+		hash := r.hashTokenValue(value)
+		hashByValue[value] = hash
+		hashes = append(hashes, hash)
+	}
+
+	var byHash map[string]*interfaces.Token
+	var err error
+	if r.cache != nil {
+		byHash, err = r.cache.GetByTokenHashes(ctx, hashes)
+	} else {
+		byHash, err = r.GetByTokenHashesDirect(ctx, hashes)
+	}
+	if err != nil {
 		return nil, err
 	}
-	return &token, nil
+
+	result := make(map[string]*interfaces.Token, len(byHash))
+	for value, hash := range hashByValue {
+		if token, ok := byHash[hash]; ok && token != nil {
+			token.MatchedPrevious = token.TokenHash != hash
+			result[value] = token
+		}
+	}
+	return result, nil
 }
 
 // ListByAccountID 查询账户的所有 Tokens（租户隔离）
@@ -213,7 +323,7 @@ func (r *MongoTokenRepository) UpdateStatus(ctx context.Context, tokenID string,
 	err := r.collection.FindOne(ctx, bson.M{"_id": tokenID}).Decode(&token)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return errors.New("token not found")
+			return interfaces.ErrTokenNotFound
 		}
 		return err
 	}
@@ -234,18 +344,127 @@ func (r *MongoTokenRepository) UpdateStatus(ctx context.Context, tokenID string,
 	}
 
 	if result.MatchedCount == 0 {
-		return errors.New("token not found")
+		return interfaces.ErrTokenNotFound
 	}
 
-	// 失效两个缓存键（token:id 和 token:val）
+	// 失效两个缓存键（token:id 和 token:hash），宽限期内的旧值也一并失效
 	if r.cache != nil {
 		_ = r.cache.InvalidateByID(ctx, tokenID)
-		_ = r.cache.InvalidateByTokenValue(ctx, token.Token)
+		_ = r.cache.InvalidateByTokenHash(ctx, token.TokenHash)
+		if token.PreviousTokenHash != "" {
+			_ = r.cache.InvalidateByTokenHash(ctx, token.PreviousTokenHash)
+		}
 	}
 
 	return nil
 }
 
+// Update 部分更新 Token（描述、过期时间、限流），返回更新后的 Token
+func (r *MongoTokenRepository) Update(ctx context.Context, tokenID string, update *interfaces.TokenUpdate) (*interfaces.Token, error) {
+	set := bson.M{}
+	unset := bson.M{}
+	if update.Description != nil {
+		set["description"] = *update.Description
+	}
+	if update.SetExpiresAt {
+		if update.ExpiresAt != nil {
+			set["expires_at"] = *update.ExpiresAt
+		} else {
+			unset["expires_at"] = ""
+		}
+	}
+	if update.SetRateLimit {
+		if update.RateLimit != nil {
+			set["rate_limit"] = update.RateLimit
+		} else {
+			unset["rate_limit"] = ""
+		}
+	}
+
+	doc := bson.M{}
+	if len(set) > 0 {
+		doc["$set"] = set
+	}
+	if len(unset) > 0 {
+		doc["$unset"] = unset
+	}
+	if len(doc) == 0 {
+		return nil, fmt.Errorf("%w: no fields to update", interfaces.ErrInvalidArgument)
+	}
+
+	var token interfaces.Token
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": tokenID},
+		doc,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&token)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, interfaces.ErrTokenNotFound
+		}
+		return nil, err
+	}
+
+	// 失效两个缓存键（token:id 和 token:hash），宽限期内的旧值也一并失效
+	if r.cache != nil {
+		_ = r.cache.InvalidateByID(ctx, tokenID)
+		_ = r.cache.InvalidateByTokenHash(ctx, token.TokenHash)
+		if token.PreviousTokenHash != "" {
+			_ = r.cache.InvalidateByTokenHash(ctx, token.PreviousTokenHash)
+		}
+	}
+
+	return &token, nil
+}
+
+// DisableByAccountID 停用账户下所有 Token，返回被停用的数量
+func (r *MongoTokenRepository) DisableByAccountID(ctx context.Context, accountID string) (int64, error) {
+	return r.disableMany(ctx, bson.M{"account_id": accountID, "is_active": true})
+}
+
+// DisableByClientID 停用 OAuth 2.0 客户端签发的所有 Token，返回被停用的数量
+func (r *MongoTokenRepository) DisableByClientID(ctx context.Context, clientID string) (int64, error) {
+	return r.disableMany(ctx, bson.M{"client_id": clientID, "is_active": true})
+}
+
+// disableMany 批量停用匹配的 Token 并失效缓存
+func (r *MongoTokenRepository) disableMany(ctx context.Context, filter bson.M) (int64, error) {
+	// 先查询待停用 token 的哈希（用于失效缓存）
+	var tokens []interfaces.Token
+	if r.cache != nil {
+		opts := options.Find().SetProjection(bson.M{"_id": 1, "token_hash": 1, "previous_token_hash": 1})
+		cursor, err := r.collection.Find(ctx, filter, opts)
+		if err != nil {
+			return 0, err
+		}
+		if err := cursor.All(ctx, &tokens); err != nil {
+			return 0, err
+		}
+	}
+
+	result, err := r.collection.UpdateMany(ctx, filter, bson.M{
+		"$set": bson.M{
+			"is_active": false,
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if r.cache != nil {
+		for _, token := range tokens {
+			_ = r.cache.InvalidateByID(ctx, token.ID)
+			_ = r.cache.InvalidateByTokenHash(ctx, token.TokenHash)
+			if token.PreviousTokenHash != "" {
+				_ = r.cache.InvalidateByTokenHash(ctx, token.PreviousTokenHash)
+			}
+		}
+	}
+
+	return result.ModifiedCount, nil
+}
+
 // Delete 删除 Token
 func (r *MongoTokenRepository) Delete(ctx context.Context, tokenID string) error {
 	// 先查询获取 token_value（用于失效缓存）
@@ -253,11 +472,23 @@ func (r *MongoTokenRepository) Delete(ctx context.Context, tokenID string) error
 	err := r.collection.FindOne(ctx, bson.M{"_id": tokenID}).Decode(&token)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return errors.New("token not found")
+			return interfaces.ErrTokenNotFound
 		}
 		return err
 	}
 
+	// 签名 Token 在过期前仍可离线验证，先写入吊销记录（保留到过期），再删除
+	if token.Format == interfaces.TokenFormatSigned && token.ExpiresAt != nil && token.ExpiresAt.After(time.Now()) {
+		_, err := r.revoked.UpdateOne(ctx,
+			bson.M{"_id": tokenID},
+			bson.M{"$set": bson.M{"expires_at": *token.ExpiresAt}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
 	// 删除 MongoDB
 	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": tokenID})
 	if err != nil {
@@ -265,18 +496,92 @@ func (r *MongoTokenRepository) Delete(ctx context.Context, tokenID string) error
 	}
 
 	if result.DeletedCount == 0 {
-		return errors.New("token not found")
+		return interfaces.ErrTokenNotFound
 	}
 
-	// 失效两个缓存键
+	// 失效两个缓存键（包括宽限期内的旧值）
 	if r.cache != nil {
 		_ = r.cache.InvalidateByID(ctx, tokenID)
-		_ = r.cache.InvalidateByTokenValue(ctx, token.Token)
+		_ = r.cache.InvalidateByTokenHash(ctx, token.TokenHash)
+		if token.PreviousTokenHash != "" {
+			_ = r.cache.InvalidateByTokenHash(ctx, token.PreviousTokenHash)
+		}
 	}
 
 	return nil
 }
 
+// Rotate 为 Token 生成新的 token 值
+// previousExpiresAt 非 nil 时，旧值的哈希保存为 previous_token_hash，在该时间之前仍可通过 GetByTokenValue 查到
+// 返回的 Token.Token 为新的明文值
+func (r *MongoTokenRepository) Rotate(ctx context.Context, tokenID string, previousExpiresAt *time.Time) (*interfaces.Token, error) {
+	var token interfaces.Token
+	err := r.collection.FindOne(ctx, bson.M{"_id": tokenID}).Decode(&token)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, interfaces.ErrTokenNotFound
+		}
+		return nil, err
+	}
+
+	// 沿用原 token 的前缀生成新值（预览中保留了完整前缀）
+	newValue, err := generateTokenValue(tokenPrefixOf(token.TokenPreview))
+	if err != nil {
+		return nil, err
+	}
+	newHash := r.hashTokenValue(newValue)
+
+	now := time.Now()
+	set := bson.M{
+		"token_hash":    newHash,
+		"token_preview": maskTokenValue(newValue),
+		"rotated_at":    now,
+	}
+	update := bson.M{"$set": set}
+	if previousExpiresAt != nil {
+		set["previous_token_hash"] = token.TokenHash
+		set["previous_token_expires_at"] = *previousExpiresAt
+	} else {
+		update["$unset"] = bson.M{"previous_token_hash": "", "previous_token_expires_at": ""}
+	}
+
+	// 以当前 token 哈希为条件更新，避免并发轮换互相覆盖
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": tokenID, "token_hash": token.TokenHash}, update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.New("token already exists")
+		}
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, fmt.Errorf("%w: token was rotated concurrently, please retry", interfaces.ErrConflict)
+	}
+
+	// 失效缓存：token:id、新旧 token 哈希，以及被本次轮换覆盖的更早旧值
+	if r.cache != nil {
+		_ = r.cache.InvalidateByID(ctx, tokenID)
+		_ = r.cache.InvalidateByTokenHash(ctx, token.TokenHash)
+		_ = r.cache.InvalidateByTokenHash(ctx, newHash)
+		if token.PreviousTokenHash != "" {
+			_ = r.cache.InvalidateByTokenHash(ctx, token.PreviousTokenHash)
+		}
+	}
+
+	if previousExpiresAt != nil {
+		token.PreviousTokenHash = token.TokenHash
+		token.PreviousTokenExpiresAt = previousExpiresAt
+	} else {
+		token.PreviousTokenHash = ""
+		token.PreviousTokenExpiresAt = nil
+	}
+	token.Token = newValue
+	token.TokenHash = newHash
+	token.TokenPreview = maskTokenValue(newValue)
+	token.RotatedAt = &now
+
+	return &token, nil
+}
+
 // IncrementUsage 增加使用次数
 func (r *MongoTokenRepository) IncrementUsage(ctx context.Context, tokenID string) error {
 	_, err := r.collection.UpdateOne(
@@ -290,6 +595,28 @@ func (r *MongoTokenRepository) IncrementUsage(ctx context.Context, tokenID strin
 	return err
 }
 
+// IncrementUsageBatch 批量增加使用次数并更新最后使用时间（单次 BulkWrite）
+func (r *MongoTokenRepository) IncrementUsageBatch(ctx context.Context, increments []interfaces.TokenUsageIncrement) error {
+	models := make([]mongo.WriteModel, 0, len(increments))
+	for _, inc := range increments {
+		if inc.Count <= 0 {
+			continue
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": inc.TokenID}).
+			SetUpdate(bson.M{
+				"$inc": bson.M{"total_requests": inc.Count},
+				"$max": bson.M{"last_used_at": inc.LastUsedAt},
+			}))
+	}
+	if len(models) == 0 {
+		return nil
+	}
+
+	_, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
 // UpdateLastUsed 更新最后使用时间
 func (r *MongoTokenRepository) UpdateLastUsed(ctx context.Context, tokenID string, lastUsedAt time.Time) error {
 	_, err := r.collection.UpdateOne(
@@ -324,12 +651,63 @@ func (r *MongoTokenRepository) DeleteExpired(ctx context.Context) (int64, error)
 	return result.DeletedCount, nil
 }
 
+// ListRevokedSignedIDs 列出已停用或已删除、但尚未过期的签名 Token ID
+func (r *MongoTokenRepository) ListRevokedSignedIDs(ctx context.Context) ([]string, error) {
+	now := time.Now()
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+
+	var ids []string
+	collect := func(coll *mongo.Collection, filter bson.M) error {
+		cursor, err := coll.Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		var docs []struct {
+			ID string `bson:"_id"`
+		}
+		if err := cursor.All(ctx, &docs); err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			ids = append(ids, doc.ID)
+		}
+		return nil
+	}
+
+	// 已停用的签名 Token
+	if err := collect(r.collection, bson.M{
+		"format":     interfaces.TokenFormatSigned,
+		"is_active":  false,
+		"expires_at": bson.M{"$gt": now},
+	}); err != nil {
+		return nil, err
+	}
+
+	// 已删除的签名 Token（TTL 索引清理可能延迟，按过期时间再过滤一次）
+	if err := collect(r.revoked, bson.M{"expires_at": bson.M{"$gt": now}}); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
 // CreateIndexes 创建索引
 func (r *MongoTokenRepository) CreateIndexes(ctx context.Context) error {
+	// 旧版本在明文 token 字段上建立了非稀疏唯一索引，新写入的文档没有该字段，不删除时第二次写入就会冲突
+	if err := r.dropLegacyTokenIndexes(ctx); err != nil {
+		return err
+	}
+
 	indexes := []mongo.IndexModel{
 		{
-			Keys:    bson.D{{Key: "token", Value: 1}},
-			Options: options.Index().SetUnique(true),
+			// 稀疏：迁移前的历史文档还没有 token_hash
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true).SetSparse(true),
+		},
+		{
+			// 轮换宽限期内的旧 token 哈希查询
+			Keys:    bson.D{{Key: "previous_token_hash", Value: 1}},
+			Options: options.Index().SetSparse(true),
 		},
 		{
 			// 租户隔离的核心索引
@@ -349,12 +727,129 @@ func (r *MongoTokenRepository) CreateIndexes(ctx context.Context) error {
 				{Key: "created_at", Value: -1},
 			},
 		},
+		{
+			// 签名 Token 吊销列表（不透明 Token 没有 format 字段）
+			Keys: bson.D{
+				{Key: "format", Value: 1},
+				{Key: "is_active", Value: 1},
+			},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			// 按 OAuth 2.0 客户端批量停用（只有 /oauth2/token 签发的 Token 有 client_id 字段）
+			Keys:    bson.D{{Key: "client_id", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			// TTL 索引：/oauth2/token 签发的短期 Token 过期后自动删除（其他 Token 没有 purge_at 字段，不受影响）
+			Keys:    bson.D{{Key: "purge_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	if _, err := r.collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return err
 	}
 
-	_, err := r.collection.Indexes().CreateMany(ctx, indexes)
+	// 吊销记录在签名 Token 过期后自动清理
+	_, err := r.revoked.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
 	return err
 }
 
+// MigrateTokenHashes 将历史文档中的明文 token / previous_token 迁移为哈希，返回迁移的文档数
+// 幂等：只处理仍含明文字段的文档，可重复执行
+func (r *MongoTokenRepository) MigrateTokenHashes(ctx context.Context) (int64, error) {
+	// 旧的 token 唯一索引不是稀疏索引，移除 token 字段前必须先删除，否则多个文档的空值会冲突
+	if err := r.dropLegacyTokenIndexes(ctx); err != nil {
+		return 0, err
+	}
+
+	opts := options.Find().SetProjection(bson.M{"token": 1, "previous_token": 1})
+
+	cursor, err := r.collection.Find(ctx, unmigratedTokenFilter(), opts)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var migrated int64
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID            string `bson:"_id"`
+			Token         string `bson:"token"`
+			PreviousToken string `bson:"previous_token"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return migrated, err
+		}
+
+		set := bson.M{}
+		if doc.Token != "" {
+			set["token_hash"] = r.hashTokenValue(doc.Token)
+			set["token_preview"] = maskTokenValue(doc.Token)
+		}
+		if doc.PreviousToken != "" {
+			set["previous_token_hash"] = r.hashTokenValue(doc.PreviousToken)
+		}
+		update := bson.M{"$unset": bson.M{"token": "", "previous_token": ""}}
+		if len(set) > 0 {
+			update["$set"] = set
+		}
+
+		if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": doc.ID}, update); err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+
+	return migrated, cursor.Err()
+}
+
+// CountUnmigratedTokens 统计仍含明文 token / previous_token 字段的文档数
+// 这些 Token 无法通过哈希查到，服务启动前必须先执行 cmd/migrate-token-hash
+func (r *MongoTokenRepository) CountUnmigratedTokens(ctx context.Context) (int64, error) {
+	return r.collection.CountDocuments(ctx, unmigratedTokenFilter())
+}
+
+// unmigratedTokenFilter 仍含明文字段的历史文档
+func unmigratedTokenFilter() bson.M {
+	return bson.M{
+		"$or": []bson.M{
+			{"token": bson.M{"$exists": true}},
+			{"previous_token": bson.M{"$exists": true}},
+		},
+	}
+}
+
+// dropLegacyTokenIndexes 删除建立在明文 token / previous_token 字段上的索引
+func (r *MongoTokenRepository) dropLegacyTokenIndexes(ctx context.Context) error {
+	cursor, err := r.collection.Indexes().List(ctx)
+	if err != nil {
+		return err
+	}
+
+	var specs []struct {
+		Name string `bson:"name"`
+		Key  bson.D `bson:"key"`
+	}
+	if err := cursor.All(ctx, &specs); err != nil {
+		return err
+	}
+
+	for _, spec := range specs {
+		if len(spec.Key) != 1 || (spec.Key[0].Key != "token" && spec.Key[0].Key != "previous_token") {
+			continue
+		}
+		if _, err := r.collection.Indexes().DropOne(ctx, spec.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // VerifyTokenOwnership 验证 Token 是否属于指定账户（租户隔离检查）
 func (r *MongoTokenRepository) VerifyTokenOwnership(ctx context.Context, tokenID string, accountID string) (bool, error) {
 	count, err := r.collection.CountDocuments(
@@ -393,6 +888,76 @@ func generateTokenValue(prefix string) (string, error) {
 	return prefix + "-" + hex.EncodeToString(b), nil
 }
 
+// hashTokenValue 计算 token 值的 HMAC-SHA256（十六进制）
+func (r *MongoTokenRepository) hashTokenValue(tokenValue string) string {
+	mac := hmac.New(sha256.New, r.pepper)
+	mac.Write([]byte(tokenValue))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// tokenHashFilter 按 token 哈希查询的条件：匹配当前值，或仍在轮换宽限期内的旧值
+func tokenHashFilter(tokenHash string) bson.M {
+	return bson.M{
+		"$or": []bson.M{
+			{"token_hash": tokenHash},
+			{
+				"previous_token_hash":       tokenHash,
+				"previous_token_expires_at": bson.M{"$gt": time.Now()},
+			},
+		},
+	}
+}
+
+// maskTokenValue 隐藏 Token 的中间部分，用于列表和详情展示
+// 格式: prefix全部显示 + 8字符 + **** + 8字符
+// 示例: sk-a1b2c3d4****e5f6g7h8
+func maskTokenValue(token string) string {
+	// 找到 prefix 分隔符位置
+	prefixEnd := strings.Index(token, "-")
+	if prefixEnd == -1 {
+		prefixEnd = 0
+	} else {
+		prefixEnd++ // 包含 "-"
+	}
+
+	suffix := token[prefixEnd:] // prefix 之后的部分
+
+	const (
+		showBefore = 8 // 显示前 8 个字符
+		showAfter  = 8 // 显示后 8 个字符
+		maskLen    = 4 // 4 个星号
+	)
+
+	// 如果 suffix 太短，直接返回原 token
+	if len(suffix) < showBefore+showAfter {
+		return token
+	}
+
+	// prefix + 前8字符 + **** + 后8字符
+	return token[:prefixEnd] + suffix[:showBefore] + strings.Repeat("*", maskLen) + suffix[len(suffix)-showAfter:]
+}
+
+// maskSignedTokenValue 隐藏签名 Token 的中间部分
+// JWT 的 base64url 字符中可能含 "-"，不能按前缀规则截取
+// 示例: eyJhbGci****Q2xvZ2Vn
+func maskSignedTokenValue(token string) string {
+	const show = 8
+	if len(token) < 2*show {
+		return token
+	}
+	return token[:show] + strings.Repeat("*", 4) + token[len(token)-show:]
+}
+
+// tokenPrefixOf 从 token 值（或其脱敏预览）中提取自定义前缀（与 generateTokenValue 对应）
+// "sk-xxx" 返回 "sk"，"myapp-xxx" 返回 "myapp"
+func tokenPrefixOf(tokenValue string) string {
+	idx := strings.LastIndex(tokenValue, "-")
+	if idx <= 0 {
+		return ""
+	}
+	return tokenValue[:idx]
+}
+
 // generateRandomID 生成随机 ID
 func generateRandomID(length int) string {
 	b := make([]byte, length)
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestHashTokenValue(t *testing.T) {
+	repo := &MongoTokenRepository{pepper: []byte("pepper-1")}
+
+	mac := hmac.New(sha256.New, []byte("pepper-1"))
+	mac.Write([]byte("sk-abc"))
+	want := hex.EncodeToString(mac.Sum(nil))
+
+	assert.Equal(t, want, repo.hashTokenValue("sk-abc"))
+	assert.Equal(t, repo.hashTokenValue("sk-abc"), repo.hashTokenValue("sk-abc"))
+	assert.NotEqual(t, repo.hashTokenValue("sk-abc"), repo.hashTokenValue("sk-abd"))
+
+	// 不同 pepper 得到不同哈希，数据库泄露后无法离线比对 token 值
+	other := &MongoTokenRepository{pepper: []byte("pepper-2")}
+	assert.NotEqual(t, repo.hashTokenValue("sk-abc"), other.hashTokenValue("sk-abc"))
+}
+
+func TestMigrateTokenHashes(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("migrates plaintext fields and is idempotent", func(mt *mtest.T) {
+		repo := NewMongoTokenRepository(mt.DB, []byte("pepper"))
+		ns := mt.DB.Name() + "." + tokensCollection
+		current := "sk-" + strings.Repeat("a1b2c3d4", 8)
+		previous := "sk-" + strings.Repeat("e5f6a7b8", 8)
+
+		// 第一次：删除旧的明文唯一索引，迁移一个含当前值和轮换旧值的文档
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+				bson.D{{Key: "name", Value: "_id_"}, {Key: "key", Value: bson.D{{Key: "_id", Value: 1}}}},
+				bson.D{{Key: "name", Value: "token_1"}, {Key: "key", Value: bson.D{{Key: "token", Value: 1}}}},
+			),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: "tk_1"}, {Key: "token", Value: current}, {Key: "previous_token", Value: previous}},
+			),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+		)
+
+		migrated, err := repo.MigrateTokenHashes(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), migrated)
+
+		var dropped, updated bool
+		for evt := mt.GetStartedEvent(); evt != nil; evt = mt.GetStartedEvent() {
+			switch evt.CommandName {
+			case "dropIndexes":
+				dropped = true
+				assert.Equal(t, "token_1", evt.Command.Lookup("index").StringValue())
+			case "update":
+				updated = true
+				update := evt.Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u").Document()
+				set := update.Lookup("$set").Document()
+				assert.Equal(t, repo.hashTokenValue(current), set.Lookup("token_hash").StringValue())
+				assert.Equal(t, repo.hashTokenValue(previous), set.Lookup("previous_token_hash").StringValue())
+				assert.Equal(t, maskTokenValue(current), set.Lookup("token_preview").StringValue())
+				assert.NotContains(t, set.String(), current)
+
+				unset := update.Lookup("$unset").Document()
+				_, err := unset.LookupErr("token")
+				assert.NoError(t, err)
+				_, err = unset.LookupErr("previous_token")
+				assert.NoError(t, err)
+			}
+		}
+		assert.True(t, dropped)
+		assert.True(t, updated)
+
+		// 第二次：索引和文档都已迁移，不再有任何写操作
+		mt.ClearEvents()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+				bson.D{{Key: "name", Value: "_id_"}, {Key: "key", Value: bson.D{{Key: "_id", Value: 1}}}},
+				bson.D{{Key: "name", Value: "token_hash_1"}, {Key: "key", Value: bson.D{{Key: "token_hash", Value: 1}}}},
+			),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch),
+		)
+
+		migrated, err = repo.MigrateTokenHashes(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), migrated)
+		for evt := mt.GetStartedEvent(); evt != nil; evt = mt.GetStartedEvent() {
+			assert.NotContains(t, []string{"dropIndexes", "update"}, evt.CommandName)
+		}
+	})
+}
+
+func TestCreateIndexes_DropsLegacyTokenIndex(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("drops non-sparse plaintext token index", func(mt *mtest.T) {
+		repo := NewMongoTokenRepository(mt.DB, []byte("pepper"))
+		ns := mt.DB.Name() + "." + tokensCollection
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+				bson.D{{Key: "name", Value: "token_1"}, {Key: "key", Value: bson.D{{Key: "token", Value: 1}}}, {Key: "unique", Value: true}},
+				bson.D{{Key: "name", Value: "previous_token_1"}, {Key: "key", Value: bson.D{{Key: "previous_token", Value: 1}}}},
+			),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+		)
+
+		require.NoError(t, repo.CreateIndexes(context.Background()))
+
+		var dropped []string
+		for evt := mt.GetStartedEvent(); evt != nil; evt = mt.GetStartedEvent() {
+			if evt.CommandName == "dropIndexes" {
+				dropped = append(dropped, evt.Command.Lookup("index").StringValue())
+			}
+		}
+		assert.Equal(t, []string{"token_1", "previous_token_1"}, dropped)
+	})
+}
+
+// recordingTokenCache 记录缓存失效调用
+type recordingTokenCache struct {
+	TokenCache
+	invalidatedIDs    []string
+	invalidatedHashes []string
+}
+
+func (c *recordingTokenCache) InvalidateByID(ctx context.Context, tokenID string) error {
+	c.invalidatedIDs = append(c.invalidatedIDs, tokenID)
+	return nil
+}
+
+func (c *recordingTokenCache) InvalidateByTokenHash(ctx context.Context, tokenHash string) error {
+	c.invalidatedHashes = append(c.invalidatedHashes, tokenHash)
+	return nil
+}
+
+func TestDisableByAccountID(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("disables all active tokens of the account and invalidates cache", func(mt *mtest.T) {
+		repo := NewMongoTokenRepository(mt.DB, []byte("pepper"))
+		cache := &recordingTokenCache{}
+		repo.SetCache(cache)
+		ns := mt.DB.Name() + "." + tokensCollection
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: "tk_1"}, {Key: "token_hash", Value: "h1"}},
+				bson.D{{Key: "_id", Value: "tk_2"}, {Key: "token_hash", Value: "h2"}, {Key: "previous_token_hash", Value: "h2_old"}},
+			),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}),
+		)
+
+		count, err := repo.DisableByAccountID(context.Background(), "acc_1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+
+		find := mt.GetStartedEvent()
+		require.Equal(t, "find", find.CommandName)
+		assert.Equal(t, "acc_1", find.Command.Lookup("filter", "account_id").StringValue())
+
+		update := mt.GetStartedEvent()
+		require.Equal(t, "update", update.CommandName)
+		stmt := update.Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(t, "acc_1", stmt.Lookup("q", "account_id").StringValue())
+		assert.True(t, stmt.Lookup("q", "is_active").Boolean())
+		assert.True(t, stmt.Lookup("multi").Boolean(), "all tokens of the account must be updated")
+		assert.False(t, stmt.Lookup("u", "$set", "is_active").Boolean())
+
+		assert.ElementsMatch(t, []string{"tk_1", "tk_2"}, cache.invalidatedIDs)
+		assert.ElementsMatch(t, []string{"h1", "h2", "h2_old"}, cache.invalidatedHashes)
+	})
+
+	mt.Run("update failure is returned", func(mt *mtest.T) {
+		repo := NewMongoTokenRepository(mt.DB, []byte("pepper"))
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 91, Message: "shutdown in progress"}))
+
+		_, err := repo.DisableByAccountID(context.Background(), "acc_1")
+		assert.Error(t, err)
+	})
+}
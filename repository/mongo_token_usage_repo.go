@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	tokenUsageStatsCollection = "token_usage_stats"
+)
+
+// MongoTokenUsageRepository MongoDB 实现的 Token 使用统计存储库
+// 每个 token 每天、每小时各一条计数文档，过期由 expire_at 上的 TTL 索引清理
+type MongoTokenUsageRepository struct {
+	collection *mongo.Collection
+
+	dailyRetention  time.Duration
+	hourlyRetention time.Duration
+}
+
+// NewMongoTokenUsageRepository 创建 Token 使用统计存储库实例
+// dailyRetention / hourlyRetention 分别为天桶和小时桶的保留时长
+func NewMongoTokenUsageRepository(db *mongo.Database, dailyRetention, hourlyRetention time.Duration) *MongoTokenUsageRepository {
+	return &MongoTokenUsageRepository{
+		collection:      db.Collection(tokenUsageStatsCollection),
+		dailyRetention:  dailyRetention,
+		hourlyRetention: hourlyRetention,
+	}
+}
+
+// IncrementBatch 将每个增量计入 LastUsedAt 所在的天桶和小时桶（单次 BulkWrite）
+func (r *MongoTokenUsageRepository) IncrementBatch(ctx context.Context, increments []interfaces.TokenUsageIncrement) error {
+	models := make([]mongo.WriteModel, 0, len(increments)*2)
+	for _, inc := range increments {
+		if inc.Count <= 0 {
+			continue
+		}
+
+		at := inc.LastUsedAt.UTC()
+		day := at.Truncate(24 * time.Hour)
+		hour := at.Truncate(time.Hour)
+
+		models = append(models,
+			r.incrementModel(inc.TokenID, inc.AccountID, interfaces.UsageGranularityDay, day, day.Add(r.dailyRetention), inc.Count),
+			r.incrementModel(inc.TokenID, inc.AccountID, interfaces.UsageGranularityHour, hour, hour.Add(r.hourlyRetention), inc.Count),
+		)
+	}
+	if len(models) == 0 {
+		return nil
+	}
+
+	_, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// incrementModel 构造单个计数桶的 upsert 操作
+func (r *MongoTokenUsageRepository) incrementModel(tokenID, accountID, granularity string, period, expireAt time.Time, count int64) mongo.WriteModel {
+	return mongo.NewUpdateOneModel().
+		SetFilter(bson.M{"_id": usageBucketID(tokenID, granularity, period)}).
+		SetUpdate(bson.M{
+			"$inc": bson.M{"requests": count},
+			"$setOnInsert": bson.M{
+				"token_id":    tokenID,
+				"account_id":  accountID,
+				"granularity": granularity,
+				"period":      period,
+				"expire_at":   expireAt,
+			},
+		}).
+		SetUpsert(true)
+}
+
+// ListByTokenID 查询 [from, to] 时间范围内指定粒度的计数桶，按时间升序
+func (r *MongoTokenUsageRepository) ListByTokenID(ctx context.Context, tokenID string, granularity string, from, to time.Time) ([]interfaces.TokenUsageBucket, error) {
+	filter := bson.M{
+		"token_id":    tokenID,
+		"granularity": granularity,
+		"period": bson.M{
+			"$gte": from.UTC(),
+			"$lte": to.UTC(),
+		},
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "period", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var buckets []interfaces.TokenUsageBucket
+	if err := cursor.All(ctx, &buckets); err != nil {
+		return nil, err
+	}
+
+	return buckets, nil
+}
+
+// CreateIndexes 创建索引
+func (r *MongoTokenUsageRepository) CreateIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			// 按 token + 粒度 + 时间范围查询
+			Keys: bson.D{
+				{Key: "token_id", Value: 1},
+				{Key: "granularity", Value: 1},
+				{Key: "period", Value: 1},
+			},
+		},
+		{
+			// TTL 索引：expire_at 到期后自动删除（天桶和小时桶保留时长不同）
+			Keys:    bson.D{{Key: "expire_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// usageBucketID 计数桶文档 ID，例如 tk_xxx:day:20250101 / tk_xxx:hour:2025010113
+func usageBucketID(tokenID, granularity string, period time.Time) string {
+	layout := "20060102"
+	if granularity == interfaces.UsageGranularityHour {
+		layout = "2006010215"
+	}
+	return fmt.Sprintf("%s:%s:%s", tokenID, granularity, period.Format(layout))
+}
@@ -73,7 +73,7 @@ func (r *MySQLUserInfoRepository) GetUserInfoByUID(ctx context.Context, uid uint
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found: uid=%d", uid)
+			return nil, fmt.Errorf("%w: uid=%d", interfaces.ErrUserNotFound, uid)
 		}
 		return nil, fmt.Errorf("failed to query user info: %w", err)
 	}
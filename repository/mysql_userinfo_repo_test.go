@@ -156,6 +156,7 @@ func TestGetUserInfoByUID_NotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, userInfo)
 	assert.Contains(t, err.Error(), "user not found")
+	assert.ErrorIs(t, err, interfaces.ErrUserNotFound)
 
 	// 验证 mock 期望
 	err = mock.ExpectationsWereMet()
@@ -2,7 +2,11 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -15,6 +19,9 @@ import (
 // RPCUserInfoRepository qconfapi RPC 实现的用户信息存储库
 type RPCUserInfoRepository struct {
 	client *qconfapi.Client
+
+	// 健康检查探测的 master 地址（qconfapi 客户端不提供探活接口）
+	masterHosts []string
 }
 
 // NewRPCUserInfoRepository 创建 RPC 用户信息存储库实例
@@ -24,6 +31,47 @@ func NewRPCUserInfoRepository(client *qconfapi.Client) *RPCUserInfoRepository {
 	}
 }
 
+// SetMasterHosts 设置健康检查探测的 master 地址（如 http://host:8510）
+func (r *RPCUserInfoRepository) SetMasterHosts(hosts []string) {
+	r.masterHosts = hosts
+}
+
+// HealthCheck 检查 qconfapi master 是否可达（任一 master 可建立 TCP 连接即视为健康）
+func (r *RPCUserInfoRepository) HealthCheck(ctx context.Context) error {
+	if len(r.masterHosts) == 0 {
+		return fmt.Errorf("qconfapi master hosts not configured")
+	}
+
+	var dialer net.Dialer
+	var lastErr error
+	for _, host := range r.masterHosts {
+		conn, err := dialer.DialContext(ctx, "tcp", masterDialAddr(host))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		conn.Close()
+		return nil
+	}
+	return fmt.Errorf("qconfapi master unreachable: %w", lastErr)
+}
+
+// masterDialAddr 将 master 地址转换为 host:port，URL 未指定端口时按 scheme 取默认端口
+func masterDialAddr(host string) string {
+	u, err := url.Parse(host)
+	if err != nil || u.Host == "" {
+		return host
+	}
+	if u.Port() != "" {
+		return u.Host
+	}
+	port := "80"
+	if strings.EqualFold(u.Scheme, "https") {
+		port = "443"
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
 // GetUserInfoByUID 根据 UID 通过 qconfapi RPC 查询用户信息
 func (r *RPCUserInfoRepository) GetUserInfoByUID(ctx context.Context, uid uint32) (*interfaces.UserInfo, error) {
 	// 创建 xlog.Logger（qconfapi 需要）
@@ -32,6 +80,11 @@ func (r *RPCUserInfoRepository) GetUserInfoByUID(ctx context.Context, uid uint32
 	// 调用 qconfapi 获取账户信息
 	accountInfo, err := r.client.GetAccountInfo(xl, uid)
 	if err != nil {
+		// qconfapi 对不存在的 uid 返回 612 / 404
+		var httpErr interface{ HTTPCode() int }
+		if errors.As(err, &httpErr) && (httpErr.HTTPCode() == 612 || httpErr.HTTPCode() == http.StatusNotFound) {
+			return nil, fmt.Errorf("%w: uid=%d", interfaces.ErrUserNotFound, uid)
+		}
 		return nil, fmt.Errorf("qconfapi GetAccountInfo failed: %w", err)
 	}
 
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMasterDialAddr(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"http://10.0.0.1:8510", "10.0.0.1:8510"},
+		{"https://qconf.example.com:9443", "qconf.example.com:9443"},
+		{"http://qconf.example.com", "qconf.example.com:80"},
+		{"https://qconf.example.com", "qconf.example.com:443"},
+		{"HTTPS://qconf.example.com/", "qconf.example.com:443"},
+		{"http://[::1]", "[::1]:80"},
+		{"10.0.0.1:8510", "10.0.0.1:8510"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			assert.Equal(t, tt.want, masterDialAddr(tt.host))
+		})
+	}
+}
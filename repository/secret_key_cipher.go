@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// encryptedSecretKeyPrefix 已加密 SecretKey 的前缀（版本号用于以后更换算法）
+const encryptedSecretKeyPrefix = "enc:v1:"
+
+// SecretKeyCipher 账户 SecretKey 静态加密（AES-256-GCM）
+// HMAC 验签需要 SecretKey 原文，不能像 token 一样单向哈希，因此使用可逆加密：
+// 数据库中只保存密文，密钥不入库；不带前缀的历史值按明文读取，启动时由 EncryptPlaintextSecretKeys 迁移
+type SecretKeyCipher struct {
+	aead cipher.AEAD
+}
+
+// NewSecretKeyCipher 创建 SecretKey 加密器，key 为 64 位十六进制字符串（32 字节）
+func NewSecretKeyCipher(key string) (*SecretKeyCipher, error) {
+	raw, err := hex.DecodeString(strings.TrimSpace(key))
+	if err != nil || len(raw) != 32 {
+		return nil, errors.New("secret key encryption key must be 32 bytes encoded as 64 hex characters")
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretKeyCipher{aead: aead}, nil
+}
+
+// Encrypt 加密 SecretKey，返回 enc:v1:<base64(nonce|密文)>
+func (c *SecretKeyCipher) Encrypt(plain string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plain), nil)
+	return encryptedSecretKeyPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密存储的 SecretKey（未加密的历史值原样返回）
+func (c *SecretKeyCipher) Decrypt(stored string) (string, error) {
+	if !IsEncryptedSecretKey(stored) {
+		return stored, nil
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(stored, encryptedSecretKeyPrefix))
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", errors.New("malformed encrypted secret key")
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret key (wrong encryption key?): %w", err)
+	}
+	return string(plain), nil
+}
+
+// IsEncryptedSecretKey 存储值是否已加密
+func IsEncryptedSecretKey(stored string) bool {
+	return strings.HasPrefix(stored, encryptedSecretKeyPrefix)
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecretKeyEncryptionKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+func TestSecretKeyCipher(t *testing.T) {
+	c, err := NewSecretKeyCipher(testSecretKeyEncryptionKey)
+	require.NoError(t, err)
+
+	sealed, err := c.Encrypt("SK_abc")
+	require.NoError(t, err)
+	assert.True(t, IsEncryptedSecretKey(sealed))
+	assert.NotContains(t, sealed, "SK_abc")
+
+	plain, err := c.Decrypt(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "SK_abc", plain)
+
+	// 每次加密使用随机 nonce
+	again, err := c.Encrypt("SK_abc")
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again)
+
+	// 历史明文原样返回
+	plain, err = c.Decrypt("SK_legacy")
+	require.NoError(t, err)
+	assert.Equal(t, "SK_legacy", plain)
+
+	// 密钥不一致或密文被篡改时解密失败
+	other, err := NewSecretKeyCipher(strings.Repeat("ab", 32))
+	require.NoError(t, err)
+	_, err = other.Decrypt(sealed)
+	assert.Error(t, err)
+
+	_, err = c.Decrypt(sealed[:len(sealed)-2] + "AA")
+	assert.Error(t, err)
+	_, err = c.Decrypt(encryptedSecretKeyPrefix + "!!")
+	assert.Error(t, err)
+}
+
+func TestNewSecretKeyCipher_InvalidKey(t *testing.T) {
+	for _, key := range []string{"", "not-hex", strings.Repeat("ab", 16)} {
+		_, err := NewSecretKeyCipher(key)
+		assert.Error(t, err, key)
+	}
+}
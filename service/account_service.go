@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// 注册密码长度限制（bcrypt 只使用前 72 字节）
+	minPasswordLength = 8
+	maxPasswordLength = 72
+)
+
+// AccountServiceImpl 账户管理服务实现
+// 注册密码使用 bcrypt 存储；SecretKey 由存储层加密后入库（HMAC 验签需要原文，不能单向哈希），
+// 只在注册和重新生成时返回一次
+type AccountServiceImpl struct {
+	accountRepo interfaces.AccountRepository
+	auditRepo   interfaces.AuditLogRepository
+}
+
+// NewAccountService 创建账户服务实例
+func NewAccountService(accountRepo interfaces.AccountRepository, auditRepo interfaces.AuditLogRepository) *AccountServiceImpl {
+	return &AccountServiceImpl{
+		accountRepo: accountRepo,
+		auditRepo:   auditRepo,
+	}
+}
+
+// Register 注册新账户，生成 AK/SK
+func (s *AccountServiceImpl) Register(ctx context.Context, req *interfaces.AccountRegisterRequest) (*interfaces.AccountRegisterResponse, error) {
+	// 1. 校验参数
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return nil, fmt.Errorf("%w: email is not a valid address", interfaces.ErrInvalidArgument)
+	}
+	company := strings.TrimSpace(req.Company)
+	if company == "" {
+		return nil, fmt.Errorf("%w: company is required", interfaces.ErrInvalidArgument)
+	}
+	if len(req.Password) < minPasswordLength || len(req.Password) > maxPasswordLength {
+		return nil, fmt.Errorf("%w: password length must be between %d and %d", interfaces.ErrInvalidArgument, minPasswordLength, maxPasswordLength)
+	}
+
+	// 2. 邮箱查重（并发注册由 email 唯一索引兜底）
+	existing, err := s.accountRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, interfaces.ErrDuplicateEmail
+	}
+
+	// 3. 生成密钥和密码哈希
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	secretKey, err := repository.GenerateSecretKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret key: %w", err)
+	}
+
+	// 4. 创建账户（AccessKey 由存储层生成）
+	account := &interfaces.Account{
+		Email:        email,
+		Company:      company,
+		SecretKey:    secretKey,
+		PasswordHash: string(passwordHash),
+		Status:       interfaces.AccountStatusActive,
+	}
+	if err := s.accountRepo.Create(ctx, account); err != nil {
+		return nil, err
+	}
+
+	s.logAction(ctx, account.ID, interfaces.AuditActionRegister, account.ID, interfaces.AuditResultSuccess, "", map[string]interface{}{
+		"email":   email,
+		"company": company,
+	})
+
+	return &interfaces.AccountRegisterResponse{
+		AccountID: account.ID,
+		Email:     account.Email,
+		Company:   account.Company,
+		AccessKey: account.AccessKey,
+		SecretKey: secretKey,
+		CreatedAt: account.CreatedAt,
+	}, nil
+}
+
+// GetAccountInfo 获取账户信息
+func (s *AccountServiceImpl) GetAccountInfo(ctx context.Context, accountID string) (*interfaces.Account, error) {
+	account, err := s.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, interfaces.ErrAccountNotFound
+	}
+	return account, nil
+}
+
+// RegenerateSecretKey 重新生成 SecretKey（旧 SecretKey 立即失效）
+func (s *AccountServiceImpl) RegenerateSecretKey(ctx context.Context, accountID string) (*interfaces.RegenerateSecretKeyResponse, error) {
+	// 子账号不能重置主账户密钥
+	if iuid, iamAlias := subAccountFromContext(ctx); iuid != "" || iamAlias != "" {
+		return nil, fmt.Errorf("%w: sub-accounts cannot regenerate the secret key", interfaces.ErrPermissionDenied)
+	}
+
+	account, err := s.GetAccountInfo(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	secretKey, err := repository.GenerateSecretKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret key: %w", err)
+	}
+
+	if err := s.accountRepo.UpdateSecretKey(ctx, accountID, secretKey); err != nil {
+		s.logAction(ctx, accountID, interfaces.AuditActionRegenerateKey, accountID, interfaces.AuditResultFailure, err.Error(), nil)
+		return nil, err
+	}
+
+	s.logAction(ctx, accountID, interfaces.AuditActionRegenerateKey, accountID, interfaces.AuditResultSuccess, "", map[string]interface{}{
+		"access_key": account.AccessKey,
+	})
+
+	return &interfaces.RegenerateSecretKeyResponse{
+		AccessKey: account.AccessKey,
+		SecretKey: secretKey,
+		UpdatedAt: time.Now(),
+	}, nil
+}
+
+// SuspendAccount 暂停账户
+func (s *AccountServiceImpl) SuspendAccount(ctx context.Context, accountID string) error {
+	return s.accountRepo.UpdateStatus(ctx, accountID, interfaces.AccountStatusSuspended)
+}
+
+// ActivateAccount 激活账户
+func (s *AccountServiceImpl) ActivateAccount(ctx context.Context, accountID string) error {
+	return s.accountRepo.UpdateStatus(ctx, accountID, interfaces.AccountStatusActive)
+}
+
+// ========================================
+// 辅助方法
+// ========================================
+
+func (s *AccountServiceImpl) logAction(ctx context.Context, accountID, action, resourceID, result, errorMsg string, requestData map[string]interface{}) {
+	if s.auditRepo == nil {
+		return
+	}
+
+	iuid, iamAlias := subAccountFromContext(ctx)
+	s.auditRepo.Create(ctx, &interfaces.AuditLog{
+		AccountID:   accountID,
+		Action:      action,
+		ResourceID:  resourceID,
+		IUID:        iuid,
+		IamAlias:    iamAlias,
+		Result:      result,
+		ErrorMsg:    errorMsg,
+		RequestData: requestData,
+		Timestamp:   time.Now(),
+	})
+}
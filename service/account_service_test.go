@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/auth"
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ========================================
+// Mock AccountRepository / AuditLogRepository
+// ========================================
+
+type MockAccountRepository struct {
+	mock.Mock
+}
+
+func (m *MockAccountRepository) Create(ctx context.Context, account *interfaces.Account) error {
+	args := m.Called(ctx, account)
+	return args.Error(0)
+}
+
+func (m *MockAccountRepository) GetByAccessKey(ctx context.Context, accessKey string) (*interfaces.Account, error) {
+	args := m.Called(ctx, accessKey)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.Account), args.Error(1)
+}
+
+func (m *MockAccountRepository) GetByEmail(ctx context.Context, email string) (*interfaces.Account, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.Account), args.Error(1)
+}
+
+func (m *MockAccountRepository) GetByID(ctx context.Context, id string) (*interfaces.Account, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.Account), args.Error(1)
+}
+
+func (m *MockAccountRepository) UpdateSecretKey(ctx context.Context, accountID string, newSecretKey string) error {
+	args := m.Called(ctx, accountID, newSecretKey)
+	return args.Error(0)
+}
+
+func (m *MockAccountRepository) UpdateStatus(ctx context.Context, accountID string, status string) error {
+	args := m.Called(ctx, accountID, status)
+	return args.Error(0)
+}
+
+func (m *MockAccountRepository) List(ctx context.Context, query *interfaces.AccountListQuery) ([]interfaces.Account, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]interfaces.Account), args.Error(1)
+}
+
+func (m *MockAccountRepository) Count(ctx context.Context, query *interfaces.AccountListQuery) (int64, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAccountRepository) ListSuspendedIDs(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+type MockAuditLogRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditLogRepository) Create(ctx context.Context, log *interfaces.AuditLog) error {
+	args := m.Called(ctx, log)
+	return args.Error(0)
+}
+
+func (m *MockAuditLogRepository) ListByAccountID(ctx context.Context, accountID string, query *interfaces.AuditLogQuery, iuid, iamAlias string) ([]interfaces.AuditLog, string, error) {
+	args := m.Called(ctx, accountID, query, iuid, iamAlias)
+	return args.Get(0).([]interfaces.AuditLog), args.String(1), args.Error(2)
+}
+
+func (m *MockAuditLogRepository) CountByAccountID(ctx context.Context, accountID string, query *interfaces.AuditLogQuery, iuid, iamAlias string) (int64, error) {
+	args := m.Called(ctx, accountID, query, iuid, iamAlias)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAuditLogRepository) DeleteOldLogs(ctx context.Context, olderThan time.Time) (int64, error) {
+	args := m.Called(ctx, olderThan)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// ========================================
+// TestRegister
+// ========================================
+
+func TestRegister_Success(t *testing.T) {
+	mockRepo := new(MockAccountRepository)
+	mockAudit := new(MockAuditLogRepository)
+	svc := NewAccountService(mockRepo, mockAudit)
+
+	var created *interfaces.Account
+	mockRepo.On("GetByEmail", mock.Anything, "user@example.com").Return(nil, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*interfaces.Account")).
+		Run(func(args mock.Arguments) {
+			created = args.Get(1).(*interfaces.Account)
+			created.ID = "acc_1"
+			created.AccessKey = "AK_test"
+		}).Return(nil)
+	mockAudit.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	resp, err := svc.Register(context.Background(), &interfaces.AccountRegisterRequest{
+		Email:    " User@Example.com ",
+		Company:  "Acme",
+		Password: "s3cret-password",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "acc_1", resp.AccountID)
+	assert.Equal(t, "user@example.com", resp.Email)
+	assert.Equal(t, "AK_test", resp.AccessKey)
+	assert.True(t, strings.HasPrefix(resp.SecretKey, interfaces.SecretKeyPrefix))
+
+	// SecretKey 按原文存储（HMAC 验签），密码按 bcrypt 存储
+	assert.Equal(t, resp.SecretKey, created.SecretKey)
+	assert.Equal(t, interfaces.AccountStatusActive, created.Status)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(created.PasswordHash), []byte("s3cret-password")))
+}
+
+func TestRegister_Rejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *interfaces.AccountRegisterRequest
+		wantErr string
+	}{
+		{"invalid email", &interfaces.AccountRegisterRequest{Email: "not-an-email", Company: "Acme", Password: "s3cret-password"}, "email is not a valid address"},
+		{"missing company", &interfaces.AccountRegisterRequest{Email: "user@example.com", Password: "s3cret-password"}, "company is required"},
+		{"short password", &interfaces.AccountRegisterRequest{Email: "user@example.com", Company: "Acme", Password: "short"}, "password length"},
+		{"duplicate email", &interfaces.AccountRegisterRequest{Email: "taken@example.com", Company: "Acme", Password: "s3cret-password"}, "email already exists"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockAccountRepository)
+			svc := NewAccountService(mockRepo, nil)
+
+			mockRepo.On("GetByEmail", mock.Anything, "taken@example.com").Return(&interfaces.Account{ID: "acc_0"}, nil)
+
+			_, err := svc.Register(context.Background(), tt.req)
+
+			assert.ErrorContains(t, err, tt.wantErr)
+			if tt.name == "duplicate email" {
+				assert.ErrorIs(t, err, interfaces.ErrDuplicateEmail)
+			} else {
+				assert.ErrorIs(t, err, interfaces.ErrInvalidArgument)
+			}
+			mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// ========================================
+// TestRegenerateSecretKey
+// ========================================
+
+func TestRegenerateSecretKey_Success(t *testing.T) {
+	mockRepo := new(MockAccountRepository)
+	mockAudit := new(MockAuditLogRepository)
+	svc := NewAccountService(mockRepo, mockAudit)
+
+	mockRepo.On("GetByID", mock.Anything, "acc_1").Return(&interfaces.Account{ID: "acc_1", AccessKey: "AK_test", SecretKey: "SK_old"}, nil)
+	mockRepo.On("UpdateSecretKey", mock.Anything, "acc_1", mock.AnythingOfType("string")).Return(nil)
+	mockAudit.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	resp, err := svc.RegenerateSecretKey(context.Background(), "acc_1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "AK_test", resp.AccessKey)
+	assert.NotEqual(t, "SK_old", resp.SecretKey)
+	mockRepo.AssertCalled(t, "UpdateSecretKey", mock.Anything, "acc_1", resp.SecretKey)
+}
+
+func TestRegenerateSecretKey_Errors(t *testing.T) {
+	mockRepo := new(MockAccountRepository)
+	svc := NewAccountService(mockRepo, nil)
+
+	mockRepo.On("GetByID", mock.Anything, "acc_missing").Return(nil, nil)
+	mockRepo.On("GetByID", mock.Anything, "acc_db").Return(nil, errors.New("connection refused"))
+
+	_, err := svc.RegenerateSecretKey(context.Background(), "acc_missing")
+	assert.ErrorContains(t, err, "not found")
+
+	_, err = svc.RegenerateSecretKey(context.Background(), "acc_db")
+	assert.ErrorContains(t, err, "connection refused")
+
+	// 子账号不能重置主账户密钥
+	ctx := context.WithValue(context.Background(), "qstub_user", &auth.QstubUserInfo{UID: "12345", IamUid: "8901234"})
+	_, err = svc.RegenerateSecretKey(ctx, "acc_1")
+	assert.ErrorContains(t, err, "permission denied")
+	mockRepo.AssertNotCalled(t, "UpdateSecretKey", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+)
+
+// DefaultAccountStatusRefreshInterval 已停用账户列表默认刷新间隔
+const DefaultAccountStatusRefreshInterval = 30 * time.Second
+
+// SuspendedAccountCache 已停用账户集合（进程内）
+// 验证路径只做一次内存查询；后台定期从账户集合全量刷新（停用账户数量很少），
+// 本实例上的停用 / 恢复操作通过 Mark 立即生效，其他实例在一个刷新间隔内生效
+type SuspendedAccountCache struct {
+	*refreshingIDSet
+}
+
+// NewSuspendedAccountCache 创建已停用账户集合（需调用 Start 加载并启动后台刷新）
+func NewSuspendedAccountCache(accountRepo interfaces.AccountRepository, refreshInterval time.Duration) *SuspendedAccountCache {
+	if refreshInterval <= 0 {
+		refreshInterval = DefaultAccountStatusRefreshInterval
+	}
+	return &SuspendedAccountCache{
+		refreshingIDSet: newRefreshingIDSet("suspended accounts", accountRepo.ListSuspendedIDs, refreshInterval),
+	}
+}
+
+// IsSuspended 账户是否已停用
+func (c *SuspendedAccountCache) IsSuspended(accountID string) bool {
+	return c.contains(accountID)
+}
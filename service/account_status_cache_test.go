@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// stubSuspendedRepo 可在测试中随时修改 ListSuspendedIDs 的返回值
+type stubSuspendedRepo struct {
+	*MockAccountRepository
+
+	mu    sync.Mutex
+	ids   []string
+	err   error
+	calls int
+}
+
+func (r *stubSuspendedRepo) ListSuspendedIDs(ctx context.Context) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	return r.ids, r.err
+}
+
+func (r *stubSuspendedRepo) set(ids []string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ids, r.err = ids, err
+}
+
+func (r *stubSuspendedRepo) callCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls
+}
+
+func newStubSuspendedRepo(ids ...string) *stubSuspendedRepo {
+	return &stubSuspendedRepo{MockAccountRepository: new(MockAccountRepository), ids: ids}
+}
+
+func TestSuspendedAccountCache_StartLoads(t *testing.T) {
+	repo := newStubSuspendedRepo("acc_1")
+	c := NewSuspendedAccountCache(repo, time.Hour)
+	c.Start()
+	defer c.Stop(context.Background())
+
+	assert.True(t, c.IsSuspended("acc_1"))
+	assert.False(t, c.IsSuspended("acc_2"))
+}
+
+func TestSuspendedAccountCache_MarkTakesEffectImmediately(t *testing.T) {
+	repo := newStubSuspendedRepo()
+	c := NewSuspendedAccountCache(repo, time.Hour)
+	c.Start()
+	defer c.Stop(context.Background())
+
+	c.Mark("acc_1", true)
+	assert.True(t, c.IsSuspended("acc_1"))
+
+	c.Mark("acc_1", false)
+	assert.False(t, c.IsSuspended("acc_1"))
+	assert.Equal(t, 1, repo.callCount(), "Mark must not wait for a refresh")
+}
+
+func TestSuspendedAccountCache_SuspensionWithinRefreshInterval(t *testing.T) {
+	repo := newStubSuspendedRepo()
+	c := NewSuspendedAccountCache(repo, 10*time.Millisecond)
+	c.Start()
+	defer c.Stop(context.Background())
+
+	mockTokenRepo := new(MockTokenRepository)
+	mockTokenRepo.On("GetByTokenValue", mock.Anything, "sk-1").
+		Return(&interfaces.Token{ID: "tk_1", AccountID: "acc_1", IsActive: true}, nil)
+	validation := NewValidationService(mockTokenRepo)
+	validation.SetSuspendedAccountCache(c)
+
+	resp, err := validation.ValidateToken(context.Background(), &interfaces.TokenValidateRequest{Token: "sk-1"})
+	require.NoError(t, err)
+	assert.True(t, resp.Valid)
+
+	// 其他实例停用账户：下一次刷新后本实例拒绝该账户的 Token
+	repo.set([]string{"acc_1"}, nil)
+	assert.Eventually(t, func() bool { return c.IsSuspended("acc_1") }, time.Second, 5*time.Millisecond)
+
+	resp, err = validation.ValidateToken(context.Background(), &interfaces.TokenValidateRequest{Token: "sk-1"})
+	require.NoError(t, err)
+	assert.False(t, resp.Valid)
+	assert.Equal(t, interfaces.ErrCodeAccountSuspended, resp.Code)
+
+	// 其他实例恢复账户
+	repo.set(nil, nil)
+	assert.Eventually(t, func() bool { return !c.IsSuspended("acc_1") }, time.Second, 5*time.Millisecond)
+}
+
+func TestSuspendedAccountCache_RefreshFailureKeepsLastResult(t *testing.T) {
+	repo := newStubSuspendedRepo("acc_1")
+	c := NewSuspendedAccountCache(repo, 10*time.Millisecond)
+	c.Start()
+	defer c.Stop(context.Background())
+
+	repo.set(nil, errors.New("mongo unavailable"))
+	calls := repo.callCount()
+	assert.Eventually(t, func() bool { return repo.callCount() >= calls+3 }, time.Second, 5*time.Millisecond)
+
+	// 刷新失败时不能把已停用账户当作已恢复
+	assert.True(t, c.IsSuspended("acc_1"))
+
+	// 本实例的操作在刷新失败期间仍然生效
+	c.Mark("acc_2", true)
+	assert.True(t, c.IsSuspended("acc_2"))
+
+	// 恢复后以数据库为准
+	repo.set([]string{"acc_2"}, nil)
+	assert.Eventually(t, func() bool { return !c.IsSuspended("acc_1") }, time.Second, 5*time.Millisecond)
+	assert.True(t, c.IsSuspended("acc_2"))
+}
+
+func TestSuspendedAccountCache_Stop(t *testing.T) {
+	c := NewSuspendedAccountCache(newStubSuspendedRepo(), 10*time.Millisecond)
+	c.Start()
+
+	require.NoError(t, c.Stop(context.Background()))
+	// 重复调用安全
+	require.NoError(t, c.Stop(context.Background()))
+}
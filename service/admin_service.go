@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/auth"
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+)
+
+// AdminServiceImpl 管理员服务实现（跨租户操作）
+// 操作记录写入目标账户的审计日志，并附带操作者的七牛 UID
+type AdminServiceImpl struct {
+	accountRepo interfaces.AccountRepository
+	tokenRepo   interfaces.TokenRepository
+	clientRepo  interfaces.OAuth2ClientRepository
+	auditRepo   interfaces.AuditLogRepository
+	suspended   *SuspendedAccountCache // 可选
+}
+
+// NewAdminService 创建管理员服务实例
+func NewAdminService(accountRepo interfaces.AccountRepository, tokenRepo interfaces.TokenRepository, clientRepo interfaces.OAuth2ClientRepository, auditRepo interfaces.AuditLogRepository) *AdminServiceImpl {
+	return &AdminServiceImpl{
+		accountRepo: accountRepo,
+		tokenRepo:   tokenRepo,
+		clientRepo:  clientRepo,
+		auditRepo:   auditRepo,
+	}
+}
+
+// SetSuspendedAccountCache 设置已停用账户集合（停用 / 恢复后立即更新本实例）
+func (s *AdminServiceImpl) SetSuspendedAccountCache(suspended *SuspendedAccountCache) {
+	s.suspended = suspended
+}
+
+// ListAccounts 按条件列出账户
+func (s *AdminServiceImpl) ListAccounts(ctx context.Context, query *interfaces.AccountListQuery) (*interfaces.AccountListResponse, error) {
+	if query.Status != "" && query.Status != interfaces.AccountStatusActive && query.Status != interfaces.AccountStatusSuspended {
+		return nil, fmt.Errorf("%w: status must be active or suspended", interfaces.ErrInvalidArgument)
+	}
+
+	accounts, err := s.accountRepo.List(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.accountRepo.Count(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if accounts == nil {
+		accounts = []interfaces.Account{}
+	}
+	return &interfaces.AccountListResponse{
+		Accounts: accounts,
+		Total:    total,
+	}, nil
+}
+
+// SuspendAccount 停用账户
+func (s *AdminServiceImpl) SuspendAccount(ctx context.Context, accountID string) error {
+	return s.updateStatus(ctx, accountID, interfaces.AccountStatusSuspended, interfaces.AuditActionSuspendAccount)
+}
+
+// ActivateAccount 恢复账户
+func (s *AdminServiceImpl) ActivateAccount(ctx context.Context, accountID string) error {
+	return s.updateStatus(ctx, accountID, interfaces.AccountStatusActive, interfaces.AuditActionActivateAccount)
+}
+
+// DisableAccountTokens 停用账户下所有 Token
+func (s *AdminServiceImpl) DisableAccountTokens(ctx context.Context, accountID string) (int64, error) {
+	count, err := s.tokenRepo.DisableByAccountID(ctx, accountID)
+	if err != nil {
+		s.logAction(ctx, accountID, interfaces.AuditActionDisableTokens, interfaces.AuditResultFailure, err.Error(), nil)
+		return 0, err
+	}
+
+	s.logAction(ctx, accountID, interfaces.AuditActionDisableTokens, interfaces.AuditResultSuccess, "", map[string]interface{}{
+		"disabled_count": count,
+	})
+	return count, nil
+}
+
+// DisableClientTokens 停用 OAuth 2.0 客户端签发的所有 Token（客户端凭证泄露时使用）
+// 审计日志记录在客户端所属账户下；签名 Token 在吊销列表刷新后失效
+func (s *AdminServiceImpl) DisableClientTokens(ctx context.Context, clientID string) (int64, error) {
+	client, err := s.clientRepo.GetByID(ctx, clientID)
+	if err != nil {
+		return 0, err
+	}
+	if client == nil {
+		return 0, interfaces.ErrOAuth2ClientNotFound
+	}
+
+	count, err := s.tokenRepo.DisableByClientID(ctx, clientID)
+	if err != nil {
+		s.logAction(ctx, client.AccountID, interfaces.AuditActionDisableClientTokens, interfaces.AuditResultFailure, err.Error(), map[string]interface{}{
+			"client_id": clientID,
+		})
+		return 0, err
+	}
+
+	s.logAction(ctx, client.AccountID, interfaces.AuditActionDisableClientTokens, interfaces.AuditResultSuccess, "", map[string]interface{}{
+		"client_id":      clientID,
+		"disabled_count": count,
+	})
+	return count, nil
+}
+
+// SetClientResourceAccounts 授予 OAuth 2.0 客户端内省 / 吊销 / 交换其他账户 Token 的权限（资源服务 / 网关）
+// 覆盖原有授权，空列表表示只能访问客户端所属账户；审计日志记录在客户端所属账户下
+func (s *AdminServiceImpl) SetClientResourceAccounts(ctx context.Context, clientID string, accountIDs []string) (*interfaces.OAuth2Client, error) {
+	if len(accountIDs) > maxOAuth2ResourceAccounts {
+		return nil, fmt.Errorf("%w: at most %d account_ids are allowed", interfaces.ErrInvalidArgument, maxOAuth2ResourceAccounts)
+	}
+
+	client, err := s.clientRepo.GetByID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, interfaces.ErrOAuth2ClientNotFound
+	}
+
+	seen := make(map[string]bool, len(accountIDs))
+	granted := make([]string, 0, len(accountIDs))
+	for _, accountID := range accountIDs {
+		if accountID == "" || seen[accountID] {
+			continue
+		}
+		account, err := s.accountRepo.GetByID(ctx, accountID)
+		if err != nil {
+			return nil, err
+		}
+		if account == nil {
+			return nil, fmt.Errorf("%w: account %s does not exist", interfaces.ErrInvalidArgument, accountID)
+		}
+		seen[accountID] = true
+		granted = append(granted, accountID)
+	}
+
+	updated, err := s.clientRepo.UpdateResourceAccounts(ctx, clientID, granted)
+	if err != nil {
+		s.logAction(ctx, client.AccountID, interfaces.AuditActionGrantResourceAccounts, interfaces.AuditResultFailure, err.Error(), map[string]interface{}{
+			"client_id":   clientID,
+			"account_ids": granted,
+		})
+		return nil, err
+	}
+
+	s.logAction(ctx, client.AccountID, interfaces.AuditActionGrantResourceAccounts, interfaces.AuditResultSuccess, "", map[string]interface{}{
+		"client_id":   clientID,
+		"account_ids": granted,
+	})
+	return updated, nil
+}
+
+// updateStatus 更新账户状态并同步已停用账户集合
+func (s *AdminServiceImpl) updateStatus(ctx context.Context, accountID, status, action string) error {
+	if err := s.accountRepo.UpdateStatus(ctx, accountID, status); err != nil {
+		s.logAction(ctx, accountID, action, interfaces.AuditResultFailure, err.Error(), nil)
+		return err
+	}
+
+	if s.suspended != nil {
+		s.suspended.Mark(accountID, status == interfaces.AccountStatusSuspended)
+	}
+
+	s.logAction(ctx, accountID, action, interfaces.AuditResultSuccess, "", nil)
+	return nil
+}
+
+// ========================================
+// 辅助方法
+// ========================================
+
+func (s *AdminServiceImpl) logAction(ctx context.Context, accountID, action, result, errorMsg string, requestData map[string]interface{}) {
+	if s.auditRepo == nil {
+		return
+	}
+
+	if requestData == nil {
+		requestData = map[string]interface{}{}
+	}
+	if operator, ok := ctx.Value("qstub_user").(*auth.QstubUserInfo); ok && operator != nil {
+		requestData["operator_uid"] = operator.UID
+	}
+
+	s.auditRepo.Create(ctx, &interfaces.AuditLog{
+		AccountID:   accountID,
+		Action:      action,
+		ResourceID:  accountID,
+		Result:      result,
+		ErrorMsg:    errorMsg,
+		RequestData: requestData,
+		Timestamp:   time.Now(),
+	})
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/qiniu/bearer-token-service/v2/auth"
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ========================================
+// TestAdminService
+// ========================================
+
+func TestAdminService_SuspendAndActivate(t *testing.T) {
+	mockRepo := new(MockAccountRepository)
+	mockAudit := new(MockAuditLogRepository)
+	suspended := NewSuspendedAccountCache(mockRepo, 0)
+	svc := NewAdminService(mockRepo, new(MockTokenRepository), new(MockOAuth2ClientRepository), mockAudit)
+	svc.SetSuspendedAccountCache(suspended)
+
+	mockRepo.On("UpdateStatus", mock.Anything, "acc_1", mock.AnythingOfType("string")).Return(nil)
+	mockAudit.On("Create", mock.Anything, mock.MatchedBy(func(log *interfaces.AuditLog) bool {
+		return log.RequestData["operator_uid"] == "1"
+	})).Return(nil)
+
+	ctx := context.WithValue(context.Background(), "qstub_user", &auth.QstubUserInfo{UID: "1", Utype: interfaces.UserTypeAdmin})
+
+	assert.NoError(t, svc.SuspendAccount(ctx, "acc_1"))
+	assert.True(t, suspended.IsSuspended("acc_1"))
+
+	assert.NoError(t, svc.ActivateAccount(ctx, "acc_1"))
+	assert.False(t, suspended.IsSuspended("acc_1"))
+
+	mockAudit.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestAdminService_SuspendFailureKeepsStatus(t *testing.T) {
+	mockRepo := new(MockAccountRepository)
+	suspended := NewSuspendedAccountCache(mockRepo, 0)
+	svc := NewAdminService(mockRepo, new(MockTokenRepository), new(MockOAuth2ClientRepository), nil)
+	svc.SetSuspendedAccountCache(suspended)
+
+	mockRepo.On("UpdateStatus", mock.Anything, "acc_missing", interfaces.AccountStatusSuspended).Return(errors.New("account not found"))
+
+	err := svc.SuspendAccount(context.Background(), "acc_missing")
+
+	assert.ErrorContains(t, err, "not found")
+	assert.False(t, suspended.IsSuspended("acc_missing"))
+}
+
+func TestAdminService_ListAccounts(t *testing.T) {
+	mockRepo := new(MockAccountRepository)
+	svc := NewAdminService(mockRepo, new(MockTokenRepository), new(MockOAuth2ClientRepository), nil)
+
+	_, err := svc.ListAccounts(context.Background(), &interfaces.AccountListQuery{Status: "deleted"})
+	assert.ErrorIs(t, err, interfaces.ErrInvalidArgument)
+
+	query := &interfaces.AccountListQuery{Keyword: "acme", Limit: 50}
+	mockRepo.On("List", mock.Anything, query).Return([]interfaces.Account(nil), nil)
+	mockRepo.On("Count", mock.Anything, query).Return(int64(0), nil)
+
+	resp, err := svc.ListAccounts(context.Background(), query)
+	assert.NoError(t, err)
+	assert.NotNil(t, resp.Accounts)
+	assert.Equal(t, int64(0), resp.Total)
+}
+
+func TestAdminService_DisableAccountTokens(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	svc := NewAdminService(new(MockAccountRepository), mockTokenRepo, new(MockOAuth2ClientRepository), nil)
+
+	mockTokenRepo.On("DisableByAccountID", mock.Anything, "acc_1").Return(int64(3), nil)
+
+	count, err := svc.DisableAccountTokens(context.Background(), "acc_1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestAdminService_DisableClientTokens(t *testing.T) {
+	mockClientRepo := new(MockOAuth2ClientRepository)
+	mockTokenRepo := new(MockTokenRepository)
+	mockAudit := new(MockAuditLogRepository)
+	svc := NewAdminService(new(MockAccountRepository), mockTokenRepo, mockClientRepo, mockAudit)
+
+	mockClientRepo.On("GetByID", mock.Anything, "oc_1").Return(&interfaces.OAuth2Client{ClientID: "oc_1", AccountID: "acc_1"}, nil)
+	mockClientRepo.On("GetByID", mock.Anything, "oc_unknown").Return(nil, nil)
+	mockTokenRepo.On("DisableByClientID", mock.Anything, "oc_1").Return(int64(2), nil)
+	mockAudit.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	count, err := svc.DisableClientTokens(context.Background(), "oc_1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	mockAudit.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(log *interfaces.AuditLog) bool {
+		return log.Action == interfaces.AuditActionDisableClientTokens && log.AccountID == "acc_1"
+	}))
+
+	_, err = svc.DisableClientTokens(context.Background(), "oc_unknown")
+	assert.ErrorIs(t, err, interfaces.ErrOAuth2ClientNotFound)
+	mockTokenRepo.AssertNotCalled(t, "DisableByClientID", mock.Anything, "oc_unknown")
+}
+
+func TestAdminService_SetClientResourceAccounts(t *testing.T) {
+	mockAccountRepo := new(MockAccountRepository)
+	mockClientRepo := new(MockOAuth2ClientRepository)
+	mockAudit := new(MockAuditLogRepository)
+	svc := NewAdminService(mockAccountRepo, new(MockTokenRepository), mockClientRepo, mockAudit)
+
+	mockClientRepo.On("GetByID", mock.Anything, "oc_gw").Return(&interfaces.OAuth2Client{ClientID: "oc_gw", AccountID: "acc_gw"}, nil)
+	mockClientRepo.On("GetByID", mock.Anything, "oc_unknown").Return(nil, nil)
+	mockAccountRepo.On("GetByID", mock.Anything, "acc_1").Return(&interfaces.Account{ID: "acc_1"}, nil)
+	mockAccountRepo.On("GetByID", mock.Anything, "acc_2").Return(&interfaces.Account{ID: "acc_2"}, nil)
+	mockAccountRepo.On("GetByID", mock.Anything, "acc_missing").Return(nil, nil)
+	mockClientRepo.On("UpdateResourceAccounts", mock.Anything, "oc_gw", []string{"acc_1", "acc_2"}).Return(&interfaces.OAuth2Client{
+		ClientID: "oc_gw", AccountID: "acc_gw", ResourceAccounts: []string{"acc_1", "acc_2"},
+	}, nil)
+	mockAudit.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	// 去重后保存，审计记录在客户端所属账户下
+	client, err := svc.SetClientResourceAccounts(context.Background(), "oc_gw", []string{"acc_1", "acc_2", "acc_1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"acc_1", "acc_2"}, client.ResourceAccounts)
+	mockAudit.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(log *interfaces.AuditLog) bool {
+		return log.Action == interfaces.AuditActionGrantResourceAccounts && log.AccountID == "acc_gw"
+	}))
+
+	// 不存在的账户不能授予
+	_, err = svc.SetClientResourceAccounts(context.Background(), "oc_gw", []string{"acc_1", "acc_missing"})
+	assert.ErrorIs(t, err, interfaces.ErrInvalidArgument)
+
+	_, err = svc.SetClientResourceAccounts(context.Background(), "oc_unknown", []string{"acc_1"})
+	assert.ErrorIs(t, err, interfaces.ErrOAuth2ClientNotFound)
+	mockClientRepo.AssertNumberOfCalls(t, "UpdateResourceAccounts", 1)
+}
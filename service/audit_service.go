@@ -29,10 +29,13 @@ func (s *AuditServiceImpl) Log(ctx context.Context, log *interfaces.AuditLog) er
 
 // LogAction 便捷方法：记录操作
 func (s *AuditServiceImpl) LogAction(ctx context.Context, accountID string, action string, resourceID string, result string, errorMsg string, requestData map[string]interface{}) error {
+	iuid, iamAlias := subAccountFromContext(ctx)
 	log := &interfaces.AuditLog{
 		AccountID:   accountID,
 		Action:      action,
 		ResourceID:  resourceID,
+		IUID:        iuid,
+		IamAlias:    iamAlias,
 		Result:      result,
 		ErrorMsg:    errorMsg,
 		RequestData: requestData,
@@ -43,19 +46,27 @@ func (s *AuditServiceImpl) LogAction(ctx context.Context, accountID string, acti
 
 // QueryLogs 查询审计日志
 func (s *AuditServiceImpl) QueryLogs(ctx context.Context, accountID string, query *interfaces.AuditLogQuery) (*interfaces.AuditLogResponse, error) {
-	logs, err := s.auditRepo.ListByAccountID(ctx, accountID, query)
+	// 子账号只能查看自己的操作记录（与 checkOwnership 一致）
+	iuid, iamAlias := subAccountFromContext(ctx)
+
+	logs, nextCursor, err := s.auditRepo.ListByAccountID(ctx, accountID, query, iuid, iamAlias)
 	if err != nil {
 		return nil, err
 	}
 
-	total, err := s.auditRepo.CountByAccountID(ctx, accountID, query)
+	total, err := s.auditRepo.CountByAccountID(ctx, accountID, query, iuid, iamAlias)
 	if err != nil {
 		return nil, err
 	}
 
+	if logs == nil {
+		logs = []interfaces.AuditLog{}
+	}
+
 	return &interfaces.AuditLogResponse{
-		AccountID: accountID,
-		Logs:      logs,
-		Total:     int(total),
+		AccountID:  accountID,
+		Logs:       logs,
+		Total:      int(total),
+		NextCursor: nextCursor,
 	}, nil
 }
@@ -0,0 +1,461 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/observability"
+)
+
+const (
+	// DefaultOAuth2TokenTTL /oauth2/token 签发的 Token 默认有效期
+	DefaultOAuth2TokenTTL = time.Hour
+
+	// oauth2ClientIDPrefix / oauth2ClientSecretPrefix OAuth 2.0 客户端凭证前缀（与账户 AK_ / SK_ 区分）
+	oauth2ClientIDPrefix     = "oc_"
+	oauth2ClientSecretPrefix = "ocs_"
+
+	// maxOAuth2ClientNameLength 客户端名称最大长度
+	maxOAuth2ClientNameLength = 128
+
+	// maxOAuth2ResourceAccounts 单个客户端最多可授予的资源账户数
+	maxOAuth2ResourceAccounts = 100
+)
+
+// OAuth2ServiceImpl OAuth 2.0 Token 签发、内省（RFC 7662）与吊销（RFC 7009）服务实现
+// 调用方（资源服务 / 网关 / 机器负载）使用账户创建的 OAuth 2.0 客户端凭证认证，不使用账户 AK/SK；
+// 客户端只能内省 / 吊销 / 交换本账户的 Token，资源服务另外可处理管理员为其授予的账户的 Token
+type OAuth2ServiceImpl struct {
+	validation  *ValidationServiceImpl
+	tokenRepo   interfaces.TokenRepository
+	accountRepo interfaces.AccountRepository
+	clientRepo  interfaces.OAuth2ClientRepository
+	auditRepo   interfaces.AuditLogRepository
+	revoked     *RevokedTokenCache
+
+	// Token 签发（/oauth2/token），未设置时不支持签发
+	tokenService *TokenServiceImpl
+	tokenTTL     time.Duration
+	tokenFormat  string
+}
+
+// NewOAuth2Service 创建 OAuth 2.0 服务实例
+func NewOAuth2Service(validation *ValidationServiceImpl, tokenRepo interfaces.TokenRepository, accountRepo interfaces.AccountRepository, clientRepo interfaces.OAuth2ClientRepository, auditRepo interfaces.AuditLogRepository) *OAuth2ServiceImpl {
+	return &OAuth2ServiceImpl{
+		validation:  validation,
+		tokenRepo:   tokenRepo,
+		accountRepo: accountRepo,
+		clientRepo:  clientRepo,
+		auditRepo:   auditRepo,
+	}
+}
+
+// SetRevokedTokenCache 设置签名 Token 吊销列表（可选，设置后吊销签名 Token 在本实例立即生效）
+func (s *OAuth2ServiceImpl) SetRevokedTokenCache(revoked *RevokedTokenCache) {
+	s.revoked = revoked
+}
+
+// SetTokenIssuer 设置 Token 签发（可选，设置后支持 /oauth2/token）
+// ttl 为签发 Token 的有效期（<= 0 时使用默认值），format 为 opaque 或 signed
+func (s *OAuth2ServiceImpl) SetTokenIssuer(tokenService *TokenServiceImpl, ttl time.Duration, format string) {
+	if ttl <= 0 {
+		ttl = DefaultOAuth2TokenTTL
+	}
+	s.tokenService = tokenService
+	s.tokenTTL = ttl
+	s.tokenFormat = format
+}
+
+// AuthenticateClient 校验 client_id / client_secret（OAuth 2.0 客户端凭证），所属账户已停用时不能认证
+func (s *OAuth2ServiceImpl) AuthenticateClient(ctx context.Context, clientID, clientSecret string) (*interfaces.OAuth2Client, error) {
+	if clientID == "" || clientSecret == "" {
+		return nil, fmt.Errorf("%w: client credentials are required", interfaces.ErrInvalidClient)
+	}
+
+	client, err := s.clientRepo.GetByID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil || subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(hashClientSecret(clientSecret))) != 1 {
+		observability.LogInfo(ctx, "OAuth2 client authentication failed", slog.String("client_id", clientID))
+		return nil, fmt.Errorf("%w: client authentication failed", interfaces.ErrInvalidClient)
+	}
+
+	account, err := s.accountRepo.GetByID(ctx, client.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil || account.Status == interfaces.AccountStatusSuspended {
+		return nil, fmt.Errorf("%w: account is suspended", interfaces.ErrInvalidClient)
+	}
+	return client, nil
+}
+
+// CreateClient 为账户创建 OAuth 2.0 客户端，client_secret 只在响应中返回一次
+func (s *OAuth2ServiceImpl) CreateClient(ctx context.Context, accountID string, req *interfaces.OAuth2ClientCreateRequest) (*interfaces.OAuth2ClientCreateResponse, error) {
+	if err := requirePrimaryAccount(ctx); err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxOAuth2ClientNameLength {
+		return nil, fmt.Errorf("%w: name is required and must not exceed %d characters", interfaces.ErrInvalidArgument, maxOAuth2ClientNameLength)
+	}
+	if len(req.Scopes) == 0 {
+		return nil, fmt.Errorf("%w: scopes is required", interfaces.ErrInvalidArgument)
+	}
+	for _, scope := range req.Scopes {
+		if !interfaces.IsValidScope(scope) {
+			return nil, fmt.Errorf("%w: invalid scope %s", interfaces.ErrInvalidArgument, scope)
+		}
+	}
+	if len(req.GrantTypes) == 0 {
+		return nil, fmt.Errorf("%w: grant_types is required", interfaces.ErrInvalidArgument)
+	}
+	for _, grantType := range req.GrantTypes {
+		if grantType != interfaces.OAuth2GrantClientCredentials && grantType != interfaces.OAuth2GrantTokenExchange {
+			return nil, fmt.Errorf("%w: unsupported grant type %s", interfaces.ErrInvalidArgument, grantType)
+		}
+	}
+
+	clientID, err := randomCredential(oauth2ClientIDPrefix, 16)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomCredential(oauth2ClientSecretPrefix, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &interfaces.OAuth2Client{
+		ClientID:   clientID,
+		AccountID:  accountID,
+		Name:       name,
+		SecretHash: hashClientSecret(secret),
+		Scopes:     req.Scopes,
+		GrantTypes: req.GrantTypes,
+	}
+	requestData := map[string]interface{}{
+		"client_id":   clientID,
+		"name":        name,
+		"scopes":      req.Scopes,
+		"grant_types": req.GrantTypes,
+	}
+	if err := s.clientRepo.Create(ctx, client); err != nil {
+		s.logAction(ctx, accountID, interfaces.AuditActionCreateOAuth2Client, clientID, interfaces.AuditResultFailure, err.Error(), requestData)
+		return nil, err
+	}
+
+	s.logAction(ctx, accountID, interfaces.AuditActionCreateOAuth2Client, clientID, interfaces.AuditResultSuccess, "", requestData)
+	return &interfaces.OAuth2ClientCreateResponse{
+		OAuth2Client: *client,
+		ClientSecret: secret,
+	}, nil
+}
+
+// requirePrimaryAccount 客户端属于整个账户（可内省、吊销、交换账户下所有子账号的 Token），只允许主账号管理
+func requirePrimaryAccount(ctx context.Context) error {
+	if iuid, iamAlias := subAccountFromContext(ctx); iuid != "" || iamAlias != "" {
+		return fmt.Errorf("%w: IAM sub-accounts cannot manage OAuth2 clients", interfaces.ErrPermissionDenied)
+	}
+	return nil
+}
+
+// ListClients 列出账户的 OAuth 2.0 客户端
+func (s *OAuth2ServiceImpl) ListClients(ctx context.Context, accountID string) (*interfaces.OAuth2ClientListResponse, error) {
+	if err := requirePrimaryAccount(ctx); err != nil {
+		return nil, err
+	}
+	clients, err := s.clientRepo.ListByAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if clients == nil {
+		clients = []interfaces.OAuth2Client{}
+	}
+	return &interfaces.OAuth2ClientListResponse{Clients: clients}, nil
+}
+
+// DeleteClient 删除账户的 OAuth 2.0 客户端，并停用其签发的所有 Token（签名 Token 在吊销列表刷新后失效）
+func (s *OAuth2ServiceImpl) DeleteClient(ctx context.Context, accountID, clientID string) error {
+	if err := requirePrimaryAccount(ctx); err != nil {
+		return err
+	}
+	if err := s.clientRepo.Delete(ctx, accountID, clientID); err != nil {
+		if !errors.Is(err, interfaces.ErrNotFound) {
+			s.logAction(ctx, accountID, interfaces.AuditActionDeleteOAuth2Client, clientID, interfaces.AuditResultFailure, err.Error(), nil)
+		}
+		return err
+	}
+
+	count, err := s.tokenRepo.DisableByClientID(ctx, clientID)
+	if err != nil {
+		s.logAction(ctx, accountID, interfaces.AuditActionDeleteOAuth2Client, clientID, interfaces.AuditResultFailure, err.Error(), nil)
+		return err
+	}
+
+	s.logAction(ctx, accountID, interfaces.AuditActionDeleteOAuth2Client, clientID, interfaces.AuditResultSuccess, "", map[string]interface{}{
+		"disabled_count": count,
+	})
+	return nil
+}
+
+// IntrospectToken 内省 Token，复用 ValidateToken 的全部检查（停用账户、过期、IP、七牛用户状态等）
+// 无效、不存在或调用方无权查看的 Token 统一返回 {"active": false}，不区分原因
+func (s *OAuth2ServiceImpl) IntrospectToken(ctx context.Context, client *interfaces.OAuth2Client, tokenValue, clientIP string) (*interfaces.TokenIntrospectionResponse, error) {
+	req := &interfaces.TokenValidateRequest{
+		Token:    tokenValue,
+		ClientIP: clientIP,
+	}
+	token, resp, err := s.validation.IntrospectToken(ctx, req, func(token *interfaces.Token) bool {
+		return client.CanAccessAccount(token.AccountID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Valid {
+		return &interfaces.TokenIntrospectionResponse{Active: false}, nil
+	}
+
+	return tokenIntrospection(token, resp.TokenInfo), nil
+}
+
+// RevokeToken 吊销 Token：停用（与 PUT /api/v2/tokens/{id}/status 相同），保留记录便于审计
+// Token 不存在时视为成功（RFC 7009 2.2）；通过轮换前旧值命中时停用的是同一个 Token
+func (s *OAuth2ServiceImpl) RevokeToken(ctx context.Context, client *interfaces.OAuth2Client, tokenValue string) error {
+	token, err := s.tokenRepo.GetByTokenValue(ctx, tokenValue)
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return nil
+	}
+	if !client.CanAccessAccount(token.AccountID) {
+		s.logRevoke(ctx, client, token, interfaces.AuditResultFailure, "permission denied")
+		return fmt.Errorf("%w: token was not issued to this client's accounts", interfaces.ErrPermissionDenied)
+	}
+
+	if err := s.tokenRepo.UpdateStatus(ctx, token.ID, false); err != nil {
+		s.logRevoke(ctx, client, token, interfaces.AuditResultFailure, err.Error())
+		return err
+	}
+
+	if token.Format == interfaces.TokenFormatSigned && s.revoked != nil {
+		s.revoked.Mark(token.ID, true)
+	}
+
+	s.logRevoke(ctx, client, token, interfaces.AuditResultSuccess, "")
+	return nil
+}
+
+// IssueToken 签发短期 Token，与 POST /api/v2/tokens 创建的 Token 相同（可管理、可内省、计入审计日志），
+// 记录签发客户端（client_id），可按客户端批量停用；过期后记录自动清理
+//   - client_credentials：为客户端所属账户签发，scope 不超出客户端 scope，为空时使用客户端 scope
+//   - token exchange（RFC 8693）：为原 Token 所属账户签发降权 Token，scope 不超出原 Token 与客户端 scope，
+//     IP 范围 / 限流 / 子账号 / 有效期都不超出原 Token
+func (s *OAuth2ServiceImpl) IssueToken(ctx context.Context, client *interfaces.OAuth2Client, req *interfaces.OAuth2TokenRequest) (*interfaces.OAuth2TokenResponse, error) {
+	switch req.GrantType {
+	case interfaces.OAuth2GrantClientCredentials, interfaces.OAuth2GrantTokenExchange:
+	case "":
+		return nil, fmt.Errorf("%w: grant_type is required", interfaces.ErrInvalidArgument)
+	default:
+		return nil, fmt.Errorf("%w: %s", interfaces.ErrUnsupportedGrantType, req.GrantType)
+	}
+	if s.tokenService == nil {
+		return nil, fmt.Errorf("%w: token issuance is not enabled", interfaces.ErrUnsupportedGrantType)
+	}
+	if !client.HasGrantType(req.GrantType) {
+		return nil, fmt.Errorf("%w: grant type %s is not allowed for this client", interfaces.ErrUnauthorizedClient, req.GrantType)
+	}
+
+	scopes := strings.Fields(req.Scope)
+	for _, scope := range scopes {
+		if !interfaces.IsValidScope(scope) {
+			return nil, fmt.Errorf("%w: %s", interfaces.ErrInvalidScope, scope)
+		}
+		if !client.HasScope(scope) {
+			return nil, fmt.Errorf("%w: %s exceeds client scopes", interfaces.ErrInvalidScope, scope)
+		}
+	}
+
+	var accountID string
+	var create *interfaces.TokenCreateRequest
+	var issuedTokenType string
+	if req.GrantType == interfaces.OAuth2GrantClientCredentials {
+		if len(scopes) == 0 {
+			scopes = client.Scopes
+		}
+		accountID = client.AccountID
+		create = &interfaces.TokenCreateRequest{
+			Description:      "OAuth2 client_credentials (" + client.ClientID + ")",
+			ExpiresInSeconds: int64(s.tokenTTL / time.Second),
+			Scopes:           scopes,
+		}
+	} else {
+		subject, err := s.exchangeSubject(ctx, client, req)
+		if err != nil {
+			return nil, err
+		}
+		create, err = s.exchangeRequest(client, subject, scopes)
+		if err != nil {
+			return nil, err
+		}
+		accountID = subject.AccountID
+		issuedTokenType = interfaces.OAuth2TokenTypeAccessToken
+	}
+	create.Format = s.tokenFormat
+	create.ClientID = client.ClientID
+
+	created, err := s.tokenService.CreateToken(ctx, accountID, create)
+	if err != nil {
+		return nil, err
+	}
+
+	return &interfaces.OAuth2TokenResponse{
+		AccessToken:     created.Token,
+		IssuedTokenType: issuedTokenType,
+		TokenType:       "Bearer",
+		ExpiresIn:       create.ExpiresInSeconds,
+		Scope:           strings.Join(created.Scopes, " "),
+	}, nil
+}
+
+// exchangeSubject 验证 token exchange 的原 Token（与内省相同的检查，调用方必须有权查看该 Token）
+func (s *OAuth2ServiceImpl) exchangeSubject(ctx context.Context, client *interfaces.OAuth2Client, req *interfaces.OAuth2TokenRequest) (*interfaces.Token, error) {
+	if req.SubjectToken == "" {
+		return nil, fmt.Errorf("%w: subject_token is required", interfaces.ErrInvalidArgument)
+	}
+	if req.SubjectTokenType != interfaces.OAuth2TokenTypeAccessToken {
+		return nil, fmt.Errorf("%w: subject_token_type must be %s", interfaces.ErrInvalidArgument, interfaces.OAuth2TokenTypeAccessToken)
+	}
+
+	validateReq := &interfaces.TokenValidateRequest{
+		Token:    req.SubjectToken,
+		ClientIP: req.ClientIP,
+	}
+	subject, resp, err := s.validation.IntrospectToken(ctx, validateReq, func(token *interfaces.Token) bool {
+		return client.CanAccessAccount(token.AccountID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Valid {
+		return nil, fmt.Errorf("%w: subject token is not active", interfaces.ErrInvalidGrant)
+	}
+	return subject, nil
+}
+
+// exchangeRequest 由原 Token 构造降权 Token 的创建请求
+// 未指定 scope 时沿用原 Token 的 scope，原 Token 的 scope 超出客户端 scope 时必须显式指定；
+// 新 Token 与原 Token（及其交换出的所有 Token）共享同一份限流额度，多次交换不能放大额度
+func (s *OAuth2ServiceImpl) exchangeRequest(client *interfaces.OAuth2Client, subject *interfaces.Token, scopes []string) (*interfaces.TokenCreateRequest, error) {
+	for _, scope := range scopes {
+		if !subject.HasScope(scope) {
+			return nil, fmt.Errorf("%w: %s exceeds subject token", interfaces.ErrInvalidScope, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		if len(subject.Scopes) == 0 {
+			scopes = client.Scopes
+		} else {
+			for _, scope := range subject.Scopes {
+				if !client.HasScope(scope) {
+					return nil, fmt.Errorf("%w: subject token scope %s exceeds client scopes", interfaces.ErrInvalidScope, scope)
+				}
+			}
+			scopes = subject.Scopes
+		}
+	}
+
+	ttl := s.tokenTTL
+	if subject.ExpiresAt != nil {
+		if remaining := time.Until(*subject.ExpiresAt); remaining < ttl {
+			ttl = remaining
+		}
+	}
+	if ttl < time.Second {
+		return nil, fmt.Errorf("%w: subject token is about to expire", interfaces.ErrInvalidGrant)
+	}
+
+	return &interfaces.TokenCreateRequest{
+		Description:      "OAuth2 token exchange (" + subject.ID + ")",
+		ExpiresInSeconds: int64(ttl / time.Second),
+		RateLimit:        subject.RateLimit,
+		Scopes:           scopes,
+		AllowedCIDRs:     subject.AllowedCIDRs,
+		IUID:             subject.IUID,
+		IamAlias:         subject.IamAlias,
+		RateLimitTokenID: subject.LimiterKey(),
+	}, nil
+}
+
+// logRevoke 记录吊销审计日志（记录在 Token 所属账户下，附带调用方 client_id）
+func (s *OAuth2ServiceImpl) logRevoke(ctx context.Context, client *interfaces.OAuth2Client, token *interfaces.Token, result, errorMsg string) {
+	s.logAction(ctx, token.AccountID, interfaces.AuditActionRevokeToken, token.ID, result, errorMsg, map[string]interface{}{
+		"client_id":         client.ClientID,
+		"client_account_id": client.AccountID,
+	})
+}
+
+// logAction 记录审计日志
+func (s *OAuth2ServiceImpl) logAction(ctx context.Context, accountID, action, resourceID, result, errorMsg string, requestData map[string]interface{}) {
+	log := &interfaces.AuditLog{
+		AccountID:   accountID,
+		Action:      action,
+		ResourceID:  resourceID,
+		Result:      result,
+		ErrorMsg:    errorMsg,
+		RequestData: requestData,
+		Timestamp:   time.Now(),
+	}
+
+	s.auditRepo.Create(ctx, log)
+}
+
+// randomCredential 生成带前缀的随机凭证（n 字节随机数的十六进制）
+func randomCredential(prefix string, n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+// hashClientSecret 计算客户端密钥的 SHA-256 摘要（只存储摘要，不存储明文）
+func hashClientSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// tokenIntrospection 将已通过验证的 Token 映射为 RFC 7662 响应字段
+func tokenIntrospection(token *interfaces.Token, info *interfaces.TokenInfo) *interfaces.TokenIntrospectionResponse {
+	resp := &interfaces.TokenIntrospectionResponse{
+		Active:    true,
+		Scope:     strings.Join(token.Scopes, " "),
+		ClientID:  token.ClientID,
+		Subject:   token.AccountID,
+		TokenID:   token.ID,
+		TokenType: "Bearer",
+		UID:       info.UID,
+		IUID:      info.IUID,
+		IamAlias:  info.IamAlias,
+	}
+	if resp.ClientID == "" {
+		resp.ClientID = token.AccountID
+	}
+	if token.ExpiresAt != nil {
+		resp.ExpiresAt = token.ExpiresAt.Unix()
+	}
+	if !token.CreatedAt.IsZero() {
+		resp.IssuedAt = token.CreatedAt.Unix()
+	}
+	return resp
+}
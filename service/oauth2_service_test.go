@@ -0,0 +1,467 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/auth"
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========================================
+// Mock OAuth2ClientRepository
+// ========================================
+
+type MockOAuth2ClientRepository struct {
+	mock.Mock
+}
+
+func (m *MockOAuth2ClientRepository) Create(ctx context.Context, client *interfaces.OAuth2Client) error {
+	args := m.Called(ctx, client)
+	return args.Error(0)
+}
+
+func (m *MockOAuth2ClientRepository) GetByID(ctx context.Context, clientID string) (*interfaces.OAuth2Client, error) {
+	args := m.Called(ctx, clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.OAuth2Client), args.Error(1)
+}
+
+func (m *MockOAuth2ClientRepository) ListByAccount(ctx context.Context, accountID string) ([]interfaces.OAuth2Client, error) {
+	args := m.Called(ctx, accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]interfaces.OAuth2Client), args.Error(1)
+}
+
+func (m *MockOAuth2ClientRepository) Delete(ctx context.Context, accountID, clientID string) error {
+	args := m.Called(ctx, accountID, clientID)
+	return args.Error(0)
+}
+
+func (m *MockOAuth2ClientRepository) UpdateResourceAccounts(ctx context.Context, clientID string, accountIDs []string) (*interfaces.OAuth2Client, error) {
+	args := m.Called(ctx, clientID, accountIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.OAuth2Client), args.Error(1)
+}
+
+// createCapturingTokenRepository 记录最后一次创建的 Token
+type createCapturingTokenRepository struct {
+	*MockTokenRepository
+	created *interfaces.Token
+}
+
+func (r *createCapturingTokenRepository) Create(ctx context.Context, token *interfaces.Token) error {
+	r.created = token
+	return nil
+}
+
+func newTestOAuth2Service() (*OAuth2ServiceImpl, *MockTokenRepository, *MockAccountRepository, *MockOAuth2ClientRepository, *MockAuditLogRepository) {
+	tokenRepo := new(MockTokenRepository)
+	accountRepo := new(MockAccountRepository)
+	clientRepo := new(MockOAuth2ClientRepository)
+	auditRepo := new(MockAuditLogRepository)
+	s := NewOAuth2Service(NewValidationService(tokenRepo), tokenRepo, accountRepo, clientRepo, auditRepo)
+	return s, tokenRepo, accountRepo, clientRepo, auditRepo
+}
+
+func TestOAuth2Service_AuthenticateClient(t *testing.T) {
+	s, _, accountRepo, clientRepo, _ := newTestOAuth2Service()
+	clientRepo.On("GetByID", mock.Anything, "oc_user").Return(&interfaces.OAuth2Client{
+		ClientID: "oc_user", AccountID: "acc_user", SecretHash: hashClientSecret("ocs_user"),
+	}, nil)
+	clientRepo.On("GetByID", mock.Anything, "oc_suspended").Return(&interfaces.OAuth2Client{
+		ClientID: "oc_suspended", AccountID: "acc_s", SecretHash: hashClientSecret("ocs_s"),
+	}, nil)
+	clientRepo.On("GetByID", mock.Anything, "oc_unknown").Return(nil, nil)
+	// 账户 AK/SK 不能作为客户端凭证
+	clientRepo.On("GetByID", mock.Anything, "AK_user").Return(nil, nil)
+	accountRepo.On("GetByID", mock.Anything, "acc_user").Return(&interfaces.Account{ID: "acc_user", Status: interfaces.AccountStatusActive}, nil)
+	accountRepo.On("GetByID", mock.Anything, "acc_s").Return(&interfaces.Account{ID: "acc_s", Status: interfaces.AccountStatusSuspended}, nil)
+
+	tests := []struct {
+		name          string
+		clientID      string
+		clientSecret  string
+		wantAccountID string
+		wantErr       bool
+	}{
+		{"valid client", "oc_user", "ocs_user", "acc_user", false},
+		{"wrong secret", "oc_user", "ocs_wrong", "", true},
+		{"secret hash as secret", "oc_user", hashClientSecret("ocs_user"), "", true},
+		{"unknown client", "oc_unknown", "ocs_x", "", true},
+		{"account access key", "AK_user", "SK_user", "", true},
+		{"suspended account", "oc_suspended", "ocs_s", "", true},
+		{"missing secret", "oc_user", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := s.AuthenticateClient(context.Background(), tt.clientID, tt.clientSecret)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, interfaces.ErrInvalidClient)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.clientID, client.ClientID)
+			assert.Equal(t, tt.wantAccountID, client.AccountID)
+		})
+	}
+}
+
+func TestOAuth2Service_CreateClient(t *testing.T) {
+	s, _, _, clientRepo, auditRepo := newTestOAuth2Service()
+	clientRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	auditRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	resp, err := s.CreateClient(context.Background(), "acc_1", &interfaces.OAuth2ClientCreateRequest{
+		Name:       "gateway",
+		Scopes:     []string{"storage:read"},
+		GrantTypes: []string{interfaces.OAuth2GrantClientCredentials},
+	})
+	require.NoError(t, err)
+	assert.Regexp(t, `^oc_[0-9a-f]{32}$`, resp.ClientID)
+	assert.Regexp(t, `^ocs_[0-9a-f]{64}$`, resp.ClientSecret)
+	assert.Equal(t, "acc_1", resp.AccountID)
+
+	// 只保存密钥摘要
+	clientRepo.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(client *interfaces.OAuth2Client) bool {
+		return client.SecretHash == hashClientSecret(resp.ClientSecret) && client.SecretHash != resp.ClientSecret
+	}))
+	auditRepo.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(log *interfaces.AuditLog) bool {
+		return log.Action == interfaces.AuditActionCreateOAuth2Client && log.AccountID == "acc_1" && log.ResourceID == resp.ClientID
+	}))
+
+	tests := []struct {
+		name string
+		req  *interfaces.OAuth2ClientCreateRequest
+	}{
+		{"missing name", &interfaces.OAuth2ClientCreateRequest{Scopes: []string{"*"}, GrantTypes: []string{interfaces.OAuth2GrantClientCredentials}}},
+		{"missing scopes", &interfaces.OAuth2ClientCreateRequest{Name: "x", GrantTypes: []string{interfaces.OAuth2GrantClientCredentials}}},
+		{"invalid scope", &interfaces.OAuth2ClientCreateRequest{Name: "x", Scopes: []string{"bogus"}, GrantTypes: []string{interfaces.OAuth2GrantClientCredentials}}},
+		{"missing grant types", &interfaces.OAuth2ClientCreateRequest{Name: "x", Scopes: []string{"*"}}},
+		{"unsupported grant type", &interfaces.OAuth2ClientCreateRequest{Name: "x", Scopes: []string{"*"}, GrantTypes: []string{"password"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.CreateClient(context.Background(), "acc_1", tt.req)
+			assert.ErrorIs(t, err, interfaces.ErrInvalidArgument)
+		})
+	}
+	clientRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestOAuth2Service_DeleteClient(t *testing.T) {
+	s, tokenRepo, _, clientRepo, auditRepo := newTestOAuth2Service()
+	clientRepo.On("Delete", mock.Anything, "acc_1", "oc_1").Return(nil)
+	clientRepo.On("Delete", mock.Anything, "acc_1", "oc_other").Return(interfaces.ErrOAuth2ClientNotFound)
+	tokenRepo.On("DisableByClientID", mock.Anything, "oc_1").Return(int64(2), nil)
+	auditRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	// 删除客户端时停用其签发的 Token
+	require.NoError(t, s.DeleteClient(context.Background(), "acc_1", "oc_1"))
+	tokenRepo.AssertCalled(t, "DisableByClientID", mock.Anything, "oc_1")
+
+	// 不能删除其他账户的客户端
+	assert.ErrorIs(t, s.DeleteClient(context.Background(), "acc_1", "oc_other"), interfaces.ErrOAuth2ClientNotFound)
+	tokenRepo.AssertNotCalled(t, "DisableByClientID", mock.Anything, "oc_other")
+}
+
+func TestOAuth2Service_ClientManagementRejectsSubAccounts(t *testing.T) {
+	s, tokenRepo, _, clientRepo, _ := newTestOAuth2Service()
+
+	for _, user := range []*auth.QstubUserInfo{
+		{UID: "1369077332", IamUid: "8901234"},
+		{UID: "1369077332", IamAlias: "dev"},
+	} {
+		ctx := context.WithValue(context.Background(), "qstub_user", user)
+
+		_, err := s.CreateClient(ctx, "qiniu_1369077332", &interfaces.OAuth2ClientCreateRequest{
+			Name:       "gateway",
+			Scopes:     []string{"storage:read"},
+			GrantTypes: []string{interfaces.OAuth2GrantClientCredentials},
+		})
+		assert.ErrorIs(t, err, interfaces.ErrPermissionDenied)
+
+		_, err = s.ListClients(ctx, "qiniu_1369077332")
+		assert.ErrorIs(t, err, interfaces.ErrPermissionDenied)
+
+		assert.ErrorIs(t, s.DeleteClient(ctx, "qiniu_1369077332", "oc_1"), interfaces.ErrPermissionDenied)
+	}
+
+	clientRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	clientRepo.AssertNotCalled(t, "ListByAccount", mock.Anything, mock.Anything)
+	clientRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	tokenRepo.AssertNotCalled(t, "DisableByClientID", mock.Anything, mock.Anything)
+}
+
+func TestOAuth2Service_IntrospectToken(t *testing.T) {
+	s, tokenRepo, _, _, _ := newTestOAuth2Service()
+
+	createdAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	tokenRepo.On("GetByTokenValue", mock.Anything, "sk-valid").Return(&interfaces.Token{
+		ID:        "tk_1",
+		AccountID: "qiniu_12345",
+		IUID:      "678",
+		Scopes:    []string{"storage:read", "cdn:*"},
+		IsActive:  true,
+		CreatedAt: createdAt,
+		ExpiresAt: &expiresAt,
+	}, nil)
+	tokenRepo.On("GetByTokenValue", mock.Anything, "sk-inactive").Return(&interfaces.Token{
+		ID: "tk_2", AccountID: "qiniu_12345", IsActive: false,
+	}, nil)
+	tokenRepo.On("GetByTokenValue", mock.Anything, "sk-unknown").Return(nil, nil)
+
+	gateway := &interfaces.OAuth2Client{ClientID: "oc_gateway", AccountID: "acc_gw", ResourceAccounts: []string{"qiniu_12345"}}
+	otherGateway := &interfaces.OAuth2Client{ClientID: "oc_gateway2", AccountID: "acc_gw2", ResourceAccounts: []string{"acc_other"}}
+	owner := &interfaces.OAuth2Client{ClientID: "oc_owner", AccountID: "qiniu_12345"}
+	other := &interfaces.OAuth2Client{ClientID: "oc_other", AccountID: "acc_other"}
+
+	resp, err := s.IntrospectToken(context.Background(), gateway, "sk-valid", "")
+	require.NoError(t, err)
+	assert.Equal(t, &interfaces.TokenIntrospectionResponse{
+		Active:    true,
+		Scope:     "storage:read cdn:*",
+		ClientID:  "qiniu_12345",
+		Subject:   "qiniu_12345",
+		ExpiresAt: expiresAt.Unix(),
+		IssuedAt:  createdAt.Unix(),
+		TokenID:   "tk_1",
+		TokenType: "Bearer",
+		UID:       "12345",
+		IUID:      "678",
+	}, resp)
+
+	tests := []struct {
+		name       string
+		client     *interfaces.OAuth2Client
+		token      string
+		wantActive bool
+	}{
+		{"owner", owner, "sk-valid", true},
+		{"other account", other, "sk-valid", false},
+		{"resource server without grant", otherGateway, "sk-valid", false},
+		{"inactive token", gateway, "sk-inactive", false},
+		{"unknown token", gateway, "sk-unknown", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := s.IntrospectToken(context.Background(), tt.client, tt.token, "")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantActive, resp.Active)
+			if !tt.wantActive {
+				assert.Equal(t, &interfaces.TokenIntrospectionResponse{Active: false}, resp)
+			}
+		})
+	}
+}
+
+func TestOAuth2Service_RevokeToken(t *testing.T) {
+	s, tokenRepo, _, _, auditRepo := newTestOAuth2Service()
+
+	tokenRepo.On("GetByTokenValue", mock.Anything, "sk-owned").Return(&interfaces.Token{ID: "tk_1", AccountID: "acc_user", IsActive: true}, nil)
+	tokenRepo.On("GetByTokenValue", mock.Anything, "sk-granted").Return(&interfaces.Token{ID: "tk_3", AccountID: "acc_granted", IsActive: true}, nil)
+	tokenRepo.On("GetByTokenValue", mock.Anything, "sk-foreign").Return(&interfaces.Token{ID: "tk_2", AccountID: "acc_other", IsActive: true}, nil)
+	tokenRepo.On("GetByTokenValue", mock.Anything, "sk-unknown").Return(nil, nil)
+	tokenRepo.On("GetByTokenValue", mock.Anything, "sk-error").Return(nil, errors.New("connection refused"))
+	tokenRepo.On("UpdateStatus", mock.Anything, "tk_1", false).Return(nil)
+	tokenRepo.On("UpdateStatus", mock.Anything, "tk_3", false).Return(nil)
+	auditRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	client := &interfaces.OAuth2Client{ClientID: "oc_user", AccountID: "acc_user", ResourceAccounts: []string{"acc_granted"}}
+
+	require.NoError(t, s.RevokeToken(context.Background(), client, "sk-owned"))
+	tokenRepo.AssertCalled(t, "UpdateStatus", mock.Anything, "tk_1", false)
+	auditRepo.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(log *interfaces.AuditLog) bool {
+		return log.Action == interfaces.AuditActionRevokeToken && log.AccountID == "acc_user" &&
+			log.ResourceID == "tk_1" && log.Result == interfaces.AuditResultSuccess
+	}))
+
+	// 管理员授予的账户
+	require.NoError(t, s.RevokeToken(context.Background(), client, "sk-granted"))
+	tokenRepo.AssertCalled(t, "UpdateStatus", mock.Anything, "tk_3", false)
+
+	// Token 不存在时视为成功
+	assert.NoError(t, s.RevokeToken(context.Background(), client, "sk-unknown"))
+
+	err := s.RevokeToken(context.Background(), client, "sk-foreign")
+	assert.ErrorIs(t, err, interfaces.ErrPermissionDenied)
+	tokenRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, "tk_2", false)
+
+	assert.Error(t, s.RevokeToken(context.Background(), client, "sk-error"))
+}
+
+func TestOAuth2Service_IssueToken(t *testing.T) {
+	s, tokenRepo, _, _, auditRepo := newTestOAuth2Service()
+	capturing := &createCapturingTokenRepository{MockTokenRepository: tokenRepo}
+	s.SetTokenIssuer(NewTokenService(capturing, auditRepo), 15*time.Minute, interfaces.TokenFormatOpaque)
+
+	expiresAt := time.Now().Add(5 * time.Minute)
+	tokenRepo.On("GetByTokenValue", mock.Anything, "sk-subject").Return(&interfaces.Token{
+		ID:        "tk_1",
+		AccountID: "acc_user",
+		IUID:      "678",
+		Scopes:    []string{"storage:*"},
+		IsActive:  true,
+		ExpiresAt: &expiresAt,
+	}, nil)
+	tokenRepo.On("GetByTokenValue", mock.Anything, "sk-inactive").Return(&interfaces.Token{ID: "tk_2", AccountID: "acc_user", IsActive: false}, nil)
+	auditRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	allGrants := []string{interfaces.OAuth2GrantClientCredentials, interfaces.OAuth2GrantTokenExchange}
+	client := &interfaces.OAuth2Client{ClientID: "oc_user", AccountID: "acc_user", Scopes: []string{"storage:*"}, GrantTypes: allGrants}
+
+	// client_credentials：按配置的有效期签发，关联到客户端，过期后清理
+	resp, err := s.IssueToken(context.Background(), client, &interfaces.OAuth2TokenRequest{
+		GrantType: interfaces.OAuth2GrantClientCredentials,
+		Scope:     "storage:read",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", resp.TokenType)
+	assert.Equal(t, int64(900), resp.ExpiresIn)
+	assert.Equal(t, "storage:read", resp.Scope)
+	auditRepo.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(log *interfaces.AuditLog) bool {
+		return log.Action == interfaces.AuditActionCreateToken && log.AccountID == "acc_user" &&
+			log.RequestData["client_id"] == "oc_user"
+	}))
+	require.NotNil(t, capturing.created)
+	assert.Equal(t, "oc_user", capturing.created.ClientID)
+	require.NotNil(t, capturing.created.PurgeAt)
+	assert.Equal(t, *capturing.created.ExpiresAt, *capturing.created.PurgeAt)
+
+	// client_credentials 未指定 scope 时使用客户端 scope
+	resp, err = s.IssueToken(context.Background(), client, &interfaces.OAuth2TokenRequest{
+		GrantType: interfaces.OAuth2GrantClientCredentials,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "storage:*", resp.Scope)
+
+	// token exchange：降权，有效期不超过原 Token
+	resp, err = s.IssueToken(context.Background(), client, &interfaces.OAuth2TokenRequest{
+		GrantType:        interfaces.OAuth2GrantTokenExchange,
+		Scope:            "storage:read",
+		SubjectToken:     "sk-subject",
+		SubjectTokenType: interfaces.OAuth2TokenTypeAccessToken,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, interfaces.OAuth2TokenTypeAccessToken, resp.IssuedTokenType)
+	assert.Equal(t, "storage:read", resp.Scope)
+	assert.LessOrEqual(t, resp.ExpiresIn, int64(300))
+
+	// 资源服务交换管理员授予账户的 Token
+	gateway := &interfaces.OAuth2Client{ClientID: "oc_gw", AccountID: "acc_gw", Scopes: []string{"*"}, GrantTypes: allGrants, ResourceAccounts: []string{"acc_user"}}
+	resp, err = s.IssueToken(context.Background(), gateway, &interfaces.OAuth2TokenRequest{
+		GrantType:        interfaces.OAuth2GrantTokenExchange,
+		SubjectToken:     "sk-subject",
+		SubjectTokenType: interfaces.OAuth2TokenTypeAccessToken,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "storage:*", resp.Scope)
+
+	tests := []struct {
+		name    string
+		client  *interfaces.OAuth2Client
+		req     *interfaces.OAuth2TokenRequest
+		wantErr error
+	}{
+		{"missing grant type", client, &interfaces.OAuth2TokenRequest{}, interfaces.ErrInvalidArgument},
+		{"unsupported grant type", client, &interfaces.OAuth2TokenRequest{GrantType: "password"}, interfaces.ErrUnsupportedGrantType},
+		{"grant type not allowed", &interfaces.OAuth2Client{ClientID: "oc_ro", AccountID: "acc_user", Scopes: []string{"*"}, GrantTypes: []string{interfaces.OAuth2GrantClientCredentials}}, &interfaces.OAuth2TokenRequest{
+			GrantType:    interfaces.OAuth2GrantTokenExchange,
+			SubjectToken: "sk-subject", SubjectTokenType: interfaces.OAuth2TokenTypeAccessToken,
+		}, interfaces.ErrUnauthorizedClient},
+		{"invalid scope", client, &interfaces.OAuth2TokenRequest{GrantType: interfaces.OAuth2GrantClientCredentials, Scope: "bogus"}, interfaces.ErrInvalidScope},
+		{"scope exceeds client", client, &interfaces.OAuth2TokenRequest{GrantType: interfaces.OAuth2GrantClientCredentials, Scope: "cdn:read"}, interfaces.ErrInvalidScope},
+		{"scope exceeds subject", gateway, &interfaces.OAuth2TokenRequest{
+			GrantType: interfaces.OAuth2GrantTokenExchange, Scope: "cdn:read",
+			SubjectToken: "sk-subject", SubjectTokenType: interfaces.OAuth2TokenTypeAccessToken,
+		}, interfaces.ErrInvalidScope},
+		{"subject scope exceeds client", &interfaces.OAuth2Client{ClientID: "oc_narrow", AccountID: "acc_user", Scopes: []string{"storage:read"}, GrantTypes: allGrants}, &interfaces.OAuth2TokenRequest{
+			GrantType:    interfaces.OAuth2GrantTokenExchange,
+			SubjectToken: "sk-subject", SubjectTokenType: interfaces.OAuth2TokenTypeAccessToken,
+		}, interfaces.ErrInvalidScope},
+		{"inactive subject", client, &interfaces.OAuth2TokenRequest{
+			GrantType:    interfaces.OAuth2GrantTokenExchange,
+			SubjectToken: "sk-inactive", SubjectTokenType: interfaces.OAuth2TokenTypeAccessToken,
+		}, interfaces.ErrInvalidGrant},
+		{"foreign subject", &interfaces.OAuth2Client{ClientID: "oc_other", AccountID: "acc_other", Scopes: []string{"*"}, GrantTypes: allGrants}, &interfaces.OAuth2TokenRequest{
+			GrantType:    interfaces.OAuth2GrantTokenExchange,
+			SubjectToken: "sk-subject", SubjectTokenType: interfaces.OAuth2TokenTypeAccessToken,
+		}, interfaces.ErrInvalidGrant},
+		{"resource server without grant", &interfaces.OAuth2Client{ClientID: "oc_gw2", AccountID: "acc_gw2", Scopes: []string{"*"}, GrantTypes: allGrants, ResourceAccounts: []string{"acc_other"}}, &interfaces.OAuth2TokenRequest{
+			GrantType:    interfaces.OAuth2GrantTokenExchange,
+			SubjectToken: "sk-subject", SubjectTokenType: interfaces.OAuth2TokenTypeAccessToken,
+		}, interfaces.ErrInvalidGrant},
+		{"wrong subject token type", client, &interfaces.OAuth2TokenRequest{
+			GrantType:    interfaces.OAuth2GrantTokenExchange,
+			SubjectToken: "sk-subject", SubjectTokenType: "urn:ietf:params:oauth:token-type:id_token",
+		}, interfaces.ErrInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.IssueToken(context.Background(), tt.client, tt.req)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestOAuth2Service_ExchangedTokensShareSubjectRateLimit(t *testing.T) {
+	s, tokenRepo, _, _, auditRepo := newTestOAuth2Service()
+	capturing := &createCapturingTokenRepository{MockTokenRepository: tokenRepo}
+	s.SetTokenIssuer(NewTokenService(capturing, auditRepo), 15*time.Minute, interfaces.TokenFormatOpaque)
+	auditRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	rateLimit := &interfaces.RateLimit{RequestsPerMinute: 10}
+	tokenRepo.On("GetByTokenValue", mock.Anything, "sk-subject").Return(&interfaces.Token{
+		ID: "tk_1", AccountID: "acc_user", Scopes: []string{"storage:*"}, RateLimit: rateLimit, IsActive: true,
+	}, nil)
+	client := &interfaces.OAuth2Client{ClientID: "oc_user", AccountID: "acc_user", Scopes: []string{"storage:*"}, GrantTypes: []string{interfaces.OAuth2GrantTokenExchange}}
+	exchange := func(subjectToken string) *interfaces.Token {
+		_, err := s.IssueToken(context.Background(), client, &interfaces.OAuth2TokenRequest{
+			GrantType:        interfaces.OAuth2GrantTokenExchange,
+			SubjectToken:     subjectToken,
+			SubjectTokenType: interfaces.OAuth2TokenTypeAccessToken,
+		})
+		require.NoError(t, err)
+		return capturing.created
+	}
+
+	// 多次交换出的 Token 都计入原 Token 的额度
+	first := exchange("sk-subject")
+	first.ID = "tk_ex1"
+	assert.Equal(t, "tk_1", first.RateLimitTokenID)
+	assert.Equal(t, rateLimit, first.RateLimit)
+	second := exchange("sk-subject")
+	second.ID = "tk_ex2"
+	assert.Equal(t, "tk_1", second.RateLimitTokenID)
+
+	// 由交换出的 Token 再交换，仍计入最初的原 Token
+	tokenRepo.On("GetByTokenValue", mock.Anything, "sk-ex1").Return(first, nil)
+	tokenRepo.On("GetByTokenValue", mock.Anything, "sk-ex2").Return(second, nil)
+	assert.Equal(t, "tk_1", exchange("sk-ex1").RateLimitTokenID)
+
+	validation := NewValidationService(tokenRepo)
+	limiter := &stubTokenLimitChecker{}
+	validation.SetTokenLimitChecker(limiter)
+	for _, value := range []string{"sk-subject", "sk-ex1", "sk-ex2"} {
+		resp, err := validation.ValidateTokenWithLimit(context.Background(), &interfaces.TokenValidateRequest{Token: value})
+		require.NoError(t, err)
+		assert.True(t, resp.Valid)
+	}
+	assert.Equal(t, []string{"tk_1", "tk_1", "tk_1"}, limiter.calls)
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/observability"
+)
+
+// idSetRefreshTimeout 单次刷新超时
+const idSetRefreshTimeout = 5 * time.Second
+
+// refreshingIDSet 定期从数据库全量刷新的进程内 ID 集合
+// 读取只做一次内存查询；本实例上的修改通过 Mark 立即生效，其他实例的修改在一个刷新间隔内生效；
+// 刷新失败时保留上次结果（SuspendedAccountCache 和 RevokedTokenCache 共用）
+type refreshingIDSet struct {
+	name            string // 用于日志
+	load            func(ctx context.Context) ([]string, error)
+	refreshInterval time.Duration
+
+	mu  sync.Mutex // 保护写入（刷新与 Mark 互斥）
+	ids atomic.Pointer[map[string]struct{}]
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// newRefreshingIDSet 创建 ID 集合（需调用 Start 加载并启动后台刷新）
+func newRefreshingIDSet(name string, load func(ctx context.Context) ([]string, error), refreshInterval time.Duration) *refreshingIDSet {
+	s := &refreshingIDSet{
+		name:            name,
+		load:            load,
+		refreshInterval: refreshInterval,
+		stop:            make(chan struct{}),
+		done:            make(chan struct{}),
+	}
+	empty := make(map[string]struct{})
+	s.ids.Store(&empty)
+	return s
+}
+
+// Start 同步加载一次，然后启动后台刷新
+func (s *refreshingIDSet) Start() {
+	s.refresh()
+	go s.run()
+}
+
+// Stop 停止后台刷新
+func (s *refreshingIDSet) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Mark 立即更新本实例中 ID 是否在集合中
+func (s *refreshingIDSet) Mark(id string, present bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := *s.ids.Load()
+	next := make(map[string]struct{}, len(current)+1)
+	for existing := range current {
+		next[existing] = struct{}{}
+	}
+	if present {
+		next[id] = struct{}{}
+	} else {
+		delete(next, id)
+	}
+	s.ids.Store(&next)
+}
+
+// contains ID 是否在集合中
+func (s *refreshingIDSet) contains(id string) bool {
+	_, ok := (*s.ids.Load())[id]
+	return ok
+}
+
+// run 后台定期刷新
+func (s *refreshingIDSet) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.refresh()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// refresh 从数据库全量加载（失败时保留上次结果）
+func (s *refreshingIDSet) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), idSetRefreshTimeout)
+	defer cancel()
+
+	ids, err := s.load(ctx)
+	if err != nil {
+		observability.LogWarn(ctx, "Failed to refresh "+s.name, slog.String("error", err.Error()))
+		return
+	}
+
+	next := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		next[id] = struct{}{}
+	}
+
+	s.mu.Lock()
+	s.ids.Store(&next)
+	s.mu.Unlock()
+}
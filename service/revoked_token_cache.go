@@ -0,0 +1,33 @@
+package service
+
+import (
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+)
+
+// DefaultRevokedTokensRefreshInterval 签名 Token 吊销列表默认刷新间隔
+const DefaultRevokedTokensRefreshInterval = 30 * time.Second
+
+// RevokedTokenCache 签名 Token 吊销列表（进程内）
+// 签名 Token 验证不查询数据库，只在此集合中检查 Token ID；后台定期从数据库全量刷新
+// （只包含已停用 / 已删除且尚未过期的签名 Token，签名 Token 有效期有上限，数量很少），
+// 本实例上的停用 / 恢复 / 删除操作通过 Mark 立即生效，其他实例在一个刷新间隔内生效
+type RevokedTokenCache struct {
+	*refreshingIDSet
+}
+
+// NewRevokedTokenCache 创建签名 Token 吊销列表（需调用 Start 加载并启动后台刷新）
+func NewRevokedTokenCache(tokenRepo interfaces.TokenRepository, refreshInterval time.Duration) *RevokedTokenCache {
+	if refreshInterval <= 0 {
+		refreshInterval = DefaultRevokedTokensRefreshInterval
+	}
+	return &RevokedTokenCache{
+		refreshingIDSet: newRefreshingIDSet("revoked signed tokens", tokenRepo.ListRevokedSignedIDs, refreshInterval),
+	}
+}
+
+// IsRevoked Token 是否已吊销
+func (c *RevokedTokenCache) IsRevoked(tokenID string) bool {
+	return c.contains(tokenID)
+}
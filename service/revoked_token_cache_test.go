@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubRevokedRepo 可在测试中随时修改 ListRevokedSignedIDs 的返回值
+type stubRevokedRepo struct {
+	*MockTokenRepository
+
+	mu    sync.Mutex
+	ids   []string
+	err   error
+	calls int
+}
+
+func (r *stubRevokedRepo) ListRevokedSignedIDs(ctx context.Context) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	return r.ids, r.err
+}
+
+func (r *stubRevokedRepo) set(ids []string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ids, r.err = ids, err
+}
+
+func (r *stubRevokedRepo) callCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls
+}
+
+func newStubRevokedRepo(ids ...string) *stubRevokedRepo {
+	return &stubRevokedRepo{MockTokenRepository: new(MockTokenRepository), ids: ids}
+}
+
+func TestRevokedTokenCache_StartLoads(t *testing.T) {
+	repo := newStubRevokedRepo("tk_1")
+	c := NewRevokedTokenCache(repo, time.Hour)
+	c.Start()
+	defer c.Stop(context.Background())
+
+	assert.True(t, c.IsRevoked("tk_1"))
+	assert.False(t, c.IsRevoked("tk_2"))
+	assert.Equal(t, 1, repo.callCount())
+}
+
+func TestRevokedTokenCache_RefreshReplacesSet(t *testing.T) {
+	repo := newStubRevokedRepo("tk_1")
+	c := NewRevokedTokenCache(repo, time.Hour)
+	c.refresh()
+
+	// 全量刷新：不在结果中的 Token 视为已恢复（或已过期后被清理）
+	repo.set([]string{"tk_2"}, nil)
+	c.refresh()
+	assert.False(t, c.IsRevoked("tk_1"))
+	assert.True(t, c.IsRevoked("tk_2"))
+}
+
+func TestRevokedTokenCache_RevocationWithinRefreshInterval(t *testing.T) {
+	repo := newStubRevokedRepo()
+	c := NewRevokedTokenCache(repo, 10*time.Millisecond)
+	c.Start()
+	defer c.Stop(context.Background())
+
+	keys := newTestKeySet(t)
+	validation := NewValidationService(repo)
+	validation.SetSignedTokenVerifier(keys, c)
+	value := signTestToken(t, keys, "tk_1", time.Now().Add(time.Hour))
+
+	resp, err := validation.ValidateToken(context.Background(), &interfaces.TokenValidateRequest{Token: value})
+	require.NoError(t, err)
+	assert.True(t, resp.Valid)
+
+	// 其他实例停用 Token：下一次刷新后本实例拒绝该 Token
+	repo.set([]string{"tk_1"}, nil)
+	assert.Eventually(t, func() bool { return c.IsRevoked("tk_1") }, time.Second, 5*time.Millisecond)
+
+	resp, err = validation.ValidateToken(context.Background(), &interfaces.TokenValidateRequest{Token: value})
+	require.NoError(t, err)
+	assert.False(t, resp.Valid)
+	assert.Equal(t, interfaces.ErrCodeTokenInactive, resp.Code)
+}
+
+func TestRevokedTokenCache_RefreshFailureKeepsLastResult(t *testing.T) {
+	repo := newStubRevokedRepo("tk_1")
+	c := NewRevokedTokenCache(repo, 10*time.Millisecond)
+	c.Start()
+	defer c.Stop(context.Background())
+
+	repo.set(nil, errors.New("mongo unavailable"))
+	calls := repo.callCount()
+	assert.Eventually(t, func() bool { return repo.callCount() >= calls+3 }, time.Second, 5*time.Millisecond)
+
+	// 刷新失败时不能把已吊销的 Token 当作已恢复
+	assert.True(t, c.IsRevoked("tk_1"))
+
+	// 本实例的操作在刷新失败期间仍然生效
+	c.Mark("tk_2", true)
+	assert.True(t, c.IsRevoked("tk_2"))
+
+	// 恢复后以数据库为准
+	repo.set([]string{"tk_2"}, nil)
+	assert.Eventually(t, func() bool { return !c.IsRevoked("tk_1") }, time.Second, 5*time.Millisecond)
+	assert.True(t, c.IsRevoked("tk_2"))
+}
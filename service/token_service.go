@@ -2,26 +2,81 @@ package service
 
 import (
 	"context"
-	"errors"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/qiniu/bearer-token-service/v2/auth"
 	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/pkg/signedtoken"
+)
+
+const (
+	// DefaultRotationGracePeriod 轮换时旧 token 默认保留时长
+	DefaultRotationGracePeriod = 24 * time.Hour
+
+	// DefaultMaxRotationGracePeriod 轮换宽限期默认上限
+	DefaultMaxRotationGracePeriod = 7 * 24 * time.Hour
+
+	// DefaultMaxSignedTokenTTL 签名 Token 有效期默认上限（离线验证方感知吊销的最长延迟）
+	DefaultMaxSignedTokenTTL = 24 * time.Hour
+
+	// 使用统计查询的默认时间范围和上限
+	defaultDailyStatsRange  = 30 * 24 * time.Hour
+	maxDailyStatsRange      = 366 * 24 * time.Hour
+	defaultHourlyStatsRange = 24 * time.Hour
+	maxHourlyStatsRange     = 31 * 24 * time.Hour
 )
 
 // TokenServiceImpl Token 管理服务实现
 type TokenServiceImpl struct {
 	tokenRepo interfaces.TokenRepository
 	auditRepo interfaces.AuditLogRepository
+	usageRepo interfaces.TokenUsageRepository
+
+	rotationGracePeriod    time.Duration
+	maxRotationGracePeriod time.Duration
+
+	signingKeys       *signedtoken.KeySet // 签名 Token 密钥（可选，未设置时不支持 format=signed）
+	maxSignedTokenTTL time.Duration
+	revoked           *RevokedTokenCache // 签名 Token 吊销列表（可选，停用 / 删除后本实例立即生效）
 }
 
 // NewTokenService 创建 Token 服务实例
 func NewTokenService(tokenRepo interfaces.TokenRepository, auditRepo interfaces.AuditLogRepository) *TokenServiceImpl {
 	return &TokenServiceImpl{
-		tokenRepo: tokenRepo,
-		auditRepo: auditRepo,
+		tokenRepo:              tokenRepo,
+		auditRepo:              auditRepo,
+		rotationGracePeriod:    DefaultRotationGracePeriod,
+		maxRotationGracePeriod: DefaultMaxRotationGracePeriod,
+	}
+}
+
+// SetRotationGracePeriod 设置轮换宽限期（默认值和上限）
+func (s *TokenServiceImpl) SetRotationGracePeriod(defaultPeriod, maxPeriod time.Duration) {
+	s.rotationGracePeriod = defaultPeriod
+	s.maxRotationGracePeriod = maxPeriod
+}
+
+// SetSignedTokenKeys 设置签名 Token 密钥及有效期上限（可选，设置后支持创建 format=signed 的 Token）
+func (s *TokenServiceImpl) SetSignedTokenKeys(keys *signedtoken.KeySet, maxTTL time.Duration) {
+	if maxTTL <= 0 {
+		maxTTL = DefaultMaxSignedTokenTTL
 	}
+	s.signingKeys = keys
+	s.maxSignedTokenTTL = maxTTL
+}
+
+// SetRevokedTokenCache 设置签名 Token 吊销列表（可选，设置后停用 / 删除签名 Token 在本实例立即生效）
+func (s *TokenServiceImpl) SetRevokedTokenCache(revoked *RevokedTokenCache) {
+	s.revoked = revoked
+}
+
+// SetUsageRepository 设置使用统计存储（可选，未设置时统计接口只返回累计值）
+func (s *TokenServiceImpl) SetUsageRepository(usageRepo interfaces.TokenUsageRepository) {
+	s.usageRepo = usageRepo
 }
 
 // CreateToken 创建新 Token
@@ -33,26 +88,48 @@ func (s *TokenServiceImpl) CreateToken(ctx context.Context, accountID string, re
 		expiresAt = &t
 	}
 
-	// 2. 从 Context 中提取 IUID 和 IamAlias（如果是 QiniuStub 认证）
+	// 2. 从 Context 中提取 IUID 和 IamAlias（如果是 QiniuStub 认证）；Token 交换时继承原 Token 的子账号
 	var iuid, iamAlias string
 	if qstubUser, ok := ctx.Value("qstub_user").(*auth.QstubUserInfo); ok {
 		iuid = qstubUser.IamUid
 		iamAlias = qstubUser.IamAlias
 	}
+	if req.IUID != "" {
+		iuid, iamAlias = req.IUID, req.IamAlias
+	}
 
 	// 3. 创建 Token 对象
 	token := &interfaces.Token{
-		AccountID:   accountID,
-		Description: req.Description,
-		RateLimit:   req.RateLimit,
-		IUID:        iuid,
-		IamAlias:    iamAlias,
-		ExpiresAt:   expiresAt,
-		IsActive:    true,
-		Prefix:      req.Prefix,
+		AccountID:        accountID,
+		Description:      req.Description,
+		RateLimit:        req.RateLimit,
+		IUID:             iuid,
+		IamAlias:         iamAlias,
+		Scopes:           req.Scopes,
+		AllowedCIDRs:     req.AllowedCIDRs,
+		ExpiresAt:        expiresAt,
+		IsActive:         true,
+		Prefix:           req.Prefix,
+		ClientID:         req.ClientID,
+		RateLimitTokenID: req.RateLimitTokenID,
+	}
+
+	// 签名 Token 在此签发，不透明 Token 值由 Repository 自动生成
+	switch req.Format {
+	case "", interfaces.TokenFormatOpaque:
+	case interfaces.TokenFormatSigned:
+		if err := s.signToken(token, req); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: format must be opaque or signed", interfaces.ErrInvalidArgument)
+	}
+
+	// /oauth2/token 按需签发的短期 Token 过期后不再保留记录，由 TTL 索引删除
+	if req.ClientID != "" {
+		token.PurgeAt = token.ExpiresAt
 	}
 
-	// Token 值由 Repository 自动生成
 	err := s.tokenRepo.Create(ctx, token)
 	if err != nil {
 		s.logAction(ctx, accountID, interfaces.AuditActionCreateToken, "", interfaces.AuditResultFailure, err.Error(), nil)
@@ -61,22 +138,86 @@ func (s *TokenServiceImpl) CreateToken(ctx context.Context, accountID string, re
 
 	// 4. 记录审计日志
 	s.logAction(ctx, accountID, interfaces.AuditActionCreateToken, token.ID, interfaces.AuditResultSuccess, "", map[string]interface{}{
-		"description": req.Description,
+		"description":   req.Description,
+		"scopes":        req.Scopes,
+		"allowed_cidrs": req.AllowedCIDRs,
+		"format":        token.Format,
+		"client_id":     token.ClientID,
 	})
 
 	// 5. 返回响应（包含完整 Token，仅此一次）
 	return &interfaces.TokenCreateResponse{
-		TokenID:     token.ID,
-		Token:       token.Token, // 完整 Token，仅在创建时返回
-		AccountID:   token.AccountID,
-		Description: token.Description,
-		RateLimit:   token.RateLimit,
-		CreatedAt:   token.CreatedAt,
-		ExpiresAt:   token.ExpiresAt,
-		IsActive:    token.IsActive,
+		TokenID:      token.ID,
+		Token:        token.Token, // 完整 Token，仅在创建时返回
+		AccountID:    token.AccountID,
+		Description:  token.Description,
+		RateLimit:    token.RateLimit,
+		Scopes:       token.Scopes,
+		AllowedCIDRs: token.AllowedCIDRs,
+		Format:       token.Format,
+		CreatedAt:    token.CreatedAt,
+		ExpiresAt:    token.ExpiresAt,
+		IsActive:     token.IsActive,
 	}, nil
 }
 
+// signToken 为签名 Token 预先生成 ID 并签发 JWT
+// Repository 仍按 JWT 的值存储哈希和记录，管理接口（查询、停用、删除、统计）与不透明 Token 一致
+func (s *TokenServiceImpl) signToken(token *interfaces.Token, req *interfaces.TokenCreateRequest) error {
+	if s.signingKeys == nil {
+		return fmt.Errorf("%w: signed tokens are not enabled", interfaces.ErrInvalidArgument)
+	}
+	if req.Prefix != "" {
+		return fmt.Errorf("%w: prefix is not supported for signed tokens", interfaces.ErrInvalidArgument)
+	}
+	ttl := time.Duration(req.ExpiresInSeconds) * time.Second
+	if ttl <= 0 || ttl > s.maxSignedTokenTTL {
+		return fmt.Errorf("%w: expires_in_seconds of a signed token must be within %d seconds", interfaces.ErrInvalidArgument, int64(s.maxSignedTokenTTL.Seconds()))
+	}
+
+	tokenID, err := newTokenID()
+	if err != nil {
+		return err
+	}
+
+	// exp 为秒级精度，存储的过期时间与之保持一致
+	now := time.Now()
+	expiresAt := now.Add(ttl).Truncate(time.Second)
+	claims := &signedtoken.Claims{
+		Subject:      token.AccountID,
+		TokenID:      tokenID,
+		IUID:         token.IUID,
+		IamAlias:     token.IamAlias,
+		ClientID:     token.ClientID,
+		Scope:        strings.Join(token.Scopes, " "),
+		AllowedCIDRs: token.AllowedCIDRs,
+		RateLimitKey: token.RateLimitTokenID,
+		IssuedAt:     now.Unix(),
+		ExpiresAt:    expiresAt.Unix(),
+	}
+	if rl := token.RateLimit; rl != nil {
+		claims.RateLimit = &signedtoken.RateLimit{
+			RequestsPerMinute: rl.RequestsPerMinute,
+			RequestsPerHour:   rl.RequestsPerHour,
+			RequestsPerDay:    rl.RequestsPerDay,
+		}
+	}
+	if uid, ok := extractUIDFromAccountID(token.AccountID); ok {
+		claims.UID = uid
+	}
+
+	value, err := s.signingKeys.Sign(claims)
+	if err != nil {
+		return fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	token.ID = tokenID
+	token.Token = value
+	token.Format = interfaces.TokenFormatSigned
+	token.ExpiresAt = &expiresAt
+	return nil
+}
+
 // ListTokens 列出账户的所有 Tokens
 func (s *TokenServiceImpl) ListTokens(ctx context.Context, accountID string, activeOnly bool, limit, offset int) (*interfaces.TokenListResponse, error) {
 	// 从 Context 中提取子账号信息（子账号隔离）
@@ -104,9 +245,13 @@ func (s *TokenServiceImpl) ListTokens(ctx context.Context, accountID string, act
 	for _, token := range tokens {
 		brief := interfaces.TokenBrief{
 			TokenID:       token.ID,
-			TokenPreview:  hideToken(token.Token),
+			TokenPreview:  token.TokenPreview,
 			Description:   token.Description,
 			RateLimit:     token.RateLimit,
+			Scopes:        token.Scopes,
+			AllowedCIDRs:  token.AllowedCIDRs,
+			Format:        token.Format,
+			ClientID:      token.ClientID,
 			CreatedAt:     token.CreatedAt,
 			IsActive:      token.IsActive,
 			Status:        calculateTokenStatus(&token, now), // 动态计算状态
@@ -137,21 +282,30 @@ func (s *TokenServiceImpl) ListTokens(ctx context.Context, accountID string, act
 // iam_alias 子账号只能操作自己创建的 token（token.IamAlias == requester iam_alias）
 func checkOwnership(ctx context.Context, token *interfaces.Token, accountID string) error {
 	if token.AccountID != accountID {
-		return errors.New("permission denied")
+		return interfaces.ErrPermissionDenied
 	}
 	qstubUser, ok := ctx.Value("qstub_user").(*auth.QstubUserInfo)
 	if !ok || qstubUser == nil {
 		return nil // 非 QiniuStub 请求，主账号校验已通过
 	}
 	if qstubUser.IamUid != "" && token.IUID != qstubUser.IamUid {
-		return errors.New("permission denied")
+		return interfaces.ErrPermissionDenied
 	}
 	if qstubUser.IamUid == "" && qstubUser.IamAlias != "" && token.IamAlias != qstubUser.IamAlias {
-		return errors.New("permission denied")
+		return interfaces.ErrPermissionDenied
 	}
 	return nil
 }
 
+// subAccountFromContext 从 Context 中提取子账号信息（QiniuStub 认证时注入）
+// 主账号或非 QiniuStub 请求返回空值
+func subAccountFromContext(ctx context.Context) (iuid, iamAlias string) {
+	if qstubUser, ok := ctx.Value("qstub_user").(*auth.QstubUserInfo); ok && qstubUser != nil {
+		return qstubUser.IamUid, qstubUser.IamAlias
+	}
+	return "", ""
+}
+
 // GetTokenInfo 获取 Token 详情
 func (s *TokenServiceImpl) GetTokenInfo(ctx context.Context, accountID string, tokenID string) (*interfaces.Token, error) {
 	token, err := s.tokenRepo.GetByID(ctx, tokenID)
@@ -160,15 +314,17 @@ func (s *TokenServiceImpl) GetTokenInfo(ctx context.Context, accountID string, t
 	}
 
 	if token == nil {
-		return nil, errors.New("token not found")
+		return nil, interfaces.ErrTokenNotFound
 	}
 
 	if err := checkOwnership(ctx, token, accountID); err != nil {
 		return nil, err
 	}
 
-	// 隐藏完整 Token
-	token.Token = hideToken(token.Token)
+	// 只返回脱敏预览，不暴露 token 哈希
+	token.Token = token.TokenPreview
+	token.TokenHash = ""
+	token.PreviousTokenHash = ""
 
 	return token, nil
 }
@@ -180,7 +336,7 @@ func (s *TokenServiceImpl) UpdateTokenStatus(ctx context.Context, accountID stri
 		return err
 	}
 	if token == nil {
-		return errors.New("token not found")
+		return interfaces.ErrTokenNotFound
 	}
 	if err := checkOwnership(ctx, token, accountID); err != nil {
 		s.logAction(ctx, accountID, interfaces.AuditActionUpdateToken, tokenID, interfaces.AuditResultFailure, err.Error(), nil)
@@ -194,6 +350,10 @@ func (s *TokenServiceImpl) UpdateTokenStatus(ctx context.Context, accountID stri
 		return err
 	}
 
+	if token.Format == interfaces.TokenFormatSigned && s.revoked != nil {
+		s.revoked.Mark(tokenID, !isActive)
+	}
+
 	s.logAction(ctx, accountID, interfaces.AuditActionUpdateToken, tokenID, interfaces.AuditResultSuccess, "", map[string]interface{}{
 		"is_active": isActive,
 	})
@@ -201,6 +361,99 @@ func (s *TokenServiceImpl) UpdateTokenStatus(ctx context.Context, accountID stri
 	return nil
 }
 
+// UpdateToken 部分更新 Token 的描述、过期时间和限流配置
+// token 值不变，无需重新分发；审计日志记录修改前后的值
+func (s *TokenServiceImpl) UpdateToken(ctx context.Context, accountID string, tokenID string, req *interfaces.TokenUpdateRequest) (*interfaces.Token, error) {
+	token, err := s.tokenRepo.GetByID(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, interfaces.ErrTokenNotFound
+	}
+	if err := checkOwnership(ctx, token, accountID); err != nil {
+		s.logAction(ctx, accountID, interfaces.AuditActionUpdateToken, tokenID, interfaces.AuditResultFailure, err.Error(), nil)
+		return nil, err
+	}
+
+	update, before, after, err := buildTokenUpdate(token, req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.tokenRepo.Update(ctx, tokenID, update)
+	if err != nil {
+		s.logAction(ctx, accountID, interfaces.AuditActionUpdateToken, tokenID, interfaces.AuditResultFailure, err.Error(), nil)
+		return nil, err
+	}
+
+	s.logAction(ctx, accountID, interfaces.AuditActionUpdateToken, tokenID, interfaces.AuditResultSuccess, "", map[string]interface{}{
+		"before": before,
+		"after":  after,
+	})
+
+	// 只返回脱敏预览，不暴露 token 哈希
+	updated.Token = updated.TokenPreview
+	updated.TokenHash = ""
+	updated.PreviousTokenHash = ""
+
+	return updated, nil
+}
+
+// buildTokenUpdate 校验部分更新请求，返回更新内容及被修改字段的前后值（用于审计）
+func buildTokenUpdate(token *interfaces.Token, req *interfaces.TokenUpdateRequest, now time.Time) (*interfaces.TokenUpdate, map[string]interface{}, map[string]interface{}, error) {
+	if req == nil || (req.Description == nil && req.ExpiresInSeconds == nil && req.RateLimit == nil) {
+		return nil, nil, nil, fmt.Errorf("%w: no fields to update", interfaces.ErrInvalidArgument)
+	}
+
+	update := &interfaces.TokenUpdate{}
+	before := make(map[string]interface{})
+	after := make(map[string]interface{})
+
+	if req.Description != nil {
+		if strings.TrimSpace(*req.Description) == "" {
+			return nil, nil, nil, fmt.Errorf("%w: description must not be empty", interfaces.ErrInvalidArgument)
+		}
+		update.Description = req.Description
+		before["description"] = token.Description
+		after["description"] = *req.Description
+	}
+
+	if req.ExpiresInSeconds != nil {
+		if token.Format == interfaces.TokenFormatSigned {
+			return nil, nil, nil, fmt.Errorf("%w: expiry of a signed token is fixed at creation", interfaces.ErrInvalidArgument)
+		}
+		if *req.ExpiresInSeconds < 0 {
+			return nil, nil, nil, fmt.Errorf("%w: expires_in_seconds must not be negative", interfaces.ErrInvalidArgument)
+		}
+		update.SetExpiresAt = true
+		if *req.ExpiresInSeconds > 0 {
+			t := now.Add(time.Duration(*req.ExpiresInSeconds) * time.Second)
+			update.ExpiresAt = &t
+		}
+		before["expires_at"] = token.ExpiresAt
+		after["expires_at"] = update.ExpiresAt
+	}
+
+	if req.RateLimit != nil {
+		if token.Format == interfaces.TokenFormatSigned {
+			return nil, nil, nil, fmt.Errorf("%w: rate_limit of a signed token is fixed at creation", interfaces.ErrInvalidArgument)
+		}
+		rl := req.RateLimit
+		if rl.RequestsPerMinute < 0 || rl.RequestsPerHour < 0 || rl.RequestsPerDay < 0 {
+			return nil, nil, nil, fmt.Errorf("%w: rate_limit must not be negative", interfaces.ErrInvalidArgument)
+		}
+		update.SetRateLimit = true
+		if rl.RequestsPerMinute > 0 || rl.RequestsPerHour > 0 || rl.RequestsPerDay > 0 {
+			update.RateLimit = rl
+		}
+		before["rate_limit"] = token.RateLimit
+		after["rate_limit"] = update.RateLimit
+	}
+
+	return update, before, after, nil
+}
+
 // DeleteToken 删除 Token
 func (s *TokenServiceImpl) DeleteToken(ctx context.Context, accountID string, tokenID string) error {
 	token, err := s.tokenRepo.GetByID(ctx, tokenID)
@@ -208,7 +461,7 @@ func (s *TokenServiceImpl) DeleteToken(ctx context.Context, accountID string, to
 		return err
 	}
 	if token == nil {
-		return errors.New("token not found")
+		return interfaces.ErrTokenNotFound
 	}
 	if err := checkOwnership(ctx, token, accountID); err != nil {
 		s.logAction(ctx, accountID, interfaces.AuditActionDeleteToken, tokenID, interfaces.AuditResultFailure, err.Error(), nil)
@@ -222,19 +475,84 @@ func (s *TokenServiceImpl) DeleteToken(ctx context.Context, accountID string, to
 		return err
 	}
 
+	if token.Format == interfaces.TokenFormatSigned && s.revoked != nil {
+		s.revoked.Mark(tokenID, true)
+	}
+
 	s.logAction(ctx, accountID, interfaces.AuditActionDeleteToken, tokenID, interfaces.AuditResultSuccess, "", nil)
 
 	return nil
 }
 
+// RotateToken 轮换 Token 值
+// 保留 Token ID 及描述、限流、子账号等元数据，旧值在宽限期内仍然有效
+func (s *TokenServiceImpl) RotateToken(ctx context.Context, accountID string, tokenID string, req *interfaces.TokenRotateRequest) (*interfaces.TokenRotateResponse, error) {
+	token, err := s.tokenRepo.GetByID(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, interfaces.ErrTokenNotFound
+	}
+	if err := checkOwnership(ctx, token, accountID); err != nil {
+		s.logAction(ctx, accountID, interfaces.AuditActionRotateToken, tokenID, interfaces.AuditResultFailure, err.Error(), nil)
+		return nil, err
+	}
+
+	// 签名 Token 的值包含 ID 和过期时间等声明，无法原地轮换
+	if token.Format == interfaces.TokenFormatSigned {
+		return nil, fmt.Errorf("%w: signed tokens cannot be rotated, create a new token instead", interfaces.ErrInvalidArgument)
+	}
+
+	// 计算宽限期（未指定时使用默认值）
+	gracePeriod := s.rotationGracePeriod
+	if req != nil && req.GracePeriodSeconds != nil {
+		if *req.GracePeriodSeconds < 0 {
+			return nil, fmt.Errorf("%w: grace period must not be negative", interfaces.ErrInvalidArgument)
+		}
+		gracePeriod = time.Duration(*req.GracePeriodSeconds) * time.Second
+	}
+	if gracePeriod > s.maxRotationGracePeriod {
+		return nil, fmt.Errorf("%w: grace period must not exceed %d seconds", interfaces.ErrInvalidArgument, int64(s.maxRotationGracePeriod.Seconds()))
+	}
+
+	var previousExpiresAt *time.Time
+	if gracePeriod > 0 {
+		t := time.Now().Add(gracePeriod)
+		previousExpiresAt = &t
+	}
+
+	rotated, err := s.tokenRepo.Rotate(ctx, tokenID, previousExpiresAt)
+	if err != nil {
+		s.logAction(ctx, accountID, interfaces.AuditActionRotateToken, tokenID, interfaces.AuditResultFailure, err.Error(), nil)
+		return nil, err
+	}
+
+	s.logAction(ctx, accountID, interfaces.AuditActionRotateToken, tokenID, interfaces.AuditResultSuccess, "", map[string]interface{}{
+		"grace_period_seconds": int64(gracePeriod.Seconds()),
+	})
+
+	return &interfaces.TokenRotateResponse{
+		TokenID:                rotated.ID,
+		Token:                  rotated.Token, // 新的完整 Token，仅此一次
+		PreviousTokenExpiresAt: rotated.PreviousTokenExpiresAt,
+		RotatedAt:              *rotated.RotatedAt,
+	}, nil
+}
+
 // GetTokenStats 获取 Token 使用统计
-func (s *TokenServiceImpl) GetTokenStats(ctx context.Context, accountID string, tokenID string) (*interfaces.TokenStatsResponse, error) {
+func (s *TokenServiceImpl) GetTokenStats(ctx context.Context, accountID string, tokenID string, query *interfaces.TokenStatsQuery) (*interfaces.TokenStatsResponse, error) {
+	granularity, from, to, err := normalizeStatsQuery(query, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
 	token, err := s.tokenRepo.GetByID(ctx, tokenID)
 	if err != nil {
 		return nil, err
 	}
 	if token == nil {
-		return nil, errors.New("token not found")
+		return nil, interfaces.ErrTokenNotFound
 	}
 	if err := checkOwnership(ctx, token, accountID); err != nil {
 		return nil, err
@@ -244,6 +562,9 @@ func (s *TokenServiceImpl) GetTokenStats(ctx context.Context, accountID string,
 		TokenID:       token.ID,
 		TotalRequests: token.TotalRequests,
 		CreatedAt:     token.CreatedAt,
+		From:          from,
+		To:            to,
+		Granularity:   granularity,
 	}
 
 	// 处理时间字段（避免零值时间）
@@ -251,18 +572,99 @@ func (s *TokenServiceImpl) GetTokenStats(ctx context.Context, accountID string,
 		resp.LastUsedAt = token.LastUsedAt
 	}
 
+	if s.usageRepo == nil {
+		return resp, nil
+	}
+
+	buckets, err := s.usageRepo.ListByTokenID(ctx, token.ID, granularity, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query usage stats: %w", err)
+	}
+
+	// 补齐没有请求的时间桶，返回连续的时间序列
+	counts := make(map[int64]int64, len(buckets))
+	for _, b := range buckets {
+		counts[b.Period.Unix()] = b.Requests
+	}
+
+	if granularity == interfaces.UsageGranularityHour {
+		resp.HourlyStats = []interfaces.HourlyStat{}
+		for t := from; !t.After(to); t = t.Add(time.Hour) {
+			resp.HourlyStats = append(resp.HourlyStats, interfaces.HourlyStat{
+				Hour:     t.Format(time.RFC3339),
+				Requests: counts[t.Unix()],
+			})
+		}
+	} else {
+		resp.DailyStats = []interfaces.DailyStat{}
+		for t := from; !t.After(to); t = t.Add(24 * time.Hour) {
+			resp.DailyStats = append(resp.DailyStats, interfaces.DailyStat{
+				Date:     t.Format("2006-01-02"),
+				Requests: counts[t.Unix()],
+			})
+		}
+	}
+
 	return resp, nil
 }
 
+// normalizeStatsQuery 校验统计查询参数并填充默认值
+// 返回的 from / to 均为 UTC 时间桶起点
+func normalizeStatsQuery(query *interfaces.TokenStatsQuery, now time.Time) (string, time.Time, time.Time, error) {
+	if query == nil {
+		query = &interfaces.TokenStatsQuery{}
+	}
+
+	granularity := query.Granularity
+	if granularity == "" {
+		granularity = interfaces.UsageGranularityDay
+	}
+
+	var bucket, defaultRange, maxRange time.Duration
+	switch granularity {
+	case interfaces.UsageGranularityDay:
+		bucket, defaultRange, maxRange = 24*time.Hour, defaultDailyStatsRange, maxDailyStatsRange
+	case interfaces.UsageGranularityHour:
+		bucket, defaultRange, maxRange = time.Hour, defaultHourlyStatsRange, maxHourlyStatsRange
+	default:
+		return "", time.Time{}, time.Time{}, fmt.Errorf("%w: granularity %s must be day or hour", interfaces.ErrInvalidArgument, granularity)
+	}
+
+	to := query.To
+	if to.IsZero() {
+		to = now
+	}
+	from := query.From
+	if from.IsZero() {
+		from = to.Add(-defaultRange + bucket)
+	}
+
+	from = from.UTC().Truncate(bucket)
+	to = to.UTC().Truncate(bucket)
+
+	if from.After(to) {
+		return "", time.Time{}, time.Time{}, fmt.Errorf("%w: from must not be after to", interfaces.ErrInvalidArgument)
+	}
+	if to.Sub(from) >= maxRange {
+		return "", time.Time{}, time.Time{}, fmt.Errorf("%w: %s granularity supports at most %d days", interfaces.ErrInvalidArgument, granularity, int(maxRange.Hours()/24))
+	}
+
+	return granularity, from, to, nil
+}
+
 // ========================================
 // 辅助方法
 // ========================================
 
 func (s *TokenServiceImpl) logAction(ctx context.Context, accountID, action, resourceID, result, errorMsg string, requestData map[string]interface{}) {
+	// 记录操作者子账号，用于审计日志查询时的子账号隔离
+	iuid, iamAlias := subAccountFromContext(ctx)
 	log := &interfaces.AuditLog{
 		AccountID:   accountID,
 		Action:      action,
 		ResourceID:  resourceID,
+		IUID:        iuid,
+		IamAlias:    iamAlias,
 		Result:      result,
 		ErrorMsg:    errorMsg,
 		RequestData: requestData,
@@ -272,33 +674,13 @@ func (s *TokenServiceImpl) logAction(ctx context.Context, accountID, action, res
 	s.auditRepo.Create(ctx, log)
 }
 
-// hideToken 隐藏 Token 的中间部分
-// 格式: prefix全部显示 + 8字符 + **** + 8字符
-// 示例: sk-a1b2c3d4****e5f6g7h8
-func hideToken(token string) string {
-	// 找到 prefix 分隔符位置
-	prefixEnd := strings.Index(token, "-")
-	if prefixEnd == -1 {
-		prefixEnd = 0
-	} else {
-		prefixEnd++ // 包含 "-"
+// newTokenID 生成 Token ID（与 Repository 生成的格式一致，签名 Token 需要在签发前确定 ID）
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
-
-	suffix := token[prefixEnd:] // prefix 之后的部分
-
-	const (
-		showBefore = 8 // 显示前 8 个字符
-		showAfter  = 8 // 显示后 8 个字符
-		maskLen    = 4 // 4 个星号
-	)
-
-	// 如果 suffix 太短，直接返回原 token
-	if len(suffix) < showBefore+showAfter {
-		return token
-	}
-
-	// prefix + 前8字符 + **** + 后8字符
-	return token[:prefixEnd] + suffix[:showBefore] + strings.Repeat("*", maskLen) + suffix[len(suffix)-showAfter:]
+	return "tk_" + hex.EncodeToString(b), nil
 }
 
 // calculateTokenStatus 计算 Token 的综合状态
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/pkg/signedtoken"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========================================
+// Test normalizeStatsQuery
+// ========================================
+
+func TestNormalizeStatsQuery(t *testing.T) {
+	now := time.Date(2025, 3, 10, 15, 30, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name            string
+		query           *interfaces.TokenStatsQuery
+		wantGranularity string
+		wantFrom        time.Time
+		wantTo          time.Time
+		wantErr         bool
+	}{
+		{
+			name:            "defaults to last 30 days",
+			query:           nil,
+			wantGranularity: interfaces.UsageGranularityDay,
+			wantFrom:        time.Date(2025, 2, 9, 0, 0, 0, 0, time.UTC),
+			wantTo:          day(10),
+		},
+		{
+			name:            "hour defaults to last 24 hours",
+			query:           &interfaces.TokenStatsQuery{Granularity: interfaces.UsageGranularityHour},
+			wantGranularity: interfaces.UsageGranularityHour,
+			wantFrom:        time.Date(2025, 3, 9, 16, 0, 0, 0, time.UTC),
+			wantTo:          time.Date(2025, 3, 10, 15, 0, 0, 0, time.UTC),
+		},
+		{
+			name:            "explicit range truncated to buckets",
+			query:           &interfaces.TokenStatsQuery{From: day(1).Add(5 * time.Hour), To: day(3).Add(23 * time.Hour)},
+			wantGranularity: interfaces.UsageGranularityDay,
+			wantFrom:        day(1),
+			wantTo:          day(3),
+		},
+		{
+			name:    "invalid granularity",
+			query:   &interfaces.TokenStatsQuery{Granularity: "week"},
+			wantErr: true,
+		},
+		{
+			name:    "from after to",
+			query:   &interfaces.TokenStatsQuery{From: day(5), To: day(1)},
+			wantErr: true,
+		},
+		{
+			name:    "hour range too large",
+			query:   &interfaces.TokenStatsQuery{Granularity: interfaces.UsageGranularityHour, From: day(1).AddDate(0, -2, 0)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			granularity, from, to, err := normalizeStatsQuery(tt.query, now)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, interfaces.ErrInvalidArgument)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantGranularity, granularity)
+			assert.Equal(t, tt.wantFrom, from)
+			assert.Equal(t, tt.wantTo, to)
+		})
+	}
+}
+
+// ========================================
+// Test buildTokenUpdate
+// ========================================
+
+func TestBuildTokenUpdate(t *testing.T) {
+	now := time.Date(2025, 3, 10, 15, 30, 0, 0, time.UTC)
+	oldExpiry := now.Add(time.Hour)
+	token := &interfaces.Token{
+		ID:          "tk_123",
+		Description: "old",
+		RateLimit:   &interfaces.RateLimit{RequestsPerMinute: 10},
+		ExpiresAt:   &oldExpiry,
+	}
+	str := func(s string) *string { return &s }
+	i64 := func(n int64) *int64 { return &n }
+
+	t.Run("partial update", func(t *testing.T) {
+		update, before, after, err := buildTokenUpdate(token, &interfaces.TokenUpdateRequest{
+			Description:      str("new"),
+			ExpiresInSeconds: i64(7200),
+		}, now)
+
+		require.NoError(t, err)
+		assert.Equal(t, "new", *update.Description)
+		assert.True(t, update.SetExpiresAt)
+		assert.Equal(t, now.Add(2*time.Hour), *update.ExpiresAt)
+		assert.False(t, update.SetRateLimit)
+		assert.Equal(t, map[string]interface{}{"description": "old", "expires_at": &oldExpiry}, before)
+		assert.Equal(t, "new", after["description"])
+		assert.NotContains(t, after, "rate_limit")
+	})
+
+	t.Run("clear expiry and rate limit", func(t *testing.T) {
+		update, before, after, err := buildTokenUpdate(token, &interfaces.TokenUpdateRequest{
+			ExpiresInSeconds: i64(0),
+			RateLimit:        &interfaces.RateLimit{},
+		}, now)
+
+		require.NoError(t, err)
+		assert.Nil(t, update.Description)
+		assert.True(t, update.SetExpiresAt)
+		assert.Nil(t, update.ExpiresAt)
+		assert.True(t, update.SetRateLimit)
+		assert.Nil(t, update.RateLimit)
+		assert.Equal(t, token.RateLimit, before["rate_limit"])
+		assert.Nil(t, after["rate_limit"])
+	})
+
+	invalid := []struct {
+		name string
+		req  *interfaces.TokenUpdateRequest
+	}{
+		{"no fields", &interfaces.TokenUpdateRequest{}},
+		{"empty description", &interfaces.TokenUpdateRequest{Description: str("  ")}},
+		{"negative expiry", &interfaces.TokenUpdateRequest{ExpiresInSeconds: i64(-1)}},
+		{"negative rate limit", &interfaces.TokenUpdateRequest{RateLimit: &interfaces.RateLimit{RequestsPerHour: -1}}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := buildTokenUpdate(token, tt.req, now)
+			assert.ErrorIs(t, err, interfaces.ErrInvalidArgument)
+		})
+	}
+}
+
+func TestBuildTokenUpdate_SignedTokenExpiryFixed(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	token := &interfaces.Token{ID: "tk_123", Format: interfaces.TokenFormatSigned, ExpiresAt: &expiresAt}
+	seconds := int64(60)
+
+	_, _, _, err := buildTokenUpdate(token, &interfaces.TokenUpdateRequest{ExpiresInSeconds: &seconds}, time.Now())
+	assert.ErrorIs(t, err, interfaces.ErrInvalidArgument)
+	assert.ErrorContains(t, err, "fixed at creation")
+
+	description := "renamed"
+	_, _, _, err = buildTokenUpdate(token, &interfaces.TokenUpdateRequest{RateLimit: &interfaces.RateLimit{RequestsPerMinute: 10}}, time.Now())
+	assert.ErrorIs(t, err, interfaces.ErrInvalidArgument)
+	assert.ErrorContains(t, err, "fixed at creation")
+
+	_, _, _, err = buildTokenUpdate(token, &interfaces.TokenUpdateRequest{Description: &description}, time.Now())
+	assert.NoError(t, err)
+}
+
+// ========================================
+// Test CreateToken（签名 Token）
+// ========================================
+
+func TestCreateToken_Signed(t *testing.T) {
+	auditRepo := new(MockAuditLogRepository)
+	auditRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	keys := newTestKeySet(t)
+
+	service := NewTokenService(new(MockTokenRepository), auditRepo)
+	service.SetSignedTokenKeys(keys, time.Hour)
+
+	resp, err := service.CreateToken(context.Background(), "qiniu_1369077332", &interfaces.TokenCreateRequest{
+		Description:      "signed",
+		ExpiresInSeconds: 600,
+		Scopes:           []string{"storage:read", "cdn:*"},
+		RateLimit:        &interfaces.RateLimit{RequestsPerMinute: 60, RequestsPerDay: 1000},
+		Format:           interfaces.TokenFormatSigned,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, interfaces.TokenFormatSigned, resp.Format)
+
+	// Token 值可离线验证，声明与记录一致
+	claims, err := keys.Verify(resp.Token, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, resp.TokenID, claims.TokenID)
+	assert.Equal(t, "qiniu_1369077332", claims.Subject)
+	assert.Equal(t, "1369077332", claims.UID)
+	assert.Equal(t, []string{"storage:read", "cdn:*"}, claims.Scopes())
+	assert.Equal(t, resp.ExpiresAt.Unix(), claims.ExpiresAt)
+	assert.Equal(t, &signedtoken.RateLimit{RequestsPerMinute: 60, RequestsPerDay: 1000}, claims.RateLimit)
+
+	invalid := []struct {
+		name string
+		req  *interfaces.TokenCreateRequest
+	}{
+		{"no expiry", &interfaces.TokenCreateRequest{Description: "x", Format: interfaces.TokenFormatSigned}},
+		{"ttl above limit", &interfaces.TokenCreateRequest{Description: "x", Format: interfaces.TokenFormatSigned, ExpiresInSeconds: 7200}},
+		{"custom prefix", &interfaces.TokenCreateRequest{Description: "x", Format: interfaces.TokenFormatSigned, ExpiresInSeconds: 60, Prefix: "app"}},
+		{"unknown format", &interfaces.TokenCreateRequest{Description: "x", Format: "paseto"}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateToken(context.Background(), "qiniu_1369077332", tt.req)
+			assert.ErrorIs(t, err, interfaces.ErrInvalidArgument)
+		})
+	}
+
+	// 未配置密钥时不支持签名 Token
+	_, err = NewTokenService(new(MockTokenRepository), auditRepo).CreateToken(context.Background(), "acc_1", &interfaces.TokenCreateRequest{
+		Description:      "signed",
+		ExpiresInSeconds: 600,
+		Format:           interfaces.TokenFormatSigned,
+	})
+	assert.ErrorContains(t, err, "not enabled")
+}
+
+// ========================================
+// Test RotateToken
+// ========================================
+
+func TestRotateToken(t *testing.T) {
+	tokenRepo := new(MockTokenRepository)
+	auditRepo := new(MockAuditLogRepository)
+	auditRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	service := NewTokenService(tokenRepo, auditRepo)
+	service.SetRotationGracePeriod(time.Hour, 2*time.Hour)
+
+	tokenRepo.On("GetByID", mock.Anything, "tk_1").Return(&interfaces.Token{ID: "tk_1", AccountID: "acc_1", IsActive: true}, nil)
+	tokenRepo.On("GetByID", mock.Anything, "tk_signed").Return(&interfaces.Token{ID: "tk_signed", AccountID: "acc_1", Format: interfaces.TokenFormatSigned}, nil)
+	tokenRepo.On("GetByID", mock.Anything, "tk_missing").Return(nil, nil)
+
+	rotatedAt := time.Now()
+	var gotExpiresAt []*time.Time
+	tokenRepo.On("Rotate", mock.Anything, "tk_1", mock.Anything).Run(func(args mock.Arguments) {
+		gotExpiresAt = append(gotExpiresAt, args.Get(2).(*time.Time))
+	}).Return(&interfaces.Token{ID: "tk_1", Token: "sk-new", RotatedAt: &rotatedAt}, nil)
+
+	// 未指定宽限期时使用默认值
+	resp, err := service.RotateToken(context.Background(), "acc_1", "tk_1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-new", resp.Token)
+	require.NotNil(t, gotExpiresAt[0])
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *gotExpiresAt[0], time.Second)
+	auditRepo.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(log *interfaces.AuditLog) bool {
+		return log.Action == interfaces.AuditActionRotateToken && log.ResourceID == "tk_1" &&
+			log.Result == interfaces.AuditResultSuccess && log.RequestData["grace_period_seconds"] == int64(3600)
+	}))
+
+	// 宽限期为 0 时旧值立即失效
+	zero := int64(0)
+	_, err = service.RotateToken(context.Background(), "acc_1", "tk_1", &interfaces.TokenRotateRequest{GracePeriodSeconds: &zero})
+	require.NoError(t, err)
+	assert.Nil(t, gotExpiresAt[1])
+
+	negative, tooLong := int64(-1), int64(3*3600)
+	tests := []struct {
+		name    string
+		account string
+		tokenID string
+		req     *interfaces.TokenRotateRequest
+		wantErr error
+	}{
+		{"not found", "acc_1", "tk_missing", nil, interfaces.ErrTokenNotFound},
+		{"other account", "acc_2", "tk_1", nil, interfaces.ErrPermissionDenied},
+		{"signed token", "acc_1", "tk_signed", nil, interfaces.ErrInvalidArgument},
+		{"negative grace period", "acc_1", "tk_1", &interfaces.TokenRotateRequest{GracePeriodSeconds: &negative}, interfaces.ErrInvalidArgument},
+		{"grace period above limit", "acc_1", "tk_1", &interfaces.TokenRotateRequest{GracePeriodSeconds: &tooLong}, interfaces.ErrInvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.RotateToken(context.Background(), tt.account, tt.tokenID, tt.req)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+	assert.Len(t, gotExpiresAt, 2)
+}
+
+func TestRotateToken_ConcurrentRotation(t *testing.T) {
+	tokenRepo := new(MockTokenRepository)
+	auditRepo := new(MockAuditLogRepository)
+	auditRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	service := NewTokenService(tokenRepo, auditRepo)
+
+	tokenRepo.On("GetByID", mock.Anything, "tk_1").Return(&interfaces.Token{ID: "tk_1", AccountID: "acc_1", IsActive: true}, nil)
+	tokenRepo.On("Rotate", mock.Anything, "tk_1", mock.Anything).
+		Return(nil, fmt.Errorf("%w: token was rotated concurrently, please retry", interfaces.ErrConflict))
+
+	_, err := service.RotateToken(context.Background(), "acc_1", "tk_1", nil)
+	assert.ErrorIs(t, err, interfaces.ErrConflict)
+	auditRepo.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(log *interfaces.AuditLog) bool {
+		return log.Action == interfaces.AuditActionRotateToken && log.Result == interfaces.AuditResultFailure
+	}))
+}
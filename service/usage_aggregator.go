@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/observability"
+)
+
+const (
+	// DefaultUsageFlushInterval 使用计数默认刷新间隔
+	DefaultUsageFlushInterval = 1 * time.Second
+
+	// DefaultUsageFlushThreshold 合并条目达到该数量时立即刷新
+	DefaultUsageFlushThreshold = 1000
+
+	// DefaultUsageQueueSize 使用事件队列默认容量
+	DefaultUsageQueueSize = 10000
+
+	// usageFlushTimeout 单次批量写入超时
+	usageFlushTimeout = 10 * time.Second
+)
+
+// usageEvent 一次成功验证产生的使用事件
+type usageEvent struct {
+	tokenID   string
+	accountID string
+	at        time.Time
+}
+
+// usageKey 合并维度：同一 token 同一小时内的使用合并为一条增量（保证按小时统计准确）
+type usageKey struct {
+	tokenID string
+	hour    int64
+}
+
+// UsageAggregator Token 使用计数聚合器
+// 验证路径只做一次非阻塞入队；后台协程按 token 合并计数，
+// 按时间间隔或条目数阈值通过 BulkWrite 批量写入 tokens 和 token_usage_stats 集合
+// 计数允许少量丢失：队列满、写入失败时直接丢弃并记录指标，不影响验证结果
+type UsageAggregator struct {
+	tokenRepo interfaces.TokenRepository
+	usageRepo interfaces.TokenUsageRepository // 可选
+
+	flushInterval  time.Duration
+	flushThreshold int
+
+	events  chan usageEvent
+	pending map[usageKey]*interfaces.TokenUsageIncrement
+
+	stopOnce sync.Once
+	stopped  atomic.Bool
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewUsageAggregator 创建使用计数聚合器（需调用 Start 启动后台刷新）
+// usageRepo 为 nil 时只更新 tokens 集合中的累计计数
+func NewUsageAggregator(tokenRepo interfaces.TokenRepository, usageRepo interfaces.TokenUsageRepository, flushInterval time.Duration, flushThreshold, queueSize int) *UsageAggregator {
+	if flushInterval <= 0 {
+		flushInterval = DefaultUsageFlushInterval
+	}
+	if flushThreshold <= 0 {
+		flushThreshold = DefaultUsageFlushThreshold
+	}
+	if queueSize <= 0 {
+		queueSize = DefaultUsageQueueSize
+	}
+
+	return &UsageAggregator{
+		tokenRepo:      tokenRepo,
+		usageRepo:      usageRepo,
+		flushInterval:  flushInterval,
+		flushThreshold: flushThreshold,
+		events:         make(chan usageEvent, queueSize),
+		pending:        make(map[usageKey]*interfaces.TokenUsageIncrement),
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+}
+
+// Start 启动后台合并 / 刷新协程
+func (a *UsageAggregator) Start() {
+	go a.run()
+}
+
+// Record 记录一次使用（非阻塞，队列满时丢弃）
+func (a *UsageAggregator) Record(tokenID, accountID string, at time.Time) {
+	if a.stopped.Load() {
+		observability.UsageEventsDroppedTotal.Inc()
+		return
+	}
+
+	select {
+	case a.events <- usageEvent{tokenID: tokenID, accountID: accountID, at: at}:
+		observability.UsageQueueDepth.Set(float64(len(a.events)))
+	default:
+		observability.UsageEventsDroppedTotal.Inc()
+	}
+}
+
+// Stop 停止接收新事件，处理完队列中剩余事件并执行最后一次刷新
+// ctx 到期时直接返回 ctx.Err()，后台协程仍会继续完成刷新
+func (a *UsageAggregator) Stop(ctx context.Context) error {
+	a.stopOnce.Do(func() {
+		a.stopped.Store(true)
+		close(a.stop)
+	})
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run 后台协程：合并事件，按间隔或阈值刷新
+func (a *UsageAggregator) run() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ev := <-a.events:
+			a.add(ev)
+			if len(a.pending) >= a.flushThreshold {
+				a.flush()
+			}
+
+		case <-ticker.C:
+			a.flush()
+
+		case <-a.stop:
+			// 排空队列后最后刷新一次
+			for {
+				select {
+				case ev := <-a.events:
+					a.add(ev)
+				default:
+					a.flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// add 将事件合并到待刷新条目
+func (a *UsageAggregator) add(ev usageEvent) {
+	key := usageKey{tokenID: ev.tokenID, hour: ev.at.Unix() / 3600}
+
+	inc, ok := a.pending[key]
+	if !ok {
+		inc = &interfaces.TokenUsageIncrement{
+			TokenID:   ev.tokenID,
+			AccountID: ev.accountID,
+		}
+		a.pending[key] = inc
+	}
+	inc.Count++
+	if ev.at.After(inc.LastUsedAt) {
+		inc.LastUsedAt = ev.at
+	}
+	observability.UsagePendingTokens.Set(float64(len(a.pending)))
+}
+
+// flush 批量写入所有待刷新条目
+func (a *UsageAggregator) flush() {
+	observability.UsageQueueDepth.Set(float64(len(a.events)))
+	if len(a.pending) == 0 {
+		observability.UsagePendingTokens.Set(0)
+		return
+	}
+
+	increments := make([]interfaces.TokenUsageIncrement, 0, len(a.pending))
+	for _, inc := range a.pending {
+		increments = append(increments, *inc)
+	}
+	a.pending = make(map[usageKey]*interfaces.TokenUsageIncrement)
+	observability.UsagePendingTokens.Set(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), usageFlushTimeout)
+	defer cancel()
+
+	start := time.Now()
+	result := "success"
+
+	if err := a.tokenRepo.IncrementUsageBatch(ctx, increments); err != nil {
+		result = "error"
+		observability.LogWarn(ctx, "Failed to flush token usage counters",
+			slog.Int("entries", len(increments)),
+			slog.String("error", err.Error()))
+	}
+	if a.usageRepo != nil {
+		if err := a.usageRepo.IncrementBatch(ctx, increments); err != nil {
+			result = "error"
+			observability.LogWarn(ctx, "Failed to flush token usage stats",
+				slog.Int("entries", len(increments)),
+				slog.String("error", err.Error()))
+		}
+	}
+
+	observability.UsageFlushDuration.Observe(time.Since(start).Seconds())
+	observability.UsageFlushBatchSize.Observe(float64(len(increments)))
+	observability.UsageFlushesTotal.WithLabelValues(result).Inc()
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ========================================
+// Test UsageAggregator
+// ========================================
+
+func TestUsageAggregator_CoalescesAndFlushesOnStop(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	mockUsageRepo := new(MockTokenUsageRepository)
+	aggregator := NewUsageAggregator(mockTokenRepo, mockUsageRepo, time.Hour, 100, 100)
+	aggregator.Start()
+
+	base := time.Date(2025, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	var flushed []interfaces.TokenUsageIncrement
+	mockTokenRepo.On("IncrementUsageBatch", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			flushed = args.Get(1).([]interfaces.TokenUsageIncrement)
+		}).Return(nil).Once()
+	mockUsageRepo.On("IncrementBatch", mock.Anything, mock.Anything).Return(nil).Once()
+
+	aggregator.Record("tk_a", "acc_1", base.Add(1*time.Minute))
+	aggregator.Record("tk_a", "acc_1", base.Add(5*time.Minute))
+	aggregator.Record("tk_a", "acc_1", base.Add(3*time.Minute))
+	aggregator.Record("tk_b", "acc_1", base.Add(2*time.Minute))
+	aggregator.Record("tk_a", "acc_1", base.Add(61*time.Minute)) // 下一个小时桶
+
+	require.NoError(t, aggregator.Stop(context.Background()))
+
+	mockTokenRepo.AssertExpectations(t)
+	mockUsageRepo.AssertExpectations(t)
+	require.Len(t, flushed, 3)
+
+	byKey := make(map[string]interfaces.TokenUsageIncrement)
+	for _, inc := range flushed {
+		byKey[inc.TokenID+"@"+inc.LastUsedAt.Format("15")] = inc
+	}
+	assert.Equal(t, int64(3), byKey["tk_a@15"].Count)
+	assert.Equal(t, base.Add(5*time.Minute), byKey["tk_a@15"].LastUsedAt)
+	assert.Equal(t, int64(1), byKey["tk_a@16"].Count)
+	assert.Equal(t, int64(1), byKey["tk_b@15"].Count)
+}
+
+func TestUsageAggregator_FlushesOnThreshold(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	aggregator := NewUsageAggregator(mockTokenRepo, nil, time.Hour, 2, 100)
+
+	flushedCh := make(chan int, 2)
+	mockTokenRepo.On("IncrementUsageBatch", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			flushedCh <- len(args.Get(1).([]interfaces.TokenUsageIncrement))
+		}).Return(nil)
+
+	aggregator.Start()
+	defer aggregator.Stop(context.Background())
+
+	now := time.Now()
+	aggregator.Record("tk_a", "acc_1", now)
+	aggregator.Record("tk_b", "acc_1", now)
+
+	select {
+	case n := <-flushedCh:
+		assert.Equal(t, 2, n)
+	case <-time.After(time.Second):
+		t.Fatal("expected flush after reaching threshold")
+	}
+}
+
+func TestUsageAggregator_DropsAfterStopAndOnError(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	aggregator := NewUsageAggregator(mockTokenRepo, nil, time.Hour, 100, 100)
+	aggregator.Start()
+
+	// 写入失败只记录日志和指标，不重试
+	mockTokenRepo.On("IncrementUsageBatch", mock.Anything, mock.Anything).
+		Return(errors.New("mongo unavailable")).Once()
+
+	aggregator.Record("tk_a", "acc_1", time.Now())
+	require.NoError(t, aggregator.Stop(context.Background()))
+
+	// 停止后的记录被丢弃，不会触发写入
+	aggregator.Record("tk_a", "acc_1", time.Now())
+	require.NoError(t, aggregator.Stop(context.Background()))
+
+	mockTokenRepo.AssertNumberOfCalls(t, "IncrementUsageBatch", 1)
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/observability"
+)
+
+const (
+	// DefaultUserStatusCacheTTL 用户状态默认缓存时长
+	DefaultUserStatusCacheTTL = time.Minute
+
+	// maxUserStatusCacheEntries 用户状态缓存条目上限（超出时先清理过期条目，仍超出则清空）
+	maxUserStatusCacheEntries = 100000
+)
+
+// userStatusRejection 用户状态检查的拒绝结果
+type userStatusRejection struct {
+	result  string // token_validations_total 的 result 标签
+	message string
+	code    int
+}
+
+type userStatusEntry struct {
+	rejection *userStatusRejection // nil 表示用户状态正常
+	expiresAt time.Time
+}
+
+// freshUserInfoGetter 可跳过过期旧值的用户信息查询（由 cache.UserInfoCache 实现）
+type freshUserInfoGetter interface {
+	GetFreshUserInfoByUID(ctx context.Context, uid uint32) (*interfaces.UserInfo, error)
+}
+
+// UserStatusPolicy 七牛用户状态检查策略
+// Token 验证时通过 UserInfoRepository 查询 Token 所属七牛用户，拒绝已冻结（utype 含 UserTypeDisabled 位）
+// 或未激活的用户；用户不存在时同样拒绝并缓存（不按后端故障处理）；
+// 查询结果在本地缓存 cacheTTL，查询失败时按 failOpen 放行或返回内部错误；
+// 用户信息缓存的过期旧值（stale-while-revalidate）不用于状态判断
+type UserStatusPolicy struct {
+	userInfoRepo      interfaces.UserInfoRepository
+	rejectUnactivated bool
+	failOpen          bool
+	cacheTTL          time.Duration
+
+	mu    sync.Mutex
+	cache map[uint32]userStatusEntry
+}
+
+// NewUserStatusPolicy 创建七牛用户状态检查策略
+func NewUserStatusPolicy(userInfoRepo interfaces.UserInfoRepository, rejectUnactivated, failOpen bool, cacheTTL time.Duration) *UserStatusPolicy {
+	if cacheTTL <= 0 {
+		cacheTTL = DefaultUserStatusCacheTTL
+	}
+
+	return &UserStatusPolicy{
+		userInfoRepo:      userInfoRepo,
+		rejectUnactivated: rejectUnactivated,
+		failOpen:          failOpen,
+		cacheTTL:          cacheTTL,
+		cache:             make(map[uint32]userStatusEntry),
+	}
+}
+
+// check 检查七牛用户状态，返回 nil 表示放行
+// 查询失败且 failOpen=false 时返回 error
+func (p *UserStatusPolicy) check(ctx context.Context, uid uint32) (*userStatusRejection, error) {
+	now := time.Now()
+
+	p.mu.Lock()
+	entry, ok := p.cache[uid]
+	p.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.rejection, nil
+	}
+
+	userInfo, err := p.lookup(ctx, uid)
+	if errors.Is(err, interfaces.ErrUserNotFound) {
+		// 用户已注销或 UID 无效：明确拒绝并缓存，避免每次验证都回源，也不受 failOpen 影响
+		rejection := &userStatusRejection{
+			result:  "user_not_found",
+			message: "User not found",
+			code:    interfaces.ErrCodeUserNotFound,
+		}
+		p.store(uid, userStatusEntry{rejection: rejection, expiresAt: now.Add(p.cacheTTL)})
+		return rejection, nil
+	}
+	if err != nil {
+		if p.failOpen {
+			observability.LogWarn(ctx, "User status lookup failed, allowing token",
+				slog.Uint64("uid", uint64(uid)),
+				slog.String("error", err.Error()))
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	rejection := p.evaluate(userInfo)
+	p.store(uid, userStatusEntry{rejection: rejection, expiresAt: now.Add(p.cacheTTL)})
+	return rejection, nil
+}
+
+// lookup 查询用户信息，优先使用不返回过期旧值的查询
+func (p *UserStatusPolicy) lookup(ctx context.Context, uid uint32) (*interfaces.UserInfo, error) {
+	if fresh, ok := p.userInfoRepo.(freshUserInfoGetter); ok {
+		return fresh.GetFreshUserInfoByUID(ctx, uid)
+	}
+	return p.userInfoRepo.GetUserInfoByUID(ctx, uid)
+}
+
+// evaluate 根据用户信息判断是否拒绝
+func (p *UserStatusPolicy) evaluate(userInfo *interfaces.UserInfo) *userStatusRejection {
+	if userInfo.IsDisabled() {
+		message := "User is disabled"
+		if userInfo.DisabledReason != "" {
+			message += ": " + userInfo.DisabledReason
+		}
+		return &userStatusRejection{
+			result:  "user_disabled",
+			message: message,
+			code:    interfaces.ErrCodeUserDisabled,
+		}
+	}
+
+	if p.rejectUnactivated && !userInfo.Activated {
+		return &userStatusRejection{
+			result:  "user_unactivated",
+			message: "User is not activated",
+			code:    interfaces.ErrCodeUserUnactivated,
+		}
+	}
+
+	return nil
+}
+
+// store 写入缓存（超出上限时清理）
+func (p *UserStatusPolicy) store(uid uint32, entry userStatusEntry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.cache) >= maxUserStatusCacheEntries {
+		now := time.Now()
+		for k, v := range p.cache {
+			if !now.Before(v.expiresAt) {
+				delete(p.cache, k)
+			}
+		}
+		if len(p.cache) >= maxUserStatusCacheEntries {
+			p.cache = make(map[uint32]userStatusEntry)
+		}
+	}
+	p.cache[uid] = entry
+}
@@ -10,12 +10,24 @@ import (
 
 	"github.com/qiniu/bearer-token-service/v2/interfaces"
 	"github.com/qiniu/bearer-token-service/v2/observability"
+	"github.com/qiniu/bearer-token-service/v2/pkg/signedtoken"
 )
 
+// TokenLimitChecker Token 层限流检查（由 ratelimit.RateLimitManager 实现）
+type TokenLimitChecker interface {
+	CheckTokenLimit(ctx context.Context, tokenID string, limit *interfaces.RateLimit) (bool, int, time.Time, error)
+}
+
 // ValidationServiceImpl Token 验证服务实现
 type ValidationServiceImpl struct {
-	tokenRepo     interfaces.TokenRepository
-	userInfoRepo  interfaces.UserInfoRepository
+	tokenRepo       interfaces.TokenRepository
+	userInfoRepo    interfaces.UserInfoRepository
+	usageAggregator *UsageAggregator
+	suspended       *SuspendedAccountCache
+	userStatus      *UserStatusPolicy
+	tokenLimit      TokenLimitChecker
+	signingKeys     *signedtoken.KeySet
+	revoked         *RevokedTokenCache
 }
 
 // NewValidationService 创建验证服务实例
@@ -33,12 +45,121 @@ func NewValidationServiceWithUserInfo(tokenRepo interfaces.TokenRepository, user
 	}
 }
 
+// SetUsageAggregator 设置使用计数聚合器（可选，设置后每次成功验证都会记录使用）
+func (s *ValidationServiceImpl) SetUsageAggregator(aggregator *UsageAggregator) {
+	s.usageAggregator = aggregator
+}
+
+// SetSuspendedAccountCache 设置已停用账户集合（可选，设置后拒绝已停用账户的 Token）
+func (s *ValidationServiceImpl) SetSuspendedAccountCache(suspended *SuspendedAccountCache) {
+	s.suspended = suspended
+}
+
+// SetUserStatusPolicy 设置七牛用户状态检查策略（可选，设置后拒绝冻结 / 未激活七牛用户的 Token）
+func (s *ValidationServiceImpl) SetUserStatusPolicy(policy *UserStatusPolicy) {
+	s.userStatus = policy
+}
+
+// SetTokenLimitChecker 设置 Token 层限流（可选，用于批量验证、ValidateTokenWithLimit 和签名 Token；
+// /validate 的不透明 Token 由 TokenLimitMiddleware 限流）
+func (s *ValidationServiceImpl) SetTokenLimitChecker(checker TokenLimitChecker) {
+	s.tokenLimit = checker
+}
+
+// SetSignedTokenVerifier 设置签名 Token 验证密钥和吊销列表（可选，设置后签名 Token 离线验证，不查询数据库）
+func (s *ValidationServiceImpl) SetSignedTokenVerifier(keys *signedtoken.KeySet, revoked *RevokedTokenCache) {
+	s.signingKeys = keys
+	s.revoked = revoked
+}
+
 // ValidateToken 验证 Token
 func (s *ValidationServiceImpl) ValidateToken(ctx context.Context, req *interfaces.TokenValidateRequest) (*interfaces.TokenValidateResponse, error) {
+	token, duration, err := s.lookup(ctx, req.Token)
+	if err != nil {
+		return &interfaces.TokenValidateResponse{
+			Valid:   false,
+			Message: "internal error",
+		}, err
+	}
+
+	// 签名 Token 不经过 TokenLimitMiddleware（中间件不查询数据库），在此按声明中的配置检查 Token 层限流
+	if token != nil && token.Format == interfaces.TokenFormatSigned {
+		limited, err := s.checkTokenLimit(ctx, token)
+		if err != nil {
+			return &interfaces.TokenValidateResponse{
+				Valid:   false,
+				Message: "internal error",
+			}, err
+		}
+		if limited != nil {
+			return limited, nil
+		}
+	}
+
+	resp, err := s.evaluate(ctx, token, req.RequiredScope, req.ClientIP, duration)
+	if err != nil {
+		return resp, err
+	}
+	s.recordUsage(token, resp)
+	return resp, nil
+}
+
+// ValidateTokenWithLimit 验证 Token 并检查 Token 层限流（超限时返回 code 429 的验证结果）
+// 用于不经过 TokenLimitMiddleware 的验证入口（反向代理鉴权等），验证通过时记录使用
+func (s *ValidationServiceImpl) ValidateTokenWithLimit(ctx context.Context, req *interfaces.TokenValidateRequest) (*interfaces.TokenValidateResponse, error) {
+	token, duration, err := s.lookup(ctx, req.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	if token != nil {
+		limited, err := s.checkTokenLimit(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		if limited != nil {
+			return limited, nil
+		}
+	}
+
+	resp, err := s.evaluate(ctx, token, req.RequiredScope, req.ClientIP, duration)
+	if err != nil {
+		return nil, err
+	}
+	s.recordUsage(token, resp)
+	return resp, nil
+}
+
+// IntrospectToken 验证 Token 并同时返回 Token 本身（OAuth 2.0 Token 内省需要签发时间等验证响应之外的字段）
+// visible 非 nil 且返回 false 时按 Token 不存在处理（调用方无权查看）；
+// 内省是资源服务器查询 Token 状态，不是使用 Token：只读，不消耗 Token 层限流额度，也不记录使用
+func (s *ValidationServiceImpl) IntrospectToken(ctx context.Context, req *interfaces.TokenValidateRequest, visible func(*interfaces.Token) bool) (*interfaces.Token, *interfaces.TokenValidateResponse, error) {
+	token, duration, err := s.lookup(ctx, req.Token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if token != nil && visible != nil && !visible(token) {
+		token = nil
+	}
+
+	resp, err := s.evaluate(ctx, token, req.RequiredScope, req.ClientIP, duration)
+	if err != nil {
+		return nil, nil, err
+	}
+	return token, resp, nil
+}
+
+// lookup 按 token 值查询 Token（签名 Token 验证签名后由声明构造，不查询数据库），返回查询耗时
+func (s *ValidationServiceImpl) lookup(ctx context.Context, value string) (*interfaces.Token, time.Duration, error) {
 	start := time.Now()
 
-	// 1. 查询 Token
-	token, err := s.tokenRepo.GetByTokenValue(ctx, req.Token)
+	var token *interfaces.Token
+	var err error
+	if s.isSigned(value) {
+		token = s.verifySigned(ctx, value)
+	} else {
+		token, err = s.tokenRepo.GetByTokenValue(ctx, value)
+	}
 
 	// 记录验证耗时
 	duration := time.Since(start)
@@ -47,32 +168,202 @@ func (s *ValidationServiceImpl) ValidateToken(ctx context.Context, req *interfac
 	if err != nil {
 		observability.TokenValidationsTotal.WithLabelValues("error").Inc()
 		observability.LogError(ctx, "Token validation failed", err)
-		return &interfaces.TokenValidateResponse{
-			Valid:   false,
-			Message: "internal error",
-		}, err
+		return nil, duration, err
 	}
+	return token, duration, nil
+}
+
+// ValidateTokens 批量验证 Token
+// 一次批量查询所有 token（缓存 MGET + MongoDB $in），再逐个执行与 ValidateToken 相同的检查；
+// 配置了 Token 层限流时，每个 token 单独计入各自的限流额度
+func (s *ValidationServiceImpl) ValidateTokens(ctx context.Context, req *interfaces.TokenBatchValidateRequest) (*interfaces.TokenBatchValidateResponse, error) {
+	start := time.Now()
+
+	// 1. 批量查询 Token（签名 Token 不参与查询，在下面逐个离线验证）
+	values := req.Tokens
+	if s.signingKeys != nil {
+		values = make([]string, 0, len(req.Tokens))
+		for _, value := range req.Tokens {
+			if !signedtoken.LooksSigned(value) {
+				values = append(values, value)
+			}
+		}
+	}
+	tokens, err := s.tokenRepo.GetByTokenValues(ctx, values)
+
+	duration := time.Since(start)
+	observability.TokenValidationDuration.Observe(duration.Seconds())
+
+	if err != nil {
+		observability.TokenValidationsTotal.WithLabelValues("error").Inc()
+		observability.LogError(ctx, "Batch token validation failed", err, slog.Int("count", len(req.Tokens)))
+		return nil, err
+	}
+
+	// 2. 逐个验证（同一 token 出现多次时各自验证、各自计入限流）
+	results := make([]*interfaces.TokenValidateResponse, len(req.Tokens))
+	for i, value := range req.Tokens {
+		token := tokens[value]
+		if s.isSigned(value) {
+			token = s.verifySigned(ctx, value)
+		}
+		if token != nil {
+			// 每个结果使用独立副本，避免重复值之间相互影响
+			t := *token
+			token = &t
 
+			limited, err := s.checkTokenLimit(ctx, token)
+			if err != nil {
+				// 限流检查失败只影响该 token 的结果，不中断整个批量请求
+				results[i] = &interfaces.TokenValidateResponse{
+					Valid:   false,
+					Message: "Rate limit check failed",
+					Code:    interfaces.ErrCodeInternalServerError,
+				}
+				continue
+			}
+			if limited != nil {
+				results[i] = limited
+				continue
+			}
+		}
+
+		resp, err := s.evaluate(ctx, token, req.RequiredScope, req.ClientIP, duration)
+		if err != nil {
+			return nil, err
+		}
+		s.recordUsage(token, resp)
+		results[i] = resp
+	}
+
+	return &interfaces.TokenBatchValidateResponse{Results: results}, nil
+}
+
+// isSigned 是否按签名 Token 验证（未配置密钥时所有 Token 都查询数据库）
+func (s *ValidationServiceImpl) isSigned(value string) bool {
+	return s.signingKeys != nil && signedtoken.LooksSigned(value)
+}
+
+// verifySigned 验证签名 Token 并由声明构造 Token，签名无效时返回 nil（按不存在处理）
+// 已过期的 Token 仍然返回，由 evaluate 报告过期；吊销列表中的 Token 视为已停用；限流配置取自声明
+func (s *ValidationServiceImpl) verifySigned(ctx context.Context, value string) *interfaces.Token {
+	claims, err := s.signingKeys.Verify(value, time.Now())
+	if err != nil && !errors.Is(err, signedtoken.ErrExpired) {
+		observability.LogInfo(ctx, "Signed token rejected", slog.String("error", err.Error()))
+		return nil
+	}
+
+	expiresAt := time.Unix(claims.ExpiresAt, 0)
+	var rateLimit *interfaces.RateLimit
+	if rl := claims.RateLimit; rl != nil {
+		rateLimit = &interfaces.RateLimit{
+			RequestsPerMinute: rl.RequestsPerMinute,
+			RequestsPerHour:   rl.RequestsPerHour,
+			RequestsPerDay:    rl.RequestsPerDay,
+		}
+	}
+	return &interfaces.Token{
+		ID:               claims.TokenID,
+		AccountID:        claims.Subject,
+		IUID:             claims.IUID,
+		IamAlias:         claims.IamAlias,
+		Scopes:           claims.Scopes(),
+		AllowedCIDRs:     claims.AllowedCIDRs,
+		RateLimit:        rateLimit,
+		CreatedAt:        time.Unix(claims.IssuedAt, 0),
+		ExpiresAt:        &expiresAt,
+		IsActive:         s.revoked == nil || !s.revoked.IsRevoked(claims.TokenID),
+		Format:           interfaces.TokenFormatSigned,
+		ClientID:         claims.ClientID,
+		RateLimitTokenID: claims.RateLimitKey,
+	}
+}
+
+// checkTokenLimit 检查 Token 层限流，超限时返回验证失败结果
+func (s *ValidationServiceImpl) checkTokenLimit(ctx context.Context, token *interfaces.Token) (*interfaces.TokenValidateResponse, error) {
+	if s.tokenLimit == nil {
+		return nil, nil
+	}
+
+	start := time.Now()
+	allowed, _, _, err := s.tokenLimit.CheckTokenLimit(ctx, token.LimiterKey(), token.RateLimit)
+	observability.RateLimitCheckDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		observability.LogError(ctx, "Token rate limit check failed", err, slog.String("token_id", token.ID))
+		return nil, err
+	}
+	if allowed {
+		return nil, nil
+	}
+
+	observability.RateLimitHitsTotal.WithLabelValues("token").Inc()
+	observability.TokenValidationsTotal.WithLabelValues("rate_limited").Inc()
+	observability.LogInfo(ctx, "Token rate limit exceeded", slog.String("token_id", token.ID))
+	return &interfaces.TokenValidateResponse{
+		Valid:   false,
+		Message: "Token rate limit exceeded",
+		Code:    interfaces.ErrCodeTooManyRequests,
+	}, nil
+}
+
+// recordUsage 验证通过时记录一次使用（入队后批量写入，不阻塞验证）
+// 只在验证入口调用，evaluate 本身只做判定
+func (s *ValidationServiceImpl) recordUsage(token *interfaces.Token, resp *interfaces.TokenValidateResponse) {
+	if s.usageAggregator != nil && resp.Valid {
+		s.usageAggregator.Record(token.ID, token.AccountID, time.Now())
+	}
+}
+
+// evaluate 对已查询到的 Token 执行验证检查（token 为 nil 表示不存在），不产生副作用
+// clientIP 为使用 Token 的客户端 IP（用于 allowed_cidrs 校验）；duration 为查询耗时，仅用于日志
+func (s *ValidationServiceImpl) evaluate(ctx context.Context, token *interfaces.Token, requiredScope, clientIP string, duration time.Duration) (*interfaces.TokenValidateResponse, error) {
 	if token == nil {
 		observability.TokenValidationsTotal.WithLabelValues("not_found").Inc()
 		observability.LogInfo(ctx, "Token not found")
 		return &interfaces.TokenValidateResponse{
 			Valid:   false,
 			Message: "Token not found",
+			Code:    interfaces.ErrCodeTokenNotFound,
 		}, nil
 	}
 
-	// 2. 检查 Token 是否激活
+	// 1. 检查账户是否已停用
+	if s.suspended != nil && s.suspended.IsSuspended(token.AccountID) {
+		observability.TokenValidationsTotal.WithLabelValues("account_suspended").Inc()
+		observability.LogInfo(ctx, "Token account is suspended",
+			slog.String("token_id", token.ID),
+			slog.String("account_id", token.AccountID))
+		return &interfaces.TokenValidateResponse{
+			Valid:   false,
+			Message: "Account is suspended",
+			Code:    interfaces.ErrCodeAccountSuspended,
+		}, nil
+	}
+
+	// 2. 通过轮换前的旧值命中时，检查宽限期是否已结束（缓存数据可能晚于宽限期失效）
+	if token.MatchedPrevious &&
+		(token.PreviousTokenExpiresAt == nil || token.PreviousTokenExpiresAt.Before(time.Now())) {
+		observability.TokenValidationsTotal.WithLabelValues("rotated").Inc()
+		observability.LogInfo(ctx, "Token has been rotated", slog.String("token_id", token.ID))
+		return &interfaces.TokenValidateResponse{
+			Valid:   false,
+			Message: "Token has been rotated",
+			Code:    interfaces.ErrCodeTokenExpired,
+		}, nil
+	}
+
+	// 3. 检查 Token 是否激活
 	if !token.IsActive {
 		observability.TokenValidationsTotal.WithLabelValues("inactive").Inc()
 		observability.LogInfo(ctx, "Token is inactive", slog.String("token_id", token.ID))
 		return &interfaces.TokenValidateResponse{
 			Valid:   false,
 			Message: "Token is inactive",
+			Code:    interfaces.ErrCodeTokenInactive,
 		}, nil
 	}
 
-	// 3. 检查 Token 是否过期
+	// 4. 检查 Token 是否过期
 	if token.ExpiresAt != nil && token.ExpiresAt.Before(time.Now()) {
 		observability.TokenValidationsTotal.WithLabelValues("expired").Inc()
 		observability.LogInfo(ctx, "Token has expired",
@@ -81,10 +372,67 @@ func (s *ValidationServiceImpl) ValidateToken(ctx context.Context, req *interfac
 		return &interfaces.TokenValidateResponse{
 			Valid:   false,
 			Message: "Token has expired",
+			Code:    interfaces.ErrCodeTokenExpired,
+		}, nil
+	}
+
+	// 5. 检查客户端 IP 是否在 Token 允许的范围内（未配置 allowed_cidrs 时不限制）
+	if !token.AllowsIP(clientIP) {
+		observability.TokenValidationsTotal.WithLabelValues("ip_denied").Inc()
+		observability.LogInfo(ctx, "Token client IP not allowed",
+			slog.String("token_id", token.ID),
+			slog.String("client_ip", clientIP))
+		return &interfaces.TokenValidateResponse{
+			Valid:   false,
+			Message: "Client IP is not allowed to use this token",
+			Code:    interfaces.ErrCodeIPNotAllowed,
 		}, nil
 	}
 
-	// 4. 验证通过，返回 Token 信息
+	// 6. 检查 Token 是否具备所需权限（仅当请求指定了 required_scope 时）
+	if requiredScope != "" && !token.HasScope(requiredScope) {
+		observability.TokenValidationsTotal.WithLabelValues("scope_denied").Inc()
+		observability.LogInfo(ctx, "Token scope not granted",
+			slog.String("token_id", token.ID),
+			slog.String("required_scope", requiredScope))
+		return &interfaces.TokenValidateResponse{
+			Valid:   false,
+			Message: "Token does not have required scope: " + requiredScope,
+			Code:    interfaces.ErrCodeScopeNotGranted,
+		}, nil
+	}
+
+	// 7. 检查 Token 所属七牛用户是否已冻结 / 未激活（仅 QiniuStub 用户）
+	if s.userStatus != nil {
+		if uid, ok := extractUIDFromAccountID(token.AccountID); ok {
+			uidInt, _ := strconv.ParseUint(uid, 10, 32)
+			rejection, err := s.userStatus.check(ctx, uint32(uidInt))
+			if err != nil {
+				observability.TokenValidationsTotal.WithLabelValues("user_status_error").Inc()
+				observability.LogError(ctx, "User status check failed", err,
+					slog.String("token_id", token.ID),
+					slog.String("uid", uid))
+				return &interfaces.TokenValidateResponse{
+					Valid:   false,
+					Message: "internal error",
+				}, err
+			}
+			if rejection != nil {
+				observability.TokenValidationsTotal.WithLabelValues(rejection.result).Inc()
+				observability.LogInfo(ctx, "Token user is not allowed",
+					slog.String("token_id", token.ID),
+					slog.String("uid", uid),
+					slog.String("reason", rejection.result))
+				return &interfaces.TokenValidateResponse{
+					Valid:   false,
+					Message: rejection.message,
+					Code:    rejection.code,
+				}, nil
+			}
+		}
+	}
+
+	// 8. 验证通过，返回 Token 信息
 	observability.TokenValidationsTotal.WithLabelValues("valid").Inc()
 	observability.LogDebug(ctx, "Token validation succeeded",
 		slog.String("token_id", token.ID),
@@ -93,6 +441,7 @@ func (s *ValidationServiceImpl) ValidateToken(ctx context.Context, req *interfac
 
 	tokenInfo := &interfaces.TokenInfo{
 		TokenID:  token.ID,
+		Scopes:   token.Scopes,
 		IsActive: token.IsActive,
 	}
 
@@ -122,19 +471,6 @@ func (s *ValidationServiceImpl) ValidateToken(ctx context.Context, req *interfac
 	}, nil
 }
 
-// RecordTokenUsage 记录 Token 使用
-func (s *ValidationServiceImpl) RecordTokenUsage(ctx context.Context, tokenValue string) error {
-	token, err := s.tokenRepo.GetByTokenValue(ctx, tokenValue)
-	if err != nil || token == nil {
-		return errors.New("token not found")
-	}
-
-	// 增加使用计数（异步，不阻塞主流程）
-	go s.tokenRepo.IncrementUsage(context.Background(), token.ID)
-
-	return nil
-}
-
 // ValidateTokenWithUserInfo 验证 Token 并返回扩展用户信息
 // 实现优雅降级：MySQL 查询失败时仍返回基本 token 信息（user_info 为 nil）
 func (s *ValidationServiceImpl) ValidateTokenWithUserInfo(ctx context.Context, req *interfaces.TokenValidateRequest) (*interfaces.TokenValidateUResponse, error) {
@@ -155,6 +491,7 @@ func (s *ValidationServiceImpl) ValidateTokenWithUserInfo(ctx context.Context, r
 		return &interfaces.TokenValidateUResponse{
 			Valid:   basicResponse.Valid,
 			Message: basicResponse.Message,
+			Code:    basicResponse.Code,
 		}, nil
 	}
 
@@ -165,6 +502,7 @@ func (s *ValidationServiceImpl) ValidateTokenWithUserInfo(ctx context.Context, r
 		UID:        basicResponse.TokenInfo.UID,
 		IUID:       basicResponse.TokenInfo.IUID,
 		IamAlias:   basicResponse.TokenInfo.IamAlias,
+		Scopes:     basicResponse.TokenInfo.Scopes,
 		IsActive:   basicResponse.TokenInfo.IsActive,
 		ExpiresAt:  basicResponse.TokenInfo.ExpiresAt,
 		LastUsedAt: basicResponse.TokenInfo.LastUsedAt,
@@ -2,11 +2,16 @@ package service
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/qiniu/bearer-token-service/v2/cache"
 	"github.com/qiniu/bearer-token-service/v2/interfaces"
+	"github.com/qiniu/bearer-token-service/v2/pkg/signedtoken"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -29,17 +34,34 @@ func (m *MockTokenRepository) GetByTokenValue(ctx context.Context, tokenValue st
 	return args.Get(0).(*interfaces.Token), args.Error(1)
 }
 
+func (m *MockTokenRepository) GetByTokenValues(ctx context.Context, tokenValues []string) (map[string]*interfaces.Token, error) {
+	args := m.Called(ctx, tokenValues)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*interfaces.Token), args.Error(1)
+}
+
 func (m *MockTokenRepository) IncrementUsage(ctx context.Context, tokenID string) error {
 	args := m.Called(ctx, tokenID)
 	return args.Error(0)
 }
 
+func (m *MockTokenRepository) IncrementUsageBatch(ctx context.Context, increments []interfaces.TokenUsageIncrement) error {
+	args := m.Called(ctx, increments)
+	return args.Error(0)
+}
+
 func (m *MockTokenRepository) Create(ctx context.Context, token *interfaces.Token) error {
 	return nil
 }
 
 func (m *MockTokenRepository) GetByID(ctx context.Context, tokenID string) (*interfaces.Token, error) {
-	return nil, nil
+	args := m.Called(ctx, tokenID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.Token), args.Error(1)
 }
 
 func (m *MockTokenRepository) ListByAccountID(ctx context.Context, accountID string, activeOnly bool, limit, offset int, iuid, iamAlias string) ([]interfaces.Token, error) {
@@ -51,13 +73,36 @@ func (m *MockTokenRepository) CountByAccountID(ctx context.Context, accountID st
 }
 
 func (m *MockTokenRepository) UpdateStatus(ctx context.Context, tokenID string, isActive bool) error {
-	return nil
+	args := m.Called(ctx, tokenID, isActive)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) Update(ctx context.Context, tokenID string, update *interfaces.TokenUpdate) (*interfaces.Token, error) {
+	return nil, nil
+}
+
+func (m *MockTokenRepository) DisableByAccountID(ctx context.Context, accountID string) (int64, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTokenRepository) DisableByClientID(ctx context.Context, clientID string) (int64, error) {
+	args := m.Called(ctx, clientID)
+	return args.Get(0).(int64), args.Error(1)
 }
 
 func (m *MockTokenRepository) Delete(ctx context.Context, tokenID string) error {
 	return nil
 }
 
+func (m *MockTokenRepository) Rotate(ctx context.Context, tokenID string, previousExpiresAt *time.Time) (*interfaces.Token, error) {
+	args := m.Called(ctx, tokenID, previousExpiresAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*interfaces.Token), args.Error(1)
+}
+
 func (m *MockTokenRepository) UpdateLastUsed(ctx context.Context, tokenID string, lastUsedAt time.Time) error {
 	return nil
 }
@@ -66,6 +111,29 @@ func (m *MockTokenRepository) DeleteExpired(ctx context.Context) (int64, error)
 	return 0, nil
 }
 
+func (m *MockTokenRepository) ListRevokedSignedIDs(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// MockTokenUsageRepository 模拟 TokenUsageRepository
+type MockTokenUsageRepository struct {
+	mock.Mock
+}
+
+func (m *MockTokenUsageRepository) IncrementBatch(ctx context.Context, increments []interfaces.TokenUsageIncrement) error {
+	args := m.Called(ctx, increments)
+	return args.Error(0)
+}
+
+func (m *MockTokenUsageRepository) ListByTokenID(ctx context.Context, tokenID string, granularity string, from, to time.Time) ([]interfaces.TokenUsageBucket, error) {
+	args := m.Called(ctx, tokenID, granularity, from, to)
+	return args.Get(0).([]interfaces.TokenUsageBucket), args.Error(1)
+}
+
 // MockUserInfoRepository 模拟 UserInfoRepository
 type MockUserInfoRepository struct {
 	mock.Mock
@@ -166,6 +234,37 @@ func TestValidateToken_Inactive(t *testing.T) {
 	mockTokenRepo.AssertExpectations(t)
 }
 
+func TestValidateToken_AccountSuspended(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	service := NewValidationService(mockTokenRepo)
+
+	suspended := NewSuspendedAccountCache(new(MockAccountRepository), 0)
+	suspended.Mark("qiniu_1369077332", true)
+	service.SetSuspendedAccountCache(suspended)
+
+	token := &interfaces.Token{
+		ID:        "tk_123",
+		AccountID: "qiniu_1369077332",
+		Token:     "sk-abc123",
+		IsActive:  true,
+	}
+
+	mockTokenRepo.On("GetByTokenValue", mock.Anything, "sk-abc123").Return(token, nil)
+
+	resp, err := service.ValidateToken(context.Background(), &interfaces.TokenValidateRequest{Token: "sk-abc123"})
+
+	require.NoError(t, err)
+	assert.False(t, resp.Valid)
+	assert.Equal(t, interfaces.ErrCodeAccountSuspended, resp.Code)
+
+	// 恢复后立即生效
+	suspended.Mark("qiniu_1369077332", false)
+	resp, err = service.ValidateToken(context.Background(), &interfaces.TokenValidateRequest{Token: "sk-abc123"})
+
+	require.NoError(t, err)
+	assert.True(t, resp.Valid)
+}
+
 func TestValidateToken_Expired(t *testing.T) {
 	mockTokenRepo := new(MockTokenRepository)
 	service := NewValidationService(mockTokenRepo)
@@ -214,6 +313,139 @@ func TestValidateToken_DatabaseError(t *testing.T) {
 	mockTokenRepo.AssertExpectations(t)
 }
 
+func TestValidateToken_Scope(t *testing.T) {
+	tests := []struct {
+		name          string
+		scopes        []string
+		requiredScope string
+		wantValid     bool
+	}{
+		{"No required scope", []string{"storage:read"}, "", true},
+		{"Exact match", []string{"storage:read"}, "storage:read", true},
+		{"Action wildcard", []string{"storage:*"}, "storage:write", true},
+		{"Global wildcard", []string{"*"}, "cdn:purge", true},
+		{"Legacy token without scopes", nil, "storage:read", true},
+		{"Different action", []string{"storage:read"}, "storage:write", false},
+		{"Different resource", []string{"storage:*"}, "cdn:read", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTokenRepo := new(MockTokenRepository)
+			service := NewValidationService(mockTokenRepo)
+
+			token := &interfaces.Token{
+				ID:        "tk_123",
+				AccountID: "qiniu_1369077332",
+				Token:     "sk-abc123",
+				Scopes:    tt.scopes,
+				IsActive:  true,
+			}
+			mockTokenRepo.On("GetByTokenValue", mock.Anything, "sk-abc123").Return(token, nil)
+
+			resp, err := service.ValidateToken(context.Background(), &interfaces.TokenValidateRequest{
+				Token:         "sk-abc123",
+				RequiredScope: tt.requiredScope,
+			})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantValid, resp.Valid)
+			if tt.wantValid {
+				assert.Equal(t, tt.scopes, resp.TokenInfo.Scopes)
+			} else {
+				assert.Equal(t, interfaces.ErrCodeScopeNotGranted, resp.Code)
+				assert.Nil(t, resp.TokenInfo)
+			}
+		})
+	}
+}
+
+func TestValidateToken_AllowedCIDRs(t *testing.T) {
+	tests := []struct {
+		name         string
+		allowedCIDRs []string
+		clientIP     string
+		wantValid    bool
+	}{
+		{"No restriction", nil, "203.0.113.7", true},
+		{"IPv4 in range", []string{"10.0.0.0/8", "192.168.1.10/32"}, "10.1.2.3", true},
+		{"Single IP", []string{"192.168.1.10/32"}, "192.168.1.10", true},
+		{"IPv4-mapped IPv6", []string{"10.0.0.0/8"}, "::ffff:10.1.2.3", true},
+		{"IPv6 in range", []string{"2001:db8::/32"}, "2001:db8::1", true},
+		{"Out of range", []string{"10.0.0.0/8"}, "203.0.113.7", false},
+		{"Unknown client IP", []string{"10.0.0.0/8"}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTokenRepo := new(MockTokenRepository)
+			service := NewValidationService(mockTokenRepo)
+
+			token := &interfaces.Token{
+				ID:           "tk_123",
+				AccountID:    "qiniu_1369077332",
+				Token:        "sk-abc123",
+				AllowedCIDRs: tt.allowedCIDRs,
+				IsActive:     true,
+			}
+			mockTokenRepo.On("GetByTokenValue", mock.Anything, "sk-abc123").Return(token, nil)
+
+			resp, err := service.ValidateToken(context.Background(), &interfaces.TokenValidateRequest{
+				Token:    "sk-abc123",
+				ClientIP: tt.clientIP,
+			})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantValid, resp.Valid)
+			if !tt.wantValid {
+				assert.Equal(t, interfaces.ErrCodeIPNotAllowed, resp.Code)
+				assert.Nil(t, resp.TokenInfo)
+			}
+		})
+	}
+}
+
+func TestValidateToken_RotatedPreviousValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		wantValid bool
+	}{
+		{"Within grace period", time.Now().Add(time.Hour), true},
+		{"Grace period ended", time.Now().Add(-time.Minute), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTokenRepo := new(MockTokenRepository)
+			service := NewValidationService(mockTokenRepo)
+
+			previousExpiresAt := tt.expiresAt
+			// Repository 通过旧值的哈希命中时会标记 MatchedPrevious
+			token := &interfaces.Token{
+				ID:                     "tk_123",
+				AccountID:              "qiniu_1369077332",
+				TokenHash:              "hash-new",
+				PreviousTokenHash:      "hash-old",
+				PreviousTokenExpiresAt: &previousExpiresAt,
+				MatchedPrevious:        true,
+				IsActive:               true,
+			}
+			mockTokenRepo.On("GetByTokenValue", mock.Anything, "sk-abc123").Return(token, nil)
+
+			resp, err := service.ValidateToken(context.Background(), &interfaces.TokenValidateRequest{
+				Token: "sk-abc123",
+			})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantValid, resp.Valid)
+			if !tt.wantValid {
+				assert.Equal(t, "Token has been rotated", resp.Message)
+			}
+		})
+	}
+}
+
 // ========================================
 // Test ValidateTokenWithUserInfo (扩展验证)
 // ========================================
@@ -380,6 +612,170 @@ func TestValidateTokenWithUserInfo_HMACUser_NoUserInfoQuery(t *testing.T) {
 	mockTokenRepo.AssertExpectations(t)
 }
 
+func TestValidateToken_UserStatusPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		userInfo  *interfaces.UserInfo
+		lookupErr error
+		failOpen  bool
+		wantValid bool
+		wantCode  int
+		wantMsg   string
+		wantErr   bool
+	}{
+		{
+			name:      "active user",
+			userInfo:  &interfaces.UserInfo{UID: 1369077332, Activated: true},
+			wantValid: true,
+			wantMsg:   "Token is valid",
+		},
+		{
+			name:      "disabled user",
+			userInfo:  &interfaces.UserInfo{UID: 1369077332, Activated: true, Utype: interfaces.UserTypeDisabled, DisabledReason: "overdue"},
+			wantValid: false,
+			wantCode:  interfaces.ErrCodeUserDisabled,
+			wantMsg:   "User is disabled: overdue",
+		},
+		{
+			name:      "unactivated user",
+			userInfo:  &interfaces.UserInfo{UID: 1369077332, Activated: false},
+			wantValid: false,
+			wantCode:  interfaces.ErrCodeUserUnactivated,
+			wantMsg:   "User is not activated",
+		},
+		{
+			name:      "user not found",
+			lookupErr: fmt.Errorf("%w: uid=1369077332", interfaces.ErrUserNotFound),
+			wantValid: false,
+			wantCode:  interfaces.ErrCodeUserNotFound,
+			wantMsg:   "User not found",
+		},
+		{
+			name:      "user not found, fail open still rejects",
+			lookupErr: fmt.Errorf("%w: uid=1369077332", interfaces.ErrUserNotFound),
+			failOpen:  true,
+			wantValid: false,
+			wantCode:  interfaces.ErrCodeUserNotFound,
+			wantMsg:   "User not found",
+		},
+		{
+			name:      "lookup failed, fail open",
+			lookupErr: errors.New("qconfapi unavailable"),
+			failOpen:  true,
+			wantValid: true,
+			wantMsg:   "Token is valid",
+		},
+		{
+			name:      "lookup failed, fail closed",
+			lookupErr: errors.New("qconfapi unavailable"),
+			wantValid: false,
+			wantMsg:   "internal error",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTokenRepo := new(MockTokenRepository)
+			mockUserInfoRepo := new(MockUserInfoRepository)
+			service := NewValidationService(mockTokenRepo)
+			service.SetUserStatusPolicy(NewUserStatusPolicy(mockUserInfoRepo, true, tt.failOpen, time.Minute))
+
+			token := &interfaces.Token{
+				ID:        "tk_123",
+				AccountID: "qiniu_1369077332",
+				Token:     "sk-abc123",
+				IsActive:  true,
+			}
+			mockTokenRepo.On("GetByTokenValue", mock.Anything, "sk-abc123").Return(token, nil)
+			mockUserInfoRepo.On("GetUserInfoByUID", mock.Anything, uint32(1369077332)).Return(tt.userInfo, tt.lookupErr)
+
+			resp, err := service.ValidateToken(context.Background(), &interfaces.TokenValidateRequest{Token: "sk-abc123"})
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantValid, resp.Valid)
+			assert.Equal(t, tt.wantCode, resp.Code)
+			assert.Equal(t, tt.wantMsg, resp.Message)
+		})
+	}
+}
+
+func TestValidateToken_UserStatusPolicyCached(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	mockUserInfoRepo := new(MockUserInfoRepository)
+	service := NewValidationService(mockTokenRepo)
+	service.SetUserStatusPolicy(NewUserStatusPolicy(mockUserInfoRepo, true, false, time.Minute))
+
+	token := &interfaces.Token{ID: "tk_123", AccountID: "qiniu_1369077332", Token: "sk-abc123", IsActive: true}
+	mockTokenRepo.On("GetByTokenValue", mock.Anything, "sk-abc123").Return(token, nil)
+	mockUserInfoRepo.On("GetUserInfoByUID", mock.Anything, uint32(1369077332)).
+		Return(&interfaces.UserInfo{UID: 1369077332, Utype: interfaces.UserTypeDisabled}, nil).Once()
+
+	for i := 0; i < 3; i++ {
+		resp, err := service.ValidateToken(context.Background(), &interfaces.TokenValidateRequest{Token: "sk-abc123"})
+		require.NoError(t, err)
+		assert.Equal(t, interfaces.ErrCodeUserDisabled, resp.Code)
+	}
+
+	mockUserInfoRepo.AssertNumberOfCalls(t, "GetUserInfoByUID", 1)
+}
+
+func TestValidateToken_UserNotFoundNegativeCached(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	mockUserInfoRepo := new(MockUserInfoRepository)
+	service := NewValidationService(mockTokenRepo)
+	service.SetUserStatusPolicy(NewUserStatusPolicy(mockUserInfoRepo, true, false, time.Minute))
+
+	token := &interfaces.Token{ID: "tk_123", AccountID: "qiniu_1369077332", Token: "sk-abc123", IsActive: true}
+	mockTokenRepo.On("GetByTokenValue", mock.Anything, "sk-abc123").Return(token, nil)
+	mockUserInfoRepo.On("GetUserInfoByUID", mock.Anything, uint32(1369077332)).
+		Return(nil, fmt.Errorf("%w: uid=1369077332", interfaces.ErrUserNotFound)).Once()
+
+	for i := 0; i < 3; i++ {
+		resp, err := service.ValidateToken(context.Background(), &interfaces.TokenValidateRequest{Token: "sk-abc123"})
+		require.NoError(t, err)
+		assert.False(t, resp.Valid)
+		assert.Equal(t, interfaces.ErrCodeUserNotFound, resp.Code)
+	}
+
+	mockUserInfoRepo.AssertNumberOfCalls(t, "GetUserInfoByUID", 1)
+}
+
+func TestValidateToken_UserStatusIgnoresStaleUserInfo(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	mockUserInfoRepo := new(MockUserInfoRepository)
+	userInfoCache := cache.NewUserInfoCache(mockUserInfoRepo, nil, cache.UserInfoCacheConfig{
+		TTL:      10 * time.Millisecond,
+		StaleTTL: time.Minute,
+	})
+	service := NewValidationService(mockTokenRepo)
+	service.SetUserStatusPolicy(NewUserStatusPolicy(userInfoCache, true, false, time.Millisecond))
+
+	token := &interfaces.Token{ID: "tk_123", AccountID: "qiniu_1369077332", Token: "sk-abc123", IsActive: true}
+	mockTokenRepo.On("GetByTokenValue", mock.Anything, "sk-abc123").Return(token, nil)
+	mockUserInfoRepo.On("GetUserInfoByUID", mock.Anything, uint32(1369077332)).
+		Return(&interfaces.UserInfo{UID: 1369077332, Activated: true}, nil).Once()
+	mockUserInfoRepo.On("GetUserInfoByUID", mock.Anything, uint32(1369077332)).
+		Return(&interfaces.UserInfo{UID: 1369077332, Activated: true, Utype: interfaces.UserTypeDisabled}, nil)
+
+	req := &interfaces.TokenValidateRequest{Token: "sk-abc123"}
+	resp, err := service.ValidateToken(context.Background(), req)
+	require.NoError(t, err)
+	assert.True(t, resp.Valid)
+
+	// 用户信息缓存条目过期（仍在 StaleTTL 内）期间用户被冻结：状态检查不使用旧值
+	time.Sleep(20 * time.Millisecond)
+	resp, err = service.ValidateToken(context.Background(), req)
+	require.NoError(t, err)
+	assert.False(t, resp.Valid)
+	assert.Equal(t, interfaces.ErrCodeUserDisabled, resp.Code)
+	require.NoError(t, userInfoCache.Wait(context.Background()))
+}
+
 func TestValidateTokenWithUserInfo_NoUserInfoRepo(t *testing.T) {
 	mockTokenRepo := new(MockTokenRepository)
 	// 没有提供 UserInfoRepository
@@ -415,41 +811,374 @@ func TestValidateTokenWithUserInfo_NoUserInfoRepo(t *testing.T) {
 }
 
 // ========================================
-// Test RecordTokenUsage
+// Test usage recording
 // ========================================
 
-func TestRecordTokenUsage_Success(t *testing.T) {
+func TestValidateToken_RecordsUsageOnlyWhenValid(t *testing.T) {
 	mockTokenRepo := new(MockTokenRepository)
+	aggregator := NewUsageAggregator(mockTokenRepo, nil, time.Hour, 100, 10)
+	aggregator.Start()
+
 	service := NewValidationService(mockTokenRepo)
+	service.SetUsageAggregator(aggregator)
+
+	mockTokenRepo.On("GetByTokenValue", mock.Anything, "sk-valid").
+		Return(&interfaces.Token{ID: "tk_valid", AccountID: "acc_1", IsActive: true}, nil)
+	mockTokenRepo.On("GetByTokenValue", mock.Anything, "sk-inactive").
+		Return(&interfaces.Token{ID: "tk_inactive", AccountID: "acc_1", IsActive: false}, nil)
+	mockTokenRepo.On("GetByTokenValue", mock.Anything, "sk-missing").Return(nil, nil)
+	mockTokenRepo.On("IncrementUsageBatch", mock.Anything, mock.MatchedBy(func(incs []interfaces.TokenUsageIncrement) bool {
+		return len(incs) == 1 && incs[0].TokenID == "tk_valid" && incs[0].AccountID == "acc_1" && incs[0].Count == 2
+	})).Return(nil)
+
+	for _, value := range []string{"sk-valid", "sk-inactive", "sk-missing", "sk-valid"} {
+		_, err := service.ValidateToken(context.Background(), &interfaces.TokenValidateRequest{Token: value})
+		require.NoError(t, err)
+	}
 
-	token := &interfaces.Token{
-		ID:    "tk_123",
-		Token: "sk-abc123",
+	// Stop 会排空队列并刷新，之后不再需要等待
+	require.NoError(t, aggregator.Stop(context.Background()))
+	mockTokenRepo.AssertExpectations(t)
+}
+
+func TestValidateTokens_RecordsUsagePerValidToken(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	aggregator := NewUsageAggregator(mockTokenRepo, nil, time.Hour, 100, 10)
+	aggregator.Start()
+
+	service := NewValidationService(mockTokenRepo)
+	service.SetUsageAggregator(aggregator)
+
+	mockTokenRepo.On("GetByTokenValues", mock.Anything, []string{"sk-a", "sk-b", "sk-a"}).
+		Return(map[string]*interfaces.Token{
+			"sk-a": {ID: "tk_a", AccountID: "acc_1", IsActive: true},
+			"sk-b": {ID: "tk_b", AccountID: "acc_1", IsActive: false},
+		}, nil)
+	mockTokenRepo.On("IncrementUsageBatch", mock.Anything, mock.MatchedBy(func(incs []interfaces.TokenUsageIncrement) bool {
+		return len(incs) == 1 && incs[0].TokenID == "tk_a" && incs[0].Count == 2
+	})).Return(nil)
+
+	_, err := service.ValidateTokens(context.Background(), &interfaces.TokenBatchValidateRequest{
+		Tokens: []string{"sk-a", "sk-b", "sk-a"},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, aggregator.Stop(context.Background()))
+	mockTokenRepo.AssertExpectations(t)
+}
+
+// ========================================
+// Test ValidateTokens (批量验证)
+// ========================================
+
+// stubTokenLimitChecker 按 token ID 指定是否超限或检查出错
+type stubTokenLimitChecker struct {
+	limited map[string]bool
+	errs    map[string]error
+	calls   []string
+	limits  []*interfaces.RateLimit
+}
+
+func (s *stubTokenLimitChecker) CheckTokenLimit(ctx context.Context, tokenID string, limit *interfaces.RateLimit) (bool, int, time.Time, error) {
+	s.calls = append(s.calls, tokenID)
+	s.limits = append(s.limits, limit)
+	if err := s.errs[tokenID]; err != nil {
+		return false, -1, time.Time{}, err
 	}
+	return !s.limited[tokenID], -1, time.Time{}, nil
+}
 
-	mockTokenRepo.On("GetByTokenValue", mock.Anything, "sk-abc123").Return(token, nil)
-	mockTokenRepo.On("IncrementUsage", mock.Anything, "tk_123").Return(nil)
+func TestValidateTokens_MixedResults(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	service := NewValidationService(mockTokenRepo)
 
-	err := service.RecordTokenUsage(context.Background(), "sk-abc123")
+	expired := time.Now().Add(-time.Hour)
+	values := []string{"sk-valid", "sk-missing", "sk-expired", "sk-valid"}
+	mockTokenRepo.On("GetByTokenValues", mock.Anything, values).Return(map[string]*interfaces.Token{
+		"sk-valid":   {ID: "tk_1", AccountID: "qiniu_1369077332", IsActive: true, Scopes: []string{"storage:read"}},
+		"sk-expired": {ID: "tk_2", AccountID: "acc_1", IsActive: true, ExpiresAt: &expired},
+	}, nil)
 
-	// Wait for goroutine to complete
-	time.Sleep(10 * time.Millisecond)
+	resp, err := service.ValidateTokens(context.Background(), &interfaces.TokenBatchValidateRequest{
+		Tokens:        values,
+		RequiredScope: "storage:read",
+	})
 
 	require.NoError(t, err)
-	mockTokenRepo.AssertExpectations(t)
+	require.Len(t, resp.Results, 4)
+
+	assert.True(t, resp.Results[0].Valid)
+	assert.Equal(t, "1369077332", resp.Results[0].TokenInfo.UID)
+
+	assert.False(t, resp.Results[1].Valid)
+	assert.Equal(t, interfaces.ErrCodeTokenNotFound, resp.Results[1].Code)
+
+	assert.False(t, resp.Results[2].Valid)
+	assert.Equal(t, interfaces.ErrCodeTokenExpired, resp.Results[2].Code)
+
+	assert.True(t, resp.Results[3].Valid)
+	assert.Equal(t, "tk_1", resp.Results[3].TokenInfo.TokenID)
+
+	mockTokenRepo.AssertNumberOfCalls(t, "GetByTokenValues", 1)
 }
 
-func TestRecordTokenUsage_TokenNotFound(t *testing.T) {
+func TestValidateTokens_TokenRateLimit(t *testing.T) {
 	mockTokenRepo := new(MockTokenRepository)
 	service := NewValidationService(mockTokenRepo)
+	limiter := &stubTokenLimitChecker{limited: map[string]bool{"tk_2": true}}
+	service.SetTokenLimitChecker(limiter)
 
-	mockTokenRepo.On("GetByTokenValue", mock.Anything, "sk-notfound").Return(nil, nil)
+	values := []string{"sk-a", "sk-b", "sk-missing"}
+	mockTokenRepo.On("GetByTokenValues", mock.Anything, values).Return(map[string]*interfaces.Token{
+		"sk-a": {ID: "tk_1", AccountID: "acc_1", IsActive: true},
+		"sk-b": {ID: "tk_2", AccountID: "acc_1", IsActive: true},
+	}, nil)
 
-	err := service.RecordTokenUsage(context.Background(), "sk-notfound")
+	resp, err := service.ValidateTokens(context.Background(), &interfaces.TokenBatchValidateRequest{Tokens: values})
 
-	require.Error(t, err)
-	assert.Equal(t, "token not found", err.Error())
-	mockTokenRepo.AssertExpectations(t)
+	require.NoError(t, err)
+	assert.True(t, resp.Results[0].Valid)
+	assert.False(t, resp.Results[1].Valid)
+	assert.Equal(t, interfaces.ErrCodeTooManyRequests, resp.Results[1].Code)
+	assert.Equal(t, interfaces.ErrCodeTokenNotFound, resp.Results[2].Code)
+
+	// 不存在的 token 不计入限流
+	assert.Equal(t, []string{"tk_1", "tk_2"}, limiter.calls)
+}
+
+func TestValidateTokens_TokenRateLimitErrorOnlyAffectsThatToken(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	service := NewValidationService(mockTokenRepo)
+	limiter := &stubTokenLimitChecker{errs: map[string]error{"tk_1": errors.New("redis unavailable")}}
+	service.SetTokenLimitChecker(limiter)
+
+	values := []string{"sk-a", "sk-b"}
+	mockTokenRepo.On("GetByTokenValues", mock.Anything, values).Return(map[string]*interfaces.Token{
+		"sk-a": {ID: "tk_1", AccountID: "acc_1", IsActive: true},
+		"sk-b": {ID: "tk_2", AccountID: "acc_1", IsActive: true},
+	}, nil)
+
+	resp, err := service.ValidateTokens(context.Background(), &interfaces.TokenBatchValidateRequest{Tokens: values})
+
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 2)
+	assert.False(t, resp.Results[0].Valid)
+	assert.Equal(t, interfaces.ErrCodeInternalServerError, resp.Results[0].Code)
+	assert.True(t, resp.Results[1].Valid)
+	assert.Equal(t, []string{"tk_1", "tk_2"}, limiter.calls)
+}
+
+func TestValidateTokenWithLimit(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	service := NewValidationService(mockTokenRepo)
+	limiter := &stubTokenLimitChecker{limited: map[string]bool{"tk_2": true}}
+	service.SetTokenLimitChecker(limiter)
+
+	mockTokenRepo.On("GetByTokenValue", mock.Anything, "sk-a").Return(&interfaces.Token{ID: "tk_1", AccountID: "acc_1", IsActive: true}, nil)
+	mockTokenRepo.On("GetByTokenValue", mock.Anything, "sk-b").Return(&interfaces.Token{ID: "tk_2", AccountID: "acc_1", IsActive: true}, nil)
+	mockTokenRepo.On("GetByTokenValue", mock.Anything, "sk-missing").Return(nil, nil)
+
+	resp, err := service.ValidateTokenWithLimit(context.Background(), &interfaces.TokenValidateRequest{Token: "sk-a"})
+	require.NoError(t, err)
+	assert.True(t, resp.Valid)
+
+	resp, err = service.ValidateTokenWithLimit(context.Background(), &interfaces.TokenValidateRequest{Token: "sk-b"})
+	require.NoError(t, err)
+	assert.False(t, resp.Valid)
+	assert.Equal(t, interfaces.ErrCodeTooManyRequests, resp.Code)
+
+	resp, err = service.ValidateTokenWithLimit(context.Background(), &interfaces.TokenValidateRequest{Token: "sk-missing"})
+	require.NoError(t, err)
+	assert.Equal(t, interfaces.ErrCodeTokenNotFound, resp.Code)
+
+	// 不存在的 token 不计入限流
+	assert.Equal(t, []string{"tk_1", "tk_2"}, limiter.calls)
+}
+
+func TestIntrospectToken_ReadOnly(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	aggregator := NewUsageAggregator(mockTokenRepo, nil, time.Hour, 100, 10)
+	aggregator.Start()
+
+	service := NewValidationService(mockTokenRepo)
+	service.SetUsageAggregator(aggregator)
+	limiter := &stubTokenLimitChecker{limited: map[string]bool{"tk_1": true}}
+	service.SetTokenLimitChecker(limiter)
+
+	mockTokenRepo.On("GetByTokenValue", mock.Anything, "sk-a").Return(&interfaces.Token{ID: "tk_1", AccountID: "acc_1", IsActive: true}, nil)
+
+	// 已超限的 Token 仍按实际状态返回，多次内省既不消耗限流额度也不记录使用
+	for i := 0; i < 3; i++ {
+		token, resp, err := service.IntrospectToken(context.Background(), &interfaces.TokenValidateRequest{Token: "sk-a"}, nil)
+		require.NoError(t, err)
+		assert.True(t, resp.Valid)
+		assert.Equal(t, "tk_1", token.ID)
+	}
+
+	require.NoError(t, aggregator.Stop(context.Background()))
+	assert.Empty(t, limiter.calls)
+	mockTokenRepo.AssertNotCalled(t, "IncrementUsageBatch", mock.Anything, mock.Anything)
+}
+
+func TestValidateTokens_RepositoryError(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	service := NewValidationService(mockTokenRepo)
+
+	mockTokenRepo.On("GetByTokenValues", mock.Anything, []string{"sk-a"}).Return(nil, errors.New("database connection failed"))
+
+	resp, err := service.ValidateTokens(context.Background(), &interfaces.TokenBatchValidateRequest{Tokens: []string{"sk-a"}})
+
+	assert.Error(t, err)
+	assert.Nil(t, resp)
+}
+
+// ========================================
+// Test 签名 Token
+// ========================================
+
+// newTestKeySet 创建测试用 Ed25519 密钥集合
+func newTestKeySet(t *testing.T) *signedtoken.KeySet {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keys, err := signedtoken.NewKeySet("", priv)
+	require.NoError(t, err)
+	return keys
+}
+
+// signTestToken 签发测试用签名 Token
+func signTestToken(t *testing.T, keys *signedtoken.KeySet, tokenID string, expiresAt time.Time) string {
+	value, err := keys.Sign(&signedtoken.Claims{
+		Subject:   "qiniu_1369077332",
+		TokenID:   tokenID,
+		UID:       "1369077332",
+		IUID:      "8901234",
+		Scope:     "storage:read",
+		IssuedAt:  time.Now().Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	require.NoError(t, err)
+	return value
+}
+
+func TestValidateToken_SignedToken(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	mockTokenRepo.On("ListRevokedSignedIDs", mock.Anything).Return([]string{"tk_revoked"}, nil)
+
+	keys := newTestKeySet(t)
+	revoked := NewRevokedTokenCache(mockTokenRepo, 0)
+	revoked.refresh()
+
+	service := NewValidationService(mockTokenRepo)
+	service.SetSignedTokenVerifier(keys, revoked)
+
+	valid := signTestToken(t, keys, "tk_valid", time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name      string
+		value     string
+		scope     string
+		wantValid bool
+		wantCode  int
+	}{
+		{"valid", valid, "storage:read", true, 0},
+		{"scope not granted", valid, "storage:write", false, interfaces.ErrCodeScopeNotGranted},
+		{"revoked", signTestToken(t, keys, "tk_revoked", time.Now().Add(time.Hour)), "", false, interfaces.ErrCodeTokenInactive},
+		{"expired", signTestToken(t, keys, "tk_expired", time.Now().Add(-time.Minute)), "", false, interfaces.ErrCodeTokenExpired},
+		{"signed by unknown key", signTestToken(t, newTestKeySet(t), "tk_valid", time.Now().Add(time.Hour)), "", false, interfaces.ErrCodeTokenNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := service.ValidateToken(context.Background(), &interfaces.TokenValidateRequest{Token: tt.value, RequiredScope: tt.scope})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantValid, resp.Valid)
+			assert.Equal(t, tt.wantCode, resp.Code)
+			if tt.wantValid {
+				assert.Equal(t, "tk_valid", resp.TokenInfo.TokenID)
+				assert.Equal(t, "1369077332", resp.TokenInfo.UID)
+				assert.Equal(t, "8901234", resp.TokenInfo.IUID)
+			}
+		})
+	}
+
+	// 本实例停用后立即生效，恢复后重新有效
+	revoked.Mark("tk_valid", true)
+	resp, err := service.ValidateToken(context.Background(), &interfaces.TokenValidateRequest{Token: valid})
+	require.NoError(t, err)
+	assert.Equal(t, interfaces.ErrCodeTokenInactive, resp.Code)
+
+	revoked.Mark("tk_valid", false)
+	resp, err = service.ValidateToken(context.Background(), &interfaces.TokenValidateRequest{Token: valid})
+	require.NoError(t, err)
+	assert.True(t, resp.Valid)
+
+	// 签名 Token 不查询数据库
+	mockTokenRepo.AssertNotCalled(t, "GetByTokenValue", mock.Anything, mock.Anything)
+}
+
+func TestValidateTokens_SignedAndOpaque(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	keys := newTestKeySet(t)
+	service := NewValidationService(mockTokenRepo)
+	service.SetSignedTokenVerifier(keys, nil)
+
+	signed := signTestToken(t, keys, "tk_signed", time.Now().Add(time.Hour))
+	mockTokenRepo.On("GetByTokenValues", mock.Anything, []string{"sk-a"}).Return(map[string]*interfaces.Token{
+		"sk-a": {ID: "tk_1", AccountID: "acc_1", IsActive: true},
+	}, nil)
+
+	resp, err := service.ValidateTokens(context.Background(), &interfaces.TokenBatchValidateRequest{Tokens: []string{signed, "sk-a"}})
+
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 2)
+	assert.True(t, resp.Results[0].Valid)
+	assert.Equal(t, "tk_signed", resp.Results[0].TokenInfo.TokenID)
+	assert.True(t, resp.Results[1].Valid)
+	assert.Equal(t, "tk_1", resp.Results[1].TokenInfo.TokenID)
+}
+
+func TestValidateToken_SignedTokenRateLimit(t *testing.T) {
+	mockTokenRepo := new(MockTokenRepository)
+	keys := newTestKeySet(t)
+	service := NewValidationService(mockTokenRepo)
+	service.SetSignedTokenVerifier(keys, nil)
+	limiter := &stubTokenLimitChecker{limited: map[string]bool{"tk_limited": true}}
+	service.SetTokenLimitChecker(limiter)
+
+	sign := func(tokenID string) string {
+		value, err := keys.Sign(&signedtoken.Claims{
+			Subject:   "acc_1",
+			TokenID:   tokenID,
+			RateLimit: &signedtoken.RateLimit{RequestsPerMinute: 60},
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(time.Hour).Unix(),
+		})
+		require.NoError(t, err)
+		return value
+	}
+
+	// 签名 Token 不经过 TokenLimitMiddleware，由 ValidateToken 按声明中的配置限流
+	resp, err := service.ValidateToken(context.Background(), &interfaces.TokenValidateRequest{Token: sign("tk_ok")})
+	require.NoError(t, err)
+	assert.True(t, resp.Valid)
+
+	resp, err = service.ValidateToken(context.Background(), &interfaces.TokenValidateRequest{Token: sign("tk_limited")})
+	require.NoError(t, err)
+	assert.False(t, resp.Valid)
+	assert.Equal(t, interfaces.ErrCodeTooManyRequests, resp.Code)
+
+	assert.Equal(t, []string{"tk_ok", "tk_limited"}, limiter.calls)
+	for _, limit := range limiter.limits {
+		assert.Equal(t, &interfaces.RateLimit{RequestsPerMinute: 60}, limit)
+	}
+
+	// 不透明 Token 仍由中间件限流，ValidateToken 不重复计数
+	mockTokenRepo.On("GetByTokenValue", mock.Anything, "sk-a").Return(&interfaces.Token{ID: "tk_1", AccountID: "acc_1", IsActive: true}, nil)
+	resp, err = service.ValidateToken(context.Background(), &interfaces.TokenValidateRequest{Token: "sk-a"})
+	require.NoError(t, err)
+	assert.True(t, resp.Valid)
+	assert.Len(t, limiter.calls, 2)
 }
 
 // ========================================
@@ -241,14 +241,15 @@ echo "========================================="
 echo "3. 创建带限流的 Token"
 echo "========================================="
 
-# 生成 HMAC 签名
+# 生成 HMAC 签名（每个请求使用新的 nonce，服务端默认要求 X-Qiniu-Nonce）
 TIMESTAMP=$(date -u +"%Y-%m-%dT%H:%M:%SZ")
+NONCE=$(openssl rand -hex 8)
 URI="/api/v2/tokens"
 METHOD="POST"
 BODY='{"description":"Test Token","scope":["storage:write"],"expires_in_seconds":3600,"rate_limit":{"requests_per_minute":2,"requests_per_hour":30,"requests_per_day":300}}'
 
 # 计算签名（注意：bash命令替换会移除末尾换行符，需要补回）
-STRING_TO_SIGN=$(printf "%s\n%s\n%s\n%s" "$METHOD" "$URI" "$TIMESTAMP" "$BODY")
+STRING_TO_SIGN=$(printf "%s\n%s\n%s %s\n%s" "$METHOD" "$URI" "$TIMESTAMP" "$NONCE" "$BODY")
 # 如果BODY为空，需要在末尾添加换行符（因为fmt.Sprintf会在最后加\n）
 # 如果BODY非空，不需要添加（fmt.Sprintf不会在BODY后加\n）
 if [ -z "$BODY" ]; then
@@ -270,6 +271,7 @@ TOKEN_RESPONSE=$(curl -s -X POST "$BASE_URL$URI" \
     -H "Content-Type: application/json" \
     -H "Authorization: $AUTH_HEADER" \
     -H "X-Qiniu-Date: $TIMESTAMP" \
+    -H "X-Qiniu-Nonce: $NONCE" \
     -d "$BODY")
 
 echo "$TOKEN_RESPONSE" | jq . || {
@@ -407,14 +409,15 @@ SUCCESS_COUNT=0
 RATE_LIMITED_COUNT=0
 
 for i in {1..6}; do
-    # 创建新的时间戳和签名
+    # 创建新的时间戳、nonce 和签名
     TIMESTAMP=$(date -u +"%Y-%m-%dT%H:%M:%SZ")
+    NONCE=$(openssl rand -hex 8)
     URI="/api/v2/accounts/me"
     METHOD="GET"
     BODY=""
 
     # 使用 printf 正确处理换行符
-    STRING_TO_SIGN=$(printf "%s\n%s\n%s\n%s" "$METHOD" "$URI" "$TIMESTAMP" "$BODY")
+    STRING_TO_SIGN=$(printf "%s\n%s\n%s %s\n%s" "$METHOD" "$URI" "$TIMESTAMP" "$NONCE" "$BODY")
     # 如果BODY为空，需要在末尾添加换行符（因为fmt.Sprintf会在最后加\n）
     # 如果BODY非空，不需要添加（fmt.Sprintf不会在BODY后加\n）
     if [ -z "$BODY" ]; then
@@ -437,24 +440,19 @@ for i in {1..6}; do
         echo "  SecretKey: ${SECRET_KEY:0:20}..."
         echo "  Signature: ${SIGNATURE:0:30}..."
         echo "  待签名字符串（cat -A格式）："
-        printf "%s\n%s\n%s\n%s" "$METHOD" "$URI" "$TIMESTAMP" "$BODY" | cat -A
+        printf "%s\n%s\n%s %s\n%s" "$METHOD" "$URI" "$TIMESTAMP" "$NONCE" "$BODY" | cat -A
         echo ""
     fi
 
-    # 获取完整响应以便调试
-    if [ $i -eq 1 ]; then
-        FULL_RESPONSE=$(curl -s -X GET "$BASE_URL$URI" \
-            -H "Authorization: $AUTH_HEADER" \
-            -H "X-Qiniu-Date: $TIMESTAMP")
-        HTTP_CODE=$(echo "$FULL_RESPONSE" | tail -1)
-        if echo "$FULL_RESPONSE" | grep -q "error"; then
-            echo "  错误响应: $FULL_RESPONSE"
-        fi
-    fi
-
-    HTTP_CODE=$(curl -s -o /dev/null -w "%{http_code}" -X GET "$BASE_URL$URI" \
+    # nonce 只能使用一次，同一请求同时获取响应体（用于调试）和状态码
+    FULL_RESPONSE=$(curl -s -w "\n%{http_code}" -X GET "$BASE_URL$URI" \
         -H "Authorization: $AUTH_HEADER" \
-        -H "X-Qiniu-Date: $TIMESTAMP")
+        -H "X-Qiniu-Date: $TIMESTAMP" \
+        -H "X-Qiniu-Nonce: $NONCE")
+    HTTP_CODE=$(echo "$FULL_RESPONSE" | tail -1)
+    if [ $i -eq 1 ] && echo "$FULL_RESPONSE" | grep -q "error"; then
+        echo "  错误响应: $FULL_RESPONSE"
+    fi
 
     if [ "$HTTP_CODE" = "200" ]; then
         SUCCESS_COUNT=$((SUCCESS_COUNT + 1))
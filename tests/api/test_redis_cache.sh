@@ -24,6 +24,9 @@ REDIS_CONTAINER="${REDIS_CONTAINER:-bearer-token-redis}"
 # 测试用的 Qiniu UID
 QINIU_UID="${QINIU_UID:-1369077332}"
 
+# Token 哈希密钥（需与服务端 TOKEN_HASH_PEPPER 一致，用于计算缓存键）
+TOKEN_HASH_PEPPER="${TOKEN_HASH_PEPPER:-}"
+
 # 测试计数
 TESTS_PASSED=0
 TESTS_FAILED=0
@@ -57,6 +60,12 @@ test_step() {
     echo -e "${BLUE}========================================${NC}"
 }
 
+# token_cache_key 计算 token 值对应的缓存键: token:hash:{HMAC-SHA256(pepper, token)}
+token_cache_key() {
+    local hash=$(printf '%s' "$1" | openssl dgst -sha256 -hmac "$TOKEN_HASH_PEPPER" | awk '{print $NF}')
+    echo "token:hash:$hash"
+}
+
 redis_cmd() {
     docker exec "$REDIS_CONTAINER" redis-cli "$@"
 }
@@ -144,12 +153,12 @@ test_first_validation_cache_write() {
     sleep 0.5
 
     # 检查缓存已写入
-    local cache_key="token:val:$TOKEN_VALUE"
+    local cache_key=$(token_cache_key "$TOKEN_VALUE")
     local cached=$(redis_cmd GET "$cache_key")
 
     if [[ -n "$cached" ]]; then
         log_success "Cache written after first validation"
-        log_info "Cache key: token:val:${TOKEN_VALUE:0:20}..."
+        log_info "Cache key: ${cache_key:0:30}..."
     else
         log_error "Cache not written after validation"
         return 1
@@ -196,7 +205,7 @@ test_disable_token_cache_invalidation() {
     log_info "Testing cache invalidation when token is disabled..."
 
     # 确认缓存存在
-    local cache_key="token:val:$TOKEN_VALUE"
+    local cache_key=$(token_cache_key "$TOKEN_VALUE")
     local cached_before=$(redis_cmd GET "$cache_key")
 
     if [[ -z "$cached_before" ]]; then
@@ -267,7 +276,7 @@ test_enable_token_cache_update() {
     sleep 0.5
 
     # 检查新缓存已写入
-    local cache_key="token:val:$TOKEN_VALUE"
+    local cache_key=$(token_cache_key "$TOKEN_VALUE")
     local cached=$(redis_cmd GET "$cache_key")
 
     if [[ -n "$cached" ]]; then
@@ -288,7 +297,7 @@ test_delete_token_cache_invalidation() {
     log_info "Token deleted"
 
     # 检查缓存已被清除
-    local cache_key="token:val:$TOKEN_VALUE"
+    local cache_key=$(token_cache_key "$TOKEN_VALUE")
     local cached=$(redis_cmd GET "$cache_key")
 
     if [[ -z "$cached" ]]; then
@@ -335,7 +344,7 @@ test_null_cache_penetration_protection() {
     sleep 0.5
 
     # 检查空对象缓存
-    local cache_key="token:val:$fake_token"
+    local cache_key=$(token_cache_key "$fake_token")
     local cached=$(redis_cmd GET "$cache_key")
 
     if [[ "$cached" == "null" ]]; then
@@ -365,7 +374,7 @@ test_cache_ttl() {
     sleep 0.5
 
     # 检查 TTL
-    local cache_key="token:val:$token_value"
+    local cache_key=$(token_cache_key "$token_value")
     local ttl=$(redis_cmd TTL "$cache_key")
 
     if [[ $ttl -gt 0 ]]; then